@@ -0,0 +1,237 @@
+package cdl
+
+import "math"
+
+// Pattern представляет свечной паттерн
+// Оценка паттерна на свече лежит в диапазоне [-1, 1]: знак - направление (бычий +, медвежий -,
+// ненаправленные паттерны всегда +), модуль - сила паттерна, 0 - паттерн не найден.
+// Оценка свечи i рассчитывается только по свечам до i включительно
+type Pattern string
+
+const (
+	Engulfing          Pattern = "Engulfing"          // Поглощение: тело свечи поглощает тело предыдущей свечи противоположного цвета
+	Hammer             Pattern = "Hammer"             // Молот после снижения: длинная нижняя тень, маленькое тело у максимума
+	ShootingStar       Pattern = "ShootingStar"       // Падающая звезда после роста: длинная верхняя тень, маленькое тело у минимума
+	Doji               Pattern = "Doji"               // Доджи: тело не больше dojiBody диапазона (ненаправленный)
+	DragonflyDoji      Pattern = "DragonflyDoji"      // Доджи-стрекоза: доджи без верхней тени (бычий)
+	GravestoneDoji     Pattern = "GravestoneDoji"     // Доджи-надгробие: доджи без нижней тени (медвежий)
+	LongLeggedDoji     Pattern = "LongLeggedDoji"     // Длинноногий доджи: доджи с длинными тенями (ненаправленный)
+	MorningStar        Pattern = "MorningStar"        // Утренняя звезда: длинная медвежья, маленькое тело внизу, бычья выше середины первой
+	EveningStar        Pattern = "EveningStar"        // Вечерняя звезда: зеркальная утренней звезде
+	ThreeWhiteSoldiers Pattern = "ThreeWhiteSoldiers" // Три белых солдата: три растущие бычьи свечи с открытием внутри тела предыдущей
+	ThreeBlackCrows    Pattern = "ThreeBlackCrows"    // Три черные вороны: три падающие медвежьи свечи с открытием внутри тела предыдущей
+	InsideBar          Pattern = "InsideBar"          // Внутренний бар: диапазон внутри диапазона предыдущей свечи (ненаправленный)
+	OutsideBar         Pattern = "OutsideBar"         // Внешний бар: диапазон перекрывает диапазон предыдущей свечи, направление по телу
+)
+
+// Пороги распознавания паттернов в долях диапазона свечи
+const (
+	dojiBody       = 0.1  // максимальное тело доджи
+	dojiFlatWick   = 0.1  // максимальная тень со стороны закрытия у стрекозы и надгробия
+	dojiLongWick   = 0.3  // минимальные тени длинноногого доджи
+	hammerBody     = 0.35 // максимальное тело молота и падающей звезды
+	hammerWickMult = 2.0  // минимальное отношение длинной тени к телу
+	hammerFlatWick = 0.15 // максимальная короткая тень молота и падающей звезды
+	longBody       = 0.5  // минимальное тело длинной свечи
+	starBody       = 0.3  // максимальное тело звезды относительно тела первой свечи
+	trendBars      = 3    // количество свечей перед текущей для проверки тренда молота и падающей звезды
+)
+
+// Patterns возвращает список всех паттернов
+func Patterns() []Pattern {
+	return []Pattern{
+		Engulfing, Hammer, ShootingStar,
+		Doji, DragonflyDoji, GravestoneDoji, LongLeggedDoji,
+		MorningStar, EveningStar, ThreeWhiteSoldiers, ThreeBlackCrows,
+		InsideBar, OutsideBar,
+	}
+}
+
+// Directional сообщает, определяет ли знак оценки паттерна направление движения
+func (p Pattern) Directional() bool {
+	switch p {
+	case Doji, LongLeggedDoji, InsideBar:
+		return false
+	}
+	return true
+}
+
+// Bars возвращает количество свечей, необходимое для распознавания паттерна
+func (p Pattern) Bars() int {
+	switch p {
+	case Engulfing, InsideBar, OutsideBar:
+		return 2
+	case MorningStar, EveningStar, ThreeWhiteSoldiers, ThreeBlackCrows:
+		return 3
+	case Hammer, ShootingStar:
+		return trendBars + 1
+	}
+	return 1
+}
+
+// ListOfPattern вычисляет оценки паттерна для каждой свечи
+func ListOfPattern(candles []Candle, p Pattern) []float64 {
+	scores := make([]float64, len(candles))
+	for i := range candles {
+		scores[i] = p.Score(candles[:i+1])
+	}
+	return scores
+}
+
+// DetectPatterns возвращает ненулевые оценки всех паттернов на последней свече
+func DetectPatterns(candles []Candle) map[Pattern]float64 {
+	found := make(map[Pattern]float64)
+	for _, p := range Patterns() {
+		if score := p.Score(candles); score != 0 {
+			found[p] = score
+		}
+	}
+	return found
+}
+
+// Score вычисляет оценку паттерна на последней свече
+func (p Pattern) Score(candles []Candle) float64 {
+	n := len(candles)
+	if n < p.Bars() {
+		return 0
+	}
+	c := &candles[n-1]
+	switch p {
+	case Engulfing:
+		return engulfing(&candles[n-2], c)
+	case Hammer:
+		// молот имеет смысл только после снижения
+		if candles[n-2].C >= candles[n-1-trendBars].C {
+			return 0
+		}
+		return hammer(c.O, c.H, c.L, c.C)
+	case ShootingStar:
+		if candles[n-2].C <= candles[n-1-trendBars].C {
+			return 0
+		}
+		// падающая звезда - зеркальный молот
+		return -hammer(-c.O, -c.L, -c.H, -c.C)
+	case Doji, DragonflyDoji, GravestoneDoji, LongLeggedDoji:
+		return doji(p, c)
+	case MorningStar:
+		return star(&candles[n-3], &candles[n-2], c, 1)
+	case EveningStar:
+		return -star(&candles[n-3], &candles[n-2], c, -1)
+	case ThreeWhiteSoldiers:
+		return threeCandles(candles[n-3:], 1)
+	case ThreeBlackCrows:
+		return -threeCandles(candles[n-3:], -1)
+	case InsideBar:
+		pr := candles[n-2].Arg(TrueRange)
+		if pr == 0 || c.H > candles[n-2].H || c.L < candles[n-2].L {
+			return 0
+		}
+		return 1 - c.Arg(TrueRange)/pr
+	case OutsideBar:
+		r := c.Arg(TrueRange)
+		if c.H <= candles[n-2].H || c.L >= candles[n-2].L {
+			return 0
+		}
+		return c.Arg(Direction) * (1 - candles[n-2].Arg(TrueRange)/r)
+	}
+	return 0
+}
+
+// engulfing оценивает поглощение: сила - доля тела свечи, не покрытая телом предыдущей
+func engulfing(pc, c *Candle) float64 {
+	dir := c.Arg(Direction)
+	if dir == 0 || dir != -pc.Arg(Direction) {
+		return 0
+	}
+	body, prevBody := c.Arg(Body), pc.Arg(Body)
+	if body <= prevBody || max(c.O, c.C) < max(pc.O, pc.C) || min(c.O, c.C) > min(pc.O, pc.C) {
+		return 0
+	}
+	return dir * (1 - prevBody/body)
+}
+
+// hammer оценивает форму молота, сила - доля нижней тени в диапазоне
+func hammer(o, h, l, c float64) float64 {
+	r := h - l
+	if r <= 0 {
+		return 0
+	}
+	body := math.Abs(c - o)
+	lowerWick := min(o, c) - l
+	upperWick := h - max(o, c)
+	if body > hammerBody*r || lowerWick < hammerWickMult*body || upperWick > hammerFlatWick*r {
+		return 0
+	}
+	return lowerWick / r
+}
+
+// doji оценивает варианты доджи, сила - насколько тело меньше порога доджи
+func doji(p Pattern, c *Candle) float64 {
+	r := c.Arg(TrueRange)
+	if r <= 0 || c.Arg(Body) > dojiBody*r {
+		return 0
+	}
+	score := 1 - c.Arg(Body)/(dojiBody*r)
+	upperWick, lowerWick := c.Arg(UpperWick), c.Arg(LowerWick)
+	switch p {
+	case DragonflyDoji:
+		if upperWick > dojiFlatWick*r {
+			return 0
+		}
+	case GravestoneDoji:
+		if lowerWick > dojiFlatWick*r {
+			return 0
+		}
+		return -score
+	case LongLeggedDoji:
+		if upperWick < dojiLongWick*r || lowerWick < dojiLongWick*r {
+			return 0
+		}
+	}
+	return score
+}
+
+// star оценивает утреннюю (dir = 1) или вечернюю (dir = -1) звезду
+// Сила - доля тела первой свечи, отыгранная третьей свечой
+func star(first, middle, last *Candle, dir float64) float64 {
+	if first.Arg(Direction) != -dir || last.Arg(Direction) != dir || first.Arg(BodyRangeRatio) < longBody {
+		return 0
+	}
+	firstBody := first.Arg(Body)
+	if middle.Arg(Body) > starBody*firstBody {
+		return 0
+	}
+	mid := (first.O + first.C) / 2
+	// тело звезды в дальней от открытия половине тела первой свечи или за его пределами
+	edge := max(middle.O, middle.C)
+	if dir < 0 {
+		edge = min(middle.O, middle.C)
+	}
+	if dir*(edge-mid) > 0 {
+		return 0
+	}
+	if dir*(last.C-mid) <= 0 {
+		return 0
+	}
+	return min(1, dir*(last.C-first.C)/firstBody)
+}
+
+// threeCandles оценивает трех белых солдат (dir = 1) или трех черных ворон (dir = -1)
+// Сила - средняя доля тела в диапазоне свечей
+func threeCandles(candles []Candle, dir float64) float64 {
+	var strength float64
+	for i := range candles {
+		c := &candles[i]
+		if c.Arg(Direction) != dir || c.Arg(BodyRangeRatio) < longBody {
+			return 0
+		}
+		if i > 0 {
+			pc := &candles[i-1]
+			if dir*(c.C-pc.C) <= 0 || c.O < min(pc.O, pc.C) || c.O > max(pc.O, pc.C) {
+				return 0
+			}
+		}
+		strength += c.Arg(BodyRangeRatio)
+	}
+	return strength / float64(len(candles))
+}
@@ -0,0 +1,111 @@
+package cdl
+
+import (
+	"math"
+	"testing"
+)
+
+// closes возвращает свечи без теней с ценами закрытия closes и свечу last в конце
+func closes(last Candle, closes ...float64) []Candle {
+	candles := make([]Candle, 0, len(closes)+1)
+	for _, c := range closes {
+		candles = append(candles, Candle{O: c, H: c, L: c, C: c})
+	}
+	return append(candles, last)
+}
+
+func TestPatternScore(t *testing.T) {
+	hammer := Candle{O: 9.8, H: 10, L: 8, C: 10}
+	star := Candle{O: 10.2, H: 12, L: 10, C: 10}
+	doji := Candle{O: 10, H: 11, L: 9, C: 10.05}
+	dragonfly := Candle{O: 10.95, H: 11, L: 9, C: 11}
+	gravestone := Candle{O: 9.05, H: 11, L: 9, C: 9}
+	marubozu := Candle{O: 9, H: 11, L: 9, C: 11}
+	morning := []Candle{
+		{O: 12, H: 12.1, L: 9.9, C: 10},
+		{O: 9.8, H: 10, L: 9.5, C: 9.7},
+		{O: 10, H: 11.6, L: 9.9, C: 11.5},
+	}
+	evening := []Candle{
+		{O: 10, H: 12.1, L: 9.9, C: 12},
+		{O: 12.2, H: 12.5, L: 12, C: 12.3},
+		{O: 12, H: 12.1, L: 10.4, C: 10.5},
+	}
+	soldiers := []Candle{
+		{O: 10, H: 11.25, L: 10, C: 11},
+		{O: 10.5, H: 11.75, L: 10.5, C: 11.5},
+		{O: 11, H: 12.25, L: 11, C: 12},
+	}
+	crows := []Candle{
+		{O: 12, H: 12, L: 10.75, C: 11},
+		{O: 11.5, H: 11.5, L: 10.25, C: 10.5},
+		{O: 11, H: 11, L: 9.75, C: 10},
+	}
+
+	tests := []struct {
+		name    string
+		pattern Pattern
+		candles []Candle
+		want    float64
+	}{
+		// поглощение: тело 1.5 покрывает тело 1
+		{"бычье поглощение", Engulfing, []Candle{{O: 10, H: 10.2, L: 8.8, C: 9}, {O: 8.9, H: 10.6, L: 8.8, C: 10.4}}, 1 - 1/1.5},
+		{"медвежье поглощение", Engulfing, []Candle{{O: 9, H: 10.2, L: 8.8, C: 10}, {O: 10.1, H: 10.2, L: 8.4, C: 8.6}}, -(1 - 1/1.5)},
+		{"поглощение одного цвета", Engulfing, []Candle{{O: 9, H: 10.2, L: 8.8, C: 10}, {O: 8.9, H: 10.6, L: 8.8, C: 10.4}}, 0},
+
+		// молот: нижняя тень 1.8 в диапазоне 2
+		{"молот после снижения", Hammer, closes(hammer, 12, 11, 10), 0.9},
+		{"молот после роста", Hammer, closes(hammer, 10, 11, 12), 0},
+		{"молот без истории", Hammer, closes(hammer, 11, 10), 0},
+		{"падающая звезда после роста", ShootingStar, closes(star, 8, 9, 10), -0.9},
+		{"падающая звезда после снижения", ShootingStar, closes(star, 12, 11, 10), 0},
+
+		// доджи: тело 0.05 при пороге 0.1 * 2
+		{"доджи", Doji, []Candle{doji}, 0.75},
+		{"длинноногий доджи", LongLeggedDoji, []Candle{doji}, 0.75},
+		{"доджи не стрекоза", DragonflyDoji, []Candle{doji}, 0},
+		{"доджи не надгробие", GravestoneDoji, []Candle{doji}, 0},
+		{"стрекоза", DragonflyDoji, []Candle{dragonfly}, 0.75},
+		{"стрекоза - доджи", Doji, []Candle{dragonfly}, 0.75},
+		{"стрекоза не длинноногий", LongLeggedDoji, []Candle{dragonfly}, 0},
+		{"стрекоза не надгробие", GravestoneDoji, []Candle{dragonfly}, 0},
+		{"надгробие", GravestoneDoji, []Candle{gravestone}, -0.75},
+		{"надгробие не стрекоза", DragonflyDoji, []Candle{gravestone}, 0},
+		{"длинное тело не доджи", Doji, []Candle{marubozu}, 0},
+
+		// звезды: третья свеча отыгрывает 1.5 из тела 2
+		{"утренняя звезда", MorningStar, morning, 0.75},
+		{"утренняя звезда без отскока", MorningStar, []Candle{morning[0], morning[1], {O: 10, H: 10.9, L: 9.9, C: 10.8}}, 0},
+		{"утренняя звезда с большим телом", MorningStar, []Candle{morning[0], {O: 9.8, H: 10, L: 8.5, C: 8.6}, morning[2]}, 0},
+		{"вечерняя звезда", EveningStar, evening, -0.75},
+		{"вечерняя звезда на утренней", EveningStar, morning, 0},
+
+		// солдаты и вороны: тело 1 в диапазоне 1.25
+		{"три белых солдата", ThreeWhiteSoldiers, soldiers, 0.8},
+		{"открытие вне тела предыдущей", ThreeWhiteSoldiers, []Candle{soldiers[0], soldiers[1], {O: 12, H: 13.25, L: 12, C: 13}}, 0},
+		{"три черные вороны", ThreeBlackCrows, crows, -0.8},
+		{"вороны на солдатах", ThreeBlackCrows, soldiers, 0},
+
+		// внутренний и внешний бар: диапазон 2 против 4
+		{"внутренний бар", InsideBar, []Candle{{O: 10, H: 12, L: 8, C: 11}, {O: 10, H: 11, L: 9, C: 10.5}}, 0.5},
+		{"внутренний бар с пробоем", InsideBar, []Candle{{O: 10, H: 12, L: 8, C: 11}, {O: 10, H: 12.5, L: 9, C: 10.5}}, 0},
+		{"бычий внешний бар", OutsideBar, []Candle{{O: 10, H: 11, L: 9, C: 10.5}, {O: 10, H: 12, L: 8, C: 11.5}}, 0.5},
+		{"медвежий внешний бар", OutsideBar, []Candle{{O: 10, H: 11, L: 9, C: 10.5}, {O: 10, H: 12, L: 8, C: 8.5}}, -0.5},
+		{"внешний бар внутри", OutsideBar, []Candle{{O: 10, H: 12, L: 8, C: 11}, {O: 10, H: 11, L: 9, C: 10.5}}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.pattern.Score(tt.candles); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: %v, ожидается %v", tt.name, got, tt.want)
+		}
+	}
+
+	// оценка свечи зависит только от предыдущих свечей
+	scores := ListOfPattern(morning, MorningStar)
+	if scores[0] != 0 || scores[1] != 0 || math.Abs(scores[2]-0.75) > 1e-9 {
+		t.Errorf("ListOfPattern: %v", scores)
+	}
+	found := DetectPatterns([]Candle{dragonfly})
+	if len(found) != 2 || found[Doji] == 0 || found[DragonflyDoji] == 0 {
+		t.Errorf("DetectPatterns: %v", found)
+	}
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Migration шаг миграции схемы. Примененную миграцию нельзя менять:
+// изменения схемы добавляются новой миграцией со следующей версией
+type Migration struct {
+	Version int    // версия, начиная с 1, строго по возрастанию
+	Name    string // краткое описание
+	Up      string // SQL запросы миграции
+}
+
+// Checksum контрольная сумма SQL миграции
+func (m *Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+const migrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    appliedAt INTEGER NOT NULL
+);
+`
+
+// Migrations возвращает функцию миграции для InitDB
+func Migrations(migrations ...Migration) func(db *sql.DB) error {
+	return func(db *sql.DB) error {
+		return Migrate(db, migrations...)
+	}
+}
+
+// Migrate применяет недостающие миграции по порядку версий, каждую в отдельной транзакции
+// Примененные версии хранятся в таблице schema_migrations. Возвращает ошибку, если
+// контрольная сумма примененной миграции изменилась или база содержит неизвестную версию
+func Migrate(db *sql.DB, migrations ...Migration) error {
+	for i := range migrations {
+		if migrations[i].Version != i+1 {
+			return fmt.Errorf("Migrate: миграция %q: ожидается версия %d, получена %d",
+				migrations[i].Name, i+1, migrations[i].Version)
+		}
+	}
+	if _, err := db.Exec(migrationsTable); err != nil {
+		return fmt.Errorf("Migrate: ошибка создания таблицы миграций: %w", err)
+	}
+
+	applied := make(map[int]string)
+	rows, err := db.Query(`SELECT version, checksum FROM schema_migrations`)
+	if err != nil {
+		return fmt.Errorf("Migrate: ошибка чтения миграций: %w", err)
+	}
+	for rows.Next() {
+		var version int
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			rows.Close()
+			return fmt.Errorf("Migrate: ошибка чтения миграций: %w", err)
+		}
+		applied[version] = checksum
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("Migrate: ошибка чтения миграций: %w", err)
+	}
+	for version := range applied {
+		if version > len(migrations) {
+			return fmt.Errorf("Migrate: база данных содержит неизвестную миграцию %d", version)
+		}
+	}
+
+	for i := range migrations {
+		m := &migrations[i]
+		if checksum, ok := applied[m.Version]; ok {
+			if checksum != m.Checksum() {
+				return fmt.Errorf("Migrate: миграция %d %q изменена после применения", m.Version, m.Name)
+			}
+			continue
+		}
+		if err := apply(db, m); err != nil {
+			return fmt.Errorf("Migrate: миграция %d %q: %w", m.Version, m.Name, err)
+		}
+	}
+	return nil
+}
+
+func apply(db *sql.DB, m *Migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(m.Up); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, checksum, appliedAt) VALUES (?, ?, ?, ?)`,
+		m.Version, m.Name, m.Checksum(), time.Now().UnixMilli())
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
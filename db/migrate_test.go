@@ -0,0 +1,52 @@
+package db
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	v1 := Migration{Version: 1, Name: "items", Up: `CREATE TABLE items (id INTEGER PRIMARY KEY);`}
+	v2 := Migration{Version: 2, Name: "items name", Up: `ALTER TABLE items ADD COLUMN name TEXT NOT NULL DEFAULT '';`}
+
+	conn, err := InitDB(path, Migrations(v1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	// повторный запуск применяет только новую миграцию
+	conn, err = InitDB(path, Migrations(v1, v2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(`INSERT INTO items (id, name) VALUES (1, 'a')`); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	changed := v1
+	changed.Up += "\n"
+	if _, err := InitDB(path, Migrations(changed, v2)); err == nil || !strings.Contains(err.Error(), "изменена") {
+		t.Errorf("изменение миграции: %v", err)
+	}
+	if _, err := InitDB(path, Migrations(v1)); err == nil || !strings.Contains(err.Error(), "неизвестную") {
+		t.Errorf("неизвестная версия: %v", err)
+	}
+
+	// ошибочная миграция откатывается вместе с записью о версии
+	bad := Migration{Version: 3, Name: "bad", Up: `CREATE TABLE other (id INTEGER); SELECT * FROM missing;`}
+	conn, err = InitDB(path, Migrations(v1, v2, bad))
+	if err == nil {
+		t.Fatal("ожидается ошибка миграции")
+	}
+	var tables, applied int
+	conn.QueryRow(`SELECT count(*) FROM sqlite_master WHERE name = 'other'`).Scan(&tables)
+	conn.QueryRow(`SELECT count(*) FROM schema_migrations`).Scan(&applied)
+	if tables != 0 || applied != 2 {
+		t.Errorf("таблица other: %d, применено миграций: %d", tables, applied)
+	}
+	conn.Close()
+}
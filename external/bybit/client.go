@@ -47,13 +47,15 @@ type ServerResponse struct {
 
 // Client представляет клиент для работы с REST API Bybit
 type Client struct {
-	baseURL    string          // базовый URL API (тестовая или основная сеть)
-	apiKey     string          // публичный API-ключ для аутентификации
-	apiSecret  string          // секретный ключ для подписи запросов (HMAC)
-	recvWindow int             // временное окно валидности запроса в миллисекундах (по умолчанию 5000)
-	category   string          // spot/linear/inverse
-	ctx        context.Context // контекст для выполнения запросов
-	timeout    time.Duration   // таймаут HTTP-запросов
+	baseURL     string              // базовый URL API (тестовая или основная сеть)
+	apiKey      string              // публичный API-ключ для аутентификации
+	apiSecret   string              // секретный ключ для подписи запросов (HMAC)
+	recvWindow  int                 // временное окно валидности запроса в миллисекундах (по умолчанию 5000)
+	category    string              // spot/linear/inverse
+	ctx         context.Context     // контекст для выполнения запросов
+	timeout     time.Duration       // таймаут HTTP-запросов
+	onReconnect func(stream string) // вызывается после переподключения WebSocket потока
+	spotMargin  bool                // спотовые ордера с заемными средствами (isLeverage)
 }
 
 // NewClient создает новый экземпляр клиента для работы с API Bybit
@@ -115,6 +117,14 @@ func WithCategory(category string) Option {
 	}
 }
 
+// WithSpotMargin включает маржинальную торговлю для спотовых ордеров
+// Без нее спотовые ордера используют только собственные средства
+func WithSpotMargin(enabled bool) Option {
+	return func(c *Client) {
+		c.spotMargin = enabled
+	}
+}
+
 // WithTimeout устанавливает таймаут для HTTP-запросов
 func WithTimeout(timeout time.Duration) Option {
 	return func(c *Client) {
@@ -122,6 +132,14 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithOnReconnect устанавливает функцию, вызываемую после переподключения WebSocket потока
+// stream - тема подписки, например "kline.5.BTCUSDT"
+func WithOnReconnect(f func(stream string)) Option {
+	return func(c *Client) {
+		c.onReconnect = f
+	}
+}
+
 // WithContext устанавливает контекст для выполнения запросов
 func WithContext(ctx context.Context) Option {
 	return func(c *Client) {
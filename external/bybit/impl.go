@@ -1,8 +1,10 @@
 package bybit
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"fmt"
 	"goTradingBot/cdl"
 	"goTradingBot/utils/numeric"
 	"strconv"
@@ -126,49 +128,204 @@ func (i *TradingClientImpl) GetOrder(orderId string) ([]byte, error) {
 	return json.Marshal(orderData)
 }
 
-// GetInstrumentInfo получении детальной информации об инструменте.
-// Возвращает данные ордера в формате JSON со следующими полями:
-//   - minOrderQty: float64  - Минимальное количество для ордера
-//   - tickSize:    float64  - Шаг изменения цены
-func (i *DataProvider) GetInstrumentInfo(symbol string) ([]byte, error) {
-	info, err := i.cli.GetInstrumentInfo(symbol)
+// GetEquity возвращает общий капитал унифицированного кошелька в USD
+func (i *TradingClientImpl) GetEquity() (float64, error) {
+	wallet, err := i.cli.GetWalletBalance()
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+	if wallet == nil {
+		return 0, fmt.Errorf("GetEquity: унифицированный кошелек не найден")
 	}
-	var minOrderAmt float64
-	var qtyPrecision int
+	return strconv.ParseFloat(wallet.TotalEquity, 64)
+}
+
+// SetMarginMode устанавливает изолированную или кросс-маржу унифицированного аккаунта
+func (i *TradingClientImpl) SetMarginMode(isolated bool) error {
+	if err := i.cli.SetMarginMode(isolated); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetLeverage устанавливает кредитное плечо по символу
+func (i *TradingClientImpl) SetLeverage(symbol string, leverage float64) error {
 	if i.cli.category == "spot" {
-		v, parseErr := strconv.ParseFloat(info.LotSizeFilter.MaxOrderAmt, 64)
-		if parseErr != nil {
-			return nil, parseErr
+		return fmt.Errorf("SetLeverage: плечо не устанавливается для категории spot")
+	}
+	if err := i.cli.SetLeverage(symbol, leverage); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetPositions возвращает открытые позиции в формате JSON массива объектов с полями:
+//   - symbol:    string  - Торговая пара
+//   - qty:       float64 - Размер позиции, отрицательный для short
+//   - avgPrice:  float64 - Средняя цена входа
+//   - markPrice: float64 - Маркировочная цена
+//   - liqPrice:  float64 - Цена ликвидации, 0 если ликвидация не ожидается
+//   - leverage:  float64 - Кредитное плечо
+//
+// Для категории spot позициями считаются ненулевые балансы монет кошелька в паре с USDT
+func (i *TradingClientImpl) GetPositions() ([]byte, error) {
+	if i.cli.category == "spot" {
+		return i.getSpotPositions()
+	}
+	positions, err := i.cli.GetPositions("")
+	if err != nil {
+		return nil, err
+	}
+	res := make([]map[string]any, 0, len(positions))
+	for _, p := range positions {
+		values := make(map[string]float64, 5)
+		for k, v := range map[string]string{
+			"qty": p.Size, "avgPrice": p.AvgPrice, "markPrice": p.MarkPrice,
+			"liqPrice": p.LiqPrice, "leverage": p.Leverage,
+		} {
+			if v == "" {
+				continue
+			}
+			f, parseErr := strconv.ParseFloat(v, 64)
+			if parseErr != nil {
+				return nil, parseErr
+			}
+			values[k] = f
+		}
+		if values["qty"] == 0 {
+			continue
+		}
+		if p.Side == "Sell" {
+			values["qty"] = -values["qty"]
+		}
+		item := map[string]any{"symbol": p.Symbol}
+		for k, v := range values {
+			item[k] = v
+		}
+		res = append(res, item)
+	}
+	return json.Marshal(res)
+}
+
+// getSpotPositions возвращает балансы монет кошелька как позиции спота
+func (i *TradingClientImpl) getSpotPositions() ([]byte, error) {
+	wallet, err := i.cli.GetWalletBalance()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]map[string]any, 0)
+	if wallet == nil {
+		return json.Marshal(res)
+	}
+	for _, coin := range wallet.Coins {
+		if coin.Coin == "USDT" || coin.Coin == "USDC" || coin.WalletBalance == "" {
+			continue
 		}
-		minOrderAmt = v
-		v, parseErr = strconv.ParseFloat(info.LotSizeFilter.BasePrecision, 64)
+		qty, parseErr := strconv.ParseFloat(coin.WalletBalance, 64)
 		if parseErr != nil {
 			return nil, parseErr
 		}
-		qtyPrecision = numeric.DecimalPlaces(v)
-	} else {
-		v, parseErr := strconv.ParseFloat(info.LotSizeFilter.MinNotionalValue, 64)
+		if qty == 0 {
+			continue
+		}
+		usdValue, _ := strconv.ParseFloat(coin.UsdValue, 64)
+		res = append(res, map[string]any{
+			"symbol":    coin.Coin + "USDT",
+			"qty":       qty,
+			"markPrice": usdValue / qty,
+			"leverage":  1,
+		})
+	}
+	return json.Marshal(res)
+}
+
+// GetFundingRates возвращает ставки финансирования начиная с from (мс) по возрастанию времени
+// в формате JSON массива объектов с полями symbol, rate и time (мс)
+// Для категории spot финансирования нет, возвращается пустой массив
+func (i *TradingClientImpl) GetFundingRates(symbol string, from int64) ([]byte, error) {
+	if i.cli.category == "spot" {
+		return []byte("[]"), nil
+	}
+	rates, err := i.cli.GetFundingRateHistory(symbol, from, 0)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]map[string]any, len(rates))
+	for j, r := range rates {
+		rate, parseErr := strconv.ParseFloat(r.FundingRate, 64)
 		if parseErr != nil {
 			return nil, parseErr
 		}
-		minOrderAmt = v
-		v, parseErr = strconv.ParseFloat(info.LotSizeFilter.QtyStep, 64)
+		ts, parseErr := strconv.ParseInt(r.FundingRateTimestamp, 10, 64)
 		if parseErr != nil {
 			return nil, parseErr
 		}
-		qtyPrecision = numeric.DecimalPlaces(v)
+		res[j] = map[string]any{"symbol": r.Symbol, "rate": rate, "time": ts}
 	}
-	tickSize, parseErr := strconv.ParseFloat(info.PriceFilter.TickSize, 64)
-	if parseErr != nil {
-		return nil, parseErr
+	return json.Marshal(res)
+}
+
+// GetInstrumentInfo получении детальной информации об инструменте.
+// Возвращает данные инструмента в формате JSON с правилами категории клиента:
+//   - category:     string  - spot, linear или inverse
+//   - status:       string  - Статус торговли (Trading - доступен)
+//   - qtyStep:      float64 - Шаг количества: basePrecision для спота, qtyStep для контрактов
+//   - qtyPrecision: int     - Количество знаков шага количества
+//   - minOrderQty:  float64 - Минимальное количество для ордера
+//   - maxOrderQty:  float64 - Максимальное количество для лимитного ордера
+//   - maxMarketQty: float64 - Максимальное количество для рыночного ордера
+//   - minOrderAmt:  float64 - Минимальная стоимость ордера: minOrderAmt для спота, minNotionalValue для linear
+//   - maxOrderAmt:  float64 - Максимальная стоимость ордера (спот)
+//   - tickSize:     float64 - Шаг изменения цены
+//   - minPrice, maxPrice: float64 - Допустимый диапазон цены
+func (i *DataProvider) GetInstrumentInfo(symbol string) ([]byte, error) {
+	info, err := i.cli.GetInstrumentInfo(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if info.Symbol == "" {
+		return nil, fmt.Errorf("GetInstrumentInfo: инструмент %s не найден в категории %s", symbol, i.cli.category)
+	}
+	lot := &info.LotSizeFilter
+	fields := map[string]string{
+		"minOrderQty": lot.MinOrderQty,
+		"tickSize":    info.PriceFilter.TickSize,
+		"minPrice":    info.PriceFilter.MinPrice,
+		"maxPrice":    info.PriceFilter.MaxPrice,
+	}
+	switch i.cli.category {
+	case "spot":
+		fields["qtyStep"] = lot.BasePrecision
+		fields["maxOrderQty"] = cmp.Or(lot.MaxLimitOrderQty, lot.MaxOrderQty)
+		fields["maxMarketQty"] = cmp.Or(lot.MaxMarketOrderQty, lot.MaxOrderQty)
+		fields["minOrderAmt"] = lot.MinOrderAmt
+		fields["maxOrderAmt"] = lot.MaxOrderAmt
+	default:
+		fields["qtyStep"] = lot.QtyStep
+		fields["maxOrderQty"] = lot.MaxOrderQty
+		fields["maxMarketQty"] = lot.MaxMktOrderQty
+		fields["minOrderAmt"] = lot.MinNotionalValue
 	}
 	infoData := map[string]any{
-		"qtyPrecision": qtyPrecision,
-		"minOrderAmt":  minOrderAmt,
-		"tickSize":     tickSize,
+		"symbol":     info.Symbol,
+		"category":   i.cli.category,
+		"status":     info.Status,
+		"baseCoin":   info.BaseCoin,
+		"quoteCoin":  info.QuoteCoin,
+		"settleCoin": info.SettleCoin,
+	}
+	for k, v := range fields {
+		if v == "" {
+			continue
+		}
+		f, parseErr := strconv.ParseFloat(v, 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("GetInstrumentInfo: %s: %w", k, parseErr)
+		}
+		infoData[k] = f
 	}
+	step, _ := infoData["qtyStep"].(float64)
+	infoData["qtyPrecision"] = numeric.DecimalPlaces(step)
 	return json.Marshal(infoData)
 }
 
@@ -194,6 +194,11 @@ func (c *Client) CandleStream(ctx context.Context, symbol string, interval cdl.I
 	outChan, err := ws.NewClient(
 		ctx,
 		ws.WithHandshake(handshakeMessage),
+		ws.WithOnReconnect(func() {
+			if c.onReconnect != nil {
+				c.onReconnect(arg)
+			}
+		}),
 	).Connect(fmt.Sprintf("%s/%s", PUBLICWS, c.category))
 	if err != nil {
 		err = fmt.Errorf("couldn't create websocket connection: %w", err)
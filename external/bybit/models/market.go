@@ -48,6 +48,8 @@ type InstrumentInfo struct {
 		MinOrderQty         string `json:"minOrderQty"`         // Минимальное количество для ордера
 		MaxOrderQty         string `json:"maxOrderQty"`         // Максимальное количество для Limit и PostOnly ордера
 		MaxMktOrderQty      string `json:"maxMktOrderQty"`      // Максимальное количество для Market ордера
+		MaxLimitOrderQty    string `json:"maxLimitOrderQty"`    // Максимальное количество для Limit ордера (спот)
+		MaxMarketOrderQty   string `json:"maxMarketOrderQty"`   // Максимальное количество для Market ордера (спот)
 		MinOrderAmt         string `json:"minOrderAmt"`         // Минимальная сумма ордера
 		MaxOrderAmt         string `json:"maxOrderAmt"`         // Максимальная сумма ордера
 		QtyStep             string `json:"qtyStep"`             // Шаг изменения количества
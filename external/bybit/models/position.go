@@ -0,0 +1,44 @@
+package models
+
+// PositionList представляет ответ API со списком позиций
+type PositionList struct {
+	Category       string         `json:"category"`       // Тип продукта (категория)
+	List           []PositionInfo `json:"list"`           // Список позиций
+	NextPageCursor string         `json:"nextPageCursor"` // Курсор для пагинации
+}
+
+// PositionInfo содержит информацию о позиции по контракту
+type PositionInfo struct {
+	PositionIdx    int    `json:"positionIdx"`    // Индекс позиции: 0 - односторонний режим
+	TradeMode      int    `json:"tradeMode"`      // Режим маржи: 0 - кросс, 1 - изолированная (классический аккаунт)
+	Symbol         string `json:"symbol"`         // Название символа
+	Side           string `json:"side"`           // Направление: Buy - long, Sell - short, пусто - нет позиции
+	Size           string `json:"size"`           // Размер позиции
+	AvgPrice       string `json:"avgPrice"`       // Средняя цена входа
+	PositionValue  string `json:"positionValue"`  // Стоимость позиции
+	Leverage       string `json:"leverage"`       // Кредитное плечо
+	MarkPrice      string `json:"markPrice"`      // Маркировочная цена
+	LiqPrice       string `json:"liqPrice"`       // Цена ликвидации, пусто - ликвидация не ожидается
+	BustPrice      string `json:"bustPrice"`      // Цена банкротства
+	PositionIM     string `json:"positionIM"`     // Начальная маржа позиции
+	PositionMM     string `json:"positionMM"`     // Поддерживающая маржа позиции
+	UnrealisedPnl  string `json:"unrealisedPnl"`  // Нереализованный P&L
+	CurRealisedPnl string `json:"curRealisedPnl"` // Реализованный P&L текущей позиции
+	CumRealisedPnl string `json:"cumRealisedPnl"` // Накопленный реализованный P&L
+	PositionStatus string `json:"positionStatus"` // Статус позиции: Normal, Liq, Adl
+	CreatedTime    string `json:"createdTime"`    // Время создания (мс)
+	UpdatedTime    string `json:"updatedTime"`    // Время обновления (мс)
+}
+
+// FundingHistory представляет ответ API с историей ставок финансирования
+type FundingHistory struct {
+	Category string        `json:"category"` // Тип продукта (категория)
+	List     []FundingRate `json:"list"`     // Ставки, отсортированные в обратном порядке по времени
+}
+
+// FundingRate содержит ставку финансирования бессрочного контракта
+type FundingRate struct {
+	Symbol               string `json:"symbol"`               // Название символа
+	FundingRate          string `json:"fundingRate"`          // Ставка финансирования
+	FundingRateTimestamp string `json:"fundingRateTimestamp"` // Время начисления (мс)
+}
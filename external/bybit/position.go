@@ -0,0 +1,168 @@
+package bybit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"goTradingBot/external/bybit/models"
+	"goTradingBot/httpx"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Коды ответа, когда настройка уже установлена
+const (
+	leverageNotModified   = 110043
+	marginModeNotModified = 110026
+)
+
+// SetLeverage устанавливает кредитное плечо для покупки и продажи
+// symbol - торговый символ (например "BTCUSDT")
+// Повторная установка того же плеча не считается ошибкой
+func (c *Client) SetLeverage(symbol string, leverage float64) *Error {
+	value := strconv.FormatFloat(leverage, 'f', -1, 64)
+	params := map[string]any{
+		"category":     c.category,
+		"symbol":       symbol,
+		"buyLeverage":  value,
+		"sellLeverage": value,
+	}
+	err := c.postPosition("/v5/position/set-leverage", params)
+	if err != nil && err.ServerResponseCode() != leverageNotModified {
+		return err.SetEndpoint("SetLeverage")
+	}
+	return nil
+}
+
+// SwitchMarginMode переключает изолированную и кросс-маржу по символу (классический аккаунт)
+// Для унифицированного аккаунта режим маржи задается для всего аккаунта через SetMarginMode
+// leverage - плечо, устанавливаемое вместе с режимом
+func (c *Client) SwitchMarginMode(symbol string, isolated bool, leverage float64) *Error {
+	tradeMode := 0
+	if isolated {
+		tradeMode = 1
+	}
+	value := strconv.FormatFloat(leverage, 'f', -1, 64)
+	params := map[string]any{
+		"category":     c.category,
+		"symbol":       symbol,
+		"tradeMode":    tradeMode,
+		"buyLeverage":  value,
+		"sellLeverage": value,
+	}
+	err := c.postPosition("/v5/position/switch-isolated", params)
+	if err != nil && err.ServerResponseCode() != marginModeNotModified {
+		return err.SetEndpoint("SwitchMarginMode")
+	}
+	return nil
+}
+
+// SetMarginMode устанавливает режим маржи унифицированного аккаунта
+// isolated - изолированная маржа (ISOLATED_MARGIN), иначе кросс-маржа (REGULAR_MARGIN)
+func (c *Client) SetMarginMode(isolated bool) *Error {
+	mode := "REGULAR_MARGIN"
+	if isolated {
+		mode = "ISOLATED_MARGIN"
+	}
+	params := map[string]any{"setMarginMode": mode}
+	if err := c.postPosition("/v5/account/set-margin-mode", params); err != nil {
+		return err.SetEndpoint("SetMarginMode")
+	}
+	return nil
+}
+
+// GetPositions возвращает открытые позиции по контрактам
+// symbol - торговый символ, при пустом значении возвращаются все позиции категории
+// (для linear - с расчетами в USDT)
+func (c *Client) GetPositions(symbol string) ([]models.PositionInfo, *Error) {
+	params := map[string]any{
+		"category": c.category,
+		"limit":    200,
+	}
+	if symbol != "" {
+		params["symbol"] = symbol
+	} else if c.category == "linear" {
+		params["settleCoin"] = "USDT"
+	}
+	var positions []models.PositionInfo
+	for {
+		res, err := c.getPositionList(params)
+		if err != nil {
+			return positions, err
+		}
+		positions = append(positions, res.List...)
+		if res.NextPageCursor == "" || len(res.List) == 0 {
+			return positions, nil
+		}
+		params["cursor"] = res.NextPageCursor
+	}
+}
+
+// GetFundingRateHistory возвращает ставки финансирования за период по возрастанию времени
+// symbol - торговый символ (например "BTCUSDT")
+// start, end - границы периода (мс), end == 0 - до текущего момента
+// Возвращается не более 200 последних ставок периода
+func (c *Client) GetFundingRateHistory(symbol string, start, end int64) ([]models.FundingRate, *Error) {
+	params := map[string]any{
+		"category": c.category,
+		"symbol":   symbol,
+		"limit":    200,
+	}
+	if start > 0 {
+		params["startTime"] = start
+	}
+	if end > 0 {
+		params["endTime"] = end
+	} else if start > 0 {
+		// без endTime биржа отклоняет запрос с одним startTime
+		params["endTime"] = time.Now().UnixMilli()
+	}
+	res, err := c.getFundingHistory(params)
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(res.List)
+	return res.List, nil
+}
+
+// postPosition отправляет POST запрос настройки позиции (внутренний метод)
+func (c *Client) postPosition(endpoint string, params map[string]any) *Error {
+	jsonData, _ := json.Marshal(params)
+	body := bytes.NewBuffer(jsonData)
+	req := httpx.Post(c.baseURL + endpoint).WithBody(body)
+	return c.callAPI(req, string(jsonData), nil)
+}
+
+// getPositionList получает список позиций (внутренний метод)
+func (c *Client) getPositionList(params map[string]any) (*models.PositionList, *Error) {
+	query := make(url.Values)
+	for k, v := range params {
+		query.Add(k, fmt.Sprintf("%v", v))
+	}
+	queryString := query.Encode()
+	fullURL := fmt.Sprintf("%s%s?%s", c.baseURL, "/v5/position/list", queryString)
+	req := httpx.Get(fullURL)
+	var positionList models.PositionList
+	if err := c.callAPI(req, queryString, &positionList); err != nil {
+		return &positionList, err.SetEndpoint("getPositionList")
+	}
+	return &positionList, nil
+}
+
+// getFundingHistory получает историю ставок финансирования (внутренний метод)
+func (c *Client) getFundingHistory(params map[string]any) (*models.FundingHistory, *Error) {
+	query := make(url.Values)
+	for k, v := range params {
+		query.Add(k, fmt.Sprintf("%v", v))
+	}
+	queryString := query.Encode()
+	fullURL := fmt.Sprintf("%s%s?%s", c.baseURL, "/v5/market/funding/history", queryString)
+	req := httpx.Get(fullURL)
+	var fundingHistory models.FundingHistory
+	if err := c.callAPI(req, queryString, &fundingHistory); err != nil {
+		return &fundingHistory, err.SetEndpoint("getFundingHistory")
+	}
+	return &fundingHistory, nil
+}
@@ -14,6 +14,7 @@ import (
 // PlaceOrder создает рыночный или лимитный ордер
 // symbol - торговый символ (например "BTCUSDT")
 // qty - объем: положительный - покупка, отрицательный - продажа
+// Количество задается в базовой монете для spot и linear и в контрактах (USD) для inverse
 // price - цена (если указан - лимитный ордер, иначе - рыночный)
 func (c *Client) PlaceOrder(symbol string, qty float64, price *float64) (*models.PlaceOrderResult, *Error) {
 	side := "Buy"
@@ -21,11 +22,10 @@ func (c *Client) PlaceOrder(symbol string, qty float64, price *float64) (*models
 		side = "Sell"
 	}
 	params := map[string]any{
-		"category":   c.category,
-		"symbol":     symbol,
-		"side":       side,
-		"orderType":  "Market",
-		"isLeverage": 1,
+		"category":  c.category,
+		"symbol":    symbol,
+		"side":      side,
+		"orderType": "Market",
 	}
 	qty = math.Abs(qty)
 	params["qty"] = strconv.FormatFloat(qty, 'f', -1, 64)
@@ -33,6 +33,15 @@ func (c *Client) PlaceOrder(symbol string, qty float64, price *float64) (*models
 		params["price"] = strconv.FormatFloat(*price, 'f', -1, 64)
 		params["orderType"] = "Limit"
 	}
+	if c.category == "spot" {
+		// рыночная покупка на споте по умолчанию задается в котируемой монете
+		if price == nil {
+			params["marketUnit"] = "baseCoin"
+		}
+		if c.spotMargin {
+			params["isLeverage"] = 1
+		}
+	}
 	res, err := c.placeOrder(params)
 	if err != nil {
 		return nil, err
@@ -16,9 +16,12 @@ var (
 
 const dbPath = "cryptos.db"
 
-// migrate выполняет необходимые миграции базы данных
-func migrate(db *sql.DB) error {
-	query := `
+// migrate применяет миграции схемы базы криптовалют
+var migrate = db.Migrations(
+	db.Migration{
+		Version: 1,
+		Name:    "cryptos",
+		Up: `
 CREATE TABLE IF NOT EXISTS cryptos (
 	id INTEGER PRIMARY KEY,
 	name TEXT NOT NULL,
@@ -27,12 +30,9 @@ CREATE TABLE IF NOT EXISTS cryptos (
 );
 
 CREATE INDEX IF NOT EXISTS idx_cryptos_symbol ON cryptos(symbol);
-`
-	if _, err := db.Exec(query); err != nil {
-		return fmt.Errorf("ошибка выполнения миграции: %w", err)
-	}
-	return nil
-}
+`,
+	},
+)
 
 type Crypto struct {
 	ID     int
@@ -2,10 +2,13 @@ package telebot
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"goTradingBot/httpx"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -80,6 +83,13 @@ func WithWriteChatID(chatID string) Option {
 	}
 }
 
+// WithBaseURL устанавливает адрес Telegram Bot API (например, локальный сервер для тестов)
+func WithBaseURL(baseURL string) Option {
+	return func(b *Bot) {
+		b.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
 // WithParseMode устанавливает режим парсинга сообщений
 func WithParseMode(parseMode string) Option {
 	return func(c *Bot) {
@@ -88,11 +98,16 @@ func WithParseMode(parseMode string) Option {
 }
 
 func (b *Bot) callAPI(req httpx.RequestBuilder, result any) error {
-	if b.ctx != nil {
-		req = req.WithContext(b.ctx)
+	return b.callAPIWith(b.ctx, b.timeout, req, result)
+}
+
+// callAPIWith выполняет запрос с указанными контекстом и таймаутом
+func (b *Bot) callAPIWith(ctx context.Context, timeout time.Duration, req httpx.RequestBuilder, result any) error {
+	if ctx != nil {
+		req = req.WithContext(ctx)
 	}
-	if b.timeout > 0 {
-		req = req.WithTimeout(b.timeout)
+	if timeout > 0 {
+		req = req.WithTimeout(timeout)
 	}
 	res := req.Do()
 	defer res.Close()
@@ -110,6 +125,8 @@ func (b *Bot) callAPI(req httpx.RequestBuilder, result any) error {
 	return nil
 }
 
+// SendMessage отправляет сообщение в чат. Текст должен быть подготовлен для parseMode бота (см. EscapeText)
+// При отказе Telegram возвращает *APIError
 func (b *Bot) SendMessage(chatId, text string) (any, error) {
 	fullURL := fmt.Sprintf("%s/bot%s/sendMessage", b.baseURL, b.apiKey)
 	params := map[string]any{
@@ -119,11 +136,19 @@ func (b *Bot) SendMessage(chatId, text string) (any, error) {
 	}
 	req := httpx.Post(fullURL).WithJsonData(params).
 		AddHeader("Content-Type", "application/json")
-	var result any
-	if err := b.callAPI(req, nil /*&result*/); err != nil {
-		return &result, err
+	var result apiResult[json.RawMessage]
+	if err := b.callAPI(req, &result); err != nil {
+		return nil, err
+	}
+	if err := result.err("SendMessage"); err != nil {
+		return nil, err
 	}
-	return result, nil
+	return result.Result, nil
+}
+
+// EscapeText экранирует текст для parseMode бота
+func (b *Bot) EscapeText(text string) string {
+	return EscapeText(b.parseMode, text)
 }
 
 // Write отправка сообщений для всех в списке writeChatIDs
@@ -131,11 +156,14 @@ func (b *Bot) Write(p []byte) (n int, err error) {
 	if len(b.writeChatIDs) == 0 {
 		return n, fmt.Errorf("%s: Write: список writeChatIDs пуст", errorTitel)
 	}
+	var errs []error
 	for _, c := range b.writeChatIDs {
-		_, err = b.SendMessage(c, string(p))
+		if _, err := b.SendMessage(c, string(p)); err != nil {
+			errs = append(errs, fmt.Errorf("чат %s: %w", c, err))
+		}
 	}
-	if err != nil {
-		return n, fmt.Errorf("%s: Write: ошибка отправки сообщения", errorTitel)
+	if len(errs) > 0 {
+		return n, fmt.Errorf("%s: Write: ошибка отправки сообщения: %w", errorTitel, errors.Join(errs...))
 	}
-	return len(p), err
+	return len(p), nil
 }
@@ -0,0 +1,85 @@
+package telebot
+
+import (
+	"html"
+	"strings"
+	"unicode/utf16"
+)
+
+// MaxMessageLength максимальная длина текста сообщения в символах UTF-16
+const MaxMessageLength = 4096
+
+// EscapeText экранирует текст, чтобы он отображался как есть в режиме parseMode
+// Поддерживаются "HTML", "MarkdownV2" и "Markdown", для остальных текст не меняется
+func EscapeText(parseMode, text string) string {
+	switch strings.ToLower(parseMode) {
+	case "html":
+		return html.EscapeString(text)
+	case "markdownv2":
+		return escapeChars(text, "_*[]()~`>#+-=|{}.!\\")
+	case "markdown":
+		return escapeChars(text, "_*`[")
+	}
+	return text
+}
+
+func escapeChars(text, chars string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		if strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SplitText делит текст на части не длиннее limit символов UTF-16
+// Деление выполняется по строкам, слишком длинные строки режутся
+func SplitText(text string, limit int) []string {
+	if textLen(text) <= limit {
+		return []string{text}
+	}
+	var (
+		parts []string
+		b     strings.Builder
+		size  int
+	)
+	flush := func() {
+		if b.Len() > 0 {
+			parts = append(parts, b.String())
+			b.Reset()
+			size = 0
+		}
+	}
+	for line := range strings.SplitAfterSeq(text, "\n") {
+		lineLen := textLen(line)
+		if size+lineLen <= limit {
+			b.WriteString(line)
+			size += lineLen
+			continue
+		}
+		if lineLen <= limit {
+			flush()
+		}
+		for _, r := range line {
+			n := utf16.RuneLen(r)
+			if size+n > limit {
+				flush()
+			}
+			b.WriteRune(r)
+			size += n
+		}
+	}
+	flush()
+	return parts
+}
+
+func textLen(text string) int {
+	var n int
+	for _, r := range text {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
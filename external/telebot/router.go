@@ -0,0 +1,213 @@
+package telebot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Command разобранная команда из сообщения
+type Command struct {
+	Name    string   // имя команды без "/" и упоминания бота
+	Args    []string // аргументы, разделенные пробелами
+	ChatID  int64
+	Message *Message
+}
+
+// Arg возвращает аргумент с номером i или пустую строку
+func (c *Command) Arg(i int) string {
+	if i < 0 || i >= len(c.Args) {
+		return ""
+	}
+	return c.Args[i]
+}
+
+// HandlerFunc обрабатывает команду и возвращает текст ответа
+// Пустой ответ не отправляется, ошибка отправляется в чат
+type HandlerFunc func(ctx context.Context, cmd *Command) (string, error)
+
+type route struct {
+	help    string
+	handler HandlerFunc
+}
+
+// Router получает обновления методом long polling и передает команды обработчикам
+// Команды принимаются только из чатов белого списка. Команды, отправленные до создания Router,
+// например пока бот был остановлен, пропускаются: Telegram хранит обновления до 24 часов
+type Router struct {
+	bot         *Bot
+	since       int64 // время создания, unix секунды
+	allowed     map[int64]bool
+	routes      map[string]route
+	order       []string
+	pollTimeout time.Duration
+	retryDelay  time.Duration
+	logger      *slog.Logger
+}
+
+// RouterOption определяет тип функции для настройки Router
+type RouterOption func(*Router)
+
+// WithPollTimeout устанавливает время ожидания обновлений на стороне сервера
+func WithPollTimeout(timeout time.Duration) RouterOption {
+	return func(r *Router) {
+		r.pollTimeout = timeout
+	}
+}
+
+// WithRetryDelay устанавливает паузу после ошибки получения обновлений
+func WithRetryDelay(delay time.Duration) RouterOption {
+	return func(r *Router) {
+		r.retryDelay = delay
+	}
+}
+
+// WithLogger устанавливает логгер ошибок маршрутизатора
+func WithLogger(logger *slog.Logger) RouterOption {
+	return func(r *Router) {
+		r.logger = logger
+	}
+}
+
+// NewRouter создает маршрутизатор команд для бота
+// allowedChatIDs - белый список чатов, которым разрешено выполнять команды
+func NewRouter(bot *Bot, allowedChatIDs []int64, opts ...RouterOption) *Router {
+	r := &Router{
+		bot:         bot,
+		since:       time.Now().Unix(),
+		allowed:     make(map[int64]bool, len(allowedChatIDs)),
+		routes:      make(map[string]route),
+		pollTimeout: 30 * time.Second,
+		retryDelay:  3 * time.Second,
+		logger:      slog.Default(),
+	}
+	for _, id := range allowedChatIDs {
+		r.allowed[id] = true
+	}
+	for _, option := range opts {
+		option(r)
+	}
+	r.Handle("help", "список команд", r.helpHandler)
+	r.Handle("start", "", r.helpHandler)
+	return r
+}
+
+// Handle регистрирует обработчик команды name (без "/")
+// Команды с пустым help не отображаются в /help
+func (r *Router) Handle(name, help string, handler HandlerFunc) {
+	if _, ok := r.routes[name]; !ok {
+		r.order = append(r.order, name)
+	}
+	r.routes[name] = route{help: help, handler: handler}
+}
+
+// Run получает обновления до отмены контекста
+func (r *Router) Run(ctx context.Context) error {
+	var offset int64
+	for {
+		updates, err := r.bot.GetUpdates(ctx, offset, r.pollTimeout)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			r.logger.Error("telegram getUpdates", "error", err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(r.retryDelay):
+			}
+			continue
+		}
+		for _, upd := range updates {
+			offset = max(offset, upd.UpdateID+1)
+			if upd.Message != nil && upd.Message.Date < r.since {
+				r.logger.Warn("telegram message sent before start skipped", "chatId", upd.Message.Chat.ID, "text", upd.Message.Text)
+				continue
+			}
+			r.Dispatch(ctx, upd)
+		}
+	}
+}
+
+// Dispatch обрабатывает одно обновление и отправляет ответ в чат
+func (r *Router) Dispatch(ctx context.Context, upd Update) {
+	cmd := ParseCommand(upd.Message)
+	if cmd == nil {
+		return
+	}
+	if !r.allowed[cmd.ChatID] {
+		r.logger.Warn("telegram command from unauthorized chat", "chatId", cmd.ChatID, "command", cmd.Name)
+		r.reply(cmd.ChatID, "нет доступа")
+		return
+	}
+	rt, ok := r.routes[cmd.Name]
+	if !ok {
+		r.reply(cmd.ChatID, fmt.Sprintf("неизвестная команда /%s, список команд: /help", cmd.Name))
+		return
+	}
+	text, err := rt.handler(ctx, cmd)
+	if err != nil {
+		text = "ошибка: " + err.Error()
+	}
+	if text != "" {
+		r.reply(cmd.ChatID, text)
+	}
+}
+
+func (r *Router) reply(chatID int64, text string) {
+	for _, part := range SplitText(text, MaxMessageLength) {
+		if _, err := r.bot.SendMessage(strconv.FormatInt(chatID, 10), r.bot.EscapeText(part)); err != nil {
+			r.logger.Error("telegram sendMessage", "chatId", chatID, "error", err)
+			return
+		}
+	}
+}
+
+func (r *Router) helpHandler(context.Context, *Command) (string, error) {
+	var b strings.Builder
+	for _, name := range r.order {
+		if help := r.routes[name].help; help != "" {
+			fmt.Fprintf(&b, "/%s - %s\n", name, help)
+		}
+	}
+	return b.String(), nil
+}
+
+// ParseCommand разбирает сообщение вида "/name@bot arg1 arg2"
+// Возвращает nil, если сообщение не является командой
+func ParseCommand(msg *Message) *Command {
+	if msg == nil || !strings.HasPrefix(msg.Text, "/") {
+		return nil
+	}
+	fields := strings.Fields(msg.Text)
+	name, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	if name == "" {
+		return nil
+	}
+	return &Command{
+		Name:    strings.ToLower(name),
+		Args:    fields[1:],
+		ChatID:  msg.Chat.ID,
+		Message: msg,
+	}
+}
+
+// ParseChatIDs разбирает список ID чатов, разделенных запятыми, например из переменной окружения
+func ParseChatIDs(s string) ([]int64, error) {
+	var ids []int64
+	for field := range strings.SplitSeq(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: ParseChatIDs: %w", errorTitel, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
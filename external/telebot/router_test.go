@@ -0,0 +1,119 @@
+package telebot_test
+
+import (
+	"context"
+	"errors"
+	"goTradingBot/external/telebot"
+	"goTradingBot/external/telebot/telebottest"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouterRun(t *testing.T) {
+	srv := telebottest.NewServer()
+	defer srv.Close()
+
+	router := telebot.NewRouter(
+		srv.Bot(telebot.WithTimeout(time.Second)),
+		[]int64{42},
+		telebot.WithPollTimeout(time.Second),
+		telebot.WithRetryDelay(10*time.Millisecond),
+		telebot.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+	var pausedName string
+	router.Handle("pause", "<стратегия> приостановить стратегию", func(_ context.Context, cmd *telebot.Command) (string, error) {
+		if cmd.Arg(0) == "" {
+			return "", errors.New("укажите стратегию")
+		}
+		pausedName = cmd.Arg(0)
+		return "пауза " + cmd.Arg(0), nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- router.Run(ctx) }()
+
+	srv.SendText(7, "/status")
+	srv.SendText(42, "/pause@test_bot HYPEUSDT-M5")
+	srv.SendText(42, "/pause")
+	srv.SendText(42, "/unknown")
+	srv.SendText(42, "просто текст")
+	srv.SendText(42, "/help")
+
+	sent, ok := srv.WaitSent(5, 5*time.Second)
+	if !ok {
+		t.Fatalf("получено %d ответов из 5: %+v", len(sent), sent)
+	}
+	expected := []struct {
+		chatID string
+		text   string
+	}{
+		{"7", "нет доступа"},
+		{"42", "пауза HYPEUSDT-M5"},
+		{"42", "ошибка: укажите стратегию"},
+		{"42", "неизвестная команда /unknown"},
+		{"42", "/pause - &lt;стратегия&gt; приостановить стратегию"},
+	}
+	for i, e := range expected {
+		if sent[i].ChatID != e.chatID || !strings.Contains(sent[i].Text, e.text) {
+			t.Errorf("ответ %d: %+v, ожидалось %q в чат %s", i, sent[i], e.text, e.chatID)
+		}
+	}
+	if pausedName != "HYPEUSDT-M5" {
+		t.Errorf("аргумент команды: %q", pausedName)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run: %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run не завершился после отмены контекста")
+	}
+}
+
+func TestRouterSkipsBacklog(t *testing.T) {
+	srv := telebottest.NewServer()
+	defer srv.Close()
+
+	// команда, отправленная пока бот был остановлен, не выполняется после запуска
+	srv.SendTextAt(42, "/pause OLD", time.Now().Add(-time.Hour))
+	router := telebot.NewRouter(
+		srv.Bot(telebot.WithTimeout(time.Second)),
+		[]int64{42},
+		telebot.WithPollTimeout(time.Second),
+		telebot.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+	var paused []string
+	router.Handle("pause", "", func(_ context.Context, cmd *telebot.Command) (string, error) {
+		paused = append(paused, cmd.Arg(0))
+		return "пауза " + cmd.Arg(0), nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go router.Run(ctx)
+
+	srv.SendText(42, "/pause NEW")
+	sent, ok := srv.WaitSent(1, 5*time.Second)
+	if !ok || !strings.Contains(sent[0].Text, "пауза NEW") {
+		t.Fatalf("ответы: %+v", sent)
+	}
+	if len(paused) != 1 || paused[0] != "NEW" {
+		t.Errorf("выполнены команды: %v", paused)
+	}
+}
+
+func TestParseChatIDs(t *testing.T) {
+	ids, err := telebot.ParseChatIDs(" 1, -100200 ,,3")
+	if err != nil || len(ids) != 3 || ids[0] != 1 || ids[1] != -100200 || ids[2] != 3 {
+		t.Fatalf("ParseChatIDs: %v %v", ids, err)
+	}
+	if _, err := telebot.ParseChatIDs("1,abc"); err == nil {
+		t.Fatal("ParseChatIDs: ожидалась ошибка")
+	}
+}
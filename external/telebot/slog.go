@@ -3,68 +3,309 @@ package telebot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
+var _ slog.Handler = (*BotSlogHandler)(nil)
+
+// BotSlogHandler отправляет записи журнала в чаты writeChatIDs бота
+// Записи ниже минимального уровня отбрасываются, повторы подавляются,
+// записи за окно батчинга объединяются в одно сообщение, отправка выполняется в фоне
 type BotSlogHandler struct {
-	bot *Bot
-	slog.Handler
+	sink   *slogSink
+	prefix string   // группы WithGroup в виде "group.subgroup."
+	attrs  []string // атрибуты WithAttrs в виде "key: value"
+}
+
+// SlogOption определяет тип функции для настройки BotSlogHandler
+type SlogOption func(*slogSink)
+
+// WithLevel устанавливает минимальный уровень записей (по умолчанию slog.LevelInfo)
+func WithLevel(level slog.Leveler) SlogOption {
+	return func(s *slogSink) {
+		s.level = level
+	}
+}
+
+// WithBatchWindow устанавливает окно, за которое записи объединяются в одно сообщение
+func WithBatchWindow(window time.Duration) SlogOption {
+	return func(s *slogSink) {
+		s.batchWindow = window
+	}
+}
+
+// WithDedupWindow устанавливает окно подавления повторов. 0 отключает подавление
+func WithDedupWindow(window time.Duration) SlogOption {
+	return func(s *slogSink) {
+		s.dedupWindow = window
+	}
+}
+
+// WithDedupKey устанавливает ключ повтора записи
+// По умолчанию повтором считается запись с тем же уровнем, сообщением и атрибутами
+func WithDedupKey(key func(r slog.Record) string) SlogOption {
+	return func(s *slogSink) {
+		s.dedupKey = key
+	}
+}
+
+// WithMaxPending устанавливает максимальное количество записей, ожидающих отправки
+func WithMaxPending(n int) SlogOption {
+	return func(s *slogSink) {
+		s.maxPending = n
+	}
+}
+
+// NewSlogHandler создает обработчик журнала, отправляющий записи через bot
+func NewSlogHandler(bot *Bot, opts ...SlogOption) *BotSlogHandler {
+	s := &slogSink{
+		bot:         bot,
+		level:       slog.LevelInfo,
+		batchWindow: 2 * time.Second,
+		dedupWindow: time.Minute,
+		maxPending:  1000,
+		seen:        make(map[string]*dedupEntry),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	for _, option := range opts {
+		option(s)
+	}
+	go s.run()
+	return &BotSlogHandler{sink: s}
 }
 
 func NewBotSlogHandler(apiKey string, writeChatID string, slogOpts *slog.HandlerOptions, botOpts ...Option) *BotSlogHandler {
 	botOpts = append(botOpts, WithWriteChatID(writeChatID))
-	return &BotSlogHandler{
-		bot:     NewBot(apiKey, botOpts...),
-		Handler: slog.NewTextHandler(os.Stdin, slogOpts),
-	}
+	return NewSlogHandler(NewBot(apiKey, botOpts...), levelOptions(slogOpts)...)
 }
 
 func NewBotSlogHandlerFromEnv(writeChatID string, slogOpts *slog.HandlerOptions, botOpts ...Option) *BotSlogHandler {
 	botOpts = append(botOpts, WithWriteChatID(writeChatID))
-	return &BotSlogHandler{
-		bot:     NewBotFromEnv(botOpts...),
-		Handler: slog.NewTextHandler(os.Stdin, slogOpts),
+	return NewSlogHandler(NewBotFromEnv(botOpts...), levelOptions(slogOpts)...)
+}
+
+func levelOptions(slogOpts *slog.HandlerOptions) []SlogOption {
+	if slogOpts == nil || slogOpts.Level == nil {
+		return nil
 	}
+	return []SlogOption{WithLevel(slogOpts.Level)}
+}
+
+func (h *BotSlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.sink.level.Level()
 }
 
 func (h *BotSlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return h
+	if len(attrs) == 0 {
+		return h
+	}
+	nh := &BotSlogHandler{sink: h.sink, prefix: h.prefix, attrs: slices.Clone(h.attrs)}
+	for _, a := range attrs {
+		nh.attrs = appendAttr(nh.attrs, h.prefix, a)
+	}
+	return nh
 }
 
 func (h *BotSlogHandler) WithGroup(name string) slog.Handler {
-	return h
+	if name == "" {
+		return h
+	}
+	return &BotSlogHandler{sink: h.sink, prefix: h.prefix + name + ".", attrs: h.attrs}
 }
 
-func (h *BotSlogHandler) Handle(ctx context.Context, r slog.Record) error {
-	level := r.Level.String()
-	switch r.Level {
+// Handle ставит запись в очередь на отправку и не блокируется на сетевых запросах
+func (h *BotSlogHandler) Handle(_ context.Context, r slog.Record) error {
+	lines := slices.Clone(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		lines = appendAttr(lines, h.prefix, a)
+		return true
+	})
+	text := levelTitle(r.Level) + ": " + r.Message
+	if len(lines) > 0 {
+		text += "\n" + strings.Join(lines, "\n")
+	}
+	key := text
+	if h.sink.dedupKey != nil {
+		key = h.sink.dedupKey(r)
+	}
+	h.sink.add(key, text)
+	return nil
+}
+
+// Close отправляет накопленные записи и останавливает фоновую отправку
+func (h *BotSlogHandler) Close() {
+	h.sink.closeOnce.Do(func() { close(h.sink.stop) })
+	<-h.sink.done
+}
+
+func levelTitle(level slog.Level) string {
+	switch level {
 	case slog.LevelDebug:
-		level = "⚪️ " + level
+		return "⚪️ " + level.String()
 	case slog.LevelInfo:
-		level = "🟢 " + level
+		return "🟢 " + level.String()
 	case slog.LevelWarn:
-		level = "🟡 " + level
+		return "🟡 " + level.String()
 	case slog.LevelError:
-		level = "🔴 " + level
-	default:
-		level = "⚫️ " + level
+		return "🔴 " + level.String()
 	}
-	fields := make(map[string]any)
-	r.Attrs(func(a slog.Attr) bool {
-		fields[a.Key] = a.Value.Any()
-		return true
-	})
-	b := []byte{}
-	if len(fields) > 0 {
-		data, err := json.MarshalIndent(fields, "", "  ")
-		if err != nil {
+	return "⚫️ " + level.String()
+}
+
+func appendAttr(lines []string, prefix string, a slog.Attr) []string {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return lines
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			lines = appendAttr(lines, prefix, ga)
+		}
+		return lines
+	}
+	return append(lines, prefix+a.Key+": "+formatValue(a.Value))
+}
+
+func formatValue(v slog.Value) string {
+	if v.Kind() != slog.KindAny {
+		return v.String()
+	}
+	switch val := v.Any().(type) {
+	case error:
+		return val.Error()
+	case fmt.Stringer:
+		return val.String()
+	}
+	if data, err := json.Marshal(v.Any()); err == nil {
+		return string(data)
+	}
+	return fmt.Sprint(v.Any())
+}
+
+type dedupEntry struct {
+	first      time.Time
+	suppressed int
+	text       string
+}
+
+// slogSink общее состояние обработчика и его копий WithAttrs/WithGroup
+type slogSink struct {
+	bot         *Bot
+	level       slog.Leveler
+	batchWindow time.Duration
+	dedupWindow time.Duration
+	dedupKey    func(r slog.Record) string
+	maxPending  int
+
+	mu      sync.Mutex
+	pending []string
+	dropped int
+	seen    map[string]*dedupEntry
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (s *slogSink) add(key, text string) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dedupWindow > 0 {
+		if e, ok := s.seen[key]; ok {
+			if now.Sub(e.first) < s.dedupWindow {
+				e.suppressed++
+				return
+			}
+			if e.suppressed > 0 {
+				text += fmt.Sprintf("\n(повторялось еще %d раз)", e.suppressed)
+			}
+		}
+		s.seen[key] = &dedupEntry{first: now, text: text}
+	}
+	if len(s.pending) >= s.maxPending {
+		s.dropped++
+		return
+	}
+	s.pending = append(s.pending, text)
+}
+
+// take забирает накопленные записи одним текстом
+func (s *slogSink) take() string {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, e := range s.seen {
+		if now.Sub(e.first) < s.dedupWindow {
+			continue
+		}
+		if e.suppressed > 0 {
+			title, _, _ := strings.Cut(e.text, "\n")
+			s.pending = append(s.pending, fmt.Sprintf("повторялось еще %d раз: %s", e.suppressed, title))
+		}
+		delete(s.seen, key)
+	}
+	if s.dropped > 0 {
+		s.pending = append(s.pending, fmt.Sprintf("пропущено записей при переполнении очереди: %d", s.dropped))
+		s.dropped = 0
+	}
+	text := strings.Join(s.pending, "\n\n")
+	s.pending = nil
+	return text
+}
+
+func (s *slogSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.batchWindow)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			s.flush()
+			return
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+func (s *slogSink) flush() {
+	text := s.take()
+	if text == "" {
+		return
+	}
+	for _, part := range SplitText(text, MaxMessageLength) {
+		for _, chatID := range s.bot.writeChatIDs {
+			if err := s.send(chatID, s.bot.EscapeText(part)); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: BotSlogHandler: %v\n", errorTitel, err)
+			}
+		}
+	}
+}
+
+// send отправляет сообщение, выдерживая паузу retry_after при ответе 429
+func (s *slogSink) send(chatID, text string) error {
+	const maxAttempts = 3
+	for attempt := 1; ; attempt++ {
+		_, err := s.bot.SendMessage(chatID, text)
+		var apiErr *APIError
+		if err == nil || attempt == maxAttempts || !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 {
 			return err
 		}
-		b = data
+		time.Sleep(apiErr.RetryAfter)
 	}
-	text := fmt.Sprintf("%s: %s\n\n%s", level, r.Message, b)
-	_, err := h.bot.Write([]byte(text))
-	return err
 }
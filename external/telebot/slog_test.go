@@ -0,0 +1,71 @@
+package telebot_test
+
+import (
+	"goTradingBot/external/telebot"
+	"goTradingBot/external/telebot/telebottest"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func TestBotSlogHandlerBatch(t *testing.T) {
+	srv := telebottest.NewServer()
+	defer srv.Close()
+
+	h := telebot.NewSlogHandler(
+		srv.Bot(telebot.WithWriteChatID("1")),
+		telebot.WithLevel(slog.LevelWarn),
+		telebot.WithBatchWindow(time.Hour),
+		telebot.WithDedupWindow(time.Hour),
+	)
+	logger := slog.New(h).With("bot", "main").WithGroup("order")
+	logger.Info("пропускается по уровню")
+	logger.Warn("первое", "id", 1)
+	logger.Warn("первое", "id", 1)
+	logger.Warn("первое", "id", 2)
+	logger.Error("второе", slog.Group("g", "k", "<b>"))
+	h.Close()
+
+	sent := srv.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("ожидалось одно сообщение, получено %d: %+v", len(sent), sent)
+	}
+	text := sent[0].Text
+	for _, s := range []string{"WARN: первое", "bot: main", "order.id: 1", "order.id: 2", "ERROR: второе", "order.g.k: &lt;b&gt;"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("нет %q в сообщении:\n%s", s, text)
+		}
+	}
+	if strings.Contains(text, "пропускается") || strings.Count(text, "order.id: 1") != 1 {
+		t.Errorf("фильтр уровня или подавление повторов не сработали:\n%s", text)
+	}
+}
+
+func TestBotSlogHandlerSplitAndRetry(t *testing.T) {
+	srv := telebottest.NewServer()
+	defer srv.Close()
+	srv.RateLimit(1, time.Second)
+
+	h := telebot.NewSlogHandler(
+		srv.Bot(telebot.WithWriteChatID("1"), telebot.WithParseMode("MarkdownV2")),
+		telebot.WithBatchWindow(time.Hour),
+	)
+	slog.New(h).Error("длинное", "data", strings.Repeat("a.", 3000))
+	h.Close()
+
+	sent := srv.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("ожидалось два сообщения, получено %d", len(sent))
+	}
+	for _, m := range sent {
+		plain := strings.ReplaceAll(m.Text, `\`, "")
+		if n := len(utf16.Encode([]rune(plain))); n > telebot.MaxMessageLength {
+			t.Errorf("длина сообщения %d больше %d", n, telebot.MaxMessageLength)
+		}
+		if strings.Contains(strings.ReplaceAll(m.Text, `\.`, ""), ".") {
+			t.Errorf("точка не экранирована для MarkdownV2")
+		}
+	}
+}
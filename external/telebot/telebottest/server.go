@@ -0,0 +1,188 @@
+// Package telebottest предоставляет локальный поддельный Telegram Bot API для тестов
+// Поддерживаются методы getUpdates (с long polling) и sendMessage
+package telebottest
+
+import (
+	"encoding/json"
+	"goTradingBot/external/telebot"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SentMessage сообщение, отправленное ботом через sendMessage
+type SentMessage struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+// Server поддельный Telegram Bot API
+type Server struct {
+	*httptest.Server
+	Token string
+
+	mu       sync.Mutex
+	nextID   int64
+	updates  []telebot.Update
+	sent     []SentMessage
+	limited  int
+	retry    int
+	notify   chan struct{}
+	received chan struct{}
+}
+
+// NewServer запускает сервер. Токен бота - "test-token"
+func NewServer() *Server {
+	s := &Server{
+		Token:    "test-token",
+		nextID:   1,
+		notify:   make(chan struct{}),
+		received: make(chan struct{}, 1024),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Bot создает клиента, настроенного на этот сервер
+func (s *Server) Bot(opts ...telebot.Option) *telebot.Bot {
+	opts = append([]telebot.Option{telebot.WithBaseURL(s.URL)}, opts...)
+	return telebot.NewBot(s.Token, opts...)
+}
+
+// SendText добавляет входящее сообщение из чата chatID
+func (s *Server) SendText(chatID int64, text string) {
+	s.SendTextAt(chatID, text, time.Now())
+}
+
+// SendTextAt добавляет входящее сообщение из чата chatID, отправленное в момент date
+func (s *Server) SendTextAt(chatID int64, text string, date time.Time) {
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.updates = append(s.updates, telebot.Update{
+		UpdateID: id,
+		Message: &telebot.Message{
+			MessageID: id,
+			From:      &telebot.User{ID: chatID},
+			Chat:      telebot.Chat{ID: chatID, Type: "private"},
+			Date:      date.Unix(),
+			Text:      text,
+		},
+	})
+	close(s.notify)
+	s.notify = make(chan struct{})
+	s.mu.Unlock()
+}
+
+// RateLimit отвечает ошибкой 429 с retry_after на следующие n вызовов sendMessage
+func (s *Server) RateLimit(n int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limited = n
+	s.retry = int(retryAfter.Seconds())
+}
+
+// Sent возвращает копию отправленных ботом сообщений
+func (s *Server) Sent() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SentMessage(nil), s.sent...)
+}
+
+// WaitSent ожидает, пока бот отправит не меньше n сообщений, и возвращает их
+// Возвращает false при истечении timeout
+func (s *Server) WaitSent(n int, timeout time.Duration) ([]SentMessage, bool) {
+	deadline := time.After(timeout)
+	for {
+		if sent := s.Sent(); len(sent) >= n {
+			return sent, true
+		}
+		select {
+		case <-s.received:
+		case <-deadline:
+			return s.Sent(), false
+		}
+	}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	prefix := "/bot" + s.Token + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		writeResult(w, http.StatusUnauthorized, false, "Unauthorized", nil)
+		return
+	}
+	switch strings.TrimPrefix(r.URL.Path, prefix) {
+	case "getUpdates":
+		s.getUpdates(w, r)
+	case "sendMessage":
+		var msg SentMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			writeResult(w, http.StatusBadRequest, false, err.Error(), nil)
+			return
+		}
+		s.mu.Lock()
+		if s.limited > 0 {
+			s.limited--
+			retry := s.retry
+			s.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(map[string]any{
+				"ok":          false,
+				"error_code":  http.StatusTooManyRequests,
+				"description": "Too Many Requests: retry after " + strconv.Itoa(retry),
+				"parameters":  map[string]any{"retry_after": retry},
+			})
+			return
+		}
+		s.sent = append(s.sent, msg)
+		s.mu.Unlock()
+		s.received <- struct{}{}
+		writeResult(w, http.StatusOK, true, "", map[string]any{"message_id": len(s.Sent())})
+	default:
+		writeResult(w, http.StatusNotFound, false, "Not Found", nil)
+	}
+}
+
+func (s *Server) getUpdates(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	timeout, _ := strconv.Atoi(r.URL.Query().Get("timeout"))
+	deadline := time.After(time.Duration(timeout) * time.Second)
+	for {
+		s.mu.Lock()
+		var pending []telebot.Update
+		for _, u := range s.updates {
+			if u.UpdateID >= offset {
+				pending = append(pending, u)
+			}
+		}
+		notify := s.notify
+		s.mu.Unlock()
+		if len(pending) > 0 || timeout == 0 {
+			writeResult(w, http.StatusOK, true, "", pending)
+			return
+		}
+		select {
+		case <-notify:
+		case <-deadline:
+			writeResult(w, http.StatusOK, true, "", []telebot.Update{})
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeResult(w http.ResponseWriter, status int, ok bool, description string, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{
+		"ok":          ok,
+		"description": description,
+		"result":      result,
+	})
+}
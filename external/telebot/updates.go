@@ -0,0 +1,89 @@
+package telebot
+
+import (
+	"context"
+	"fmt"
+	"goTradingBot/httpx"
+	"strconv"
+	"time"
+)
+
+// Update входящее обновление Telegram Bot API
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message,omitempty"`
+}
+
+// Message входящее сообщение
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	From      *User  `json:"from,omitempty"`
+	Chat      Chat   `json:"chat"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text"`
+}
+
+// Chat чат, из которого пришло сообщение
+type Chat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+// User отправитель сообщения
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+// apiResult общий формат ответа Telegram Bot API
+type apiResult[T any] struct {
+	Ok          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Result      T      `json:"result"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// APIError ошибка, возвращенная Telegram Bot API
+type APIError struct {
+	Method      string
+	Code        int
+	Description string
+	RetryAfter  time.Duration // для кода 429: время до следующей попытки
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %s: %d %s", errorTitel, e.Method, e.Code, e.Description)
+}
+
+// err возвращает *APIError, если запрос не выполнен
+func (r *apiResult[T]) err(method string) error {
+	if r.Ok {
+		return nil
+	}
+	e := &APIError{Method: method, Code: r.ErrorCode, Description: r.Description}
+	if r.Parameters != nil {
+		e.RetryAfter = time.Duration(r.Parameters.RetryAfter) * time.Second
+	}
+	return e
+}
+
+// GetUpdates запрашивает обновления начиная с offset методом long polling
+// timeout - время ожидания новых обновлений на стороне сервера
+func (b *Bot) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	fullURL := fmt.Sprintf("%s/bot%s/getUpdates", b.baseURL, b.apiKey)
+	req := httpx.Get(fullURL).
+		SetQueryParam("offset", strconv.FormatInt(offset, 10)).
+		SetQueryParam("timeout", strconv.Itoa(int(timeout.Seconds()))).
+		SetQueryParam("allowed_updates", `["message"]`)
+	var result apiResult[[]Update]
+	if err := b.callAPIWith(ctx, timeout+b.timeout, req, &result); err != nil {
+		return nil, err
+	}
+	if err := result.err("GetUpdates"); err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
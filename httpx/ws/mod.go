@@ -29,7 +29,8 @@ type Client struct {
 	pingInterval time.Duration // Интервал пингов
 
 	// Дополнительные параметры
-	handshake []byte // Данные для начального рукопожатия
+	handshake   []byte // Данные для начального рукопожатия
+	onReconnect func() // Вызывается после успешного переподключения
 }
 
 // NewClient создает новый WebSocket клиент с опциональными настройками
@@ -65,6 +66,11 @@ func WithHeader(h http.Header) Option {
 	return func(c *Client) { c.header = h }
 }
 
+// WithOnReconnect устанавливает функцию, вызываемую после успешного переподключения
+func WithOnReconnect(f func()) Option {
+	return func(c *Client) { c.onReconnect = f }
+}
+
 // WithWriteTimeout устанавливает таймаут записи
 func WithWriteTimeout(d time.Duration) Option {
 	return func(c *Client) { c.writeWait = d }
@@ -120,6 +126,9 @@ func (c *Client) runPumps(url string) {
 			return
 		case <-ticker.C:
 			if _, err := c.Connect(url); err == nil {
+				if c.onReconnect != nil {
+					c.onReconnect()
+				}
 				return
 			}
 		}
@@ -1,21 +1,43 @@
 package main
 
 import (
+	"bufio"
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"goTradingBot/cdl"
 	"goTradingBot/external/bybit"
+	"goTradingBot/external/cryptos"
+	cryptosdb "goTradingBot/external/cryptos/db"
 	"goTradingBot/external/telebot"
+	"goTradingBot/httpx"
 	"goTradingBot/predict"
 	"goTradingBot/predict/dataset"
 	"goTradingBot/predict/portal"
 	"goTradingBot/predict/signals"
 	"goTradingBot/trading"
+	"goTradingBot/trading/config"
+	orderdb "goTradingBot/trading/db"
+	"goTradingBot/trading/notify"
+	"goTradingBot/trading/portfolio"
+	"goTradingBot/trading/regime"
+	"goTradingBot/trading/schedule"
 	"goTradingBot/trading/strategies"
+	"goTradingBot/trading/types"
 	"goTradingBot/utils/slogx"
+	"goTradingBot/web/app"
+	"goTradingBot/web/auth"
+	"io"
 	"log"
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -47,7 +69,34 @@ func GenDataset() {
 	sgb = sgb.AddNextPerfectTrend(4)
 	sgb = sgb.AddPerfectTrend(9)
 	sgb = sgb.AddNextPerfectTrend(9)
-	dataset.CreateDataset(client, params, fg, sgb.Build())
+	if _, err := dataset.CreateDataset(client, params, fg, sgb.Build()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// NewNotifier создает уведомления с приемниками из переменных окружения:
+// локальный файл NOTIFY_FILE, webhook NOTIFY_WEBHOOK_URL и Telegram-чаты chatIDs
+func NewNotifier(ctx context.Context, logger *slog.Logger, reporter *notify.Reporter, chatIDs []int64, sinks ...notify.Sink) *notify.Notifier {
+	opts := []notify.Option{
+		notify.WithLogger(logger),
+		notify.WithSink(reporter, notify.OrderFilled, notify.PositionClosed),
+		notify.WithSink(notify.NewFileSink(notifyFilePath())),
+	}
+	for _, sink := range sinks {
+		opts = append(opts, notify.WithSink(sink))
+	}
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		opts = append(opts, notify.WithSink(notify.NewWebhookSink(url, nil)))
+	}
+	if len(chatIDs) > 0 {
+		writeChatIDs := make([]string, len(chatIDs))
+		for i, id := range chatIDs {
+			writeChatIDs[i] = strconv.FormatInt(id, 10)
+		}
+		tg := telebot.NewBotFromEnv(telebot.WithContext(ctx), telebot.WithWriteChatIDs(writeChatIDs))
+		opts = append(opts, notify.WithSink(notify.NewTelegramSink(tg)))
+	}
+	return notify.NewNotifier(ctx, opts...)
 }
 
 func Run(ctx context.Context) {
@@ -55,33 +104,430 @@ func Run(ctx context.Context) {
 		log.Fatal(err)
 	}
 
+	var bot *trading.TradingBot
+	// поток свечей, уведомлений и журнала для веб-клиентов панели управления
+	// Свечи доступны только по инструментам запущенных стратегий: поток свечей бота не освобождается
+	hub := app.NewStreamHub(ctx, app.CandleSubscriberFunc(
+		func(symbol string, interval cdl.Interval, ch chan<- *cdl.CandleStreamData) (chan<- struct{}, error) {
+			return bot.SubData().SubscribeChan(symbol, interval, ch)
+		},
+	), app.WithCandleFilter(func(symbol string, _ cdl.Interval) bool { return bot.Trades(symbol) }))
+	logger := slog.New(slogx.Fanout(
+		slog.NewJSONHandler(os.Stdout, nil),
+		telebot.NewBotSlogHandlerFromEnv("", nil),
+		hub.LogHandler(),
+	))
+	// управление ботом и уведомления в чатах TELEBOT_ADMIN_CHAT_IDS
+	adminChatIDs, err := telebot.ParseChatIDs(os.Getenv("TELEBOT_ADMIN_CHAT_IDS"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	reporter := notify.NewReporter(func() []notify.Exposure { return bot.Exposure() }, notify.Daily, notify.Weekly)
+	notifier := NewNotifier(ctx, logger, reporter, adminChatIDs, hub)
+
 	cli := bybit.NewClientFromEnv(
 		// bybit.WithContext(ctx),
 		bybit.WithCategory("linear"),
 		bybit.WithTimeout(3*time.Second),
+		bybit.WithOnReconnect(func(stream string) {
+			notifier.Notify(&notify.Event{Kind: notify.StreamReconnect, Message: stream})
+		}),
 	)
-	logger := slog.New(slogx.Fanout(
-		slog.NewJSONHandler(os.Stdout, nil),
-		telebot.NewBotSlogHandlerFromEnv("", nil),
-	))
-	bot := trading.NewTradingBot(
+	// файл конфигурации BOT_CONFIG, режим маржи бессрочных контрактов MARGIN_MODE=isolated|cross
+	cfg := config.DefaultTradingBotConfig()
+	if path := os.Getenv("BOT_CONFIG"); path != "" {
+		if cfg, err = config.Load(path); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if mode := os.Getenv("MARGIN_MODE"); mode != "" {
+		cfg.MarginMode = mode
+	}
+	// журнал ордеров, путь к базе задается переменной ORDERS_DB
+	store, err := orderdb.OpenSQLite(ordersDBPath())
+	if err != nil {
+		log.Fatal(err)
+	}
+	bot = trading.NewTradingBot(
 		ctx,
 		cli.TradingClientImpl(),
 		cli.DataProviderImpl(),
 		logger,
-		nil,
+		cfg,
+		store,
 	)
+	bot.SetNotifier(notifier)
+	// распределение капитала кошелька между стратегиями: PORTFOLIO_METHOD=fixed|equal|volatility|kelly
+	var pf *portfolio.Portfolio
+	if name := os.Getenv("PORTFOLIO_METHOD"); name != "" {
+		method, err := portfolio.ParseMethod(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		pf = portfolio.New(
+			cli.TradingClientImpl().GetEquity,
+			portfolio.WithMethod(method),
+			portfolio.WithStats(bot.StrategyStats),
+			portfolio.WithLogger(logger),
+		)
+		bot.SetPortfolio(pf)
+	}
+	go reporter.Run(ctx, notifier)
+	go notifier.WatchHealth(ctx, time.Minute, portal.Ping)
+	go bot.WatchLiquidation(ctx, time.Minute, 0.1)
+	go bot.WatchFunding(ctx, 10*time.Minute)
 
-	bot.AddStrategys(
-		strategies.NewStrategy(
-			"HYPEUSDT", cdl.M5,
-			"xgb_linear-M5_PerfectTrend-p4",
-			15, 0.6, 0.02,
-		),
+	// режим рынка инструмента стратегии, позиции открываются в режимах regimes файла конфигурации
+	hypeRegime := regime.NewDetector("HYPEUSDT", cdl.M5, regime.NewClassifier())
+	hypeRegime.Subscribe(func(change regime.Change) {
+		logger.Info("market regime changed", "symbol", change.Symbol, "from", change.From, "to", change.To)
+	})
+	hypeOpts := []strategies.StrategyOption{strategies.WithRegimes(hypeRegime, false, cfg.Regimes...)}
+	// сигналы модели подтверждаются трендом рыночной структуры из раздела structure файла конфигурации
+	if cfg.Structure != nil {
+		rule, err := strategies.StructureRule(*cfg.Structure)
+		if err != nil {
+			log.Fatal(err)
+		}
+		hypeOpts = append(hypeOpts, strategies.WithRules(rule))
+	}
+	hype := strategies.NewStrategy(
+		"HYPEUSDT", cdl.M5,
+		"xgb_linear-M5_PerfectTrend-p4",
+		15, 0.6, 0.02,
+		hypeOpts...,
+	)
+	// окна торговли и задачи из раздела schedule файла конфигурации
+	scheduler, err := schedule.New(
+		cfg.Schedule,
+		schedule.WithLogger(logger),
+		schedule.WithJob("cryptosdb", func(context.Context) error { return UpdateCryptosDB(300) }),
+		schedule.WithJob("logs", func(context.Context) error { return RotateFile(notifyFilePath()) }),
+		schedule.WithJob("report", func(context.Context) error {
+			notifier.Notify(&notify.Event{Kind: notify.ReportReady, Report: reporter.Snapshot(notify.Daily)})
+			return nil
+		}),
+		schedule.WithJob("history", func(context.Context) error { return DownloadHistory(cli, hype) }),
 	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	bot.SetScheduler(scheduler)
+
+	bot.AddStrategys(hype)
+	if pf != nil {
+		go pf.Run(ctx)
+	}
+	go scheduler.Run(ctx)
+
+	// панель управления ботом, команды управления требуют WEB_CONTROL_TOKEN или
+	// пользователя с уровнем control; авторизация и TLS - переменные WEB_AUTH_* и WEB_TLS_*
+	if addr := os.Getenv("WEB_DASHBOARD_ADDR"); addr != "" {
+		opts, err := app.ServerOptionsFromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts = append(opts, app.WithStreamHub(hub), app.WithOrderStore(store))
+		if token := os.Getenv("WEB_CONTROL_TOKEN"); token != "" {
+			opts = append(opts, app.WithControlToken(token))
+		}
+		go func() {
+			err := app.RunDashboard(addr, bot, opts...)
+			logger.Error("dashboard stopped", "error", err)
+		}()
+	}
+
+	if len(adminChatIDs) > 0 {
+		router := bot.TelegramRouter(
+			telebot.NewBotFromEnv(telebot.WithContext(ctx)),
+			adminChatIDs,
+			telebot.WithLogger(logger),
+		)
+		go router.Run(ctx)
+	}
+}
+
+// notifyFilePath путь к файлу уведомлений из NOTIFY_FILE или notifications.jsonl
+func notifyFilePath() string {
+	return cmp.Or(os.Getenv("NOTIFY_FILE"), "notifications.jsonl")
+}
+
+// UpdateCryptosDB добавляет в базу криптовалют отсутствующие монеты из первых limit по капитализации
+func UpdateCryptosDB(limit int) error {
+	cryptoList, err := cryptos.NewClient().GetCryptoList(limit)
+	if err != nil {
+		return fmt.Errorf("UpdateCryptosDB: %w", err)
+	}
+	for _, crypto := range cryptoList {
+		if _, err := cryptosdb.GetCryptoByID(crypto.ID); err == nil {
+			continue
+		}
+		logoUrl := fmt.Sprintf("https://s2.coinmarketcap.com/static/img/coins/64x64/%d.png", crypto.ID)
+		res := httpx.Get(logoUrl).Do()
+		logo, err := res.ReadBody()
+		res.Close()
+		if err != nil {
+			continue
+		}
+		err = cryptosdb.InsertCrypto(&cryptosdb.Crypto{
+			ID:     crypto.ID,
+			Name:   crypto.Name,
+			Symbol: crypto.Symbol,
+			Logo:   logo,
+		})
+		if err != nil {
+			return fmt.Errorf("UpdateCryptosDB: %w", err)
+		}
+	}
+	return nil
+}
+
+// RotateFile переименовывает непустой файл path, добавляя к имени время UTC
+// Следующая запись создает новый файл
+func RotateFile(path string) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) || err == nil && info.Size() == 0 {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("RotateFile: %w", err)
+	}
+	ext := filepath.Ext(path)
+	rotated := strings.TrimSuffix(path, ext) + "-" + time.Now().UTC().Format("20060102-150405") + ext
+	if err := os.Rename(path, rotated); err != nil {
+		return fmt.Errorf("RotateFile: %w", err)
+	}
+	return nil
+}
+
+// DownloadHistory обновляет кеш исторических свечей инструментов стратегий
+func DownloadHistory(client *bybit.Client, markets ...types.MarketStrategy) error {
+	for _, m := range markets {
+		if _, err := client.GetAllCandles(m.Symbol(), m.Interval()); err != nil {
+			return fmt.Errorf("DownloadHistory: %s: %w", m.Symbol(), err)
+		}
+	}
+	return nil
+}
+
+// ordersDBPath путь к базе ордеров из ORDERS_DB или orderdb.DefaultPath
+func ordersDBPath() string {
+	if path := os.Getenv("ORDERS_DB"); path != "" {
+		return path
+	}
+	return orderdb.DefaultPath
+}
+
+// OrdersCommand выгружает журнал ордеров из базы в w
+// Пример: go run . orders -s BTCUSDT,ETHUSDT -status closed -period 168h -format csv > orders.csv
+// Формат stats выводит объем, комиссии и реализованный результат по монетам
+func OrdersCommand(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("orders", flag.ContinueOnError)
+	symbols := fs.String("s", "", "монеты через запятую")
+	tags := fs.String("tag", "", "теги ордеров через запятую")
+	status := fs.String("status", "", "статус: open, closed или rejected")
+	side := fs.String("side", "", "направление: buy или sell")
+	period := fs.Duration("period", 0, "период от текущего момента, например 24h")
+	from := fs.String("from", "", "начало периода в формате 2006-01-02")
+	to := fs.String("to", "", "конец периода в формате 2006-01-02, не включая")
+	format := fs.String("format", orderdb.FormatCSV, "формат: csv, json или stats")
+	dbPath := fs.String("db", ordersDBPath(), "путь к базе ордеров")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	filter := &orderdb.OrderFilter{Status: *status, Side: *side}
+	if *symbols != "" {
+		filter.Symbols = strings.Split(strings.ToUpper(*symbols), ",")
+	}
+	if *tags != "" {
+		filter.Tags = strings.Split(*tags, ",")
+	}
+	if *period > 0 {
+		filter.From = time.Now().Add(-*period).UnixMilli()
+	}
+	for value, dst := range map[string]*int64{*from: &filter.From, *to: &filter.To} {
+		if value == "" {
+			continue
+		}
+		t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+		if err != nil {
+			return fmt.Errorf("OrdersCommand: %w", err)
+		}
+		*dst = t.UnixMilli()
+	}
+	if err := filter.Validate(); err != nil {
+		return fmt.Errorf("OrdersCommand: %w", err)
+	}
+	store, err := orderdb.OpenSQLite(*dbPath)
+	if err != nil {
+		return fmt.Errorf("OrdersCommand: %w", err)
+	}
+	defer store.Close()
+	if *format == "stats" {
+		stats, err := orderdb.AggregateOrders(store, filter)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+	return orderdb.ExportOrders(w, store, *format, filter)
+}
+
+// StrategyCommand управляет стратегиями работающего бота через API панели управления
+// Пример: go run . strategy -flatten stop HYPEUSDT-M5
+// Команды: list, show <стратегия>, pause, resume, start, stop, remove <стратегия>,
+// reload <стратегия> ключ=значение... Адрес панели - WEB_DASHBOARD_ADDR, токен - WEB_CONTROL_TOKEN
+func StrategyCommand(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("strategy", flag.ContinueOnError)
+	addr := fs.String("addr", dashboardURL(), "адрес панели управления")
+	token := fs.String("token", os.Getenv("WEB_CONTROL_TOKEN"), "токен команд управления")
+	flatten := fs.Bool("flatten", false, "закрыть позицию стратегии при stop и remove")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	command, name := fs.Arg(0), fs.Arg(1)
+	if command == "" {
+		return fmt.Errorf("StrategyCommand: не указана команда")
+	}
+	if command != "list" && name == "" {
+		return fmt.Errorf("StrategyCommand: не указана стратегия")
+	}
+
+	var req httpx.RequestBuilder
+	switch command {
+	case "list":
+		req = httpx.Get(*addr + "/api/v1/bot/status")
+	case "show":
+		req = httpx.Get(*addr+"/api/v1/bot/strategy").SetQueryParam("name", name)
+	case "pause", "resume", "start", "stop", "remove", "reload":
+		body := map[string]any{"strategy": name, "flatten": *flatten}
+		if command == "reload" {
+			params := make(map[string]any)
+			for _, arg := range fs.Args()[2:] {
+				key, value, ok := strings.Cut(arg, "=")
+				if !ok {
+					return fmt.Errorf("StrategyCommand: параметр %q не в формате ключ=значение", arg)
+				}
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					params[key] = v
+				} else {
+					params[key] = value
+				}
+			}
+			body["params"] = params
+		}
+		req = httpx.Post(*addr + "/api/v1/bot/" + command).WithJsonData(body)
+		if *token != "" {
+			req = req.SetHeaderValue("Authorization", "Bearer "+*token)
+		}
+	default:
+		return fmt.Errorf("StrategyCommand: неизвестная команда %q", command)
+	}
+
+	res := req.WithTimeout(10 * time.Second).Do()
+	defer res.Close()
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := res.UnmarshalBody(&response); err != nil {
+		return fmt.Errorf("StrategyCommand: %w", err)
+	}
+	if response.Error != "" {
+		return fmt.Errorf("StrategyCommand: %s", response.Error)
+	}
+	var result any
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return fmt.Errorf("StrategyCommand: %w", err)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// UserCommand управляет пользователями веб-интерфейса в базе WEB_AUTH_USERS_DB
+// Пример: go run . user -perm control set admin < password.txt
+// Команды: set <имя> - создать пользователя или сменить пароль и уровень доступа,
+// delete <имя>. Пароль читается из первой строки in, чтобы не попадать в историю команд
+func UserCommand(args []string, in io.Reader) error {
+	fs := flag.NewFlagSet("user", flag.ContinueOnError)
+	dbPath := fs.String("db", os.Getenv("WEB_AUTH_USERS_DB"), "путь к базе пользователей")
+	perm := fs.String("perm", auth.Read.String(), "уровень доступа: read или control")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	command, name := fs.Arg(0), fs.Arg(1)
+	if command != "set" && command != "delete" {
+		return fmt.Errorf("UserCommand: неизвестная команда %q", command)
+	}
+	if name == "" {
+		return fmt.Errorf("UserCommand: не указан пользователь")
+	}
+	if *dbPath == "" {
+		return fmt.Errorf("UserCommand: не задана база пользователей WEB_AUTH_USERS_DB")
+	}
+	users, err := auth.OpenUserStore(*dbPath)
+	if err != nil {
+		return fmt.Errorf("UserCommand: %w", err)
+	}
+	defer users.Close()
+	if command == "delete" {
+		return users.DeleteUser(name)
+	}
+	p, err := auth.ParsePermission(*perm)
+	if err != nil {
+		return fmt.Errorf("UserCommand: %w", err)
+	}
+	password, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("UserCommand: %w", err)
+	}
+	return users.SetUser(name, strings.TrimRight(password, "\r\n"), p)
+}
+
+// dashboardURL адрес панели управления по WEB_DASHBOARD_ADDR, по умолчанию http://localhost:8080
+func dashboardURL() string {
+	addr := cmp.Or(os.Getenv("WEB_DASHBOARD_ADDR"), ":8080")
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return addr
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "orders" {
+		if err := OrdersCommand(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "strategy" {
+		if err := StrategyCommand(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "user" {
+		if err := UserCommand(os.Args[2:], os.Stdin); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	ctx, cancel, stop := NewContext()
 	defer func() {
 		cancel()
@@ -14,9 +14,14 @@ import (
 	"goTradingBot/utils/numeric"
 	"goTradingBot/utils/saveform"
 	"goTradingBot/web/app"
+	"goTradingBot/web/auth"
 	"log/slog"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/joho/godotenv"
 )
 
 func TestUpdCryptosDB(t *testing.T) {
@@ -69,8 +74,11 @@ func TestSignals(t *testing.T) {
 	candles, _ := client.GetAllCandles(symbol+"USDT", cdl.M15)
 	n := len(candles)
 
-	features := predict.FeaturesGeneratorModel(predict.A6N21P9).
+	features, err := predict.FeaturesGeneratorModel(predict.A6N21P9).
 		GenTranspose(candles, n-2000, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	res, err := portal.GetPrediction(features, "M15", "p4").Unwrap()
 	if err != nil {
@@ -145,7 +153,10 @@ func TestPy(t *testing.T) {
 	candles, _ := client.GetAllCandles(symbol+"USDT", cdl.H1)
 
 	n := len(candles)
-	features := fg.GenTranspose(candles, n-5, n)
+	features, err := fg.GenTranspose(candles, n-5, n)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	pred, err := portal.GetPrediction(features, "H1").Unwrap()
 	if err != nil {
@@ -190,7 +201,10 @@ func TestPreds(t *testing.T) {
 	candles, _ := client.GetCandles("ETHUSDT", cdl.M15, 7000)
 
 	fg := predict.FeaturesGeneratorModel(predict.A6N21P9)
-	features := fg.GenTranspose(candles, predict.FeatureOffset, -1)
+	features, err := fg.GenTranspose(candles, predict.FeatureOffset, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	prediction, err := portal.GetPrediction(features, "M15").Unwrap()
 	if err != nil {
@@ -211,14 +225,45 @@ func TestPreds(t *testing.T) {
 	portal.Stop()
 }
 
+// serverOptions настройки авторизации и TLS веб-серверов из .env
+func serverOptions(t *testing.T) []app.ServerOption {
+	godotenv.Load()
+	opts, err := app.ServerOptionsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return opts
+}
+
 func TestRunTerminal(t *testing.T) {
 	portal.Start()
 	defer portal.Stop()
-	app.RunTerminal(":7788")
+	t.Log(app.RunTerminal(":7788", serverOptions(t)...))
 }
 
 func TestRunOrderLog(t *testing.T) {
-	app.RunOrderLog(":7789")
+	t.Log(app.RunOrderLog(":7789", serverOptions(t)...))
+}
+
+func TestUserCommand(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "users.db")
+	if err := UserCommand([]string{"-db", db, "-perm", "control", "set", "admin"}, strings.NewReader("secret\n")); err != nil {
+		t.Fatal(err)
+	}
+	users, err := auth.OpenUserStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer users.Close()
+	if id, err := users.Verify("admin", "secret"); err != nil || id.Permission != auth.Control {
+		t.Fatalf("Verify: %+v %v", id, err)
+	}
+	if err := UserCommand([]string{"-db", db, "delete", "admin"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.Verify("admin", "secret"); err != auth.ErrUnauthorized {
+		t.Errorf("после удаления: %v", err)
+	}
 }
 
 func TestTemp(t *testing.T) {
@@ -229,7 +274,10 @@ func TestTemp(t *testing.T) {
 	candles, _ := client.GetCandles("HYPEUSDT", cdl.M5, 1800)
 
 	fg := predict.FeaturesGeneratorModel(predict.A6N21P9)
-	features := fg.GenTranspose(candles, predict.FeatureOffset, -1)
+	features, err := fg.GenTranspose(candles, predict.FeatureOffset, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	pred, err := portal.GetPrediction(
 		features,
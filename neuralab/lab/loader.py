@@ -10,7 +10,14 @@ def read_csv(path: str) -> List[List[float]]:
         return [[float(i) for i in row] for row in reader]
 
 
-class SampleMetadata(TypedDict):
+def read_arrow(path: str, columns: List[str]) -> np.ndarray:
+    # pyarrow требуется только для датасетов в формате arrow
+    import pyarrow.feather as feather
+    table = feather.read_table(path, columns=columns)
+    return np.column_stack([c.to_numpy() for c in table.columns])
+
+
+class SampleMetadata(TypedDict, total=False):
     index: int
     symbol: str
     client: str
@@ -18,6 +25,7 @@ class SampleMetadata(TypedDict):
     yShape: List[int]
     xPath: str
     yPath: str
+    dataPath: str
 
 
 class Dataset:
@@ -32,6 +40,7 @@ class Dataset:
         self.name: str = metadata['params']['name']
         self.root_dir: str = metadata['params']['rootDir']
         self.interval: str = metadata['params']['interval']
+        self.format: str = metadata['params'].get('format') or 'csv'
 
         # Общая информация о датасете
         self.total_features: int = metadata['totalFeatures']
@@ -50,14 +59,21 @@ class Dataset:
         if self._X_cache is None:
             X_list = []
             for s in self.samples:
-                X = np.array(read_csv(s['xPath']), self.Xtype)
+                if self.format == 'arrow':
+                    X = read_arrow(s['dataPath'], self.features).astype(self.Xtype)
+                else:
+                    X = np.array(read_csv(s['xPath']), self.Xtype)
                 X_list.append(X)
             self._X_cache = np.vstack(X_list)
 
     def load(self, signal_index: int):
         y_list = []
         for s in self.samples:
-            y = np.array(read_csv(s['yPath']), self.ytype)[:, signal_index]
+            if self.format == 'arrow':
+                signal = self.signals[signal_index]
+                y = read_arrow(s['dataPath'], [signal]).astype(self.ytype)[:, 0]
+            else:
+                y = np.array(read_csv(s['yPath']), self.ytype)[:, signal_index]
             y_list.append(y)
         self.__load_X()
         y = np.concatenate(y_list)
@@ -0,0 +1,129 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"goTradingBot/utils/saveform"
+	"path"
+	"slices"
+)
+
+// Format определяет формат хранения образцов датасета
+type Format string
+
+const (
+	CSVFormat   Format = "csv"   // X.csv, y.csv и t.csv для каждого образца
+	ArrowFormat Format = "arrow" // единый файл Arrow IPC (Feather v2) для каждого образца
+)
+
+// Имена служебных столбцов в файлах Arrow
+const (
+	timeColumn   = "time"
+	symbolColumn = "symbol"
+)
+
+// sampleWriter сохраняет данные образца и заполняет пути к файлам в SampleInfo
+type sampleWriter func(itemPath string, info *SampleInfo, features, signals [][]float64, times []float64) error
+
+// newSampleWriter возвращает функцию сохранения образцов для выбранного формата
+func newSampleWriter(format Format, featureLabels, signalLabels []string) (sampleWriter, error) {
+	if err := checkColumnLabels(featureLabels, signalLabels); err != nil {
+		return nil, fmt.Errorf("newSampleWriter: %w", err)
+	}
+	switch format {
+	case "", CSVFormat:
+		return writeCSVSample, nil
+	case ArrowFormat:
+		metadata, err := arrowMetadata(featureLabels, signalLabels)
+		if err != nil {
+			return nil, err
+		}
+		return func(itemPath string, info *SampleInfo, features, signals [][]float64, times []float64) error {
+			return writeArrowSample(itemPath, info, features, signals, times, featureLabels, signalLabels, metadata)
+		}, nil
+	default:
+		return nil, fmt.Errorf("newSampleWriter: неизвестный формат датасета: %q", format)
+	}
+}
+
+func writeCSVSample(itemPath string, info *SampleInfo, features, signals [][]float64, times []float64) error {
+	info.XPath = path.Join(itemPath, "X.csv")
+	info.YPath = path.Join(itemPath, "y.csv")
+	info.TPath = path.Join(itemPath, "t.csv")
+	if err := saveform.ColumnsToCSV(info.XPath, features, nil); err != nil {
+		return err
+	}
+	if err := saveform.ColumnsToCSV(info.YPath, signals, nil); err != nil {
+		return err
+	}
+	return saveform.ColumnsToCSV(info.TPath, [][]float64{times}, []string{timeColumn})
+}
+
+// writeArrowSample сохраняет образец одним файлом: time, symbol, признаки, сигналы
+func writeArrowSample(
+	itemPath string,
+	info *SampleInfo,
+	features, signals [][]float64,
+	times []float64,
+	featureLabels, signalLabels []string,
+	metadata map[string]string,
+) error {
+	if len(features) != len(featureLabels) || len(signals) != len(signalLabels) {
+		return fmt.Errorf("writeArrowSample: количество столбцов не соответствует количеству меток")
+	}
+	n := len(times)
+	timeValues := make([]int64, n)
+	symbols := make([]string, n)
+	for i, t := range times {
+		timeValues[i] = int64(t)
+		symbols[i] = info.Symbol
+	}
+	cols := make([]saveform.ArrowColumn, 0, len(features)+len(signals)+2)
+	cols = append(cols,
+		saveform.Int64Column(timeColumn, timeValues),
+		saveform.StringColumn(symbolColumn, symbols),
+	)
+	for i, col := range features {
+		cols = append(cols, saveform.Float64Column(featureLabels[i], col))
+	}
+	for i, col := range signals {
+		cols = append(cols, saveform.Float64Column(signalLabels[i], col))
+	}
+	info.DataPath = path.Join(itemPath, "data.arrow")
+	return saveform.ColumnsToArrow(info.DataPath, cols, metadata)
+}
+
+// checkColumnLabels проверяет, что метки признаков и сигналов уникальны и не совпадают
+// со служебными столбцами: в файле Arrow метки становятся именами столбцов
+func checkColumnLabels(featureLabels, signalLabels []string) error {
+	seen := make(map[string]bool)
+	for _, label := range slices.Concat(featureLabels, signalLabels) {
+		if label == timeColumn || label == symbolColumn {
+			return fmt.Errorf("метка %q совпадает со служебным столбцом", label)
+		}
+		if seen[label] {
+			return fmt.Errorf("повторяющаяся метка столбца %q", label)
+		}
+		seen[label] = true
+	}
+	return nil
+}
+
+// arrowMetadata формирует метаданные схемы Arrow с метками признаков и сигналов
+func arrowMetadata(featureLabels, signalLabels []string) (map[string]string, error) {
+	features, err := json.Marshal(featureLabels)
+	if err != nil {
+		return nil, err
+	}
+	signals, err := json.Marshal(signalLabels)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"features":     string(features),
+		"signals":      string(signals),
+		"timeColumn":   timeColumn,
+		"timeUnit":     "ms",
+		"symbolColumn": symbolColumn,
+	}, nil
+}
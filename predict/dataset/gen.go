@@ -1,16 +1,14 @@
 package dataset
 
 import (
-	"encoding/json"
 	"fmt"
 	"goTradingBot/cdl"
 	"goTradingBot/external/cryptos"
+	"goTradingBot/external/cryptos/models"
 	"goTradingBot/predict/features"
 	"goTradingBot/predict/signals"
 	"goTradingBot/utils"
 	"goTradingBot/utils/numeric"
-	"goTradingBot/utils/saveform"
-	"log"
 	"log/slog"
 	"os"
 	"path"
@@ -21,161 +19,498 @@ type DatasetParams struct {
 	Name                     string       `json:"name"`
 	RootDir                  string       `json:"rootDir"`
 	Interval                 cdl.Interval `json:"interval"`
-	LimitOfInstruments       int          `json:"limitOfInstruments"`
+	LimitOfInstruments       int          `json:"limitOfInstruments"`       // 0 - только Symbols
 	MinInstrumentSecDuration int          `json:"minInstrumentSecDuration"` // 63072
 	PercInitialMargin        float64      `json:"percInitialMargin"`
 	IndentationFromEnd       int          `json:"indentationFromEnd"`
 	FilterPerfectTrendFlat   bool         `json:"filterPerfectTrendFlat"`
+	MarketContextDays        int          `json:"marketContextDays"` // глубина истории рыночного контекста (дней)
+	Split                    SplitParams  `json:"split"`             // разбиение на train/validation/test или фолды
+	Format                   Format       `json:"format"`            // формат образцов: csv (по умолчанию) или arrow
+	Symbols                  []string     `json:"symbols,omitempty"` // дополнительные инструменты (базовые тикеры)
 }
 
 type SampleInfo struct {
-	Index  int    `json:"index"`
+	Index        int     `json:"index"`
+	Symbol       string  `json:"symbol"`
+	Client       string  `json:"client"`
+	XShape       [2]int  `json:"xShape"`
+	YShape       [2]int  `json:"yShape"`
+	XPath        string  `json:"xPath,omitempty"`
+	YPath        string  `json:"yPath,omitempty"`
+	TPath        string  `json:"tPath,omitempty"`    // время открытия свечи (мс) для каждой строки
+	DataPath     string  `json:"dataPath,omitempty"` // файл Arrow с признаками, сигналами, временем и символом
+	Folds        []Fold  `json:"folds,omitempty"`
+	NormAvgRange float64 `json:"normAvgRange"` // средний нормализованный диапазон свечей, участвует в отсеве
+	Checksum     string  `json:"checksum"`     // sha256 файлов образца
+}
+
+// SkippedSymbol инструмент, исключенный фильтром
+type SkippedSymbol struct {
+	Symbol       string  `json:"symbol"`
+	Reason       string  `json:"reason"`
+	NormAvgRange float64 `json:"normAvgRange,omitempty"`
+}
+
+// FailedSymbol инструмент, обработка которого завершилась ошибкой
+// При повторном запуске такие инструменты обрабатываются заново
+type FailedSymbol struct {
 	Symbol string `json:"symbol"`
-	Client string `json:"client"`
-	XShape [2]int `json:"xShape"`
-	YShape [2]int `json:"yShape"`
-	XPath  string `json:"xPath"`
-	YPath  string `json:"yPath"`
+	Error  string `json:"error"`
 }
 
 type DatasetInfo struct {
-	Params        DatasetParams `json:"params"`
-	TotalFeatures int           `json:"totalFeatures"`
-	TotalSignals  int           `json:"totalSignals"`
-	Signals       []string      `json:"signals"`
-	Features      []string      `json:"features"`
-	TotalRows     int           `json:"TotalRows"`
-	TotalSamples  int           `json:"totalSamples"`
-	Samples       []SampleInfo  `json:"samples"`
+	Params        DatasetParams   `json:"params"`
+	TotalFeatures int             `json:"totalFeatures"`
+	TotalSignals  int             `json:"totalSignals"`
+	LookAhead     int             `json:"lookAhead"` // горизонт сигналов в свечах
+	PurgeBars     int             `json:"purgeBars"` // минимальный размер окна очистки, фактический зависит от горизонта сигналов свечей
+	Signals       []string        `json:"signals"`
+	Features      []string        `json:"features"`
+	TotalRows     int             `json:"TotalRows"`
+	TotalSamples  int             `json:"totalSamples"`
+	Samples       []SampleInfo    `json:"samples"`
+	Order         []string        `json:"order"` // порядок принятия решений фильтром, определяет индексы образцов
+	Skipped       []SkippedSymbol `json:"skipped,omitempty"`
+	Failed        []FailedSymbol  `json:"failed,omitempty"`
+	Complete      bool            `json:"complete"` // построение завершено без ошибок
 }
 
+// defaultMarketContextDays глубина истории рыночного контекста по умолчанию
+const defaultMarketContextDays = 1825
+
 // CandleProvider определяет интерфейс для работы с поставщиком свечных данных
 type CandleProvider interface {
 	GetAllCandles(symbol string, interval cdl.Interval) ([]cdl.Candle, error)
 }
 
-func CreateDataset(cp CandleProvider, params DatasetParams, fg *features.Generator, sg *signals.Generator) {
-	datasetPath := path.Join(params.RootDir, params.Name)
-	if utils.PathExists(datasetPath) {
-		log.Fatalf("Dataset %s already exists...", datasetPath)
+// SampleStatus результат обработки инструмента
+type SampleStatus string
+
+const (
+	StatusSaved    SampleStatus = "saved"    // образец сгенерирован и сохранен
+	StatusResumed  SampleStatus = "resumed"  // образец сохранен ранее и прошел проверку контрольной суммы
+	StatusFiltered SampleStatus = "filtered" // инструмент исключен фильтром
+	StatusFailed   SampleStatus = "failed"   // ошибка загрузки, генерации или сохранения
+)
+
+// Progress описывает ход построения датасета после обработки очередного инструмента
+type Progress struct {
+	Done   int
+	Total  int
+	Symbol string
+	Status SampleStatus
+	Reason string // причина исключения фильтром
+	Err    error
+}
+
+type options struct {
+	workers  int
+	progress func(Progress)
+}
+
+// Option настраивает построение датасета
+type Option func(*options)
+
+// WithWorkers задает количество параллельно обрабатываемых инструментов
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		o.workers = n
 	}
-	if err := os.MkdirAll(datasetPath, os.ModePerm); err != nil {
-		log.Fatal(err)
+}
+
+// WithProgress задает обработчик хода построения
+// По умолчанию ход построения пишется в slog
+func WithProgress(fn func(Progress)) Option {
+	return func(o *options) {
+		o.progress = fn
 	}
-	datasetSamples := path.Join(datasetPath, "samples")
-	if err := os.MkdirAll(datasetSamples, os.ModePerm); err != nil {
-		log.Fatal(err)
+}
+
+// CreateDataset строит датасет или продолжает построение существующего
+// Образцы, сохраненные ранее и прошедшие проверку контрольной суммы, не генерируются повторно.
+// Решения фильтра принимаются в порядке DatasetInfo.Order независимо от порядка завершения
+// загрузки, поэтому при одинаковых данных состав датасета не меняется от запуска к запуску.
+// Новые инструменты (из списка по капитализации или DatasetParams.Symbols) добавляются в конец порядка
+func CreateDataset(
+	cp CandleProvider,
+	params DatasetParams,
+	fg *features.Generator,
+	sg *signals.Generator,
+	opts ...Option,
+) (*DatasetInfo, error) {
+	o := &options{workers: 4, progress: logProgress}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.workers < 1 {
+		o.workers = 1
+	}
+	if err := params.Split.Validate(); err != nil {
+		return nil, err
 	}
+	// на истории ряд CMC100 был бы нулевым везде, кроме последних суток, в отличие от инференса
+	if _, needCMC := fg.NeedsMarketContext(); needCMC {
+		return nil, fmt.Errorf("CreateDataset: признак CMC100 не поддерживается: история индекса доступна только за последние сутки")
+	}
+	writeSample, err := newSampleWriter(params.Format, fg.Labels(), sg.Labels())
+	if err != nil {
+		return nil, err
+	}
+
+	datasetPath := path.Join(params.RootDir, params.Name)
+	datasetSamples := path.Join(datasetPath, "samples")
+	datasetInfoPath := path.Join(datasetPath, "metadata.json")
+
 	datasetInfo := new(DatasetInfo)
 	datasetInfo.Params = params
 	datasetInfo.Features = fg.Labels()
 	datasetInfo.Signals = sg.Labels()
 	datasetInfo.TotalFeatures = len(datasetInfo.Features)
 	datasetInfo.TotalSignals = len(datasetInfo.Signals)
+	lookAhead := sg.LookAhead()
+	datasetInfo.LookAhead = lookAhead
+	if params.Split.Mode != NoSplit {
+		datasetInfo.PurgeBars = max(params.Split.PurgeBars, lookAhead)
+	}
+
+	prev, err := loadDatasetInfo(datasetInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	if prev == nil && utils.PathExists(datasetPath) {
+		if entries, _ := os.ReadDir(datasetPath); len(entries) > 0 {
+			return nil, fmt.Errorf("CreateDataset: каталог %s не пуст и не содержит metadata.json", datasetPath)
+		}
+	}
+	if prev != nil {
+		if err := prev.compatible(datasetInfo); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(datasetSamples, os.ModePerm); err != nil {
+		return nil, err
+	}
 
+	// при LimitOfInstruments == 0 датасет строится только по DatasetParams.Symbols
 	cryptosClient := cryptos.NewClient()
-	cryptoList, err := cryptosClient.GetCryptoList(params.LimitOfInstruments)
+	var cryptoList []models.CryptoInfo
+	if params.LimitOfInstruments > 0 {
+		if cryptoList, err = cryptosClient.GetCryptoList(params.LimitOfInstruments); err != nil {
+			return nil, err
+		}
+	}
+	symbols := make([]string, 0, len(cryptoList)+len(params.Symbols))
+	for _, crypto := range cryptoList {
+		symbols = append(symbols, crypto.Symbol)
+	}
+	symbols = append(symbols, params.Symbols...)
+
+	aux, err := loadAux(cp, cryptosClient, params, fg)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	if aux != nil {
+		fg = fg.WithAux(aux)
 	}
 
-	var wg sync.WaitGroup
-	var mu sync.Mutex
+	// Порядок решений: ранее обработанные инструменты, затем новые в порядке списка
+	order, done, skipped := resumeState(prev, datasetSamples, symbols)
+	datasetInfo.Order = order
 
-	var globalNormAvgRange float64
+	b := &builder{
+		cp:          cp,
+		params:      params,
+		fg:          fg,
+		sg:          sg,
+		lookAhead:   lookAhead,
+		writeSample: writeSample,
+		samplesDir:  datasetSamples,
+		infoPath:    datasetInfoPath,
+		info:        datasetInfo,
+		done:        done,
+		skipped:     skipped,
+		progress:    o.progress,
+		turns:       make([]chan struct{}, len(order)+1),
+	}
+	for i := range b.turns {
+		b.turns[i] = make(chan struct{})
+	}
+	close(b.turns[0])
 
-	workers := 4
-	for j, crypto := range cryptoList {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range o.workers {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-
-			symbol := crypto.Symbol + "USDT"
-			candles, _ := cp.GetAllCandles(symbol, params.Interval)
-			if len(candles) == 0 {
-				return
+			for i := range jobs {
+				b.process(i)
 			}
+		}()
+	}
+	for i := range order {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
 
-			n := len(candles)
+	datasetInfo.Complete = len(datasetInfo.Failed) == 0
+	if err := b.checkpoint(); err != nil {
+		return datasetInfo, err
+	}
+	if !datasetInfo.Complete {
+		return datasetInfo, fmt.Errorf("CreateDataset: не обработано инструментов: %d", len(datasetInfo.Failed))
+	}
+	return datasetInfo, nil
+}
 
-			start := int(float64(n) * params.PercInitialMargin)
-			end := n - params.IndentationFromEnd
+// builder хранит состояние построения датасета
+type builder struct {
+	cp          CandleProvider
+	params      DatasetParams
+	fg          *features.Generator
+	sg          *signals.Generator
+	lookAhead   int
+	writeSample sampleWriter
+	samplesDir  string
+	infoPath    string
+	progress    func(Progress)
 
-			// Отсев ----------------------------
+	done    map[string]SampleInfo    // проверенные образцы предыдущих запусков
+	skipped map[string]SkippedSymbol // решения фильтра предыдущих запусков
 
-			timeDiff := (candles[n-1].Time - candles[0].Time) / 1000
-			if timeDiff < int64(params.MinInstrumentSecDuration) {
-				slog.Info("time filter", "symbol", symbol, "timeDiff", timeDiff)
-				return
-			}
+	// turns[i] закрывается, когда принято решение фильтра по инструменту i-1
+	turns              []chan struct{}
+	globalNormAvgRange float64
+	hasGlobal          bool
 
-			trList := cdl.ListOfCandleArg(candles[start:end], cdl.NormalizedRange)
-			normAvgRange := numeric.Avg(trList)
-			mu.Lock()
-			if j == 0 {
-				globalNormAvgRange = normAvgRange
-			}
-			if normAvgRange < globalNormAvgRange/2 {
-				mu.Unlock()
-				slog.Info("avgRange filter", "symbol", symbol)
-				return
+	mu        sync.Mutex
+	info      *DatasetInfo
+	processed int
+}
+
+// process обрабатывает инструмент с порядковым номером i
+func (b *builder) process(i int) {
+	symbol := b.info.Order[i]
+	index := i + 1
+
+	if sample, ok := b.done[symbol]; ok {
+		b.decide(i, func() (bool, float64) {
+			b.updateGlobal(sample.NormAvgRange)
+			return true, 0
+		})
+		b.finish(Progress{Symbol: symbol, Status: StatusResumed}, &sample, nil, nil)
+		return
+	}
+	if skip, ok := b.skipped[symbol]; ok {
+		b.decide(i, func() (bool, float64) { return false, 0 })
+		b.finish(Progress{Symbol: symbol, Status: StatusFiltered, Reason: skip.Reason}, nil, &skip, nil)
+		return
+	}
+
+	candles, err := b.cp.GetAllCandles(symbol+"USDT", b.params.Interval)
+	if err == nil && len(candles) == 0 {
+		err = fmt.Errorf("нет свечей")
+	}
+	if err != nil {
+		b.decide(i, func() (bool, float64) { return false, 0 })
+		b.fail(symbol, err)
+		return
+	}
+
+	n := len(candles)
+	start := int(float64(n) * b.params.PercInitialMargin)
+	// последние свечи, сигналы которых не зафиксированы в пределах ряда, не имеют достоверных сигналов
+	horizons := b.sg.Horizons(candles)
+	end := n - max(b.params.IndentationFromEnd, b.lookAhead)
+	for i := max(start, 0); i < end; i++ {
+		if i+horizons[i] >= n {
+			end = i
+			break
+		}
+	}
+
+	// Отсев ----------------------------
+
+	reason := ""
+	var normAvgRange float64
+	timeDiff := (candles[n-1].Time - candles[0].Time) / 1000
+	if end <= start {
+		reason = "too short"
+	} else if timeDiff < int64(b.params.MinInstrumentSecDuration) {
+		reason = fmt.Sprintf("time filter: %d", timeDiff)
+	} else {
+		trList := cdl.ListOfCandleArg(candles[start:end], cdl.NormalizedRange)
+		normAvgRange = numeric.Avg(trList)
+	}
+	var keep bool
+	var perfectTrendFlatFilterFactor float64
+	if reason == "" {
+		keep, perfectTrendFlatFilterFactor = b.decide(i, func() (bool, float64) {
+			if b.hasGlobal && normAvgRange < b.globalNormAvgRange/2 {
+				return false, 0
 			}
-			globalNormAvgRange = (globalNormAvgRange + normAvgRange) / 2
-			perfectTrendFlatFilterFactor := 0.5
-			if normAvgRange < globalNormAvgRange*0.8 {
-				perfectTrendFlatFilterFactor = 0.7
+			b.updateGlobal(normAvgRange)
+			if normAvgRange < b.globalNormAvgRange*0.8 {
+				return true, 0.7
 			}
-			mu.Unlock()
+			return true, 0.5
+		})
+		if !keep {
+			reason = "avgRange filter"
+		}
+	} else {
+		b.decide(i, func() (bool, float64) { return false, 0 })
+	}
+	if reason != "" {
+		skip := SkippedSymbol{Symbol: symbol, Reason: reason, NormAvgRange: normAvgRange}
+		b.finish(Progress{Symbol: symbol, Status: StatusFiltered, Reason: reason}, nil, &skip, nil)
+		return
+	}
 
-			// --------------------------------------
+	// --------------------------------------
 
-			features := fg.Gen(candles, start, end)
-			signals := sg.Gen(candles, start, end)
+	features, err := b.fg.Gen(candles, start, end)
+	if err != nil {
+		b.fail(symbol, err)
+		return
+	}
+	signals := b.sg.Gen(candles, start, end)
 
-			filter := NewFilter(candles, start, end)
-			if params.FilterPerfectTrendFlat {
-				filter.AddPerfectTrendFlatFilter(perfectTrendFlatFilterFactor)
-			}
-			filter.Apply(features, signals)
+	filter := NewFilter(candles, start, end)
+	if b.params.FilterPerfectTrendFlat {
+		filter.AddPerfectTrendFlatFilter(perfectTrendFlatFilterFactor)
+	}
+	times := make([]float64, end-start)
+	for i := range times {
+		times[i] = float64(candles[start+i].Time)
+	}
+	timeCols := [][]float64{times}
+	filter.Apply(features, signals, timeCols)
+	times = timeCols[0]
 
-			itemPath := path.Join(datasetSamples, fmt.Sprintf("%d-%s-bybit", j+1, crypto.Symbol))
-			if err := os.MkdirAll(itemPath, os.ModePerm); err != nil {
-				return
-			}
+	itemPath := path.Join(b.samplesDir, fmt.Sprintf("%d-%s-bybit", index, symbol))
+	if err := os.MkdirAll(itemPath, os.ModePerm); err != nil {
+		b.fail(symbol, err)
+		return
+	}
 
-			XPath := path.Join(itemPath, "X.csv")
-			yPath := path.Join(itemPath, "y.csv")
-
-			sampleInfo := SampleInfo{
-				Index:  j + 1,
-				Symbol: crypto.Symbol,
-				Client: "bybit",
-				XShape: [2]int{len(features[0]), len(features)},
-				YShape: [2]int{len(signals[0]), len(signals)},
-				XPath:  XPath,
-				YPath:  yPath,
-			}
+	sampleInfo := SampleInfo{
+		Index:        index,
+		Symbol:       symbol,
+		Client:       "bybit",
+		XShape:       [2]int{len(features[0]), len(features)},
+		YShape:       [2]int{len(signals[0]), len(signals)},
+		Folds:        b.params.Split.MakeFolds(candles, start, end, horizons),
+		NormAvgRange: normAvgRange,
+	}
+	if err := b.writeSample(itemPath, &sampleInfo, features, signals, times); err != nil {
+		b.fail(symbol, err)
+		return
+	}
+	if sampleInfo.Checksum, err = sampleChecksum(&sampleInfo); err != nil {
+		b.fail(symbol, err)
+		return
+	}
+	b.finish(Progress{Symbol: symbol, Status: StatusSaved}, &sampleInfo, nil, nil)
+}
 
-			mu.Lock()
-			datasetInfo.Samples = append(datasetInfo.Samples, sampleInfo)
-			datasetInfo.TotalRows += sampleInfo.XShape[0]
-			mu.Unlock()
+// decide выполняет решение фильтра по инструменту i строго после решения по инструменту i-1
+func (b *builder) decide(i int, fn func() (bool, float64)) (bool, float64) {
+	<-b.turns[i]
+	defer close(b.turns[i+1])
+	return fn()
+}
+
+// updateGlobal обновляет скользящее среднее нормализованного диапазона принятых инструментов
+// Вызывается только внутри decide
+func (b *builder) updateGlobal(normAvgRange float64) {
+	if !b.hasGlobal {
+		b.globalNormAvgRange = normAvgRange
+		b.hasGlobal = true
+	}
+	b.globalNormAvgRange = (b.globalNormAvgRange + normAvgRange) / 2
+}
 
-			sampleData, _ := json.MarshalIndent(&sampleInfo, "", "    ")
-			fmt.Println(string(sampleData))
-			fmt.Printf("Saving %d %s...\n", j+1, crypto.Symbol)
+func (b *builder) fail(symbol string, err error) {
+	b.finish(Progress{Symbol: symbol, Status: StatusFailed, Err: err}, nil, nil, &FailedSymbol{Symbol: symbol, Error: err.Error()})
+}
 
-			saveform.ColumnsToCSV(XPath, features, nil)
-			saveform.ColumnsToCSV(yPath, signals, nil)
-		}()
-		if j == 0 {
-			wg.Wait()
-		} else if (j+1)%workers == 0 {
-			wg.Wait()
+// finish регистрирует результат обработки инструмента, сохраняет контрольную точку и сообщает о ходе построения
+func (b *builder) finish(p Progress, sample *SampleInfo, skip *SkippedSymbol, failed *FailedSymbol) {
+	b.mu.Lock()
+	switch {
+	case sample != nil:
+		b.info.Samples = append(b.info.Samples, *sample)
+		b.info.TotalRows += sample.XShape[0]
+	case skip != nil:
+		b.info.Skipped = append(b.info.Skipped, *skip)
+	case failed != nil:
+		b.info.Failed = append(b.info.Failed, *failed)
+	}
+	b.processed++
+	p.Done = b.processed
+	p.Total = len(b.info.Order)
+	var err error
+	if p.Status != StatusResumed {
+		err = b.checkpointLocked()
+	}
+	b.mu.Unlock()
+	if err != nil {
+		slog.Error("dataset checkpoint", "error", err)
+	}
+	b.progress(p)
+}
+
+// checkpoint сохраняет текущее состояние датасета в metadata.json
+func (b *builder) checkpoint() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.checkpointLocked()
+}
+
+func (b *builder) checkpointLocked() error {
+	b.info.sort()
+	b.info.TotalSamples = len(b.info.Samples)
+	return saveDatasetInfo(b.infoPath, b.info)
+}
+
+// logProgress обработчик хода построения по умолчанию
+func logProgress(p Progress) {
+	args := []any{"done", p.Done, "total", p.Total, "symbol", p.Symbol, "status", p.Status}
+	switch {
+	case p.Err != nil:
+		slog.Error("dataset", append(args, "error", p.Err)...)
+	case p.Reason != "":
+		slog.Info("dataset", append(args, "reason", p.Reason)...)
+	default:
+		slog.Info("dataset", args...)
+	}
+}
+
+// loadAux загружает полную историю вспомогательных инструментов и рыночный контекст,
+// необходимые генератору признаков
+func loadAux(cp CandleProvider, ms features.MarketSource, params DatasetParams, fg *features.Generator) (*features.AuxData, error) {
+	symbols := fg.AuxSymbols()
+	needFG, needCMC := fg.NeedsMarketContext()
+	if len(symbols) == 0 && !needFG && !needCMC {
+		return nil, nil
+	}
+	aux := features.NewAuxData()
+	for _, symbol := range symbols {
+		candles, err := cp.GetAllCandles(symbol, params.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("loadAux: %s: %w", symbol, err)
 		}
+		aux.Candles[symbol] = candles
 	}
-	wg.Wait()
-	datasetInfo.TotalSamples = len(datasetInfo.Samples)
-	datasetInfoPath := path.Join(datasetPath, "metadata.json")
-	saveform.ToJSON(datasetInfoPath, datasetInfo)
+	days := params.MarketContextDays
+	if days <= 0 {
+		days = defaultMarketContextDays
+	}
+	if err := fg.LoadMarketContext(aux, ms, days); err != nil {
+		return nil, fmt.Errorf("loadAux: %w", err)
+	}
+	return aux, nil
 }
@@ -0,0 +1,192 @@
+package dataset
+
+import (
+	"bytes"
+	"fmt"
+	"goTradingBot/cdl"
+	"goTradingBot/predict/features"
+	"goTradingBot/predict/signals"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeProvider возвращает детерминированное случайное блуждание для каждого инструмента
+type fakeProvider struct {
+	fail map[string]bool
+}
+
+func (p *fakeProvider) GetAllCandles(symbol string, interval cdl.Interval) ([]cdl.Candle, error) {
+	if p.fail[symbol] {
+		return nil, fmt.Errorf("нет соединения")
+	}
+	h := fnv.New64a()
+	h.Write([]byte(symbol))
+	rnd := rand.New(rand.NewPCG(h.Sum64(), 1))
+	candles := make([]cdl.Candle, 300)
+	price := 100.0
+	for i := range candles {
+		next := price * math.Exp(rnd.NormFloat64()*0.01)
+		candles[i] = cdl.Candle{
+			Time:   int64(i) * 300000,
+			O:      price,
+			H:      max(price, next) * 1.002,
+			L:      min(price, next) * 0.998,
+			C:      next,
+			Volume: 1 + rnd.Float64(),
+		}
+		price = next
+	}
+	return candles, nil
+}
+
+var testSymbols = []string{"BTC", "ETH", "SOL", "XRP", "ADA", "DOGE"}
+
+func buildTestDataset(t *testing.T, cp CandleProvider, root, name string, workers int) (*DatasetInfo, map[string]SampleStatus, error) {
+	t.Helper()
+	params := DatasetParams{
+		Name:              name,
+		RootDir:           root,
+		Interval:          cdl.M5,
+		PercInitialMargin: 0.1,
+		Split:             SplitParams{Mode: HoldoutSplit, TrainRatio: 0.6, ValidRatio: 0.2},
+		Symbols:           testSymbols,
+	}
+	fg := features.NewGeneratorBuilder().AddCandleArgs([]cdl.CandleArg{cdl.Close, cdl.Volume}, 20, 3).Build()
+	sg := signals.NewGeneratorBuilder().AddPerfectTrend(5).AddForwardLogReturn(3).Build()
+	var mu sync.Mutex
+	statuses := make(map[string]SampleStatus)
+	info, err := CreateDataset(cp, params, fg, sg, WithWorkers(workers), WithProgress(func(p Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+		statuses[p.Symbol] = p.Status
+	}))
+	return info, statuses, err
+}
+
+// readSample возвращает содержимое файлов образца с путями относительно каталога образца
+func readSample(t *testing.T, s SampleInfo) [][]byte {
+	t.Helper()
+	var res [][]byte
+	for _, p := range sampleFiles(&s) {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, []byte(filepath.Base(p)), data)
+	}
+	return res
+}
+
+func TestCreateDatasetDeterministic(t *testing.T) {
+	root := t.TempDir()
+	serial, _, err := buildTestDataset(t, &fakeProvider{}, root, "serial", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, _, err := buildTestDataset(t, &fakeProvider{}, root, "parallel", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serial.Samples) == 0 || !slices.Equal(serial.Order, parallel.Order) || !slices.Equal(serial.Skipped, parallel.Skipped) {
+		t.Fatalf("состав датасета: %v %v, отсеяно %v %v", serial.Order, parallel.Order, serial.Skipped, parallel.Skipped)
+	}
+	if len(serial.Samples) != len(parallel.Samples) || serial.TotalRows != parallel.TotalRows {
+		t.Fatalf("образцов %d и %d", len(serial.Samples), len(parallel.Samples))
+	}
+	for i, s := range serial.Samples {
+		p := parallel.Samples[i]
+		if s.Symbol != p.Symbol || s.Index != p.Index || s.Checksum != p.Checksum {
+			t.Errorf("образец %d: %s %s", i, s.Symbol, p.Symbol)
+		}
+		a, b := readSample(t, s), readSample(t, p)
+		if len(a) != len(b) || !slices.EqualFunc(a, b, bytes.Equal) {
+			t.Errorf("файлы образца %s отличаются", s.Symbol)
+		}
+	}
+}
+
+func TestCreateDatasetResume(t *testing.T) {
+	root := t.TempDir()
+	ref, _, err := buildTestDataset(t, &fakeProvider{}, root, "ref", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// прерванное построение: ETH не загрузился
+	info, _, err := buildTestDataset(t, &fakeProvider{fail: map[string]bool{"ETHUSDT": true}}, root, "data", 2)
+	if err == nil || info.Complete || len(info.Failed) != 1 || info.Failed[0].Symbol != "ETH" {
+		t.Fatalf("прерванное построение: %v %+v", err, info.Failed)
+	}
+	loaded, err := loadDatasetInfo(filepath.Join(root, "data", "metadata.json"))
+	if err != nil || loaded == nil || loaded.Complete || len(loaded.Samples) != len(ref.Samples)-1 {
+		t.Fatalf("metadata.json: %v", err)
+	}
+
+	// поврежденный файл образца не проходит проверку контрольной суммы
+	corrupted := loaded.Samples[0]
+	files := sampleFiles(&corrupted)
+	if err := os.WriteFile(files[0], []byte("broken"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if checksum, _ := sampleChecksum(&corrupted); checksum == corrupted.Checksum {
+		t.Fatal("контрольная сумма не изменилась")
+	}
+
+	info, statuses, err := buildTestDataset(t, &fakeProvider{}, root, "data", 3)
+	if err != nil || !info.Complete {
+		t.Fatalf("продолжение построения: %v", err)
+	}
+	for _, s := range info.Samples {
+		want := StatusResumed
+		if s.Symbol == "ETH" || s.Symbol == corrupted.Symbol {
+			want = StatusSaved
+		}
+		if statuses[s.Symbol] != want {
+			t.Errorf("%s: %s, ожидается %s", s.Symbol, statuses[s.Symbol], want)
+		}
+	}
+	if len(info.Samples) != len(ref.Samples) {
+		t.Fatalf("образцов %d, ожидается %d", len(info.Samples), len(ref.Samples))
+	}
+	for i, s := range info.Samples {
+		if s.Symbol != ref.Samples[i].Symbol || s.Checksum != ref.Samples[i].Checksum {
+			t.Errorf("образец %s отличается от построенного за один запуск", s.Symbol)
+		}
+	}
+}
+
+func TestCreateDatasetCMC100(t *testing.T) {
+	params := DatasetParams{Name: "cmc", RootDir: t.TempDir(), Interval: cdl.M5, Symbols: testSymbols}
+	fg := features.NewGeneratorBuilder().AddCMC100(0, 2).Build()
+	sg := signals.NewGeneratorBuilder().AddForwardLogReturn(3).Build()
+	if _, err := CreateDataset(&fakeProvider{}, params, fg, sg); err == nil || !strings.Contains(err.Error(), "CMC100") {
+		t.Errorf("датасет с признаком CMC100: %v", err)
+	}
+}
+
+func TestSampleWriterLabels(t *testing.T) {
+	tests := []struct {
+		features, signals []string
+		ok                bool
+	}{
+		{[]string{"RSI", "MACD"}, []string{"fwd"}, true},
+		{[]string{"RSI", "RSI"}, []string{"fwd"}, false},
+		{[]string{"RSI"}, []string{"RSI"}, false},
+		{[]string{timeColumn}, []string{"fwd"}, false},
+		{[]string{"RSI"}, []string{symbolColumn}, false},
+	}
+	for _, format := range []Format{CSVFormat, ArrowFormat} {
+		for _, tt := range tests {
+			if _, err := newSampleWriter(format, tt.features, tt.signals); (err == nil) != tt.ok {
+				t.Errorf("%s, метки %v %v: %v", format, tt.features, tt.signals, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package dataset
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goTradingBot/utils/saveform"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+)
+
+// loadDatasetInfo загружает metadata.json существующего датасета
+// Возвращает nil, если файл отсутствует
+func loadDatasetInfo(infoPath string) (*DatasetInfo, error) {
+	data, err := os.ReadFile(infoPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	info := new(DatasetInfo)
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("loadDatasetInfo: %s: %w", infoPath, err)
+	}
+	return info, nil
+}
+
+// saveDatasetInfo атомарно сохраняет metadata.json
+func saveDatasetInfo(infoPath string, info *DatasetInfo) error {
+	tmp := infoPath + ".tmp"
+	if err := saveform.ToJSON(tmp, info); err != nil {
+		return err
+	}
+	return os.Rename(tmp, infoPath)
+}
+
+// compatible проверяет, что существующий датасет построен с теми же признаками, сигналами и параметрами
+func (info *DatasetInfo) compatible(other *DatasetInfo) error {
+	a, b := info.Params, other.Params
+	switch {
+	case !slices.Equal(info.Features, other.Features):
+		return fmt.Errorf("CreateDataset: набор признаков отличается от существующего датасета")
+	case !slices.Equal(info.Signals, other.Signals):
+		return fmt.Errorf("CreateDataset: набор сигналов отличается от существующего датасета")
+	case a.Interval != b.Interval ||
+		a.PercInitialMargin != b.PercInitialMargin ||
+		a.IndentationFromEnd != b.IndentationFromEnd ||
+		a.MinInstrumentSecDuration != b.MinInstrumentSecDuration ||
+		a.FilterPerfectTrendFlat != b.FilterPerfectTrendFlat ||
+		a.Format != b.Format ||
+		a.Split != b.Split:
+		return fmt.Errorf("CreateDataset: параметры отличаются от существующего датасета")
+	}
+	return nil
+}
+
+// resumeState восстанавливает порядок обработки и решения предыдущих запусков
+// Образцы с неверной контрольной суммой или отсутствующими файлами генерируются заново,
+// инструменты с ошибками обрабатываются повторно
+func resumeState(prev *DatasetInfo, samplesDir string, symbols []string) (order []string, done map[string]SampleInfo, skipped map[string]SkippedSymbol) {
+	done = make(map[string]SampleInfo)
+	skipped = make(map[string]SkippedSymbol)
+	if prev != nil {
+		order = slices.Clone(prev.Order)
+		for _, s := range prev.Samples {
+			checksum, err := sampleChecksum(&s)
+			if err != nil || checksum != s.Checksum {
+				slog.Info("dataset sample is invalid", "symbol", s.Symbol, "error", err)
+				continue
+			}
+			done[s.Symbol] = s
+		}
+		for _, s := range prev.Skipped {
+			skipped[s.Symbol] = s
+		}
+	}
+	for _, symbol := range symbols {
+		if !slices.Contains(order, symbol) {
+			order = append(order, symbol)
+		}
+	}
+	return order, done, skipped
+}
+
+// sampleFiles возвращает файлы образца
+func sampleFiles(s *SampleInfo) []string {
+	var files []string
+	for _, p := range []string{s.XPath, s.YPath, s.TPath, s.DataPath} {
+		if p != "" {
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// sampleChecksum вычисляет sha256 содержимого файлов образца
+func sampleChecksum(s *SampleInfo) (string, error) {
+	files := sampleFiles(s)
+	if len(files) == 0 {
+		return "", fmt.Errorf("sampleChecksum: у образца %s нет файлов", s.Symbol)
+	}
+	h := sha256.New()
+	for _, p := range files {
+		file, err := os.Open(p)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+		io.WriteString(h, path.Base(p))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sort упорядочивает образцы и решения по порядку обработки
+func (info *DatasetInfo) sort() {
+	pos := make(map[string]int, len(info.Order))
+	for i, s := range info.Order {
+		pos[s] = i
+	}
+	slices.SortFunc(info.Samples, func(a, b SampleInfo) int { return cmp.Compare(a.Index, b.Index) })
+	slices.SortFunc(info.Skipped, func(a, b SkippedSymbol) int { return cmp.Compare(pos[a.Symbol], pos[b.Symbol]) })
+	slices.SortFunc(info.Failed, func(a, b FailedSymbol) int { return cmp.Compare(pos[a.Symbol], pos[b.Symbol]) })
+}
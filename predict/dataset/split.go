@@ -0,0 +1,167 @@
+package dataset
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+)
+
+// SplitMode определяет способ разбиения выборки по времени
+type SplitMode string
+
+const (
+	NoSplit      SplitMode = ""            // без разбиения
+	HoldoutSplit SplitMode = "holdout"     // train/validation/test по времени
+	WalkForward  SplitMode = "walkForward" // последовательные фолды с расширяющимся или скользящим окном обучения
+	PurgedKFold  SplitMode = "purgedKFold" // k-fold с очисткой (purge) и эмбарго
+)
+
+// SplitParams параметры разбиения выборки
+type SplitParams struct {
+	Mode       SplitMode `json:"mode"`
+	TrainRatio float64   `json:"trainRatio"` // доля train для holdout
+	ValidRatio float64   `json:"validRatio"` // доля validation для holdout (test - остаток)
+	Folds      int       `json:"folds"`      // количество фолдов для walkForward и purgedKFold
+	TrainBars  int       `json:"trainBars"`  // размер окна обучения для walkForward (0 - расширяющееся окно)
+	// PurgeBars - минимальное количество свечей, исключаемых из train перед каждым test/validation.
+	// Кроме них исключаются свечи, сигналы которых используют свечи test/validation (горизонт сигналов)
+	PurgeBars int `json:"purgeBars"`
+	// EmbargoBars - количество свечей, исключаемых из train после каждого test/validation
+	EmbargoBars int `json:"embargoBars"`
+}
+
+// Segment отрезок времени [Start, End) в миллисекундах
+type Segment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// Fold описывает границы одного фолда
+type Fold struct {
+	Index int       `json:"index"`
+	Train []Segment `json:"train"`
+	Valid []Segment `json:"valid,omitempty"`
+	Test  []Segment `json:"test"`
+}
+
+// barRange отрезок индексов свечей [start, end)
+type barRange struct {
+	start, end int
+}
+
+// Validate проверяет корректность параметров разбиения
+func (p *SplitParams) Validate() error {
+	switch p.Mode {
+	case NoSplit:
+		return nil
+	case HoldoutSplit:
+		if p.TrainRatio <= 0 || p.ValidRatio < 0 || p.TrainRatio+p.ValidRatio >= 1 {
+			return fmt.Errorf("SplitParams: неверные доли holdout: train=%v valid=%v", p.TrainRatio, p.ValidRatio)
+		}
+	case WalkForward, PurgedKFold:
+		if p.Folds < 2 {
+			return fmt.Errorf("SplitParams: количество фолдов должно быть >= 2: %d", p.Folds)
+		}
+	default:
+		return fmt.Errorf("SplitParams: неизвестный режим разбиения: %q", p.Mode)
+	}
+	if p.PurgeBars < 0 || p.EmbargoBars < 0 || p.TrainBars < 0 {
+		return fmt.Errorf("SplitParams: размеры окон не могут быть отрицательными")
+	}
+	return nil
+}
+
+// MakeFolds рассчитывает границы фолдов для свечей candles[start:end]
+// horizons - горизонт сигналов каждой свечи (signals.Generator.Horizons), определяет окна очистки
+func (p *SplitParams) MakeFolds(candles []cdl.Candle, start, end int, horizons []int) []Fold {
+	n := end - start
+	if p.Mode == NoSplit || n <= 0 {
+		return nil
+	}
+	var folds []Fold
+	switch p.Mode {
+	case HoldoutSplit:
+		trainEnd := start + int(float64(n)*p.TrainRatio)
+		validEnd := trainEnd + int(float64(n)*p.ValidRatio)
+		fold := Fold{
+			Train: toSegments(candles, []barRange{{start, p.purge(horizons, start, trainEnd)}}),
+			Test:  toSegments(candles, []barRange{{validEnd, end}}),
+		}
+		if validEnd > trainEnd {
+			fold.Valid = toSegments(candles, []barRange{{trainEnd, p.purge(horizons, trainEnd, validEnd)}})
+		}
+		folds = append(folds, fold)
+	case WalkForward:
+		// первый блок используется только для обучения
+		size := n / (p.Folds + 1)
+		for k := 1; k <= p.Folds; k++ {
+			testStart := start + k*size
+			testEnd := testStart + size
+			if k == p.Folds {
+				testEnd = end
+			}
+			trainEnd := p.purge(horizons, start, testStart)
+			trainStart := start
+			if p.TrainBars > 0 {
+				trainStart = max(start, trainEnd-p.TrainBars)
+			}
+			folds = append(folds, Fold{
+				Index: k - 1,
+				Train: toSegments(candles, []barRange{{trainStart, trainEnd}}),
+				Test:  toSegments(candles, []barRange{{testStart, testEnd}}),
+			})
+		}
+	case PurgedKFold:
+		size := n / p.Folds
+		for k := 0; k < p.Folds; k++ {
+			testStart := start + k*size
+			testEnd := testStart + size
+			if k == p.Folds-1 {
+				testEnd = end
+			}
+			folds = append(folds, Fold{
+				Index: k,
+				Train: toSegments(candles, []barRange{
+					{start, p.purge(horizons, start, testStart)},
+					{testEnd + p.EmbargoBars, end},
+				}),
+				Test: toSegments(candles, []barRange{{testStart, testEnd}}),
+			})
+		}
+	}
+	return folds
+}
+
+// purge возвращает конец отрезка обучения [from, boundary) перед test/validation, начинающимся со свечи boundary
+// Исключаются PurgeBars последних свечей и все свечи, начиная с первой, сигнал которой использует свечи boundary и дальше
+func (p *SplitParams) purge(horizons []int, from, boundary int) int {
+	end := boundary - p.PurgeBars
+	for i := max(from, 0); i < end && i < len(horizons); i++ {
+		if i+horizons[i] >= boundary {
+			return i
+		}
+	}
+	return end
+}
+
+// toSegments преобразует отрезки индексов свечей во временные отрезки, отбрасывая пустые
+func toSegments(candles []cdl.Candle, ranges []barRange) []Segment {
+	segments := make([]Segment, 0, len(ranges))
+	for _, r := range ranges {
+		r.start = max(r.start, 0)
+		r.end = min(r.end, len(candles))
+		if r.end <= r.start {
+			continue
+		}
+		var endTime int64
+		if r.end < len(candles) {
+			endTime = candles[r.end].Time
+		} else {
+			endTime = candles[r.end-1].Time + 1
+		}
+		segments = append(segments, Segment{
+			Start: candles[r.start].Time,
+			End:   endTime,
+		})
+	}
+	return segments
+}
@@ -0,0 +1,38 @@
+package dataset
+
+import (
+	"goTradingBot/cdl"
+	"testing"
+)
+
+func TestMakeFoldsPurge(t *testing.T) {
+	candles := make([]cdl.Candle, 100)
+	for i := range candles {
+		candles[i].Time = int64(i)
+	}
+	// горизонт 2, свеча 40 использует свечи до 60
+	horizons := make([]int, len(candles))
+	for i := range horizons {
+		horizons[i] = 2
+	}
+	horizons[40] = 20
+
+	p := SplitParams{Mode: HoldoutSplit, TrainRatio: 0.5, ValidRatio: 0.2, PurgeBars: 1}
+	folds := p.MakeFolds(candles, 0, 100, horizons)
+	fold := folds[0]
+	if fold.Train[0].End != 40 {
+		t.Errorf("train: %+v", fold.Train)
+	}
+	if fold.Valid[0].Start != 50 || fold.Valid[0].End != 68 || fold.Test[0].Start != 70 {
+		t.Errorf("valid %+v, test %+v", fold.Valid, fold.Test)
+	}
+
+	p = SplitParams{Mode: PurgedKFold, Folds: 2, EmbargoBars: 3}
+	folds = p.MakeFolds(candles, 0, 100, horizons)
+	if len(folds[1].Train) != 1 || folds[1].Train[0].End != 40 {
+		t.Errorf("purgedKFold: %+v", folds[1].Train)
+	}
+	if folds[0].Train[0].Start != 53 {
+		t.Errorf("эмбарго: %+v", folds[0].Train)
+	}
+}
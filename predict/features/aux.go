@@ -0,0 +1,278 @@
+package features
+
+import (
+	"cmp"
+	"fmt"
+	"goTradingBot/cdl"
+	"goTradingBot/external/cryptos/models"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// auxRangeLimit максимальное количество свечей вспомогательного инструмента, загружаемых LoadAuxRange
+const auxRangeLimit = 20000
+
+// CandleSource определяет источник свечей вспомогательных инструментов (например, types.SubData)
+type CandleSource interface {
+	GetCandles(symbol string, interval cdl.Interval, limit int) ([]cdl.Candle, error)
+}
+
+// MarketSource определяет источник рыночного контекста (например, cryptos.Client)
+type MarketSource interface {
+	GetFearAndGreedChart(limit int) (*models.FearAndGreedChart, error)
+	GetCMC100Chart24H() (*models.CMC100Chart, error)
+}
+
+// Series представляет временной ряд значений с временными метками (мс)
+type Series struct {
+	Time   []int64   `json:"time"`
+	Values []float64 `json:"values"`
+}
+
+// AuxData содержит вспомогательные ряды для генерации признаков
+type AuxData struct {
+	Candles      map[string][]cdl.Candle // свечи вспомогательных инструментов того же интервала
+	FearAndGreed *Series                 // индекс страха и жадности
+	CMC100       *Series                 // изменения индекса CMC100 (доли)
+}
+
+// NewAuxData создает пустой набор вспомогательных данных
+func NewAuxData() *AuxData {
+	return &AuxData{Candles: make(map[string][]cdl.Candle)}
+}
+
+// AuxSymbols возвращает список вспомогательных инструментов, требуемых генератором
+func (fg *Generator) AuxSymbols() []string {
+	var symbols []string
+	for _, f := range fg.absFeatures {
+		if f.Type != crossFT {
+			continue
+		}
+		symbol, _ := f.Params["symbol"].(string)
+		if symbol != "" && !slices.Contains(symbols, symbol) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+// NeedsMarketContext сообщает, какие ряды рыночного контекста требуются генератору
+func (fg *Generator) NeedsMarketContext() (fearAndGreed bool, cmc100 bool) {
+	for _, f := range fg.absFeatures {
+		if f.Type != marketFT {
+			continue
+		}
+		switch f.Name {
+		case fearAndGreedName:
+			fearAndGreed = true
+		case cmc100Name:
+			cmc100 = true
+		}
+	}
+	return
+}
+
+// WithAux возвращает копию генератора, использующую переданные вспомогательные данные
+func (fg *Generator) WithAux(aux *AuxData) *Generator {
+	return &Generator{
+		absFeatures: fg.absFeatures,
+		aux:         aux,
+	}
+}
+
+// LoadAux загружает вспомогательные данные, необходимые генератору
+// limit - количество свечей вспомогательных инструментов
+// Возвращает nil, если генератор не использует вспомогательные ряды
+func (fg *Generator) LoadAux(cs CandleSource, ms MarketSource, interval cdl.Interval, limit int) (*AuxData, error) {
+	symbols := fg.AuxSymbols()
+	needFG, needCMC := fg.NeedsMarketContext()
+	if len(symbols) == 0 && !needFG && !needCMC {
+		return nil, nil
+	}
+	aux := NewAuxData()
+	for _, symbol := range symbols {
+		if cs == nil {
+			return nil, fmt.Errorf("LoadAux: не указан источник свечей для %s", symbol)
+		}
+		candles, err := cs.GetCandles(symbol, interval, limit)
+		if err != nil {
+			return nil, fmt.Errorf("LoadAux: %s: %w", symbol, err)
+		}
+		aux.Candles[symbol] = candles
+	}
+	days := max(1, limit*interval.AsSeconds()/86400+1)
+	if err := fg.LoadMarketContext(aux, ms, days); err != nil {
+		return nil, fmt.Errorf("LoadAux: %w", err)
+	}
+	return aux, nil
+}
+
+// LoadAuxRange загружает вспомогательные данные для основных свечей со временем открытия от from до to (мс)
+// Источник отдает последние свечи, поэтому загружается история от текущего момента до from,
+// свечи вспомогательных инструментов обрезаются по периоду
+func (fg *Generator) LoadAuxRange(cs CandleSource, ms MarketSource, interval cdl.Interval, from, to int64) (*AuxData, error) {
+	step := int64(interval.AsSeconds()) * 1000
+	if step <= 0 || from > to {
+		return nil, fmt.Errorf("LoadAuxRange: неверный период %d-%d", from, to)
+	}
+	limit := int((time.Now().UnixMilli()-from)/step) + 2
+	if limit > auxRangeLimit {
+		return nil, fmt.Errorf("LoadAuxRange: период старше %d свечей %s", auxRangeLimit, interval.AsDisplayName())
+	}
+	aux, err := fg.LoadAux(cs, ms, interval, limit)
+	if err != nil || aux == nil {
+		return aux, err
+	}
+	for symbol, candles := range aux.Candles {
+		aux.Candles[symbol] = slices.DeleteFunc(candles, func(c cdl.Candle) bool {
+			return c.Time < from || c.Time > to
+		})
+	}
+	return aux, nil
+}
+
+// LoadMarketContext загружает в aux ряды рыночного контекста, требуемые генератором
+// days - глубина истории индекса страха и жадности в днях
+func (fg *Generator) LoadMarketContext(aux *AuxData, ms MarketSource, days int) error {
+	needFG, needCMC := fg.NeedsMarketContext()
+	if !needFG && !needCMC {
+		return nil
+	}
+	if ms == nil {
+		return fmt.Errorf("LoadMarketContext: не указан источник рыночного контекста")
+	}
+	if needFG {
+		chart, err := ms.GetFearAndGreedChart(days)
+		if err != nil {
+			return err
+		}
+		if aux.FearAndGreed, err = SeriesFromFearAndGreed(chart); err != nil {
+			return err
+		}
+	}
+	if needCMC {
+		chart, err := ms.GetCMC100Chart24H()
+		if err != nil {
+			return err
+		}
+		if aux.CMC100, err = SeriesFromCMC100(chart); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SeriesFromFearAndGreed преобразует график индекса страха и жадности во временной ряд
+func SeriesFromFearAndGreed(chart *models.FearAndGreedChart) (*Series, error) {
+	series := &Series{
+		Time:   make([]int64, 0, len(chart.DataList)),
+		Values: make([]float64, 0, len(chart.DataList)),
+	}
+	for _, item := range chart.DataList {
+		ts, err := parseTimestamp(item.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		series.Time = append(series.Time, ts)
+		series.Values = append(series.Values, float64(item.Score))
+	}
+	series.sort()
+	return series, nil
+}
+
+// SeriesFromCMC100 преобразует график индекса CMC100 в ряд относительных изменений
+// Первое значение ряда равно 0
+func SeriesFromCMC100(chart *models.CMC100Chart) (*Series, error) {
+	raw := &Series{
+		Time:   make([]int64, 0, len(chart.Values)),
+		Values: make([]float64, 0, len(chart.Values)),
+	}
+	for _, v := range chart.Values {
+		ts, err := parseTimestamp(v.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		raw.Time = append(raw.Time, ts)
+		raw.Values = append(raw.Values, v.Value)
+	}
+	raw.sort()
+	changes := &Series{
+		Time:   raw.Time,
+		Values: make([]float64, len(raw.Values)),
+	}
+	for i := 1; i < len(raw.Values); i++ {
+		if prev := raw.Values[i-1]; prev != 0 {
+			changes.Values[i] = (raw.Values[i] - prev) / prev
+		}
+	}
+	return changes, nil
+}
+
+// sort упорядочивает ряд по времени
+func (s *Series) sort() {
+	idx := make([]int, len(s.Time))
+	for i := range idx {
+		idx[i] = i
+	}
+	slices.SortStableFunc(idx, func(a, b int) int {
+		return cmp.Compare(s.Time[a], s.Time[b])
+	})
+	times := make([]int64, len(idx))
+	values := make([]float64, len(idx))
+	for i, j := range idx {
+		times[i] = s.Time[j]
+		values[i] = s.Values[j]
+	}
+	s.Time, s.Values = times, values
+}
+
+// AlignSeries выравнивает ряд по времени основных свечей с заполнением вперед
+// Значения до первой точки ряда равны 0
+func AlignSeries(candles []cdl.Candle, s *Series) []float64 {
+	aligned := make([]float64, len(candles))
+	if s == nil || len(s.Time) == 0 {
+		return aligned
+	}
+	j := -1
+	for i, c := range candles {
+		for j+1 < len(s.Time) && s.Time[j+1] <= c.Time {
+			j++
+		}
+		if j >= 0 {
+			aligned[i] = s.Values[j]
+		}
+	}
+	return aligned
+}
+
+// AlignCandles выравнивает свечи вспомогательного инструмента по времени основных свечей
+// с заполнением вперед. Свечи до начала истории вспомогательного инструмента остаются нулевыми
+func AlignCandles(candles []cdl.Candle, aux []cdl.Candle) []cdl.Candle {
+	aligned := make([]cdl.Candle, len(candles))
+	if len(aux) == 0 {
+		return aligned
+	}
+	j := -1
+	for i, c := range candles {
+		for j+1 < len(aux) && aux[j+1].Time <= c.Time {
+			j++
+		}
+		if j >= 0 {
+			aligned[i] = aux[j]
+		}
+	}
+	return aligned
+}
+
+// parseTimestamp разбирает временную метку в секундах или миллисекундах и возвращает миллисекунды
+func parseTimestamp(s string) (int64, error) {
+	ts, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("неверная временная метка %q: %w", s, err)
+	}
+	if ts < 1e12 {
+		ts *= 1000
+	}
+	return ts, nil
+}
@@ -0,0 +1,56 @@
+package features
+
+import (
+	"goTradingBot/cdl"
+	"testing"
+	"time"
+)
+
+// candleSource источник последних свечей интервала M1 до текущего момента
+type candleSource struct{}
+
+func (candleSource) GetCandles(_ string, _ cdl.Interval, limit int) ([]cdl.Candle, error) {
+	last := time.Now().UnixMilli() / 60000 * 60000
+	candles := make([]cdl.Candle, limit)
+	for i := range candles {
+		t := last - int64(limit-1-i)*60000
+		candles[i] = cdl.Candle{Time: t, O: 1, H: 1, L: 1, C: 1}
+	}
+	return candles, nil
+}
+
+func TestAuxRange(t *testing.T) {
+	fg := NewGeneratorBuilder().AddCrossAsset("BTCUSDT", []cdl.CandleArg{cdl.Close}, 10, 1).Build()
+	candles, _ := candleSource{}.GetCandles("", cdl.M1, 100)
+	if _, err := fg.Gen(candles, 20, -1); err == nil {
+		t.Fatal("Gen без вспомогательных данных")
+	}
+
+	from, to := candles[10].Time, candles[50].Time
+	aux, err := fg.LoadAuxRange(candleSource{}, nil, cdl.M1, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	btc := aux.Candles["BTCUSDT"]
+	if len(btc) != 41 || btc[0].Time != from || btc[len(btc)-1].Time != to {
+		t.Errorf("свечи за период: %d", len(btc))
+	}
+	if _, err := fg.WithAux(aux).Gen(candles[10:51], 20, -1); err != nil {
+		t.Error(err)
+	}
+	// свечи другого инструмента не заменяют отсутствующие свечи BTCUSDT нулями
+	other := &AuxData{Candles: map[string][]cdl.Candle{"ETHUSDT": btc}}
+	if _, err := fg.WithAux(other).Gen(candles[10:51], 20, -1); err == nil {
+		t.Error("Gen без свечей BTCUSDT")
+	}
+	noZScore := NewGeneratorBuilder().AddCrossAsset("BTCUSDT", []cdl.CandleArg{cdl.Close}, 1, 1).Build()
+	if _, err := noZScore.WithAux(aux).Gen(candles[10:51], 20, -1); err == nil {
+		t.Error("zScorePeriod <= 1")
+	}
+	if _, err := fg.LoadAuxRange(candleSource{}, nil, cdl.M1, to, from); err == nil {
+		t.Error("неверный период")
+	}
+	if _, err := fg.LoadAuxRange(candleSource{}, nil, cdl.M1, from-auxRangeLimit*60000, to); err == nil {
+		t.Error("слишком старый период")
+	}
+}
@@ -2,10 +2,10 @@ package features
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"goTradingBot/cdl"
 	"goTradingBot/ta"
-	"goTradingBot/utils"
 	"goTradingBot/utils/norm"
 	"goTradingBot/utils/numeric"
 	"os"
@@ -14,6 +14,7 @@ import (
 
 type Generator struct {
 	absFeatures []*absFeature
+	aux         *AuxData
 }
 
 func (fg *Generator) Save(path string) error {
@@ -52,20 +53,29 @@ func (fg *Generator) Labels() []string {
 	return labels
 }
 
-func (fg *Generator) GenTranspose(candles []cdl.Candle, start, end int) [][]float64 {
-	return numeric.TransposeMatrix(fg.Gen(candles, start, end))
+func (fg *Generator) GenTranspose(candles []cdl.Candle, start, end int) ([][]float64, error) {
+	features, err := fg.Gen(candles, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return numeric.TransposeMatrix(features), nil
 }
 
 // Если end < 0, без обрезки с конца
-func (fg *Generator) Gen(candles []cdl.Candle, start, end int) [][]float64 {
+// Возвращает ошибку, если для признаков инструментов или рыночного контекста не переданы вспомогательные данные
+func (fg *Generator) Gen(candles []cdl.Candle, start, end int) ([][]float64, error) {
 	// n := len(candles)
 	// if n < 100 {
 	// 	panic("GenFeatures: Недостаточно данных")
 	// }
+	if err := fg.checkAux(); err != nil {
+		return nil, err
+	}
 	if end < 0 {
 		end = len(candles)
 	}
 	featuresList := make([][]float64, len(fg.absFeatures))
+	errs := make([]error, len(fg.absFeatures))
 	var wg sync.WaitGroup
 	for n, f := range fg.absFeatures {
 		if f.IsShift || f.IsField {
@@ -84,7 +94,8 @@ func (fg *Generator) Gen(candles []cdl.Candle, start, end int) [][]float64 {
 					ind = cdl.ListOfCandleRatio(candles, cdl.CandleRatio(feature.Name), 1)
 				}
 				if zScorePeriod <= 1 {
-					panic("zScorePeriod <= 1")
+					errs[index] = fmt.Errorf("Gen: %s: zScorePeriod должен быть больше 1", feature.Label())
+					return
 				}
 				features := norm.ZScoreNormalize(ind, zScorePeriod)
 				for s := 0; s < feature.WinSize; s++ {
@@ -92,52 +103,79 @@ func (fg *Generator) Gen(candles []cdl.Candle, start, end int) [][]float64 {
 				}
 				return
 			}
-			var features []float64
-			switch feature.Name {
-			case "MACD":
-				arg := feature.Params["arg"].(cdl.CandleArg)
-				fPeriod := feature.Params["fPeriod"].(int)
-				sPeriod := feature.Params["sPeriod"].(int)
-				dPeriod := feature.Params["dPeriod"].(int)
-				macd := ta.NewMACD(candles, arg, fPeriod, sPeriod, dPeriod)
-				totalFields := len(feature.Fields)
+			if feature.Type == patternFT {
+				// оценки паттернов ограничены [-1, 1] и используются без нормализации при zScorePeriod <= 1
+				features := cdl.ListOfPattern(candles, cdl.Pattern(feature.Name))
+				if zScorePeriod > 1 {
+					features = norm.ZScoreNormalize(features, zScorePeriod)
+				}
 				for s := 0; s < feature.WinSize; s++ {
-					for fn, field := range feature.Fields {
-						fieldV, err := utils.GetField[[]float64](*macd, field)
-						if err != nil {
-							panic(err)
-						}
-						if zScorePeriod > 1 {
-							features = norm.ZScoreNormalize(fieldV, zScorePeriod)
-						} else {
-							features = fieldV
-						}
-						featuresList[index+s*totalFields+fn] = features[start-s : end-s]
-					}
+					featuresList[index+s] = features[start-s : end-s]
+				}
+				return
+			}
+			if feature.Type == crossFT {
+				symbol := feature.Params["symbol"].(string)
+				auxCandles := AlignCandles(candles, fg.aux.Candles[symbol])
+				ind := cdl.ListOfCandleArg(auxCandles, cdl.CandleArg(feature.Name))
+				if zScorePeriod <= 1 {
+					errs[index] = fmt.Errorf("Gen: %s: zScorePeriod должен быть больше 1", feature.Label())
+					return
 				}
-			case "RSI":
-				arg := feature.Params["arg"].(cdl.CandleArg)
-				period := feature.Params["period"].(int)
-				rsi := ta.NewRSI(candles, arg, period).Res
+				features := norm.ZScoreNormalize(ind, zScorePeriod)
+				for s := 0; s < feature.WinSize; s++ {
+					featuresList[index+s] = features[start-s : end-s]
+				}
+				return
+			}
+			if feature.Type == marketFT {
+				var ind []float64
+				switch feature.Name {
+				case fearAndGreedName:
+					ind = AlignSeries(candles, fg.aux.FearAndGreed)
+				case cmc100Name:
+					ind = AlignSeries(candles, fg.aux.CMC100)
+				}
+				features := ind
 				if zScorePeriod > 1 {
-					features = norm.ZScoreNormalize(rsi, zScorePeriod)
-				} else {
-					features = rsi
+					features = norm.ZScoreNormalize(ind, zScorePeriod)
 				}
 				for s := 0; s < feature.WinSize; s++ {
 					featuresList[index+s] = features[start-s : end-s]
 				}
-			case "SMA", "EMA", "VWMA":
-				arg := feature.Params["arg"].(cdl.CandleArg)
-				period := feature.Params["period"].(int)
-				maT := ta.MaType(feature.Name)
-				ma := ta.NewMovingAverage(maT, candles, arg, period).MaRes()
+				return
+			}
+			ind, err := ta.NewIndicatorFromMap(feature.Name, feature.Params)
+			if err != nil {
+				errs[index] = fmt.Errorf("Gen: %s: %w", feature.Label(), err)
+				return
+			}
+			series := ta.Compute(ind, candles)
+			fields := feature.Fields
+			if len(fields) == 0 {
+				fields = ind.Outputs()[:1]
+			}
+			switch ta.MaType(feature.Name) {
+			case ta.S, ta.E, ta.VW, ta.W, ta.H, ta.K, ta.D, ta.T:
+				// уровни скользящих средних имеют смысл только после нормализации
 				if zScorePeriod <= 1 {
-					panic("zScorePeriod <= 1")
+					errs[index] = fmt.Errorf("Gen: %s: zScorePeriod должен быть больше 1", feature.Label())
+					return
+				}
+			}
+			totalFields := len(fields)
+			for fn, field := range fields {
+				values, ok := series[field]
+				if !ok {
+					errs[index] = fmt.Errorf("Gen: %s: неизвестный выход %q", feature.Name, field)
+					return
+				}
+				features := values
+				if zScorePeriod > 1 {
+					features = norm.ZScoreNormalize(values, zScorePeriod)
 				}
-				features = norm.ZScoreNormalize(ma, zScorePeriod)
 				for s := 0; s < feature.WinSize; s++ {
-					featuresList[index+s] = features[start-s : end-s]
+					featuresList[index+s*totalFields+fn] = features[start-s : end-s]
 				}
 			}
 		}(n, f)
@@ -146,15 +184,42 @@ func (fg *Generator) Gen(candles []cdl.Candle, start, end int) [][]float64 {
 		}
 	}
 	wg.Wait()
-	return featuresList
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return featuresList, nil
+}
+
+// checkAux проверяет, что генератор получил вспомогательные данные, если они ему нужны,
+// в том числе свечи каждого инструмента признаков других инструментов
+func (fg *Generator) checkAux() error {
+	for _, f := range fg.absFeatures {
+		if f.Type != crossFT && f.Type != marketFT {
+			continue
+		}
+		if fg.aux == nil {
+			return fmt.Errorf("Gen: отсутствуют вспомогательные данные для %s", f.Label())
+		}
+		if f.Type == crossFT {
+			symbol, _ := f.Params["symbol"].(string)
+			if len(fg.aux.Candles[symbol]) == 0 {
+				return fmt.Errorf("Gen: отсутствуют свечи %s для %s", symbol, f.Label())
+			}
+		}
+	}
+	return nil
 }
 
 type GeneratorBuilder interface {
 	AddCandleArgs(args []cdl.CandleArg, period, WinSize int) GeneratorBuilder
 	AddCandleRatios(ratios []cdl.CandleRatio, period, WinSize int) GeneratorBuilder
+	AddCandlePatterns(patterns []cdl.Pattern, zScorePeriod, winSize int) GeneratorBuilder
 	AddMACD(fields []string, arg cdl.CandleArg, fPeriod, sPeriod, dPeriod, zScorePeriod, winSize int) GeneratorBuilder
 	AddRSI(arg cdl.CandleArg, period, zScorePeriod, winSize int) GeneratorBuilder
 	AddMovingAverage(maT ta.MaType, arg cdl.CandleArg, period, zScorePeriod, winSize int) GeneratorBuilder
+	AddCrossAsset(symbol string, args []cdl.CandleArg, zScorePeriod, winSize int) GeneratorBuilder
+	AddFearAndGreed(zScorePeriod, winSize int) GeneratorBuilder
+	AddCMC100(zScorePeriod, winSize int) GeneratorBuilder
 	Build() *Generator
 }
 
@@ -170,6 +235,14 @@ const (
 	argFT       FeatureType = "A"
 	ratioFT     FeatureType = "R"
 	indicatorFT FeatureType = "I"
+	crossFT     FeatureType = "X" // признаки вспомогательного инструмента
+	marketFT    FeatureType = "M" // признаки рыночного контекста
+	patternFT   FeatureType = "P" // оценки свечных паттернов
+)
+
+const (
+	fearAndGreedName = "FearAndGreed"
+	cmc100Name       = "CMC100"
 )
 
 type absFeature struct {
@@ -238,6 +311,29 @@ func (fgb *fGB) AddCandleRatios(ratios []cdl.CandleRatio, zScorePeriod, winSize
 	return fgb
 }
 
+// AddCandlePatterns добавляет оценки свечных паттернов
+// При zScorePeriod <= 1 используются исходные оценки в диапазоне [-1, 1]
+func (fgb *fGB) AddCandlePatterns(patterns []cdl.Pattern, zScorePeriod, winSize int) GeneratorBuilder {
+	for _, p := range patterns {
+		for shift := 0; shift < winSize; shift++ {
+			f := &absFeature{
+				Type: patternFT,
+				Name: string(p),
+				Params: map[string]any{
+					"zScorePeriod": zScorePeriod,
+					"shift":        shift,
+				},
+				OrderParams: []string{"zScorePeriod", "shift"},
+				IsShift:     shift > 0,
+				WinSize:     winSize,
+				IsField:     false,
+			}
+			fgb.fg.absFeatures = append(fgb.fg.absFeatures, f)
+		}
+	}
+	return fgb
+}
+
 func (fgb *fGB) AddMACD(fields []string, arg cdl.CandleArg, fPeriod, sPeriod, dPeriod, zScorePeriod, winSize int) GeneratorBuilder {
 	for shift := 0; shift < winSize; shift++ {
 		for fn, field := range fields {
@@ -311,6 +407,62 @@ func (fgb *fGB) AddMovingAverage(maT ta.MaType, arg cdl.CandleArg, period, zScor
 	return fgb
 }
 
+// AddCrossAsset добавляет признаки свечей вспомогательного инструмента (например, BTCUSDT)
+// Свечи выравниваются по времени основного инструмента с заполнением вперед
+func (fgb *fGB) AddCrossAsset(symbol string, args []cdl.CandleArg, zScorePeriod, winSize int) GeneratorBuilder {
+	for _, a := range args {
+		for shift := 0; shift < winSize; shift++ {
+			f := &absFeature{
+				Type: crossFT,
+				Name: string(a),
+				Params: map[string]any{
+					"symbol":       symbol,
+					"zScorePeriod": zScorePeriod,
+					"shift":        shift,
+				},
+				OrderParams: []string{"symbol", "zScorePeriod", "shift"},
+				IsShift:     shift > 0,
+				WinSize:     winSize,
+				IsField:     false,
+			}
+			fgb.fg.absFeatures = append(fgb.fg.absFeatures, f)
+		}
+	}
+	return fgb
+}
+
+// AddFearAndGreed добавляет индекс страха и жадности
+// При zScorePeriod <= 1 используется исходное значение индекса
+func (fgb *fGB) AddFearAndGreed(zScorePeriod, winSize int) GeneratorBuilder {
+	return fgb.addMarket(fearAndGreedName, zScorePeriod, winSize)
+}
+
+// AddCMC100 добавляет относительные изменения индекса CMC100
+// При zScorePeriod <= 1 используется исходное значение изменения.
+// История индекса доступна только за последние сутки, поэтому признак не поддерживается в датасетах
+func (fgb *fGB) AddCMC100(zScorePeriod, winSize int) GeneratorBuilder {
+	return fgb.addMarket(cmc100Name, zScorePeriod, winSize)
+}
+
+func (fgb *fGB) addMarket(name string, zScorePeriod, winSize int) GeneratorBuilder {
+	for shift := 0; shift < winSize; shift++ {
+		f := &absFeature{
+			Type: marketFT,
+			Name: name,
+			Params: map[string]any{
+				"zScorePeriod": zScorePeriod,
+				"shift":        shift,
+			},
+			OrderParams: []string{"zScorePeriod", "shift"},
+			IsShift:     shift > 0,
+			WinSize:     winSize,
+			IsField:     false,
+		}
+		fgb.fg.absFeatures = append(fgb.fg.absFeatures, f)
+	}
+	return fgb
+}
+
 func (fgb *fGB) Build() *Generator {
 	return fgb.fg
 }
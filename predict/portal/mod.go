@@ -147,6 +147,14 @@ func (pr *Response) UnwrapSinglePredict() ([]float64, error) {
 	return nil, fmt.Errorf("UnwrapSinglePredict: нет предсказаний")
 }
 
+// Ping проверяет доступность портала
+func Ping() error {
+	if ping() != "pong" {
+		return fmt.Errorf("Ping: portal недоступен")
+	}
+	return nil
+}
+
 // ping проверяет доступность портала
 // Возвращает "pong" если portal доступен, иначе пустую строку
 func ping() string {
@@ -1,6 +1,9 @@
 package signals
 
-import "goTradingBot/cdl"
+import (
+	"goTradingBot/cdl"
+	"goTradingBot/ta/structure"
+)
 
 // PerfectTrend определяет "идеальные" точки входа в long/short на основе фракталов.
 // Функция ищет последовательные максимумы (для short) и минимумы (для long),
@@ -10,12 +13,50 @@ import "goTradingBot/cdl"
 // 1 - сигнал к short (после медвежьего фрактала)
 // period - количество свечей по обе стороны, которые должны быть ниже/выше фрактала
 func PerfectTrend(candles []cdl.Candle, period int) []float64 {
+	upFractals, downFractals := perfectTrendFractals(candles, period)
+	if upFractals == nil {
+		return nil
+	}
+	n := len(candles)
+	firstSignal := 0
+	for i := 0; firstSignal == 0 && i < n; i++ {
+		if upFractals[i] {
+			firstSignal++
+		}
+		if downFractals[i] {
+			firstSignal--
+		}
+	}
+	lastIsUp := false
+	if firstSignal == 1 {
+		lastIsUp = true
+	}
+	signals := make([]float64, n)
+	for i := 1; i < n; i++ {
+		if upFractals[i-1] {
+			lastIsUp = true
+		}
+		if downFractals[i-1] {
+			lastIsUp = false
+		}
+		if lastIsUp {
+			signals[i] = 0
+			continue
+		}
+		signals[i] = 1
+	}
+	return signals
+}
+
+// perfectTrendFractals возвращает чередующиеся фракталы PerfectTrend: из подряд идущих фракталов
+// одного типа остается самый экстремальный. Фрактал свечи i подтверждается свечой i+period
+func perfectTrendFractals(candles []cdl.Candle, period int) (upFractals, downFractals []bool) {
 	n := len(candles)
 	if n == 0 || period < 2 || n <= 2*period {
-		return nil
+		return nil, nil
 	}
-	upFractals := make([]bool, n)
-	downFractals := make([]bool, n)
+	upFractals = make([]bool, n)
+	downFractals = make([]bool, n)
 	highs := make([]float64, n)
 	lows := make([]float64, n)
 	for i := 0; i < n; i++ {
@@ -27,12 +68,7 @@ func PerfectTrend(candles []cdl.Candle, period int) []float64 {
 	for i := period; i < n-period; i++ {
 		highV := highs[i]
 		lowV := lows[i]
-		isUpFractal := true
-		for j := 1; j <= period && isUpFractal; j++ {
-			if highs[i-j] >= highV || highs[i+j] >= highV {
-				isUpFractal = false
-			}
-		}
+		isUpFractal := structure.IsFractalHigh(highs, i, period)
 		if isUpFractal {
 			if lastFr == 1 {
 				if highV >= lastV {
@@ -48,12 +84,7 @@ func PerfectTrend(candles []cdl.Candle, period int) []float64 {
 				lastFrIndex = i
 			}
 		}
-		isDownFractal := true
-		for j := 1; j <= period && isDownFractal; j++ {
-			if lows[i-j] <= lowV || lows[i+j] <= lowV {
-				isDownFractal = false
-			}
-		}
+		isDownFractal := structure.IsFractalLow(lows, i, period)
 		if isDownFractal {
 			if lastFr == -1 {
 				if lowV <= lastV {
@@ -70,35 +101,43 @@ func PerfectTrend(candles []cdl.Candle, period int) []float64 {
 			}
 		}
 	}
-	highs, lows = nil, nil
-	firstSignal := 0
-	for i := 0; firstSignal == 0 && i < n; i++ {
-		if upFractals[i] {
-			firstSignal++
-		}
-		if downFractals[i] {
-			firstSignal--
-		}
+	return upFractals, downFractals
+}
+
+// PerfectTrendHorizon возвращает для каждой свечи количество будущих свечей, после которого сигнал
+// PerfectTrend свечи не меняется. Сигнал свечи определяется последним фракталом до нее и фиксируется
+// подтверждением следующего фрактала, расстояние до которого не ограничено периодом.
+// Сигнал свечей до первого фрактала фиксируется вторым фракталом. Для свечей, сигнал которых
+// не зафиксирован в пределах ряда, горизонт равен расстоянию до конца ряда
+func PerfectTrendHorizon(candles []cdl.Candle, period int) []int {
+	upFractals, downFractals := perfectTrendFractals(candles, period)
+	if upFractals == nil {
+		return nil
 	}
-	lastIsUp := false
-	if firstSignal == 1 {
-		lastIsUp = true
+	n := len(candles)
+	var fractals []int
+	for i := range n {
+		if upFractals[i] || downFractals[i] {
+			fractals = append(fractals, i)
+		}
 	}
-	signals := make([]float64, n)
-	for i := 1; i < n; i++ {
-		if upFractals[i-1] {
-			lastIsUp = true
+	horizons := make([]int, n)
+	next := len(fractals) // индекс первого фрактала не раньше свечи i
+	for i := n - 1; i >= 0; i-- {
+		for next > 0 && fractals[next-1] >= i {
+			next--
 		}
-		if downFractals[i-1] {
-			lastIsUp = false
+		confirm := next
+		if confirm == 0 {
+			confirm = 1
 		}
-		if lastIsUp {
-			signals[i] = 0
-			continue
+		if confirm < len(fractals) {
+			horizons[i] = fractals[confirm] + period - i
+		} else {
+			horizons[i] = n - i
 		}
-		signals[i] = 1
 	}
-	return signals
+	return horizons
 }
 
 // NextDirSignal возвращает сигнал (1 или 0) в зависимости от направления следующей свечи:
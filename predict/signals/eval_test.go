@@ -0,0 +1,50 @@
+package signals
+
+import (
+	"goTradingBot/cdl"
+	"math/rand/v2"
+	"testing"
+)
+
+// walk возвращает свечи случайного блуждания с равными ценами открытия, закрытия и экстремумами
+func walk(n int, seed uint64) []cdl.Candle {
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	candles := make([]cdl.Candle, n)
+	price := 100.0
+	for i := range candles {
+		price += rnd.NormFloat64()
+		candles[i] = cdl.Candle{Time: int64(i) * 60000, O: price, H: price, L: price, C: price}
+	}
+	return candles
+}
+
+func TestPerfectTrendHorizon(t *testing.T) {
+	const period = 3
+	candles := walk(400, 1)
+	full := PerfectTrend(candles, period)
+	horizons := PerfectTrendHorizon(candles, period)
+	var beyondPeriod, leaks int
+	for i, h := range horizons {
+		if i+h >= len(candles) {
+			continue
+		}
+		if h > period+1 {
+			beyondPeriod++
+		}
+		// прежний горизонт period не фиксирует сигнал
+		if short := PerfectTrend(candles[:min(i+period+1, len(candles))], period); short != nil && short[i] != full[i] {
+			leaks++
+		}
+		// сигнал не меняется после горизонта свечи
+		prefix := PerfectTrend(candles[:i+h+1], period)
+		if prefix != nil && prefix[i] != full[i] {
+			t.Fatalf("свеча %d: сигнал изменился после горизонта %d", i, h)
+		}
+	}
+	if beyondPeriod == 0 || leaks == 0 {
+		t.Errorf("горизонт не превышает период фрактала: %d %d", beyondPeriod, leaks)
+	}
+	if h := horizons[len(horizons)-1]; h != 1 {
+		t.Errorf("горизонт последней свечи: %d", h)
+	}
+}
@@ -0,0 +1,165 @@
+package signals
+
+import (
+	"goTradingBot/cdl"
+	"math"
+)
+
+// Классы тройного барьера
+const (
+	LowerBarrier = 0 // первым достигнут нижний барьер (стоп для long)
+	TimeBarrier  = 1 // за maxBars свечей не достигнут ни один из ценовых барьеров
+	UpperBarrier = 2 // первым достигнут верхний барьер (тейк для long)
+)
+
+// TripleBarrier размечает свечи методом тройного барьера.
+// Для свечи i от цены закрытия откладываются верхний барьер (tpMult * ATR) и нижний (slMult * ATR),
+// где ATR рассчитывается по свечам до i включительно. Вертикальный барьер - maxBars свечей.
+// Возвращает массив классов: LowerBarrier, TimeBarrier, UpperBarrier.
+// Если на одной свече задеты оба барьера, считается что первым достигнут нижний (стоп)
+func TripleBarrier(candles []cdl.Candle, atrPeriod int, tpMult, slMult float64, maxBars int) []float64 {
+	n := len(candles)
+	if n == 0 || atrPeriod <= 0 || maxBars <= 0 {
+		return nil
+	}
+	atr := wilderATR(candles, atrPeriod)
+	signals := make([]float64, n)
+	for i := range n {
+		signals[i] = barrierClass(candles, i, atr[i]*tpMult, atr[i]*slMult, maxBars, true)
+	}
+	return signals
+}
+
+// barrierClass определяет, какой из барьеров для входа по закрытию свечи i достигнут первым
+// upDist и downDist - расстояния до верхнего и нижнего барьеров
+// lowerFirst - какой барьер считать достигнутым первым, если на одной свече задеты оба
+func barrierClass(candles []cdl.Candle, i int, upDist, downDist float64, maxBars int, lowerFirst bool) float64 {
+	entry := candles[i].C
+	upper := entry + upDist
+	lower := entry - downDist
+	for j := i + 1; j <= i+maxBars && j < len(candles); j++ {
+		hitLower := candles[j].L <= lower
+		hitUpper := candles[j].H >= upper
+		switch {
+		case hitLower && hitUpper && lowerFirst, hitLower && !hitUpper:
+			return LowerBarrier
+		case hitUpper:
+			return UpperBarrier
+		}
+	}
+	return TimeBarrier
+}
+
+// ForwardLogReturn возвращает логарифмическую доходность за horizon свечей вперед: ln(C[i+horizon]/C[i])
+// Для последних horizon свечей значение равно 0
+func ForwardLogReturn(candles []cdl.Candle, horizon int) []float64 {
+	n := len(candles)
+	if n == 0 || horizon <= 0 {
+		return nil
+	}
+	signals := make([]float64, n)
+	for i := 0; i+horizon < n; i++ {
+		signals[i] = logReturn(candles[i].C, candles[i+horizon].C)
+	}
+	return signals
+}
+
+// VolNormalizedReturn возвращает доходность за horizon свечей вперед, нормированную на волатильность:
+// ln(C[i+horizon]/C[i]) / (σ * sqrt(horizon)), где σ - стандартное отклонение
+// логарифмических доходностей за volPeriod свечей до i включительно
+func VolNormalizedReturn(candles []cdl.Candle, horizon, volPeriod int) []float64 {
+	n := len(candles)
+	if n == 0 || horizon <= 0 || volPeriod < 2 {
+		return nil
+	}
+	forward := ForwardLogReturn(candles, horizon)
+	vol := rollingVolatility(candles, volPeriod)
+	scale := math.Sqrt(float64(horizon))
+	signals := make([]float64, n)
+	for i := range n {
+		if vol[i] > 0 {
+			signals[i] = forward[i] / (vol[i] * scale)
+		}
+	}
+	return signals
+}
+
+// MetaLabel размечает сделки первичного сигнала для мета-модели.
+// sides - направление первичного сигнала для каждой свечи: 1 - long, -1 - short, 0 - нет позиции.
+// Возвращает 1, если для сделки в направлении первичного сигнала первым достигнут тейк (tpMult * ATR),
+// и 0, если первым достигнут стоп (slMult * ATR), истек вертикальный барьер или позиции нет.
+// Если на одной свече задеты тейк и стоп, считается что первым достигнут стоп
+func MetaLabel(candles []cdl.Candle, sides []float64, atrPeriod int, tpMult, slMult float64, maxBars int) []float64 {
+	n := len(candles)
+	if n == 0 || len(sides) != n || atrPeriod <= 0 || maxBars <= 0 {
+		return nil
+	}
+	atr := wilderATR(candles, atrPeriod)
+	signals := make([]float64, n)
+	for i := range n {
+		switch {
+		case sides[i] > 0:
+			if barrierClass(candles, i, atr[i]*tpMult, atr[i]*slMult, maxBars, true) == UpperBarrier {
+				signals[i] = 1
+			}
+		case sides[i] < 0:
+			if barrierClass(candles, i, atr[i]*slMult, atr[i]*tpMult, maxBars, false) == LowerBarrier {
+				signals[i] = 1
+			}
+		}
+	}
+	return signals
+}
+
+// wilderATR рассчитывает ATR со сглаживанием Уайлдера по свечам до i включительно
+func wilderATR(candles []cdl.Candle, period int) []float64 {
+	n := len(candles)
+	atr := make([]float64, n)
+	if n == 0 {
+		return atr
+	}
+	atr[0] = candles[0].Arg(cdl.TrueRange)
+	alpha := 1 / float64(period)
+	for i := 1; i < n; i++ {
+		tr := candles[i].Ratio(cdl.TrueRangeRatio, &candles[i-1])
+		atr[i] = tr*alpha + atr[i-1]*(1-alpha)
+	}
+	return atr
+}
+
+// rollingVolatility рассчитывает стандартное отклонение логарифмических доходностей
+// в окне period свечей до i включительно
+func rollingVolatility(candles []cdl.Candle, period int) []float64 {
+	n := len(candles)
+	vol := make([]float64, n)
+	returns := make([]float64, n)
+	for i := 1; i < n; i++ {
+		returns[i] = logReturn(candles[i-1].C, candles[i].C)
+	}
+	var sum, sumSq float64
+	for i := 1; i < n; i++ {
+		sum += returns[i]
+		sumSq += returns[i] * returns[i]
+		if i > period {
+			old := returns[i-period]
+			sum -= old
+			sumSq -= old * old
+		}
+		count := float64(min(i, period))
+		if count < 2 {
+			continue
+		}
+		mean := sum / count
+		if variance := (sumSq - count*mean*mean) / (count - 1); variance > 0 {
+			vol[i] = math.Sqrt(variance)
+		}
+	}
+	return vol
+}
+
+func logReturn(from, to float64) float64 {
+	if from <= 0 || to <= 0 {
+		return 0
+	}
+	return math.Log(to / from)
+}
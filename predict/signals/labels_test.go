@@ -0,0 +1,94 @@
+package signals
+
+import (
+	"goTradingBot/cdl"
+	"math"
+	"testing"
+)
+
+// barrierCandles свечи с ATR(1), посчитанным вручную: истинный диапазон и барьеры при tp = sl = 1
+func barrierCandles() []cdl.Candle {
+	return []cdl.Candle{
+		{H: 11, L: 9, C: 10},        // TR 2, барьеры 12 / 8
+		{H: 10.5, L: 9.5, C: 10},    // TR 1, барьеры 11 / 9
+		{H: 12.5, L: 7.5, C: 10},    // TR 5, барьеры 15 / 5, задевает оба барьера свечей 0 и 1
+		{H: 11, L: 9.5, C: 10.5},    // TR 1.5, барьеры 12 / 9
+		{H: 10.6, L: 10.4, C: 10.5}, // TR 0.2, барьеры 10.7 / 10.3
+		{H: 10.6, L: 10.4, C: 10.5}, // TR 0.2, барьеры 10.7 / 10.3
+		{H: 11, L: 10.45, C: 10.9},  // TR 0.55, барьеры 11.45 / 10.35
+		{H: 11, L: 9, C: 10},        // TR 2
+	}
+}
+
+func TestTripleBarrier(t *testing.T) {
+	got := TripleBarrier(barrierCandles(), 1, 1, 1, 2)
+	// 0, 1 - оба барьера на одной свече, считается стоп; 4, 5 - тейк, у 5 горизонт неполный;
+	// 6 - только нижний барьер; 7 - последняя свеча без будущих свечей
+	want := []float64{LowerBarrier, LowerBarrier, TimeBarrier, TimeBarrier, UpperBarrier, UpperBarrier, LowerBarrier, TimeBarrier}
+	checkLabels(t, "TripleBarrier", got, want)
+
+	// с maxBars = 1 свеча 0 не доходит до свечи 2
+	if got := TripleBarrier(barrierCandles(), 1, 1, 1, 1); got[0] != TimeBarrier || got[1] != LowerBarrier {
+		t.Errorf("maxBars = 1: %v", got)
+	}
+	if TripleBarrier(nil, 1, 1, 1, 2) != nil || TripleBarrier(barrierCandles(), 1, 1, 1, 0) != nil {
+		t.Error("некорректные параметры")
+	}
+}
+
+func TestMetaLabel(t *testing.T) {
+	sides := []float64{1, -1, -1, 0, 1, 0, -1, 1}
+	got := MetaLabel(barrierCandles(), sides, 1, 1, 1, 2)
+	// 0 long и 1 short - оба барьера на одной свече, считается стоп; 4 long - тейк;
+	// 5 - нет позиции, хотя верхний барьер достигнут; 6 short - тейк по нижнему барьеру
+	want := []float64{0, 0, 0, 0, 1, 0, 1, 0}
+	checkLabels(t, "MetaLabel", got, want)
+
+	// тейк short в 4 ATR ниже входа не достигается, стоп в 1 ATR выше входа тоже
+	if got := MetaLabel(barrierCandles(), sides, 1, 4, 1, 2); got[6] != 0 {
+		t.Errorf("дальний тейк short: %v", got[6])
+	}
+	if MetaLabel(barrierCandles(), sides[:3], 1, 1, 1, 2) != nil {
+		t.Error("длина sides")
+	}
+}
+
+// logCandles свечи с ln(C) = 0, 1, 3, 2, 2, 4: лог-доходности 1, 2, -1, 0, 2
+func logCandles() []cdl.Candle {
+	var candles []cdl.Candle
+	for _, v := range []float64{0, 1, 3, 2, 2, 4} {
+		candles = append(candles, cdl.Candle{C: math.Exp(v)})
+	}
+	return candles
+}
+
+func TestForwardLogReturn(t *testing.T) {
+	checkLabels(t, "ForwardLogReturn(1)", ForwardLogReturn(logCandles(), 1), []float64{1, 2, -1, 0, 2, 0})
+	// последние horizon свечей без полного горизонта равны 0
+	checkLabels(t, "ForwardLogReturn(2)", ForwardLogReturn(logCandles(), 2), []float64{3, 1, -1, 2, 0, 0})
+	checkLabels(t, "ForwardLogReturn(6)", ForwardLogReturn(logCandles(), 6), make([]float64, 6))
+}
+
+func TestVolNormalizedReturn(t *testing.T) {
+	// σ по двум доходностям: свеча 2 - (1, 2) σ = √0.5, свеча 3 - (2, -1) σ = √4.5;
+	// свечи 0 и 1 без двух доходностей и 4, 5 без полного горизонта равны 0
+	got := VolNormalizedReturn(logCandles(), 2, 2)
+	want := []float64{0, 0, -1 / (math.Sqrt(0.5) * math.Sqrt2), 2 / (math.Sqrt(4.5) * math.Sqrt2), 0, 0}
+	checkLabels(t, "VolNormalizedReturn", got, want)
+
+	// нулевая волатильность не дает деления на ноль
+	flat := []cdl.Candle{{C: 1}, {C: 1}, {C: 1}, {C: 2}}
+	checkLabels(t, "VolNormalizedReturn flat", VolNormalizedReturn(flat, 1, 2), []float64{0, 0, 0, 0})
+}
+
+func checkLabels(t *testing.T, name string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: длина %d, ожидается %d", name, len(got), len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Errorf("%s[%d] = %v, ожидается %v", name, i, got[i], want[i])
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"goTradingBot/cdl"
 	"goTradingBot/utils/numeric"
+	"maps"
 	"os"
+	"slices"
 	"sync"
 )
 
@@ -45,6 +47,47 @@ func (sg *Generator) Labels() []string {
 	return labels
 }
 
+// LookAhead возвращает минимальное количество будущих свечей, используемых сигналами.
+// Для фрактальных сигналов это период подтверждения фрактала (+1 для Next-сигналов),
+// фактический горизонт которых зависит от свечей и рассчитывается Horizons
+func (sg *Generator) LookAhead() int {
+	var lookAhead int
+	for _, s := range sg.absSignals {
+		lookAhead = max(lookAhead, s.lookAhead())
+	}
+	return lookAhead
+}
+
+// Horizons возвращает для каждой свечи количество будущих свечей, используемых сигналами свечи.
+// Используется для расчета окон очистки (purge) между обучающей и тестовой выборками
+func (sg *Generator) Horizons(candles []cdl.Candle) []int {
+	n := len(candles)
+	horizons := make([]int, n)
+	for _, s := range sg.absSignals {
+		var signal []int
+		switch s.Name {
+		case "PerfectTrend":
+			signal = PerfectTrendHorizon(candles, intParam(s.Params, "period"))
+		case "NextPerfectTrend":
+			if h := PerfectTrendHorizon(candles, intParam(s.Params, "period")); h != nil {
+				signal = make([]int, n)
+				for i := range n - 1 {
+					signal[i] = h[i+1] + 1
+				}
+				signal[n-1] = 1
+			}
+		}
+		for i := range horizons {
+			if signal != nil {
+				horizons[i] = max(horizons[i], signal[i])
+			} else {
+				horizons[i] = max(horizons[i], s.lookAhead())
+			}
+		}
+	}
+	return horizons
+}
+
 // GenTranspose генерирует матрицу сигналов и транспонирует ее
 func (sg *Generator) GenTranspose(candles []cdl.Candle, start, end int) [][]float64 {
 	return numeric.TransposeMatrix(sg.Gen(candles, start, end))
@@ -59,27 +102,9 @@ func (sg *Generator) Gen(candles []cdl.Candle, start, end int) [][]float64 {
 		wg.Add(1)
 		go func(index int, signal *absSignal) {
 			defer wg.Done()
-
-			var signals []float64
-			switch signal.Name {
-			case "PerfectTrend":
-				period := signal.Params["period"].(int)
-				signals = PerfectTrend(candles, period)[start:end]
-			case "NextPerfectTrend":
-				period := signal.Params["period"].(int)
-				signals = PerfectTrend(candles, period)[start+1 : end+1]
-			// case "TrendQualityZone":
-			// 	signals = TrendQualityZone(candles)[start:end]
-			// case "NextTrendQualityZone":
-			// 	signals = TrendQualityZone(candles)[start+1 : end+1]
-			case "NextDirSignal":
-				signals = NextDirSignal(candles)[start:end]
-			case "NextBodyWiderSignal":
-				signals = NextBodyWiderSignal(candles)[start:end]
-			case "NextCandleOutsideSignal":
-				signals = NextCandleOutsideSignal(candles)[start:end]
+			if signals := sg.series(signal, candles); signals != nil {
+				signalsList[index] = signals[start:end]
 			}
-			signalsList[index] = signals
 		}(n, s)
 		if n%8 == 0 {
 			wg.Wait()
@@ -89,6 +114,78 @@ func (sg *Generator) Gen(candles []cdl.Candle, start, end int) [][]float64 {
 	return signalsList
 }
 
+// series рассчитывает сигнал для всех свечей
+func (sg *Generator) series(signal *absSignal, candles []cdl.Candle) []float64 {
+	switch signal.Name {
+	case "PerfectTrend":
+		return PerfectTrend(candles, intParam(signal.Params, "period"))
+	case "NextPerfectTrend":
+		signals := PerfectTrend(candles, intParam(signal.Params, "period"))
+		if signals == nil {
+			return nil
+		}
+		return append(signals[1:], 0)
+	// case "TrendQualityZone":
+	// 	signals = TrendQualityZone(candles)[start:end]
+	// case "NextTrendQualityZone":
+	// 	signals = TrendQualityZone(candles)[start+1 : end+1]
+	case "NextDirSignal":
+		return NextDirSignal(candles)
+	case "NextBodyWiderSignal":
+		return NextBodyWiderSignal(candles)
+	case "NextCandleOutsideSignal":
+		return NextCandleOutsideSignal(candles)
+	case "TripleBarrier":
+		return TripleBarrier(
+			candles,
+			intParam(signal.Params, "atrPeriod"),
+			floatParam(signal.Params, "tpMult"),
+			floatParam(signal.Params, "slMult"),
+			intParam(signal.Params, "maxBars"),
+		)
+	case "ForwardLogReturn":
+		return ForwardLogReturn(candles, intParam(signal.Params, "horizon"))
+	case "VolNormalizedReturn":
+		return VolNormalizedReturn(
+			candles,
+			intParam(signal.Params, "horizon"),
+			intParam(signal.Params, "volPeriod"),
+		)
+	case "MetaLabel":
+		primary := sg.find(stringParam(signal.Params, "primary"))
+		if primary == nil {
+			panic(fmt.Sprintf("MetaLabel: первичный сигнал %q не найден", signal.Params["primary"]))
+		}
+		values := sg.series(primary, candles)
+		if values == nil {
+			return nil
+		}
+		sides := make([]float64, len(values))
+		for i, v := range values {
+			sides[i] = primary.side(v)
+		}
+		return MetaLabel(
+			candles,
+			sides,
+			intParam(signal.Params, "atrPeriod"),
+			floatParam(signal.Params, "tpMult"),
+			floatParam(signal.Params, "slMult"),
+			intParam(signal.Params, "maxBars"),
+		)
+	}
+	return nil
+}
+
+// find возвращает сигнал с указанной меткой
+func (sg *Generator) find(label string) *absSignal {
+	for _, s := range sg.absSignals {
+		if s.Label() == label {
+			return s
+		}
+	}
+	return nil
+}
+
 // GeneratorBuilder интерфейс для построения Generator
 type GeneratorBuilder interface {
 	AddPerfectTrend(period int) GeneratorBuilder
@@ -98,6 +195,10 @@ type GeneratorBuilder interface {
 	AddNextDirSignal() GeneratorBuilder
 	AddNextBodyWiderSignal() GeneratorBuilder
 	AddNextCandleOutsideSignal() GeneratorBuilder
+	AddTripleBarrier(atrPeriod int, tpMult, slMult float64, maxBars int) GeneratorBuilder
+	AddForwardLogReturn(horizon int) GeneratorBuilder
+	AddVolNormalizedReturn(horizon, volPeriod int) GeneratorBuilder
+	AddMetaLabel(primary string, atrPeriod int, tpMult, slMult float64, maxBars int) GeneratorBuilder
 	Build() *Generator
 }
 
@@ -108,19 +209,107 @@ func NewGeneratorBuilder() GeneratorBuilder {
 
 // absSignal представляет абстрактный торговый сигнал с параметрами
 type absSignal struct {
-	Name   string         `json:"name"`
-	Params map[string]any `json:"params"`
+	Name        string         `json:"name"`
+	Params      map[string]any `json:"params"`
+	OrderParams []string       `json:"orderParams,omitempty"` // порядок параметров в метке
 }
 
 // Label генерирует читаемую метку для сигнала
+// Параметры перечисляются в порядке OrderParams, а при его отсутствии - по алфавиту
 func (s *absSignal) Label() string {
+	keys := s.OrderParams
+	if keys == nil {
+		keys = slices.Sorted(maps.Keys(s.Params))
+	}
 	label := s.Name
-	for k, p := range s.Params {
-		label = fmt.Sprintf("%s-%s%v", label, string(k[0]), p)
+	for _, k := range keys {
+		label = fmt.Sprintf("%s-%s%v", label, string(k[0]), s.Params[k])
 	}
 	return label
 }
 
+// side преобразует значение сигнала в направление сделки: 1 - long, -1 - short, 0 - нет позиции
+func (s *absSignal) side(v float64) float64 {
+	switch s.Name {
+	case "PerfectTrend", "NextPerfectTrend":
+		// 0 - long, 1 - short
+		if v == 0 {
+			return 1
+		}
+		return -1
+	case "TripleBarrier":
+		switch v {
+		case UpperBarrier:
+			return 1
+		case LowerBarrier:
+			return -1
+		}
+		return 0
+	case "ForwardLogReturn", "VolNormalizedReturn":
+		switch {
+		case v > 0:
+			return 1
+		case v < 0:
+			return -1
+		}
+		return 0
+	case "MetaLabel":
+		return v
+	}
+	if v > 0 {
+		return 1
+	}
+	return -1
+}
+
+// lookAhead возвращает количество будущих свечей, используемых сигналом
+func (s *absSignal) lookAhead() int {
+	switch s.Name {
+	case "PerfectTrend":
+		return intParam(s.Params, "period")
+	case "NextPerfectTrend":
+		return intParam(s.Params, "period") + 1
+	case "NextDirSignal", "NextBodyWiderSignal", "NextCandleOutsideSignal":
+		return 1
+	case "TripleBarrier":
+		return intParam(s.Params, "maxBars")
+	case "ForwardLogReturn", "VolNormalizedReturn":
+		return intParam(s.Params, "horizon")
+	case "MetaLabel":
+		// горизонт первичного сигнала учитывается через Generator.LookAhead
+		return intParam(s.Params, "maxBars")
+	}
+	return 0
+}
+
+// intParam извлекает целочисленный параметр с учетом десериализации из JSON (float64)
+func intParam(params map[string]any, key string) int {
+	switch v := params[key].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// floatParam извлекает вещественный параметр
+func floatParam(params map[string]any, key string) float64 {
+	switch v := params[key].(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+// stringParam извлекает строковый параметр
+func stringParam(params map[string]any, key string) string {
+	v, _ := params[key].(string)
+	return v
+}
+
 // sGB реализация билдера для Generator
 type sGB struct {
 	sg *Generator
@@ -171,6 +360,62 @@ func (sgb *sGB) AddNextCandleOutsideSignal() GeneratorBuilder {
 	return sgb
 }
 
+func (sgb *sGB) AddTripleBarrier(atrPeriod int, tpMult, slMult float64, maxBars int) GeneratorBuilder {
+	sgb.sg.absSignals = append(sgb.sg.absSignals, &absSignal{
+		Name: "TripleBarrier",
+		Params: map[string]any{
+			"atrPeriod": atrPeriod,
+			"tpMult":    tpMult,
+			"slMult":    slMult,
+			"maxBars":   maxBars,
+		},
+		OrderParams: []string{"atrPeriod", "tpMult", "slMult", "maxBars"},
+	})
+	return sgb
+}
+
+func (sgb *sGB) AddForwardLogReturn(horizon int) GeneratorBuilder {
+	sgb.sg.absSignals = append(sgb.sg.absSignals, &absSignal{
+		Name: "ForwardLogReturn",
+		Params: map[string]any{
+			"horizon": horizon,
+		},
+	})
+	return sgb
+}
+
+func (sgb *sGB) AddVolNormalizedReturn(horizon, volPeriod int) GeneratorBuilder {
+	sgb.sg.absSignals = append(sgb.sg.absSignals, &absSignal{
+		Name: "VolNormalizedReturn",
+		Params: map[string]any{
+			"horizon":   horizon,
+			"volPeriod": volPeriod,
+		},
+		OrderParams: []string{"horizon", "volPeriod"},
+	})
+	return sgb
+}
+
+// AddMetaLabel добавляет мета-метку для первичного сигнала
+// primary - метка (Label) сигнала, добавленного в генератор ранее
+func (sgb *sGB) AddMetaLabel(primary string, atrPeriod int, tpMult, slMult float64, maxBars int) GeneratorBuilder {
+	if sgb.sg.find(primary) == nil {
+		panic(fmt.Sprintf("AddMetaLabel: первичный сигнал %q не найден", primary))
+	}
+	sgb.sg.absSignals = append(sgb.sg.absSignals, &absSignal{
+		Name: "MetaLabel",
+		Params: map[string]any{
+			"primary":   primary,
+			"atrPeriod": atrPeriod,
+			"tpMult":    tpMult,
+			"slMult":    slMult,
+			"maxBars":   maxBars,
+		},
+		OrderParams: []string{"primary", "atrPeriod", "tpMult", "slMult", "maxBars"},
+	})
+	return sgb
+}
+
 func (sgb *sGB) Build() *Generator {
 	return sgb.sg
 }
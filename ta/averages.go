@@ -0,0 +1,284 @@
+package ta
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+	"math"
+	"slices"
+)
+
+// Дополнительные скользящие средние: WMA, HMA, KAMA, DEMA, TEMA
+// До заполнения окна значения рассчитываются по доступным свечам
+
+func init() {
+	register(string(W), defaultMAParams, func(p maParams) (Indicator, error) {
+		return p.validate(func() Indicator { return NewWMAIndicator(p.Arg, p.Period) })
+	})
+	register(string(H), defaultMAParams, func(p maParams) (Indicator, error) {
+		return p.validate(func() Indicator { return NewHMAIndicator(p.Arg, p.Period) })
+	})
+	register(string(K), func() kamaParams {
+		return kamaParams{Arg: cdl.Close, Period: 10, Fast: 2, Slow: 30}
+	}, func(p kamaParams) (Indicator, error) {
+		if p.Period <= 0 || p.Fast <= 0 || p.Slow <= p.Fast {
+			return nil, fmt.Errorf("неверные параметры: period=%d fast=%d slow=%d", p.Period, p.Fast, p.Slow)
+		}
+		return NewKAMAIndicator(p.Arg, p.Period, p.Fast, p.Slow), nil
+	})
+	register(string(D), defaultMAParams, func(p maParams) (Indicator, error) {
+		return p.validate(func() Indicator { return NewDEMAIndicator(p.Arg, p.Period) })
+	})
+	register(string(T), defaultMAParams, func(p maParams) (Indicator, error) {
+		return p.validate(func() Indicator { return NewTEMAIndicator(p.Arg, p.Period) })
+	})
+}
+
+type kamaParams struct {
+	Arg    cdl.CandleArg `json:"arg"`
+	Period int           `json:"period"`
+	Fast   int           `json:"fast"`
+	Slow   int           `json:"slow"`
+}
+
+// Пакетные формы
+
+// NewWMA рассчитывает линейно взвешенную скользящую среднюю, выход "MA"
+func NewWMA(candles []cdl.Candle, arg cdl.CandleArg, period int) *Series {
+	return NewSeries(NewWMAIndicator(arg, period), candles)
+}
+
+// NewHMA рассчитывает скользящую среднюю Халла, выход "MA"
+func NewHMA(candles []cdl.Candle, arg cdl.CandleArg, period int) *Series {
+	return NewSeries(NewHMAIndicator(arg, period), candles)
+}
+
+// NewKAMA рассчитывает адаптивную скользящую среднюю Кауфмана, выход "MA"
+func NewKAMA(candles []cdl.Candle, arg cdl.CandleArg, period, fast, slow int) *Series {
+	return NewSeries(NewKAMAIndicator(arg, period, fast, slow), candles)
+}
+
+// NewDEMA рассчитывает двойную экспоненциальную скользящую среднюю, выход "MA"
+func NewDEMA(candles []cdl.Candle, arg cdl.CandleArg, period int) *Series {
+	return NewSeries(NewDEMAIndicator(arg, period), candles)
+}
+
+// NewTEMA рассчитывает тройную экспоненциальную скользящую среднюю, выход "MA"
+func NewTEMA(candles []cdl.Candle, arg cdl.CandleArg, period int) *Series {
+	return NewSeries(NewTEMAIndicator(arg, period), candles)
+}
+
+// WMA -----------------------------
+
+type wmaIndicator struct {
+	arg    cdl.CandleArg
+	period int
+	wma    wmaState
+	value  float64
+}
+
+// NewWMAIndicator создает потоковую линейно взвешенную скользящую среднюю (веса 1..period)
+func NewWMAIndicator(arg cdl.CandleArg, period int) Indicator {
+	return &wmaIndicator{arg: arg, period: period, wma: wmaState{size: period}}
+}
+
+func (w *wmaIndicator) Name() string      { return string(W) }
+func (w *wmaIndicator) Outputs() []string { return []string{"MA"} }
+func (w *wmaIndicator) WarmUp() int       { return w.period }
+
+func (w *wmaIndicator) Update(candle cdl.Candle) {
+	w.value = w.wma.push(candle.Arg(w.arg))
+}
+
+func (w *wmaIndicator) Value(output string) float64 {
+	if output == "MA" {
+		return w.value
+	}
+	return 0
+}
+
+func (w *wmaIndicator) Reset() {
+	*w = wmaIndicator{arg: w.arg, period: w.period, wma: wmaState{size: w.period}}
+}
+
+func (w *wmaIndicator) Clone() Indicator {
+	c := *w
+	c.wma = w.wma.clone()
+	return &c
+}
+
+// HMA -----------------------------
+
+type hmaIndicator struct {
+	arg    cdl.CandleArg
+	period int
+	half   wmaState
+	full   wmaState
+	smooth wmaState
+	value  float64
+}
+
+// NewHMAIndicator создает потоковую скользящую среднюю Халла:
+// WMA(2*WMA(period/2) - WMA(period), sqrt(period))
+func NewHMAIndicator(arg cdl.CandleArg, period int) Indicator {
+	h := &hmaIndicator{arg: arg, period: period}
+	h.Reset()
+	return h
+}
+
+func (h *hmaIndicator) Name() string      { return string(H) }
+func (h *hmaIndicator) Outputs() []string { return []string{"MA"} }
+func (h *hmaIndicator) WarmUp() int       { return h.period + h.smooth.size }
+
+func (h *hmaIndicator) Update(candle cdl.Candle) {
+	x := candle.Arg(h.arg)
+	h.value = h.smooth.push(2*h.half.push(x) - h.full.push(x))
+}
+
+func (h *hmaIndicator) Value(output string) float64 {
+	if output == "MA" {
+		return h.value
+	}
+	return 0
+}
+
+func (h *hmaIndicator) Reset() {
+	*h = hmaIndicator{
+		arg:    h.arg,
+		period: h.period,
+		half:   wmaState{size: max(1, h.period/2)},
+		full:   wmaState{size: h.period},
+		smooth: wmaState{size: max(1, int(math.Sqrt(float64(h.period))))},
+	}
+}
+
+func (h *hmaIndicator) Clone() Indicator {
+	c := *h
+	c.half = h.half.clone()
+	c.full = h.full.clone()
+	c.smooth = h.smooth.clone()
+	return &c
+}
+
+// KAMA -----------------------------
+
+type kamaIndicator struct {
+	arg        cdl.CandleArg
+	period     int
+	fast, slow int
+	prices     []float64
+	value      float64
+}
+
+// NewKAMAIndicator создает потоковую адаптивную скользящую среднюю Кауфмана
+// Коэффициент эффективности считается за period свечей, fast и slow - периоды EMA для границ сглаживания.
+// Пока не накоплено period+1 свечей, значение равно цене
+func NewKAMAIndicator(arg cdl.CandleArg, period, fast, slow int) Indicator {
+	return &kamaIndicator{arg: arg, period: period, fast: fast, slow: slow}
+}
+
+func (k *kamaIndicator) Name() string      { return string(K) }
+func (k *kamaIndicator) Outputs() []string { return []string{"MA"} }
+func (k *kamaIndicator) WarmUp() int       { return k.period + 1 }
+
+func (k *kamaIndicator) Update(candle cdl.Candle) {
+	x := candle.Arg(k.arg)
+	k.prices, _, _ = pushWindow(k.prices, x, k.period+1)
+	if len(k.prices) <= k.period {
+		k.value = x
+		return
+	}
+	change := math.Abs(x - k.prices[0])
+	var volatility float64
+	for i := 1; i < len(k.prices); i++ {
+		volatility += math.Abs(k.prices[i] - k.prices[i-1])
+	}
+	var er float64
+	if volatility > 0 {
+		er = change / volatility
+	}
+	fastSC := 2 / (float64(k.fast) + 1)
+	slowSC := 2 / (float64(k.slow) + 1)
+	sc := math.Pow(er*(fastSC-slowSC)+slowSC, 2)
+	k.value += sc * (x - k.value)
+}
+
+func (k *kamaIndicator) Value(output string) float64 {
+	if output == "MA" {
+		return k.value
+	}
+	return 0
+}
+
+func (k *kamaIndicator) Reset() {
+	*k = kamaIndicator{arg: k.arg, period: k.period, fast: k.fast, slow: k.slow}
+}
+
+func (k *kamaIndicator) Clone() Indicator {
+	c := *k
+	c.prices = slices.Clone(k.prices)
+	return &c
+}
+
+// DEMA / TEMA -----------------------------
+
+type multiEMAIndicator struct {
+	name   MaType
+	arg    cdl.CandleArg
+	period int
+	emas   []emaState
+	value  float64
+}
+
+// NewDEMAIndicator создает потоковую двойную EMA: 2*EMA - EMA(EMA)
+func NewDEMAIndicator(arg cdl.CandleArg, period int) Indicator {
+	return newMultiEMAIndicator(D, arg, period, 2)
+}
+
+// NewTEMAIndicator создает потоковую тройную EMA: 3*EMA - 3*EMA(EMA) + EMA(EMA(EMA))
+func NewTEMAIndicator(arg cdl.CandleArg, period int) Indicator {
+	return newMultiEMAIndicator(T, arg, period, 3)
+}
+
+func newMultiEMAIndicator(name MaType, arg cdl.CandleArg, period, depth int) *multiEMAIndicator {
+	m := &multiEMAIndicator{name: name, arg: arg, period: period, emas: make([]emaState, depth)}
+	m.Reset()
+	return m
+}
+
+func (m *multiEMAIndicator) Name() string      { return string(m.name) }
+func (m *multiEMAIndicator) Outputs() []string { return []string{"MA"} }
+func (m *multiEMAIndicator) WarmUp() int       { return len(m.emas) * m.period }
+
+func (m *multiEMAIndicator) Update(candle cdl.Candle) {
+	v := candle.Arg(m.arg)
+	levels := make([]float64, len(m.emas))
+	for i := range m.emas {
+		v = m.emas[i].push(v)
+		levels[i] = v
+	}
+	if len(levels) == 2 {
+		m.value = 2*levels[0] - levels[1]
+	} else {
+		m.value = 3*levels[0] - 3*levels[1] + levels[2]
+	}
+}
+
+func (m *multiEMAIndicator) Value(output string) float64 {
+	if output == "MA" {
+		return m.value
+	}
+	return 0
+}
+
+func (m *multiEMAIndicator) Reset() {
+	for i := range m.emas {
+		m.emas[i] = newEMAState(m.period)
+	}
+	m.value = 0
+}
+
+func (m *multiEMAIndicator) Clone() Indicator {
+	c := *m
+	c.emas = slices.Clone(m.emas)
+	return &c
+}
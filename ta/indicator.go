@@ -0,0 +1,124 @@
+package ta
+
+import (
+	"encoding/json"
+	"fmt"
+	"goTradingBot/cdl"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Indicator единый потоковый интерфейс индикаторов
+// Индикатор обновляется по одной свече и хранит только состояние, необходимое для следующего шага
+type Indicator interface {
+	// Name возвращает имя индикатора в реестре
+	Name() string
+	// Update обновляет состояние индикатора закрытой свечой
+	Update(candle cdl.Candle)
+	// Outputs возвращает имена выходов индикатора, первый выход - основной
+	Outputs() []string
+	// Value возвращает последнее значение выхода. Для неизвестного выхода возвращает 0
+	Value(output string) float64
+	// WarmUp возвращает количество свечей, после которого значения индикатора стабилизируются
+	WarmUp() int
+	// Reset сбрасывает состояние, сохраняя параметры
+	Reset()
+	// Clone возвращает независимую копию индикатора вместе с состоянием
+	Clone() Indicator
+}
+
+// Factory создает индикатор из JSON параметров
+// Пустые параметры означают значения по умолчанию
+type Factory func(params json.RawMessage) (Indicator, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register регистрирует фабрику индикатора под указанным именем
+// Повторная регистрация заменяет фабрику
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[name] = factory
+}
+
+// NewIndicator создает индикатор по имени и JSON параметрам
+func NewIndicator(name string, params json.RawMessage) (Indicator, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("NewIndicator: неизвестный индикатор %q", name)
+	}
+	ind, err := factory(params)
+	if err != nil {
+		return nil, fmt.Errorf("NewIndicator: %s: %w", name, err)
+	}
+	return ind, nil
+}
+
+// NewIndicatorFromMap создает индикатор по имени и параметрам в виде map
+func NewIndicatorFromMap(name string, params map[string]any) (Indicator, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("NewIndicatorFromMap: %w", err)
+	}
+	return NewIndicator(name, raw)
+}
+
+// Indicators возвращает отсортированный список зарегистрированных индикаторов
+func Indicators() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return slices.Sorted(maps.Keys(registry))
+}
+
+// Compute обновляет индикатор всеми свечами и возвращает ряды значений каждого выхода
+func Compute(ind Indicator, candles []cdl.Candle) map[string][]float64 {
+	outputs := ind.Outputs()
+	series := make(map[string][]float64, len(outputs))
+	for _, o := range outputs {
+		series[o] = make([]float64, len(candles))
+	}
+	for i, c := range candles {
+		ind.Update(c)
+		for _, o := range outputs {
+			series[o][i] = ind.Value(o)
+		}
+	}
+	return series
+}
+
+// decodeParams разбирает JSON параметры поверх значений по умолчанию в p
+func decodeParams(raw json.RawMessage, p any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return json.Unmarshal(raw, p)
+}
+
+// register регистрирует индикатор с параметрами типа P
+// defaults возвращает параметры по умолчанию, build проверяет параметры и создает индикатор
+func register[P any](name string, defaults func() P, build func(P) (Indicator, error)) {
+	Register(name, func(raw json.RawMessage) (Indicator, error) {
+		p := defaults()
+		if err := decodeParams(raw, &p); err != nil {
+			return nil, err
+		}
+		return build(p)
+	})
+}
+
+// pushWindow добавляет значение в окно размера size
+// Возвращает вытесненное значение и признак вытеснения
+func pushWindow(w []float64, v float64, size int) ([]float64, float64, bool) {
+	w = append(w, v)
+	if len(w) <= size {
+		return w, 0, false
+	}
+	old := w[0]
+	return w[1:], old, true
+}
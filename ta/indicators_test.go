@@ -0,0 +1,229 @@
+package ta
+
+import (
+	"encoding/json"
+	"fmt"
+	"goTradingBot/cdl"
+	"math"
+	"os"
+	"testing"
+)
+
+// Фикстуры генерируются эталонной реализацией testdata/reference.py
+
+type fixtureCandle struct {
+	T int64   `json:"t"`
+	O float64 `json:"o"`
+	H float64 `json:"h"`
+	L float64 `json:"l"`
+	C float64 `json:"c"`
+	V float64 `json:"v"`
+}
+
+type fixtureCase struct {
+	Name    string               `json:"name"`
+	Params  map[string]any       `json:"params"`
+	Outputs map[string][]float64 `json:"outputs"`
+}
+
+type fixtures struct {
+	Candles []fixtureCandle `json:"candles"`
+	Cases   []fixtureCase   `json:"cases"`
+}
+
+func loadFixtures(t *testing.T) ([]cdl.Candle, []fixtureCase) {
+	t.Helper()
+	data, err := os.ReadFile("testdata/fixtures.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f fixtures
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	candles := make([]cdl.Candle, len(f.Candles))
+	for i, c := range f.Candles {
+		candles[i] = cdl.Candle{Time: c.T, O: c.O, H: c.H, L: c.L, C: c.C, Volume: c.V}
+	}
+	return candles, f.Cases
+}
+
+func closeEnough(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-9*max(1, math.Abs(want))
+}
+
+func checkSeries(t *testing.T, label string, got, want []float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: длина %d, ожидалось %d", label, len(got), len(want))
+	}
+	for i := range want {
+		if !closeEnough(got[i], want[i]) {
+			t.Fatalf("%s[%d] = %v, ожидалось %v", label, i, got[i], want[i])
+		}
+	}
+}
+
+func TestIndicatorFixtures(t *testing.T) {
+	candles, cases := loadFixtures(t)
+	for _, tc := range cases {
+		t.Run(fmt.Sprintf("%s%v", tc.Name, tc.Params), func(t *testing.T) {
+			ind, err := NewIndicatorFromMap(tc.Name, tc.Params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ind.Outputs()) != len(tc.Outputs) {
+				t.Fatalf("выходы %v, в фикстуре %d", ind.Outputs(), len(tc.Outputs))
+			}
+
+			// пакетная форма
+			batch := NewSeries(ind.Clone(), candles)
+			for output, want := range tc.Outputs {
+				checkSeries(t, "batch "+output, batch.Get(output), want)
+			}
+
+			// пакетная форма с дозаполнением через Next
+			half := len(candles) / 2
+			grown := NewSeries(ind.Clone(), candles[:half])
+			for i := half; i < len(candles); i++ {
+				grown.Next(candles[:i+1])
+			}
+			for output, want := range tc.Outputs {
+				checkSeries(t, "next "+output, grown.Get(output), want)
+			}
+
+			// потоковая форма: клон посередине, сброс и повторный расчет
+			for i, c := range candles[:half] {
+				ind.Update(c)
+				for output, want := range tc.Outputs {
+					if got := ind.Value(output); !closeEnough(got, want[i]) {
+						t.Fatalf("stream %s[%d] = %v, ожидалось %v", output, i, got, want[i])
+					}
+				}
+			}
+			clone := ind.Clone()
+			for i, c := range candles[half:] {
+				clone.Update(c)
+				for output, want := range tc.Outputs {
+					if got := clone.Value(output); !closeEnough(got, want[half+i]) {
+						t.Fatalf("clone %s[%d] = %v, ожидалось %v", output, half+i, got, want[half+i])
+					}
+				}
+			}
+			ind.Reset()
+			for output, want := range Compute(ind, candles) {
+				checkSeries(t, "reset "+output, want, tc.Outputs[output])
+			}
+		})
+	}
+}
+
+func TestMovingAverageIndicatorTypes(t *testing.T) {
+	candles, _ := loadFixtures(t)
+	for _, maT := range []MaType{W, H, K, D, T} {
+		ind := NewMovingAverageIndicator(maT, cdl.Close, 10)
+		if ind.Name() != string(maT) {
+			t.Fatalf("NewMovingAverageIndicator(%s) создал %s", maT, ind.Name())
+		}
+		reg, err := NewIndicatorFromMap(string(maT), map[string]any{"arg": cdl.Close, "period": 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSeries(t, string(maT), Compute(ind, candles)["MA"], Compute(reg, candles)["MA"])
+	}
+}
+
+func TestMovingAverageBatchStream(t *testing.T) {
+	candles, _ := loadFixtures(t)
+	half := len(candles) / 2
+	for _, maT := range []MaType{S, E, VW, W, H, K, D, T} {
+		want := Compute(NewMovingAverageIndicator(maT, cdl.Close, 10), candles)["MA"]
+		ma := NewMovingAverage(maT, candles[:half], cdl.Close, 10)
+		checkSeries(t, "batch "+string(maT), ma.MaRes(), want[:half])
+		for i := half; i < len(candles); i++ {
+			ma.Next(candles[:i+1])
+			if i%10 == 0 {
+				ma.Crop()
+			}
+			if got := ma.Last(); !closeEnough(got, want[i]) {
+				t.Fatalf("next %s[%d] = %v, ожидалось %v", maT, i, got, want[i])
+			}
+		}
+
+		bands := Compute(NewBollingerBandsIndicator(cdl.Close, maT, 10, 2), candles)
+		bb := NewBollingerBands(candles[:half], cdl.Close, maT, 10, 2)
+		for i := half; i < len(candles); i++ {
+			bb.Next(candles[:i+1])
+		}
+		checkSeries(t, "BollingerBands "+string(maT), bb.MiddleBand, bands["MiddleBand"])
+		checkSeries(t, "BollingerBands "+string(maT), bb.UpperBand, bands["UpperBand"])
+		checkSeries(t, "BollingerBands "+string(maT), bb.LowerBand, bands["LowerBand"])
+	}
+
+	if _, err := NewIndicatorFromMap("BollingerBands", map[string]any{"maType": "XMA"}); err == nil {
+		t.Error("BollingerBands с неизвестным типом средней")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("NewMovingAverage с неизвестным типом не паникует")
+		}
+	}()
+	NewMovingAverage("XMA", candles, cdl.Close, 10)
+}
+
+func TestBatchNext(t *testing.T) {
+	candles, _ := loadFixtures(t)
+	half := len(candles) / 2
+
+	// пакетные типы дозаполняются через Next и совпадают с потоковыми индикаторами
+	adx := NewAdxDi(candles[:half], 14, 1)
+	st := NewSuperTrend(candles[:half], 10, cdl.HL, 3, 1)
+	ce := NewChandelierExit(candles[:half], 22, 3, 1)
+	rsi := NewRSI(candles[:half], cdl.Close, 14)
+	tsi := NewTSI(candles[:half], cdl.Close, 14)
+	mfi := NewMFI(candles[:half], 14)
+	macd := NewMACD(candles[:half], cdl.Close, 12, 26, 9)
+	for i := half; i < len(candles); i++ {
+		adx.Next(candles[i])
+		st.Next(candles[i])
+		ce.Next(candles[i])
+		rsi.Next(candles[:i+1])
+		tsi.Next(candles[:i+1])
+		mfi.Next(candles[:i+1])
+		macd.Next(candles[:i+1])
+	}
+	want := Compute(NewAdxDiIndicator(14, 1), candles)
+	checkSeries(t, "AdxDi ADX", adx.ADX, want["ADX"])
+	checkSeries(t, "AdxDi DiPlus", adx.DiPlus, want["DiPlus"])
+	checkSeries(t, "AdxDi DiMinus", adx.DiMinus, want["DiMinus"])
+	want = Compute(NewSuperTrendIndicator(10, cdl.HL, 3, 1), candles)
+	checkSeries(t, "SuperTrend", st.LongStop, want["LongStop"])
+	checkSeries(t, "SuperTrend", st.ShortStop, want["ShortStop"])
+	want = Compute(NewChandelierExitIndicator(22, 3, 1), candles)
+	checkSeries(t, "ChandelierExit", ce.LongStop, want["LongStop"])
+	checkSeries(t, "ChandelierExit", ce.ShortStop, want["ShortStop"])
+	checkSeries(t, "RSI", rsi.Res, Compute(NewRSIIndicator(cdl.Close, 14), candles)["RSI"])
+	checkSeries(t, "TSI", tsi.Res, Compute(NewTSIIndicator(cdl.Close, 14), candles)["TSI"])
+	checkSeries(t, "MFI", mfi.Res, Compute(NewMFIIndicator(14), candles)["MFI"])
+	want = Compute(NewMACDIndicator(cdl.Close, 12, 26, 9), candles)
+	checkSeries(t, "MACD", macd.MACD, want["MACD"])
+	checkSeries(t, "MACD Signal", macd.Signal, want["Signal"])
+	checkSeries(t, "MACD Hist", macd.Hist, want["Hist"])
+
+	// ряды значений считаются как цены закрытия
+	closes := make([]float64, len(candles))
+	for i, c := range candles {
+		closes[i] = c.C
+	}
+	tsiV := NewTsiForV(closes[:half], 14)
+	smaV := NewSmaForV(closes[:half], 10)
+	emaV := NewEmaForV(closes[:half], 10, 2)
+	for i := half; i < len(candles); i++ {
+		tsiV.Next(closes[:i+1])
+		smaV.NextForV(closes[:i+1])
+		emaV.NextForV(closes[i])
+	}
+	checkSeries(t, "TsiForV", tsiV.Res, Compute(NewTSIIndicator(cdl.Close, 14), candles)["TSI"])
+	checkSeries(t, "SmaForV", smaV.Res, Compute(NewSMAIndicator(cdl.Close, 10), candles)["MA"])
+	checkSeries(t, "EmaForV", emaV.Res, Compute(NewEMAIndicator(cdl.Close, 10, 2), candles)["MA"])
+}
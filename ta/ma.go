@@ -1,8 +1,10 @@
 package ta
 
 import (
+	"fmt"
 	"goTradingBot/cdl"
 	"goTradingBot/utils/numeric"
+	"slices"
 )
 
 type MaType string
@@ -11,6 +13,11 @@ const (
 	S  MaType = "SMA"
 	E  MaType = "EMA"
 	VW MaType = "VWMA"
+	W  MaType = "WMA"
+	H  MaType = "HMA"
+	K  MaType = "KAMA"
+	D  MaType = "DEMA"
+	T  MaType = "TEMA"
 )
 
 type MovingAverage interface {
@@ -20,6 +27,18 @@ type MovingAverage interface {
 	MaRes() []float64
 }
 
+// Validate проверяет, что тип скользящей средней известен
+func (maT MaType) Validate() error {
+	switch maT {
+	case S, E, VW, W, H, K, D, T:
+		return nil
+	}
+	return fmt.Errorf("неизвестный тип скользящей средней %q", string(maT))
+}
+
+// NewMovingAverage рассчитывает скользящую среднюю указанного типа
+// Для EMA используется вес 2, для KAMA - быстрый и медленный периоды 2 и 30.
+// Паникует на неизвестном типе
 func NewMovingAverage(maT MaType, candles []cdl.Candle, arg cdl.CandleArg, period int) MovingAverage {
 	switch maT {
 	case S:
@@ -28,9 +47,30 @@ func NewMovingAverage(maT MaType, candles []cdl.Candle, arg cdl.CandleArg, perio
 		return NewEMA(candles, arg, period, 2)
 	case VW:
 		return NewVWMA(candles, arg, period)
-	default:
-		return NewVWMA(candles, arg, period)
+	case W, H, K, D, T:
+		return &seriesMA{Series: NewSeries(NewMovingAverageIndicator(maT, arg, period), candles), period: period}
 	}
+	panic("NewMovingAverage: " + maT.Validate().Error())
+}
+
+// seriesMA скользящая средняя на основе потокового индикатора с выходом "MA"
+type seriesMA struct {
+	*Series
+	period int
+}
+
+func (s *seriesMA) MaRes() []float64 {
+	return s.Res["MA"]
+}
+
+func (s *seriesMA) Last() float64 {
+	return s.Series.Last("MA")
+}
+
+// Crop оставляет последние period значений
+func (s *seriesMA) Crop() {
+	res := s.Res["MA"]
+	s.Res["MA"] = slices.Clone(res[max(0, len(res)-s.period):])
 }
 
 func CMA[V numeric.Number](s []V) []float64 {
@@ -49,8 +89,8 @@ type SMA[V numeric.Number] struct {
 	Res       []float64
 	Len       int
 	Period    int
-	sum       float64
 	CandleArg cdl.CandleArg
+	ind       Indicator
 }
 
 func (s *SMA[V]) MaRes() []float64 {
@@ -73,64 +113,43 @@ func (s *SMA[V]) Last() float64 {
 }
 
 func (a *SMA[V]) NextForV(s []V) {
-	n := len(s)
-	a.sum += float64(s[n-1]) - float64(s[n-min(n, a.Period+1)])
-	a.Res = append(a.Res, a.sum/float64(a.Period))
-	a.Len++
+	a.next(valueCandle(s[len(s)-1]))
 }
 
 func (a *SMA[V]) Next(candles []cdl.Candle) {
-	n := len(candles)
-	a.sum = a.sum - candles[n-min(n, a.Period+1)].Arg(a.CandleArg) +
-		candles[n-1].Arg(a.CandleArg)
-	a.Res = append(a.Res, a.sum/float64(a.Period))
+	a.next(candles[len(candles)-1])
+}
+
+func (a *SMA[V]) next(candle cdl.Candle) {
+	a.ind.Update(candle)
+	a.Res = append(a.Res, a.ind.Value("MA"))
 	a.Len++
 }
 
 func NewSmaForV[V numeric.Number](s []V, period int) *SMA[V] {
-	n := len(s)
-	if n == 0 || period <= 0 {
+	if len(s) == 0 || period <= 0 {
 		return nil
 	}
-	res := make([]float64, n)
-	var sum float64
-	for i := 0; i < period && i < n; i++ {
-		sum += float64(s[i])
-		res[i] = sum / float64(i+1)
-	}
-	for i := period; i < n; i++ {
-		sum = sum - float64(s[i-period]) + float64(s[i])
-		res[i] = sum / float64(period)
-	}
+	ind := NewSMAIndicator(cdl.Close, period)
 	return &SMA[V]{
-		Res:    res,
-		Len:    len(res),
+		Res:    Compute(ind, valueCandles(s))["MA"],
+		Len:    len(s),
 		Period: period,
-		sum:    sum,
+		ind:    ind,
 	}
 }
 
 func NewSMA[V float64](candles []cdl.Candle, arg cdl.CandleArg, period int) *SMA[V] {
-	n := len(candles)
-	if n == 0 || period <= 0 {
+	if len(candles) == 0 || period <= 0 {
 		return nil
 	}
-	res := make([]float64, n)
-	var sum float64
-	for i := 0; i < period && i < n; i++ {
-		sum += candles[i].Arg(arg)
-		res[i] = sum / float64(i+1)
-	}
-	for i := period; i < n; i++ {
-		sum += candles[i].Arg(arg) - candles[i-period].Arg(arg)
-		res[i] = sum / float64(period)
-	}
+	ind := NewSMAIndicator(arg, period)
 	return &SMA[V]{
-		Res:       res,
-		Len:       len(res),
+		Res:       Compute(ind, candles)["MA"],
+		Len:       len(candles),
 		Period:    period,
-		sum:       sum,
 		CandleArg: arg,
+		ind:       ind,
 	}
 }
 
@@ -139,8 +158,8 @@ type EMA[V numeric.Number] struct {
 	Len       int
 	Period    int
 	W         float64
-	alpha     float64
 	CandleArg cdl.CandleArg
+	ind       Indicator
 }
 
 func (s *EMA[V]) MaRes() []float64 {
@@ -163,64 +182,54 @@ func (s *EMA[V]) Crop() {
 }
 
 func (e *EMA[V]) NextForV(v V) {
-	e.Res = append(e.Res, float64(v)*e.alpha+e.Res[e.Len-1]*(1-e.alpha))
-	e.Len++
+	e.next(valueCandle(v))
 }
 
 func (e *EMA[V]) Next(candles []cdl.Candle) {
-	n := len(candles)
-	e.Res = append(e.Res, candles[n-1].Arg(e.CandleArg)*e.alpha+e.Res[e.Len-1]*(1-e.alpha))
+	e.next(candles[len(candles)-1])
+}
+
+func (e *EMA[V]) next(candle cdl.Candle) {
+	e.ind.Update(candle)
+	e.Res = append(e.Res, e.ind.Value("MA"))
 	e.Len++
 }
 
 func NewEmaForV[V numeric.Number](s []V, period int, w float64) *EMA[V] {
-	n := len(s)
-	if n == 0 || period <= 0 {
+	if len(s) == 0 || period <= 0 {
 		return nil
 	}
-	res := make([]float64, n)
-	res[0] = float64(s[0])
-	alpha := w / (float64(period) + w - 1)
-	for i := 1; i < n; i++ {
-		res[i] = float64(s[i])*alpha + res[i-1]*(1-alpha)
-	}
+	ind := NewEMAIndicator(cdl.Close, period, w)
 	return &EMA[V]{
-		Res:    res,
-		Len:    len(res),
+		Res:    Compute(ind, valueCandles(s))["MA"],
+		Len:    len(s),
 		Period: period,
 		W:      w,
-		alpha:  alpha,
+		ind:    ind,
 	}
 }
 
 func NewEMA[V float64](candles []cdl.Candle, arg cdl.CandleArg, period int, w float64) *EMA[V] {
-	n := len(candles)
-	if n == 0 || period <= 0 {
+	if len(candles) == 0 || period <= 0 {
 		return nil
 	}
-	res := make([]float64, n)
-	res[0] = candles[0].Arg(arg)
-	alpha := w / (float64(period) + w - 1)
-	for i := 1; i < n; i++ {
-		res[i] = candles[i].Arg(arg)*alpha + res[i-1]*(1-alpha)
-	}
+	ind := NewEMAIndicator(arg, period, w)
 	return &EMA[V]{
-		Res:       res,
-		Len:       len(res),
+		Res:       Compute(ind, candles)["MA"],
+		Len:       len(candles),
 		Period:    period,
 		W:         w,
-		alpha:     alpha,
 		CandleArg: arg,
+		ind:       ind,
 	}
 }
 
 type VWMA struct {
-	Res         []float64
-	Len         int
-	Period      int
-	CandleArg   cdl.CandleArg
-	sumPriceVol float64
-	sumVolume   float64
+	Res       []float64
+	Len       int
+	Period    int
+	CandleArg cdl.CandleArg
+	ind       Indicator
 }
 
 func (s *VWMA) MaRes() []float64 {
@@ -243,51 +252,21 @@ func (s *VWMA) Crop() {
 }
 
 func (v *VWMA) Next(candles []cdl.Candle) {
-	n := len(candles)
-	price := candles[n-1].Arg(v.CandleArg)
-	volume := candles[n-1].Volume
-	oldPrice := candles[n-min(n, v.Period+1)].Arg(v.CandleArg)
-	oldVolume := candles[n-min(n, v.Period+1)].Volume
-
-	v.sumPriceVol += (price * volume) - (oldPrice * oldVolume)
-	v.sumVolume += volume - oldVolume
-
-	v.Res = append(v.Res, v.sumPriceVol/v.sumVolume)
+	v.ind.Update(candles[len(candles)-1])
+	v.Res = append(v.Res, v.ind.Value("MA"))
 	v.Len++
 }
 
 func NewVWMA(candles []cdl.Candle, arg cdl.CandleArg, period int) *VWMA {
-	n := len(candles)
-	if n == 0 || period <= 0 {
+	if len(candles) == 0 || period <= 0 {
 		return nil
 	}
-	res := make([]float64, n)
-	var sumPriceVol float64
-	var sumVolume float64
-	for i := 0; i < period && i < n; i++ {
-		price := candles[i].Arg(arg)
-		volume := candles[i].Volume
-		sumPriceVol += price * volume
-		sumVolume += volume
-		res[i] = sumPriceVol / sumVolume
-	}
-	for i := period; i < n; i++ {
-		price := candles[i].Arg(arg)
-		volume := candles[i].Volume
-		oldPrice := candles[i-period].Arg(arg)
-		oldVolume := candles[i-period].Volume
-
-		sumPriceVol += (price * volume) - (oldPrice * oldVolume)
-		sumVolume += volume - oldVolume
-
-		res[i] = sumPriceVol / sumVolume
-	}
+	ind := NewVWMAIndicator(arg, period)
 	return &VWMA{
-		Res:         res,
-		Len:         len(res),
-		Period:      period,
-		CandleArg:   arg,
-		sumPriceVol: sumPriceVol,
-		sumVolume:   sumVolume,
+		Res:       Compute(ind, candles)["MA"],
+		Len:       len(candles),
+		Period:    period,
+		CandleArg: arg,
+		ind:       ind,
 	}
 }
@@ -0,0 +1,269 @@
+package ta
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+	"math"
+	"slices"
+)
+
+// Осцилляторы: Stochastic, StochRSI, CCI
+// Stochastic и StochRSI возвращают значения в диапазоне 0..1, как RSI пакета
+
+func init() {
+	register("Stochastic", func() stochParams {
+		return stochParams{Period: 14, KSmooth: 3, DPeriod: 3}
+	}, func(p stochParams) (Indicator, error) {
+		if p.Period <= 0 || p.KSmooth <= 0 || p.DPeriod <= 0 {
+			return nil, fmt.Errorf("неверные периоды: %d %d %d", p.Period, p.KSmooth, p.DPeriod)
+		}
+		return NewStochasticIndicator(p.Period, p.KSmooth, p.DPeriod), nil
+	})
+	register("StochRSI", func() stochRSIParams {
+		return stochRSIParams{Arg: cdl.Close, RsiPeriod: 14, Period: 14, KSmooth: 3, DPeriod: 3}
+	}, func(p stochRSIParams) (Indicator, error) {
+		if p.RsiPeriod <= 0 || p.Period <= 0 || p.KSmooth <= 0 || p.DPeriod <= 0 {
+			return nil, fmt.Errorf("неверные периоды: %d %d %d %d", p.RsiPeriod, p.Period, p.KSmooth, p.DPeriod)
+		}
+		return NewStochRSIIndicator(p.Arg, p.RsiPeriod, p.Period, p.KSmooth, p.DPeriod), nil
+	})
+	register("CCI", func() argPeriodParams { return argPeriodParams{Arg: cdl.HLC, Period: 20} }, func(p argPeriodParams) (Indicator, error) {
+		if p.Period <= 0 {
+			return nil, fmt.Errorf("неверный период: %d", p.Period)
+		}
+		return NewCCIIndicator(p.Arg, p.Period), nil
+	})
+}
+
+type stochParams struct {
+	Period  int `json:"period"`
+	KSmooth int `json:"kSmooth"`
+	DPeriod int `json:"dPeriod"`
+}
+
+type stochRSIParams struct {
+	Arg       cdl.CandleArg `json:"arg"`
+	RsiPeriod int           `json:"rsiPeriod"`
+	Period    int           `json:"period"`
+	KSmooth   int           `json:"kSmooth"`
+	DPeriod   int           `json:"dPeriod"`
+}
+
+// Пакетные формы
+
+// NewStochastic рассчитывает стохастический осциллятор, выходы "K", "D"
+func NewStochastic(candles []cdl.Candle, period, kSmooth, dPeriod int) *Series {
+	return NewSeries(NewStochasticIndicator(period, kSmooth, dPeriod), candles)
+}
+
+// NewStochRSI рассчитывает стохастический RSI, выходы "K", "D"
+func NewStochRSI(candles []cdl.Candle, arg cdl.CandleArg, rsiPeriod, period, kSmooth, dPeriod int) *Series {
+	return NewSeries(NewStochRSIIndicator(arg, rsiPeriod, period, kSmooth, dPeriod), candles)
+}
+
+// NewCCI рассчитывает индекс товарного канала, выход "CCI"
+func NewCCI(candles []cdl.Candle, arg cdl.CandleArg, period int) *Series {
+	return NewSeries(NewCCIIndicator(arg, period), candles)
+}
+
+// stochastic положение значения в диапазоне [lowest, highest], при нулевом диапазоне - 0.5
+func stochastic(v, highest, lowest float64) float64 {
+	if highest == lowest {
+		return 0.5
+	}
+	return (v - lowest) / (highest - lowest)
+}
+
+// kdSmoother сглаживает сырое значение стохастика: K = SMA(raw, kSmooth), D = SMA(K, dPeriod)
+type kdSmoother struct {
+	k, d rollingMean
+	kv   float64
+	dv   float64
+}
+
+func newKDSmoother(kSmooth, dPeriod int) kdSmoother {
+	return kdSmoother{k: rollingMean{size: kSmooth}, d: rollingMean{size: dPeriod}}
+}
+
+func (s *kdSmoother) push(raw float64) {
+	s.kv = s.k.push(raw)
+	s.dv = s.d.push(s.kv)
+}
+
+func (s *kdSmoother) value(output string) float64 {
+	switch output {
+	case "K":
+		return s.kv
+	case "D":
+		return s.dv
+	}
+	return 0
+}
+
+func (s *kdSmoother) clone() kdSmoother {
+	c := *s
+	c.k = s.k.clone()
+	c.d = s.d.clone()
+	return c
+}
+
+// Stochastic -----------------------------
+
+type stochasticIndicator struct {
+	period  int
+	kSmooth int
+	dPeriod int
+	window  highLowWindow
+	kd      kdSmoother
+}
+
+// NewStochasticIndicator создает потоковый стохастический осциллятор
+// Сырое значение - положение закрытия в диапазоне period свечей
+func NewStochasticIndicator(period, kSmooth, dPeriod int) Indicator {
+	s := &stochasticIndicator{period: period, kSmooth: kSmooth, dPeriod: dPeriod}
+	s.Reset()
+	return s
+}
+
+func (s *stochasticIndicator) Name() string      { return "Stochastic" }
+func (s *stochasticIndicator) Outputs() []string { return []string{"K", "D"} }
+func (s *stochasticIndicator) WarmUp() int       { return s.period + s.kSmooth + s.dPeriod - 2 }
+
+func (s *stochasticIndicator) Update(candle cdl.Candle) {
+	highest, lowest := s.window.push(candle.H, candle.L)
+	s.kd.push(stochastic(candle.C, highest, lowest))
+}
+
+func (s *stochasticIndicator) Value(output string) float64 {
+	return s.kd.value(output)
+}
+
+func (s *stochasticIndicator) Reset() {
+	*s = stochasticIndicator{
+		period:  s.period,
+		kSmooth: s.kSmooth,
+		dPeriod: s.dPeriod,
+		window:  highLowWindow{size: s.period},
+		kd:      newKDSmoother(s.kSmooth, s.dPeriod),
+	}
+}
+
+func (s *stochasticIndicator) Clone() Indicator {
+	c := *s
+	c.window = s.window.clone()
+	c.kd = s.kd.clone()
+	return &c
+}
+
+// StochRSI -----------------------------
+
+type stochRSIIndicator struct {
+	period  int
+	kSmooth int
+	dPeriod int
+	rsi     *rsiIndicator
+	values  []float64
+	kd      kdSmoother
+}
+
+// NewStochRSIIndicator создает потоковый стохастический RSI
+// Сырое значение - положение RSI(rsiPeriod) в диапазоне его значений за period свечей
+func NewStochRSIIndicator(arg cdl.CandleArg, rsiPeriod, period, kSmooth, dPeriod int) Indicator {
+	s := &stochRSIIndicator{
+		period:  period,
+		kSmooth: kSmooth,
+		dPeriod: dPeriod,
+		rsi:     &rsiIndicator{arg: arg, period: rsiPeriod},
+	}
+	s.Reset()
+	return s
+}
+
+func (s *stochRSIIndicator) Name() string      { return "StochRSI" }
+func (s *stochRSIIndicator) Outputs() []string { return []string{"K", "D"} }
+func (s *stochRSIIndicator) WarmUp() int {
+	return s.rsi.WarmUp() + s.period + s.kSmooth + s.dPeriod - 3
+}
+
+func (s *stochRSIIndicator) Update(candle cdl.Candle) {
+	s.rsi.Update(candle)
+	v := s.rsi.value
+	s.values, _, _ = pushWindow(s.values, v, s.period)
+	s.kd.push(stochastic(v, slices.Max(s.values), slices.Min(s.values)))
+}
+
+func (s *stochRSIIndicator) Value(output string) float64 {
+	return s.kd.value(output)
+}
+
+func (s *stochRSIIndicator) Reset() {
+	s.rsi.Reset()
+	s.values = nil
+	s.kd = newKDSmoother(s.kSmooth, s.dPeriod)
+}
+
+func (s *stochRSIIndicator) Clone() Indicator {
+	c := *s
+	rsi := *s.rsi
+	c.rsi = &rsi
+	c.values = slices.Clone(s.values)
+	c.kd = s.kd.clone()
+	return &c
+}
+
+// CCI -----------------------------
+
+type cciIndicator struct {
+	arg    cdl.CandleArg
+	period int
+	window []float64
+	value  float64
+}
+
+// NewCCIIndicator создает потоковый CCI: (x - SMA) / (0.015 * среднее абсолютное отклонение)
+// При нулевом отклонении значение равно 0
+func NewCCIIndicator(arg cdl.CandleArg, period int) Indicator {
+	return &cciIndicator{arg: arg, period: period}
+}
+
+func (c *cciIndicator) Name() string      { return "CCI" }
+func (c *cciIndicator) Outputs() []string { return []string{"CCI"} }
+func (c *cciIndicator) WarmUp() int       { return c.period }
+
+func (c *cciIndicator) Update(candle cdl.Candle) {
+	x := candle.Arg(c.arg)
+	c.window, _, _ = pushWindow(c.window, x, c.period)
+	n := float64(len(c.window))
+	var sum float64
+	for _, v := range c.window {
+		sum += v
+	}
+	mean := sum / n
+	var dev float64
+	for _, v := range c.window {
+		dev += math.Abs(v - mean)
+	}
+	dev /= n
+	if dev == 0 {
+		c.value = 0
+		return
+	}
+	c.value = (x - mean) / (0.015 * dev)
+}
+
+func (c *cciIndicator) Value(output string) float64 {
+	if output == "CCI" {
+		return c.value
+	}
+	return 0
+}
+
+func (c *cciIndicator) Reset() {
+	*c = cciIndicator{arg: c.arg, period: c.period}
+}
+
+func (c *cciIndicator) Clone() Indicator {
+	cc := *c
+	cc.window = slices.Clone(c.window)
+	return &cc
+}
@@ -0,0 +1,52 @@
+package ta
+
+import "goTradingBot/cdl"
+
+// Series пакетная форма потокового индикатора: ряды значений всех выходов по свечам
+// Next дописывает значения по последней свече, как Next пакетных индикаторов пакета
+type Series struct {
+	Len int
+	Res map[string][]float64
+	ind Indicator
+}
+
+// NewSeries рассчитывает индикатор по всем свечам
+// Индикатор становится частью Series и не должен использоваться отдельно
+func NewSeries(ind Indicator, candles []cdl.Candle) *Series {
+	return &Series{
+		Len: len(candles),
+		Res: Compute(ind, candles),
+		ind: ind,
+	}
+}
+
+// Next обновляет ряды последней свечой из candles
+func (s *Series) Next(candles []cdl.Candle) {
+	n := len(candles)
+	if n == 0 {
+		return
+	}
+	s.ind.Update(candles[n-1])
+	for _, o := range s.ind.Outputs() {
+		s.Res[o] = append(s.Res[o], s.ind.Value(o))
+	}
+	s.Len++
+}
+
+// Get возвращает ряд значений выхода, для неизвестного выхода - nil
+func (s *Series) Get(output string) []float64 {
+	return s.Res[output]
+}
+
+// Last возвращает последнее значение выхода
+func (s *Series) Last(output string) float64 {
+	if s.Len == 0 {
+		return 0
+	}
+	return s.ind.Value(output)
+}
+
+// Indicator возвращает копию потокового индикатора с текущим состоянием
+func (s *Series) Indicator() Indicator {
+	return s.ind.Clone()
+}
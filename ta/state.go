@@ -0,0 +1,123 @@
+package ta
+
+import (
+	"math"
+	"slices"
+)
+
+// Вспомогательные потоковые вычислители над рядом значений
+
+// rollingMean скользящее среднее по доступным значениям окна (до size)
+type rollingMean struct {
+	size   int
+	window []float64
+	sum    float64
+}
+
+func (r *rollingMean) push(v float64) float64 {
+	var old float64
+	var evicted bool
+	r.window, old, evicted = pushWindow(r.window, v, r.size)
+	if evicted {
+		r.sum += v - old
+	} else {
+		r.sum += v
+	}
+	return r.sum / float64(len(r.window))
+}
+
+func (r *rollingMean) clone() rollingMean {
+	c := *r
+	c.window = slices.Clone(r.window)
+	return c
+}
+
+// emaState экспоненциальное сглаживание с инициализацией первым значением
+type emaState struct {
+	alpha float64
+	value float64
+	init  bool
+}
+
+func newEMAState(period int) emaState {
+	return emaState{alpha: 2 / (float64(period) + 1)}
+}
+
+func (e *emaState) push(v float64) float64 {
+	if !e.init {
+		e.value = v
+		e.init = true
+		return v
+	}
+	e.value = v*e.alpha + e.value*(1-e.alpha)
+	return e.value
+}
+
+// wmaState линейно взвешенное среднее по доступным значениям окна (до size)
+type wmaState struct {
+	size   int
+	window []float64
+}
+
+func (w *wmaState) push(v float64) float64 {
+	w.window, _, _ = pushWindow(w.window, v, w.size)
+	var sum, weights float64
+	for i, x := range w.window {
+		weight := float64(i + 1)
+		sum += x * weight
+		weights += weight
+	}
+	return sum / weights
+}
+
+func (w *wmaState) clone() wmaState {
+	c := *w
+	c.window = slices.Clone(w.window)
+	return c
+}
+
+// trueRange рассчитывает истинный диапазон свечи
+// Для первой свечи (hasPrev == false) используется high - low
+func trueRange(h, l, prevClose float64, hasPrev bool) float64 {
+	if !hasPrev {
+		return h - l
+	}
+	return max(h-l, math.Abs(h-prevClose), math.Abs(l-prevClose))
+}
+
+// wilderState сглаживание Уайлдера: среднее первых period значений, затем (prev*(n-1) + v)/n
+type wilderState struct {
+	period int
+	count  int
+	value  float64
+}
+
+func (s *wilderState) push(v float64) float64 {
+	s.count++
+	if s.count <= s.period {
+		s.value += (v - s.value) / float64(s.count)
+		return s.value
+	}
+	s.value = (s.value*float64(s.period-1) + v) / float64(s.period)
+	return s.value
+}
+
+// highLowWindow хранит максимумы и минимумы последних size свечей
+type highLowWindow struct {
+	size  int
+	highs []float64
+	lows  []float64
+}
+
+func (w *highLowWindow) push(h, l float64) (highest, lowest float64) {
+	w.highs, _, _ = pushWindow(w.highs, h, w.size)
+	w.lows, _, _ = pushWindow(w.lows, l, w.size)
+	return slices.Max(w.highs), slices.Min(w.lows)
+}
+
+func (w *highLowWindow) clone() highLowWindow {
+	c := *w
+	c.highs = slices.Clone(w.highs)
+	c.lows = slices.Clone(w.lows)
+	return c
+}
@@ -0,0 +1,940 @@
+package ta
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+	"math"
+	"slices"
+)
+
+// Потоковые реализации индикаторов пакета.
+// Пакетные конструкторы (NewSMA, NewRSI, ...) рассчитывают ряды этими же индикаторами
+
+func init() {
+	register(string(S), defaultMAParams, func(p maParams) (Indicator, error) {
+		return p.validate(func() Indicator { return NewSMAIndicator(p.Arg, p.Period) })
+	})
+	register(string(E), defaultMAParams, func(p maParams) (Indicator, error) {
+		return p.validate(func() Indicator { return NewEMAIndicator(p.Arg, p.Period, p.W) })
+	})
+	register(string(VW), defaultMAParams, func(p maParams) (Indicator, error) {
+		return p.validate(func() Indicator { return NewVWMAIndicator(p.Arg, p.Period) })
+	})
+	register("ADX", func() adxParams { return adxParams{Period: 14, W: 1} }, func(p adxParams) (Indicator, error) {
+		if p.Period <= 0 || p.W <= 0 {
+			return nil, fmt.Errorf("неверные параметры: period=%d w=%v", p.Period, p.W)
+		}
+		return NewAdxDiIndicator(p.Period, p.W), nil
+	})
+	register("SuperTrend", func() atrBandParams {
+		return atrBandParams{Arg: cdl.HL, Period: 10, Factor: 3, W: 1}
+	}, func(p atrBandParams) (Indicator, error) {
+		if p.Period <= 0 || p.W <= 0 {
+			return nil, fmt.Errorf("неверные параметры: period=%d w=%v", p.Period, p.W)
+		}
+		return NewSuperTrendIndicator(p.Period, p.Arg, p.Factor, p.W), nil
+	})
+	register("ChandelierExit", func() atrBandParams {
+		return atrBandParams{Period: 22, Factor: 3, W: 1}
+	}, func(p atrBandParams) (Indicator, error) {
+		if p.Period <= 0 || p.W <= 0 {
+			return nil, fmt.Errorf("неверные параметры: period=%d w=%v", p.Period, p.W)
+		}
+		return NewChandelierExitIndicator(p.Period, p.Factor, p.W), nil
+	})
+	register("BollingerBands", func() bbParams {
+		return bbParams{Arg: cdl.Close, MaType: S, Period: 20, Mult: 2}
+	}, func(p bbParams) (Indicator, error) {
+		if p.Period <= 0 {
+			return nil, fmt.Errorf("неверный период: %d", p.Period)
+		}
+		if err := p.MaType.Validate(); err != nil {
+			return nil, err
+		}
+		return NewBollingerBandsIndicator(p.Arg, p.MaType, p.Period, p.Mult), nil
+	})
+	register("RSI", func() argPeriodParams { return argPeriodParams{Arg: cdl.Close, Period: 14} }, func(p argPeriodParams) (Indicator, error) {
+		if p.Period <= 0 {
+			return nil, fmt.Errorf("неверный период: %d", p.Period)
+		}
+		return NewRSIIndicator(p.Arg, p.Period), nil
+	})
+	register("TSI", func() argPeriodParams { return argPeriodParams{Arg: cdl.Close, Period: 14} }, func(p argPeriodParams) (Indicator, error) {
+		if p.Period <= 0 {
+			return nil, fmt.Errorf("неверный период: %d", p.Period)
+		}
+		return NewTSIIndicator(p.Arg, p.Period), nil
+	})
+	register("MFI", func() argPeriodParams { return argPeriodParams{Period: 14} }, func(p argPeriodParams) (Indicator, error) {
+		if p.Period <= 0 {
+			return nil, fmt.Errorf("неверный период: %d", p.Period)
+		}
+		return NewMFIIndicator(p.Period), nil
+	})
+	register("MACD", func() macdParams {
+		return macdParams{Arg: cdl.Close, FPeriod: 12, SPeriod: 26, DPeriod: 9}
+	}, func(p macdParams) (Indicator, error) {
+		if p.FPeriod <= 0 || p.SPeriod <= 0 || p.DPeriod <= 0 {
+			return nil, fmt.Errorf("неверные периоды: %d %d %d", p.FPeriod, p.SPeriod, p.DPeriod)
+		}
+		return NewMACDIndicator(p.Arg, p.FPeriod, p.SPeriod, p.DPeriod), nil
+	})
+}
+
+// Параметры индикаторов в реестре -----------------------------
+
+type maParams struct {
+	Arg    cdl.CandleArg `json:"arg"`
+	Period int           `json:"period"`
+	W      float64       `json:"w"` // вес EMA
+}
+
+func defaultMAParams() maParams {
+	return maParams{Arg: cdl.Close, Period: 20, W: 2}
+}
+
+func (p maParams) validate(build func() Indicator) (Indicator, error) {
+	if p.Period <= 0 {
+		return nil, fmt.Errorf("неверный период: %d", p.Period)
+	}
+	return build(), nil
+}
+
+type argPeriodParams struct {
+	Arg    cdl.CandleArg `json:"arg"`
+	Period int           `json:"period"`
+}
+
+type adxParams struct {
+	Period int     `json:"period"`
+	W      float64 `json:"w"`
+}
+
+type atrBandParams struct {
+	Arg    cdl.CandleArg `json:"arg"`
+	Period int           `json:"period"`
+	Factor float64       `json:"factor"`
+	W      float64       `json:"w"`
+}
+
+type bbParams struct {
+	Arg    cdl.CandleArg `json:"arg"`
+	MaType MaType        `json:"maType"`
+	Period int           `json:"period"`
+	Mult   float64       `json:"mult"`
+}
+
+type macdParams struct {
+	Arg     cdl.CandleArg `json:"arg"`
+	FPeriod int           `json:"fPeriod"`
+	SPeriod int           `json:"sPeriod"`
+	DPeriod int           `json:"dPeriod"`
+}
+
+// Скользящие средние -----------------------------
+
+type smaIndicator struct {
+	arg    cdl.CandleArg
+	period int
+	window []float64
+	sum    float64
+	value  float64
+}
+
+// NewSMAIndicator создает потоковую простую скользящую среднюю
+func NewSMAIndicator(arg cdl.CandleArg, period int) Indicator {
+	return &smaIndicator{arg: arg, period: period}
+}
+
+func (s *smaIndicator) Name() string      { return string(S) }
+func (s *smaIndicator) Outputs() []string { return []string{"MA"} }
+func (s *smaIndicator) WarmUp() int       { return s.period }
+
+func (s *smaIndicator) Update(candle cdl.Candle) {
+	x := candle.Arg(s.arg)
+	var old float64
+	var evicted bool
+	s.window, old, evicted = pushWindow(s.window, x, s.period)
+	if evicted {
+		s.sum += x - old
+		s.value = s.sum / float64(s.period)
+		return
+	}
+	s.sum += x
+	s.value = s.sum / float64(len(s.window))
+}
+
+func (s *smaIndicator) Value(output string) float64 {
+	if output == "MA" {
+		return s.value
+	}
+	return 0
+}
+
+func (s *smaIndicator) Reset() {
+	*s = smaIndicator{arg: s.arg, period: s.period}
+}
+
+func (s *smaIndicator) Clone() Indicator {
+	c := *s
+	c.window = slices.Clone(s.window)
+	return &c
+}
+
+type emaIndicator struct {
+	arg    cdl.CandleArg
+	period int
+	w      float64
+	alpha  float64
+	count  int
+	value  float64
+}
+
+// NewEMAIndicator создает потоковую экспоненциальную скользящую среднюю
+func NewEMAIndicator(arg cdl.CandleArg, period int, w float64) Indicator {
+	return &emaIndicator{
+		arg:    arg,
+		period: period,
+		w:      w,
+		alpha:  w / (float64(period) + w - 1),
+	}
+}
+
+func (e *emaIndicator) Name() string      { return string(E) }
+func (e *emaIndicator) Outputs() []string { return []string{"MA"} }
+func (e *emaIndicator) WarmUp() int       { return e.period }
+
+func (e *emaIndicator) Update(candle cdl.Candle) {
+	x := candle.Arg(e.arg)
+	if e.count == 0 {
+		e.value = x
+	} else {
+		e.value = x*e.alpha + e.value*(1-e.alpha)
+	}
+	e.count++
+}
+
+func (e *emaIndicator) Value(output string) float64 {
+	if output == "MA" {
+		return e.value
+	}
+	return 0
+}
+
+func (e *emaIndicator) Reset() {
+	e.count, e.value = 0, 0
+}
+
+func (e *emaIndicator) Clone() Indicator {
+	c := *e
+	return &c
+}
+
+type vwmaIndicator struct {
+	arg         cdl.CandleArg
+	period      int
+	prices      []float64
+	volumes     []float64
+	sumPriceVol float64
+	sumVolume   float64
+	value       float64
+}
+
+// NewVWMAIndicator создает потоковую скользящую среднюю, взвешенную по объему
+func NewVWMAIndicator(arg cdl.CandleArg, period int) Indicator {
+	return &vwmaIndicator{arg: arg, period: period}
+}
+
+func (v *vwmaIndicator) Name() string      { return string(VW) }
+func (v *vwmaIndicator) Outputs() []string { return []string{"MA"} }
+func (v *vwmaIndicator) WarmUp() int       { return v.period }
+
+func (v *vwmaIndicator) Update(candle cdl.Candle) {
+	price := candle.Arg(v.arg)
+	volume := candle.Volume
+	var oldPrice, oldVolume float64
+	var evicted bool
+	v.prices, oldPrice, evicted = pushWindow(v.prices, price, v.period)
+	v.volumes, oldVolume, _ = pushWindow(v.volumes, volume, v.period)
+	if evicted {
+		v.sumPriceVol += (price * volume) - (oldPrice * oldVolume)
+		v.sumVolume += volume - oldVolume
+	} else {
+		v.sumPriceVol += price * volume
+		v.sumVolume += volume
+	}
+	v.value = v.sumPriceVol / v.sumVolume
+}
+
+func (v *vwmaIndicator) Value(output string) float64 {
+	if output == "MA" {
+		return v.value
+	}
+	return 0
+}
+
+func (v *vwmaIndicator) Reset() {
+	*v = vwmaIndicator{arg: v.arg, period: v.period}
+}
+
+func (v *vwmaIndicator) Clone() Indicator {
+	c := *v
+	c.prices = slices.Clone(v.prices)
+	c.volumes = slices.Clone(v.volumes)
+	return &c
+}
+
+// NewMovingAverageIndicator создает потоковую скользящую среднюю указанного типа
+// Для EMA используется вес 2, как в NewMovingAverage. Паникует на неизвестном типе
+func NewMovingAverageIndicator(maT MaType, arg cdl.CandleArg, period int) Indicator {
+	switch maT {
+	case S:
+		return NewSMAIndicator(arg, period)
+	case E:
+		return NewEMAIndicator(arg, period, 2)
+	case W:
+		return NewWMAIndicator(arg, period)
+	case H:
+		return NewHMAIndicator(arg, period)
+	case K:
+		return NewKAMAIndicator(arg, period, 2, 30)
+	case D:
+		return NewDEMAIndicator(arg, period)
+	case T:
+		return NewTEMAIndicator(arg, period)
+	case VW:
+		return NewVWMAIndicator(arg, period)
+	}
+	panic("NewMovingAverageIndicator: " + maT.Validate().Error())
+}
+
+// ADX / DI -----------------------------
+
+type adxIndicator struct {
+	period  int
+	w       float64
+	alpha   float64
+	count   int
+	prev    cdl.Candle
+	atr     float64
+	dmPlus  float64
+	dmMinus float64
+	adx     float64
+	diPlus  float64
+	diMinus float64
+}
+
+// NewAdxDiIndicator создает потоковый ADX с линиями DI+ и DI-
+func NewAdxDiIndicator(period int, w float64) Indicator {
+	return &adxIndicator{period: period, w: w, alpha: w / (float64(period) + w - 1)}
+}
+
+func (a *adxIndicator) Name() string      { return "ADX" }
+func (a *adxIndicator) Outputs() []string { return []string{"ADX", "DiPlus", "DiMinus"} }
+func (a *adxIndicator) WarmUp() int       { return 2 * a.period }
+
+func (a *adxIndicator) Update(candle cdl.Candle) {
+	defer func() {
+		a.prev = candle
+		a.count++
+	}()
+	if a.count == 0 {
+		a.atr = candle.Ratio(cdl.TrueRangeRatio, &candle)
+		a.adx = 50
+		return
+	}
+	tr := candle.Ratio(cdl.TrueRangeRatio, &a.prev)
+	a.atr = tr*a.alpha + a.atr*(1-a.alpha)
+	highDif := candle.H - a.prev.H
+	lowDif := a.prev.L - candle.L
+	if highDif >= lowDif {
+		a.dmMinus = 0*a.alpha + a.dmMinus*(1-a.alpha)
+		a.dmPlus = max(0, highDif)*a.alpha + a.dmPlus*(1-a.alpha)
+	} else {
+		a.dmPlus = 0*a.alpha + a.dmPlus*(1-a.alpha)
+		a.dmMinus = max(0, lowDif)*a.alpha + a.dmMinus*(1-a.alpha)
+	}
+	if a.atr == 0 {
+		a.diPlus, a.diMinus = 0, 0
+	} else {
+		a.diPlus = a.dmPlus / a.atr
+		a.diMinus = a.dmMinus / a.atr
+	}
+	dx := math.Abs(a.diPlus-a.diMinus) / math.Abs(a.diPlus+a.diMinus)
+	a.adx = dx*a.alpha + a.adx*(1-a.alpha)
+}
+
+func (a *adxIndicator) Value(output string) float64 {
+	switch output {
+	case "ADX":
+		return a.adx
+	case "DiPlus":
+		return a.diPlus
+	case "DiMinus":
+		return a.diMinus
+	}
+	return 0
+}
+
+func (a *adxIndicator) Reset() {
+	*a = adxIndicator{period: a.period, w: a.w, alpha: a.alpha}
+}
+
+func (a *adxIndicator) Clone() Indicator {
+	c := *a
+	return &c
+}
+
+// SuperTrend -----------------------------
+
+type superTrendIndicator struct {
+	period    int
+	arg       cdl.CandleArg
+	factor    float64
+	w         float64
+	alpha     float64
+	count     int
+	prev      cdl.Candle
+	atr       float64
+	longStop  float64
+	shortStop float64
+}
+
+// NewSuperTrendIndicator создает потоковый SuperTrend
+func NewSuperTrendIndicator(period int, arg cdl.CandleArg, factor float64, w float64) Indicator {
+	return &superTrendIndicator{
+		period: period,
+		arg:    arg,
+		factor: factor,
+		w:      w,
+		alpha:  w / (float64(period) + w - 1),
+	}
+}
+
+func (st *superTrendIndicator) Name() string      { return "SuperTrend" }
+func (st *superTrendIndicator) Outputs() []string { return []string{"LongStop", "ShortStop"} }
+func (st *superTrendIndicator) WarmUp() int       { return st.period }
+
+func (st *superTrendIndicator) Update(candle cdl.Candle) {
+	defer func() {
+		st.prev = candle
+		st.count++
+	}()
+	if st.count == 0 {
+		st.atr = candle.Ratio(cdl.TrueRangeRatio, &candle)
+		st.longStop = candle.C - st.atr*st.factor
+		st.shortStop = candle.C + st.atr*st.factor
+		return
+	}
+	tr := candle.Ratio(cdl.TrueRangeRatio, &st.prev)
+	st.atr = tr*st.alpha + st.atr*(1-st.alpha)
+	src := candle.Arg(st.arg)
+	if st.prev.C > st.longStop {
+		st.longStop = max(src-st.atr*st.factor, st.longStop)
+	} else {
+		st.longStop = src - st.atr*st.factor
+	}
+	if st.prev.C < st.shortStop {
+		st.shortStop = min(src+st.atr*st.factor, st.shortStop)
+	} else {
+		st.shortStop = src + st.atr*st.factor
+	}
+}
+
+func (st *superTrendIndicator) Value(output string) float64 {
+	switch output {
+	case "LongStop":
+		return st.longStop
+	case "ShortStop":
+		return st.shortStop
+	}
+	return 0
+}
+
+func (st *superTrendIndicator) Reset() {
+	*st = superTrendIndicator{period: st.period, arg: st.arg, factor: st.factor, w: st.w, alpha: st.alpha}
+}
+
+func (st *superTrendIndicator) Clone() Indicator {
+	c := *st
+	return &c
+}
+
+// Chandelier Exit -----------------------------
+
+type chandelierIndicator struct {
+	period    int
+	factor    float64
+	w         float64
+	alpha     float64
+	count     int
+	prev      cdl.Candle
+	atr       float64
+	highs     []float64
+	lows      []float64
+	longStop  float64
+	shortStop float64
+}
+
+// NewChandelierExitIndicator создает потоковый Chandelier Exit
+func NewChandelierExitIndicator(period int, factor float64, w float64) Indicator {
+	return &chandelierIndicator{
+		period: period,
+		factor: factor,
+		w:      w,
+		alpha:  w / (float64(period) + w - 1),
+	}
+}
+
+func (ce *chandelierIndicator) Name() string      { return "ChandelierExit" }
+func (ce *chandelierIndicator) Outputs() []string { return []string{"LongStop", "ShortStop"} }
+func (ce *chandelierIndicator) WarmUp() int       { return ce.period }
+
+func (ce *chandelierIndicator) Update(candle cdl.Candle) {
+	defer func() {
+		ce.prev = candle
+		ce.count++
+	}()
+	if ce.count == 0 {
+		ce.highs = append(ce.highs, candle.H)
+		ce.lows = append(ce.lows, candle.L)
+		ce.atr = candle.Ratio(cdl.TrueRangeRatio, &candle)
+		ce.longStop = candle.H - ce.atr*ce.factor
+		ce.shortStop = candle.L + ce.atr*ce.factor
+		return
+	}
+	tr := candle.Ratio(cdl.TrueRangeRatio, &ce.prev)
+	ce.atr = tr*ce.alpha + ce.atr*(1-ce.alpha)
+	ce.highs, _, _ = pushWindow(ce.highs, candle.H, ce.period)
+	ce.lows, _, _ = pushWindow(ce.lows, candle.L, ce.period)
+	maxHigh := slices.Max(ce.highs)
+	minLow := slices.Min(ce.lows)
+	if ce.prev.C > ce.longStop {
+		ce.longStop = max(maxHigh-ce.atr*ce.factor, ce.longStop)
+	} else {
+		ce.longStop = maxHigh - ce.atr*ce.factor
+	}
+	if ce.prev.C < ce.shortStop {
+		ce.shortStop = min(minLow+ce.atr*ce.factor, ce.shortStop)
+	} else {
+		ce.shortStop = minLow + ce.atr*ce.factor
+	}
+}
+
+func (ce *chandelierIndicator) Value(output string) float64 {
+	switch output {
+	case "LongStop":
+		return ce.longStop
+	case "ShortStop":
+		return ce.shortStop
+	}
+	return 0
+}
+
+func (ce *chandelierIndicator) Reset() {
+	*ce = chandelierIndicator{period: ce.period, factor: ce.factor, w: ce.w, alpha: ce.alpha}
+}
+
+func (ce *chandelierIndicator) Clone() Indicator {
+	c := *ce
+	c.highs = slices.Clone(ce.highs)
+	c.lows = slices.Clone(ce.lows)
+	return &c
+}
+
+// Bollinger Bands -----------------------------
+
+type bbIndicator struct {
+	arg    cdl.CandleArg
+	maT    MaType
+	period int
+	mult   float64
+	middle Indicator
+	prices []float64
+	sum    float64
+	sumSq  float64
+	upper  float64
+	mid    float64
+	lower  float64
+}
+
+// NewBollingerBandsIndicator создает потоковые полосы Боллинджера
+func NewBollingerBandsIndicator(arg cdl.CandleArg, maT MaType, period int, mult float64) Indicator {
+	return &bbIndicator{
+		arg:    arg,
+		maT:    maT,
+		period: period,
+		mult:   mult,
+		middle: NewMovingAverageIndicator(maT, arg, period),
+	}
+}
+
+func (bb *bbIndicator) Name() string      { return "BollingerBands" }
+func (bb *bbIndicator) Outputs() []string { return []string{"MiddleBand", "UpperBand", "LowerBand"} }
+func (bb *bbIndicator) WarmUp() int       { return bb.period }
+
+func (bb *bbIndicator) Update(candle cdl.Candle) {
+	price := candle.Arg(bb.arg)
+	bb.middle.Update(candle)
+	bb.mid = bb.middle.Value("MA")
+	var old float64
+	var evicted bool
+	bb.prices, old, evicted = pushWindow(bb.prices, price, bb.period)
+	var mean, variance float64
+	if evicted {
+		bb.sum += price - old
+		bb.sumSq += price*price - old*old
+		mean = bb.sum / float64(bb.period)
+		variance = (bb.sumSq / float64(bb.period)) - (mean * mean)
+	} else {
+		count := float64(len(bb.prices))
+		bb.sum += price
+		bb.sumSq += price * price
+		mean = bb.sum / count
+		variance = (bb.sumSq / count) - (mean * mean)
+	}
+	multStdDev := math.Sqrt(variance) * bb.mult
+	bb.upper = bb.mid + multStdDev
+	bb.lower = bb.mid - multStdDev
+}
+
+func (bb *bbIndicator) Value(output string) float64 {
+	switch output {
+	case "MiddleBand":
+		return bb.mid
+	case "UpperBand":
+		return bb.upper
+	case "LowerBand":
+		return bb.lower
+	}
+	return 0
+}
+
+func (bb *bbIndicator) Reset() {
+	bb.middle.Reset()
+	*bb = bbIndicator{arg: bb.arg, maT: bb.maT, period: bb.period, mult: bb.mult, middle: bb.middle}
+}
+
+func (bb *bbIndicator) Clone() Indicator {
+	c := *bb
+	c.middle = bb.middle.Clone()
+	c.prices = slices.Clone(bb.prices)
+	return &c
+}
+
+// RSI -----------------------------
+
+type rsiIndicator struct {
+	arg     cdl.CandleArg
+	period  int
+	count   int
+	prev    float64
+	avgGain float64
+	avgLoss float64
+	value   float64
+}
+
+// NewRSIIndicator создает потоковый RSI (значения в диапазоне 0..1)
+func NewRSIIndicator(arg cdl.CandleArg, period int) Indicator {
+	return &rsiIndicator{arg: arg, period: period}
+}
+
+func (r *rsiIndicator) Name() string      { return "RSI" }
+func (r *rsiIndicator) Outputs() []string { return []string{"RSI"} }
+func (r *rsiIndicator) WarmUp() int       { return r.period + 1 }
+
+func (r *rsiIndicator) Update(candle cdl.Candle) {
+	x := candle.Arg(r.arg)
+	defer func() {
+		r.prev = x
+		r.count++
+	}()
+	if r.count == 0 {
+		return
+	}
+	priceDiff := x - r.prev
+	if r.count == 1 {
+		if priceDiff > 0 {
+			r.avgGain += priceDiff
+		} else {
+			r.avgLoss += math.Abs(priceDiff)
+		}
+	}
+	if r.count < r.period {
+		if priceDiff > 0 {
+			r.avgGain += (priceDiff - r.avgGain) / float64(r.count+1)
+		} else {
+			r.avgLoss += (math.Abs(priceDiff) - r.avgLoss) / float64(r.count+1)
+		}
+	} else {
+		var gain, loss float64
+		if priceDiff > 0 {
+			gain = priceDiff
+		} else {
+			loss = math.Abs(priceDiff)
+		}
+		pv, pf := float64(r.period-1), float64(r.period)
+		r.avgGain = (r.avgGain*pv + gain) / pf
+		r.avgLoss = (r.avgLoss*pv + loss) / pf
+	}
+	if r.avgLoss == 0 {
+		r.value = 1
+	} else {
+		rs := r.avgGain / r.avgLoss
+		r.value = 1 - (1 / (1 + rs))
+	}
+}
+
+func (r *rsiIndicator) Value(output string) float64 {
+	if output == "RSI" {
+		return r.value
+	}
+	return 0
+}
+
+func (r *rsiIndicator) Reset() {
+	*r = rsiIndicator{arg: r.arg, period: r.period}
+}
+
+func (r *rsiIndicator) Clone() Indicator {
+	c := *r
+	return &c
+}
+
+// TSI -----------------------------
+
+type tsiIndicator struct {
+	arg     cdl.CandleArg
+	period  int
+	prices  []float64
+	sumX    float64
+	sumXSqr float64
+	sumXY   float64
+	sumY    float64
+	sumYSqr float64
+	value   float64
+}
+
+// NewTSIIndicator создает потоковый индекс силы тренда (корреляция цены со временем)
+func NewTSIIndicator(arg cdl.CandleArg, period int) Indicator {
+	t := &tsiIndicator{arg: arg, period: period}
+	t.Reset()
+	return t
+}
+
+func (t *tsiIndicator) Name() string      { return "TSI" }
+func (t *tsiIndicator) Outputs() []string { return []string{"TSI"} }
+func (t *tsiIndicator) WarmUp() int       { return t.period }
+
+func (t *tsiIndicator) Update(candle cdl.Candle) {
+	price := candle.Arg(t.arg)
+	i := len(t.prices)
+	var old float64
+	var evicted bool
+	t.prices, old, evicted = pushWindow(t.prices, price, t.period)
+	if !evicted {
+		t.sumX += price
+		t.sumXSqr += price * price
+		t.sumXY += price * float64(i)
+		t.value = 0
+		return
+	}
+	pf := float64(t.period)
+	t.sumX += price - old
+	t.sumXSqr += price*price - old*old
+	t.sumXY += price*float64(t.period-1) - (t.sumX - price)
+	meanX := t.sumX / pf
+	meanY := t.sumY / pf
+	varianceX := max(0, t.sumXSqr/pf-meanX*meanX)
+	varianceY := max(0, t.sumYSqr/pf-meanY*meanY)
+	stdDevX := math.Sqrt(varianceX)
+	stdDevY := math.Sqrt(varianceY)
+	if stdDevX <= 1e-10 || stdDevY <= 1e-10 {
+		t.value = 0
+		return
+	}
+	covariance := t.sumXY/pf - meanX*meanY
+	t.value = max(-1, min(1, covariance/(stdDevX*stdDevY)))
+}
+
+func (t *tsiIndicator) Value(output string) float64 {
+	if output == "TSI" {
+		return t.value
+	}
+	return 0
+}
+
+func (t *tsiIndicator) Reset() {
+	*t = tsiIndicator{
+		arg:     t.arg,
+		period:  t.period,
+		sumY:    float64(t.period * (t.period - 1) / 2),
+		sumYSqr: float64((t.period - 1) * t.period * (2*t.period - 1) / 6),
+	}
+}
+
+func (t *tsiIndicator) Clone() Indicator {
+	c := *t
+	c.prices = slices.Clone(t.prices)
+	return &c
+}
+
+// MFI -----------------------------
+
+type mfiIndicator struct {
+	period     int
+	count      int
+	prevPrice  float64
+	flows      []float64 // денежные потоки последних period свечей, положительные - для роста цены
+	sumPosFlow float64
+	sumNegFlow float64
+	value      float64
+}
+
+// NewMFIIndicator создает потоковый индекс денежного потока (значения в диапазоне 0..1)
+func NewMFIIndicator(period int) Indicator {
+	return &mfiIndicator{period: period}
+}
+
+func (m *mfiIndicator) Name() string      { return "MFI" }
+func (m *mfiIndicator) Outputs() []string { return []string{"MFI"} }
+func (m *mfiIndicator) WarmUp() int       { return m.period + 1 }
+
+func (m *mfiIndicator) Update(candle cdl.Candle) {
+	price := candle.Arg(cdl.HLC)
+	defer func() {
+		m.prevPrice = price
+		m.count++
+	}()
+	if m.count == 0 {
+		return
+	}
+	moneyFlow := candle.Volume * price
+	flow := -moneyFlow
+	if price-m.prevPrice > 0 {
+		flow = moneyFlow
+	}
+	if len(m.flows) == m.period {
+		if old := m.flows[0]; old > 0 {
+			m.sumPosFlow -= old
+		} else {
+			m.sumNegFlow -= -old
+		}
+	}
+	m.flows, _, _ = pushWindow(m.flows, flow, m.period)
+	if flow > 0 {
+		m.sumPosFlow += moneyFlow
+	} else {
+		m.sumNegFlow += moneyFlow
+	}
+	if m.count < m.period {
+		return
+	}
+	if m.sumNegFlow == 0 {
+		m.value = 1
+	} else {
+		ratio := m.sumPosFlow / m.sumNegFlow
+		m.value = 1 * ratio / (1 + ratio)
+	}
+}
+
+func (m *mfiIndicator) Value(output string) float64 {
+	if output == "MFI" {
+		return m.value
+	}
+	return 0
+}
+
+func (m *mfiIndicator) Reset() {
+	*m = mfiIndicator{period: m.period}
+}
+
+func (m *mfiIndicator) Clone() Indicator {
+	c := *m
+	c.flows = slices.Clone(m.flows)
+	return &c
+}
+
+// MACD -----------------------------
+
+type macdIndicator struct {
+	arg     cdl.CandleArg
+	fPeriod int
+	sPeriod int
+	dPeriod int
+	fAlpha  float64
+	sAlpha  float64
+	dAlpha  float64
+	count   int
+	fastMa  float64
+	slowMa  float64
+	macd    float64
+	signal  float64
+	hist    float64
+}
+
+// NewMACDIndicator создает потоковый MACD (значения нормированы на цену)
+func NewMACDIndicator(arg cdl.CandleArg, fPeriod, sPeriod, dPeriod int) Indicator {
+	return &macdIndicator{
+		arg:     arg,
+		fPeriod: fPeriod,
+		sPeriod: sPeriod,
+		dPeriod: dPeriod,
+		fAlpha:  2 / (float64(fPeriod) + 1),
+		sAlpha:  2 / (float64(sPeriod) + 1),
+		dAlpha:  2 / (float64(dPeriod) + 1),
+	}
+}
+
+func (m *macdIndicator) Name() string      { return "MACD" }
+func (m *macdIndicator) Outputs() []string { return []string{"MACD", "Signal", "Hist"} }
+func (m *macdIndicator) WarmUp() int       { return max(m.fPeriod, m.sPeriod) + m.dPeriod }
+
+func (m *macdIndicator) Update(candle cdl.Candle) {
+	price := candle.Arg(m.arg)
+	defer func() { m.count++ }()
+	if m.count == 0 {
+		m.fastMa = (price + candle.O) / 2
+		m.slowMa = (price + candle.O) / 2
+		m.signal = (price + candle.O) / 2
+		return
+	}
+	m.fastMa = price*m.fAlpha + m.fastMa*(1-m.fAlpha)
+	m.slowMa = price*m.sAlpha + m.slowMa*(1-m.sAlpha)
+	if price != 0 {
+		m.macd = (m.fastMa - m.slowMa) / price
+	} else {
+		m.macd = 0
+	}
+	m.signal = m.macd*m.dAlpha + m.signal*(1-m.dAlpha)
+	m.hist = m.macd - m.signal
+}
+
+func (m *macdIndicator) Value(output string) float64 {
+	switch output {
+	case "MACD":
+		return m.macd
+	case "Signal":
+		return m.signal
+	case "Hist":
+		return m.hist
+	}
+	return 0
+}
+
+func (m *macdIndicator) Reset() {
+	*m = macdIndicator{
+		arg:     m.arg,
+		fPeriod: m.fPeriod,
+		sPeriod: m.sPeriod,
+		dPeriod: m.dPeriod,
+		fAlpha:  m.fAlpha,
+		sAlpha:  m.sAlpha,
+		dAlpha:  m.dAlpha,
+	}
+}
+
+func (m *macdIndicator) Clone() Indicator {
+	c := *m
+	return &c
+}
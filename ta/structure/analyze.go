@@ -0,0 +1,34 @@
+package structure
+
+import "goTradingBot/cdl"
+
+// Structure рыночная структура, построенная по всем свечам
+type Structure struct {
+	Swings     []Swing     `json:"swings"`     // все подтвержденные точки разворота
+	Events     []Event     `json:"events"`     // события в порядке свечей
+	Trend      []Trend     `json:"trend"`      // состояние тренда на каждой свече
+	Levels     []Level     `json:"levels"`     // уровни на последней свече
+	Trendlines []Trendline `json:"trendlines"` // линии тренда на последней свече
+}
+
+// Analyze строит структуру по свечам
+// Значения на свече i совпадают с результатом Analyzer после обработки свечей до i включительно
+func Analyze(candles []cdl.Candle, params Params) (*Structure, error) {
+	a, err := NewAnalyzer(params)
+	if err != nil {
+		return nil, err
+	}
+	s := &Structure{Trend: make([]Trend, len(candles))}
+	for i, c := range candles {
+		for _, e := range a.Update(c) {
+			if e.Kind == SwingEvent {
+				s.Swings = append(s.Swings, *e.Swing)
+			}
+			s.Events = append(s.Events, e)
+		}
+		s.Trend[i] = a.Trend()
+	}
+	s.Levels = a.Levels()
+	s.Trendlines = a.Trendlines()
+	return s, nil
+}
@@ -0,0 +1,27 @@
+package structure
+
+// IsFractalHigh сообщает, является ли values[i] фрактальным максимумом:
+// period значений с каждой стороны строго меньше values[i]
+// Индексы i-period и i+period должны лежать в пределах values
+func IsFractalHigh(values []float64, i, period int) bool {
+	v := values[i]
+	for j := 1; j <= period; j++ {
+		if values[i-j] >= v || values[i+j] >= v {
+			return false
+		}
+	}
+	return true
+}
+
+// IsFractalLow сообщает, является ли values[i] фрактальным минимумом:
+// period значений с каждой стороны строго больше values[i]
+// Индексы i-period и i+period должны лежать в пределах values
+func IsFractalLow(values []float64, i, period int) bool {
+	v := values[i]
+	for j := 1; j <= period; j++ {
+		if values[i-j] <= v || values[i+j] <= v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package structure
+
+import (
+	"math"
+	"slices"
+)
+
+// LevelKind тип уровня относительно последней цены закрытия
+type LevelKind string
+
+const (
+	Support    LevelKind = "support"
+	Resistance LevelKind = "resistance"
+)
+
+// Level уровень, образованный близкими точками разворота
+type Level struct {
+	Kind      LevelKind `json:"kind"`
+	Price     float64   `json:"price"`     // среднее цен точек разворота уровня
+	Low       float64   `json:"low"`       // минимальная цена точек уровня
+	High      float64   `json:"high"`      // максимальная цена точек уровня
+	Touches   int       `json:"touches"`   // количество точек разворота в уровне
+	LastIndex int       `json:"lastIndex"` // номер свечи последней точки уровня
+	LastTime  int64     `json:"lastTime"`
+}
+
+// clusterLevels объединяет точки разворота в уровни
+// Точки сортируются по цене, соседние точки на расстоянии не больше tolerance * цена попадают в один уровень
+func clusterLevels(swings []Swing, close, tolerance float64, minTouches int) []Level {
+	if len(swings) == 0 {
+		return nil
+	}
+	sorted := slices.Clone(swings)
+	slices.SortStableFunc(sorted, func(a, b Swing) int {
+		switch {
+		case a.Price < b.Price:
+			return -1
+		case a.Price > b.Price:
+			return 1
+		}
+		return 0
+	})
+	var levels []Level
+	flush := func(cluster []Swing) {
+		if len(cluster) < minTouches {
+			return
+		}
+		level := Level{Low: math.Inf(1), High: math.Inf(-1), Touches: len(cluster)}
+		var sum float64
+		for _, s := range cluster {
+			sum += s.Price
+			level.Low = min(level.Low, s.Price)
+			level.High = max(level.High, s.Price)
+			if s.Index >= level.LastIndex {
+				level.LastIndex, level.LastTime = s.Index, s.Time
+			}
+		}
+		level.Price = sum / float64(len(cluster))
+		level.Kind = Resistance
+		if close > level.Price {
+			level.Kind = Support
+		}
+		levels = append(levels, level)
+	}
+	start := 0
+	for i := 1; i < len(sorted); i++ {
+		prev := sorted[i-1].Price
+		if sorted[i].Price-prev > tolerance*math.Abs(prev) {
+			flush(sorted[start:i])
+			start = i
+		}
+	}
+	flush(sorted[start:])
+	return levels
+}
+
+// Trendline линия тренда через две точки разворота одного типа
+type Trendline struct {
+	Kind  LevelKind `json:"kind"` // Support по минимумам, Resistance по максимумам
+	From  Swing     `json:"from"`
+	To    Swing     `json:"to"`
+	Slope float64   `json:"slope"` // изменение цены за одну свечу
+}
+
+// ValueAt возвращает значение линии на свече с номером index
+func (t Trendline) ValueAt(index int) float64 {
+	return t.To.Price + t.Slope*float64(index-t.To.Index)
+}
+
+// trendlines строит линии по двум последним минимумам и двум последним максимумам
+func trendlines(swings []Swing) []Trendline {
+	var lines []Trendline
+	for _, kind := range []SwingKind{SwingLow, SwingHigh} {
+		var last []Swing
+		for i := len(swings) - 1; i >= 0 && len(last) < 2; i-- {
+			if swings[i].Kind == kind {
+				last = append(last, swings[i])
+			}
+		}
+		if len(last) < 2 {
+			continue
+		}
+		from, to := last[1], last[0]
+		line := Trendline{Kind: Support, From: from, To: to}
+		if kind == SwingHigh {
+			line.Kind = Resistance
+		}
+		line.Slope = (to.Price - from.Price) / float64(to.Index-from.Index)
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,274 @@
+// Package structure строит причинную (без перерисовки) рыночную структуру по фракталам:
+// подтвержденные точки разворота, уровни поддержки/сопротивления, состояние тренда
+// по последовательности максимумов и минимумов, события слома структуры и линии тренда.
+// Фрактал на свече i подтверждается только на свече i+Period, когда известны свечи справа,
+// и после подтверждения не меняется
+package structure
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+	"slices"
+)
+
+// SwingKind тип точки разворота
+type SwingKind string
+
+const (
+	SwingHigh SwingKind = "high"
+	SwingLow  SwingKind = "low"
+)
+
+// SwingLabel положение точки разворота относительно предыдущей точки того же типа
+type SwingLabel string
+
+const (
+	FirstSwing SwingLabel = ""
+	HigherHigh SwingLabel = "HH"
+	LowerHigh  SwingLabel = "LH"
+	HigherLow  SwingLabel = "HL"
+	LowerLow   SwingLabel = "LL"
+)
+
+// Trend состояние тренда по последним подтвержденным максимуму и минимуму
+type Trend string
+
+const (
+	Range     Trend = "range" // структура не определена или смешанная
+	UpTrend   Trend = "up"    // последний максимум HH и последний минимум HL
+	DownTrend Trend = "down"  // последний максимум LH и последний минимум LL
+)
+
+// EventKind тип события структуры
+type EventKind string
+
+const (
+	SwingEvent       EventKind = "swing"       // подтверждена точка разворота
+	BreakUpEvent     EventKind = "breakUp"     // закрытие выше последнего подтвержденного максимума
+	BreakDownEvent   EventKind = "breakDown"   // закрытие ниже последнего подтвержденного минимума
+	TrendChangeEvent EventKind = "trendChange" // изменилось состояние тренда
+)
+
+// Swing подтвержденная точка разворота
+type Swing struct {
+	Kind         SwingKind  `json:"kind"`
+	Label        SwingLabel `json:"label"`
+	Index        int        `json:"index"`        // номер свечи фрактала
+	Time         int64      `json:"time"`         // время свечи фрактала
+	Price        float64    `json:"price"`        // значение HighArg или LowArg свечи фрактала
+	ConfirmIndex int        `json:"confirmIndex"` // номер свечи, на которой фрактал подтвержден
+	ConfirmTime  int64      `json:"confirmTime"`
+}
+
+// Event событие структуры на свече Index
+type Event struct {
+	Kind  EventKind `json:"kind"`
+	Index int       `json:"index"`
+	Time  int64     `json:"time"`
+	// Price - цена точки разворота или пробитого уровня
+	Price float64 `json:"price,omitempty"`
+	// Swing - точка разворота для SwingEvent и пробитая точка для событий слома
+	Swing *Swing `json:"swing,omitempty"`
+	// Reversal - слом против текущего тренда (смена характера движения)
+	Reversal bool  `json:"reversal,omitempty"`
+	Trend    Trend `json:"trend"` // состояние тренда после события
+}
+
+// Params параметры построения структуры
+type Params struct {
+	// Period - количество свечей с каждой стороны фрактала, как в signals.PerfectTrend
+	Period int `json:"period"`
+	// HighArg и LowArg - значения свечи для поиска максимумов и минимумов
+	HighArg cdl.CandleArg `json:"highArg"`
+	LowArg  cdl.CandleArg `json:"lowArg"`
+	// Tolerance - относительное расстояние, в пределах которого точки разворота объединяются в уровень
+	Tolerance float64 `json:"tolerance"`
+	// MinTouches - минимальное количество точек разворота в уровне
+	MinTouches int `json:"minTouches"`
+	// MaxSwings - количество последних точек разворота, по которым строятся уровни
+	MaxSwings int `json:"maxSwings"`
+}
+
+// DefaultParams возвращает параметры по умолчанию
+func DefaultParams() Params {
+	return Params{
+		Period:     3,
+		HighArg:    cdl.High,
+		LowArg:     cdl.Low,
+		Tolerance:  0.003,
+		MinTouches: 2,
+		MaxSwings:  50,
+	}
+}
+
+// Validate проверяет параметры
+func (p Params) Validate() error {
+	if p.Period < 1 {
+		return fmt.Errorf("Params: period должен быть не меньше 1: %d", p.Period)
+	}
+	if p.Tolerance < 0 {
+		return fmt.Errorf("Params: отрицательный tolerance: %v", p.Tolerance)
+	}
+	if p.MinTouches < 1 || p.MaxSwings < 1 {
+		return fmt.Errorf("Params: minTouches и maxSwings должны быть положительными: %d %d", p.MinTouches, p.MaxSwings)
+	}
+	return nil
+}
+
+// Analyzer потоково строит рыночную структуру по закрытым свечам
+type Analyzer struct {
+	params     Params
+	count      int
+	highs      []float64
+	lows       []float64
+	times      []int64
+	swings     []Swing // последние MaxSwings точек разворота
+	lastHigh   *Swing
+	lastLow    *Swing
+	highBroken bool
+	lowBroken  bool
+	trend      Trend
+	close      float64
+}
+
+// NewAnalyzer создает анализатор структуры
+func NewAnalyzer(params Params) (*Analyzer, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &Analyzer{params: params, trend: Range}, nil
+}
+
+// Update обрабатывает закрытую свечу и возвращает события, произошедшие на ней
+func (a *Analyzer) Update(candle cdl.Candle) []Event {
+	index := a.count
+	a.count++
+	a.close = candle.C
+	size := 2*a.params.Period + 1
+	a.highs = pushWindow(a.highs, candle.Arg(a.params.HighArg), size)
+	a.lows = pushWindow(a.lows, candle.Arg(a.params.LowArg), size)
+	a.times = pushWindow(a.times, candle.Time, size)
+
+	var events []Event
+	if len(a.highs) == size {
+		center := a.params.Period
+		if IsFractalHigh(a.highs, center, center) {
+			events = append(events, a.addSwing(SwingHigh, a.highs[center], index, candle.Time))
+		}
+		if IsFractalLow(a.lows, center, center) {
+			events = append(events, a.addSwing(SwingLow, a.lows[center], index, candle.Time))
+		}
+	}
+	trend := a.trend
+	if a.lastHigh != nil && !a.highBroken && candle.C > a.lastHigh.Price {
+		a.highBroken = true
+		events = append(events, Event{
+			Kind:     BreakUpEvent,
+			Index:    index,
+			Time:     candle.Time,
+			Price:    a.lastHigh.Price,
+			Swing:    a.lastHigh,
+			Reversal: trend == DownTrend,
+		})
+	}
+	if a.lastLow != nil && !a.lowBroken && candle.C < a.lastLow.Price {
+		a.lowBroken = true
+		events = append(events, Event{
+			Kind:     BreakDownEvent,
+			Index:    index,
+			Time:     candle.Time,
+			Price:    a.lastLow.Price,
+			Swing:    a.lastLow,
+			Reversal: trend == UpTrend,
+		})
+	}
+	a.trend = a.classify()
+	if a.trend != trend {
+		events = append(events, Event{Kind: TrendChangeEvent, Index: index, Time: candle.Time})
+	}
+	for i := range events {
+		events[i].Trend = a.trend
+	}
+	return events
+}
+
+func (a *Analyzer) addSwing(kind SwingKind, price float64, index int, confirmTime int64) Event {
+	center := a.params.Period
+	swing := &Swing{
+		Kind:         kind,
+		Index:        index - center,
+		Time:         a.times[center],
+		Price:        price,
+		ConfirmIndex: index,
+		ConfirmTime:  confirmTime,
+	}
+	if kind == SwingHigh {
+		swing.Label = label(a.lastHigh, price, HigherHigh, LowerHigh)
+		a.lastHigh, a.highBroken = swing, false
+	} else {
+		swing.Label = label(a.lastLow, price, HigherLow, LowerLow)
+		a.lastLow, a.lowBroken = swing, false
+	}
+	a.swings = append(a.swings, *swing)
+	if len(a.swings) > a.params.MaxSwings {
+		a.swings = slices.Clone(a.swings[len(a.swings)-a.params.MaxSwings:])
+	}
+	return Event{Kind: SwingEvent, Index: index, Time: confirmTime, Price: price, Swing: swing}
+}
+
+func label(prev *Swing, price float64, higher, lower SwingLabel) SwingLabel {
+	switch {
+	case prev == nil:
+		return FirstSwing
+	case price > prev.Price:
+		return higher
+	default:
+		return lower
+	}
+}
+
+func (a *Analyzer) classify() Trend {
+	if a.lastHigh == nil || a.lastLow == nil {
+		return Range
+	}
+	switch {
+	case a.lastHigh.Label == HigherHigh && a.lastLow.Label == HigherLow:
+		return UpTrend
+	case a.lastHigh.Label == LowerHigh && a.lastLow.Label == LowerLow:
+		return DownTrend
+	}
+	return Range
+}
+
+// Trend возвращает текущее состояние тренда
+func (a *Analyzer) Trend() Trend {
+	return a.trend
+}
+
+// Swings возвращает последние подтвержденные точки разворота (не более MaxSwings)
+func (a *Analyzer) Swings() []Swing {
+	return slices.Clone(a.swings)
+}
+
+// LastSwings возвращает последние подтвержденные максимум и минимум, nil если их еще нет
+func (a *Analyzer) LastSwings() (high, low *Swing) {
+	return a.lastHigh, a.lastLow
+}
+
+// Levels возвращает уровни поддержки и сопротивления по последним точкам разворота
+func (a *Analyzer) Levels() []Level {
+	return clusterLevels(a.swings, a.close, a.params.Tolerance, a.params.MinTouches)
+}
+
+// Trendlines возвращает линии тренда по двум последним минимумам и двум последним максимумам
+func (a *Analyzer) Trendlines() []Trendline {
+	return trendlines(a.swings)
+}
+
+func pushWindow[T any](w []T, v T, size int) []T {
+	w = append(w, v)
+	if len(w) > size {
+		w = w[1:]
+	}
+	return w
+}
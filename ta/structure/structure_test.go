@@ -0,0 +1,82 @@
+package structure
+
+import (
+	"goTradingBot/cdl"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func walk(n int, seed uint64) []cdl.Candle {
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	candles := make([]cdl.Candle, n)
+	price := 100.0
+	for i := range candles {
+		next := price * math.Exp(rnd.NormFloat64()*0.01)
+		candles[i] = cdl.Candle{
+			Time: int64(i) * 60000,
+			O:    price,
+			H:    max(price, next) * (1 + rnd.Float64()*0.005),
+			L:    min(price, next) * (1 - rnd.Float64()*0.005),
+			C:    next,
+		}
+		price = next
+	}
+	return candles
+}
+
+// Значения до свечи i не меняются при добавлении следующих свечей
+func TestAnalyzeCausal(t *testing.T) {
+	candles := walk(600, 1)
+	params := DefaultParams()
+	full, err := Analyze(candles, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(full.Swings) < 20 || len(full.Events) <= len(full.Swings) {
+		t.Fatalf("точек разворота %d, событий %d", len(full.Swings), len(full.Events))
+	}
+	for _, s := range full.Swings {
+		if s.ConfirmIndex != s.Index+params.Period || s.Time != candles[s.Index].Time {
+			t.Fatalf("подтверждение фрактала: %+v", s)
+		}
+	}
+
+	for _, k := range []int{10, 57, 200, 401, 599} {
+		prefix, err := Analyze(candles[:k], params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(prefix.Trend, full.Trend[:k]) {
+			t.Errorf("%d: тренд изменился", k)
+		}
+		var events []Event
+		for _, e := range full.Events {
+			if e.Index < k {
+				events = append(events, e)
+			}
+		}
+		if !reflect.DeepEqual(prefix.Events, events) {
+			t.Errorf("%d: события изменились", k)
+		}
+		var swings []Swing
+		for _, s := range full.Swings {
+			if s.ConfirmIndex < k {
+				swings = append(swings, s)
+			}
+		}
+		if !reflect.DeepEqual(prefix.Swings, swings) {
+			t.Errorf("%d: точки разворота изменились", k)
+		}
+
+		// потоковый анализатор на свече k-1 совпадает с пакетным расчетом по префиксу
+		a, _ := NewAnalyzer(params)
+		for _, c := range candles[:k] {
+			a.Update(c)
+		}
+		if !reflect.DeepEqual(a.Levels(), prefix.Levels) || !reflect.DeepEqual(a.Trendlines(), prefix.Trendlines) {
+			t.Errorf("%d: уровни анализатора отличаются от Analyze", k)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+"""Эталонная реализация индикаторов для фикстур ta/testdata/fixtures.json.
+
+Расчеты намеренно прямолинейные: каждое значение считается по полному окну
+без инкрементального состояния, чтобы независимо проверить потоковые версии пакета ta.
+
+Запуск: python3 ta/testdata/reference.py > ta/testdata/fixtures.json
+"""
+
+import json
+import math
+import random
+import sys
+
+
+def make_candles(n=160, seed=42):
+    rnd = random.Random(seed)
+    start = 1704067200000 + 5 * 3600000  # 2024-01-01 05:00 UTC
+    price = 100.0
+    candles = []
+    for i in range(n):
+        t = start + i * 3600000
+        if i in (20, 21, 22):
+            # плоские свечи без объема: нулевой диапазон и нулевой объем
+            p = round(price, 2)
+            candles.append({"t": t, "o": p, "h": p, "l": p, "c": p, "v": 0.0})
+            continue
+        o = price
+        c = max(1.0, o * (1 + rnd.gauss(0.0003, 0.012)))
+        if i in (60, 61):
+            c = o
+        h = max(o, c) * (1 + abs(rnd.gauss(0, 0.004)))
+        l = min(o, c) * (1 - abs(rnd.gauss(0, 0.004)))
+        v = round(rnd.uniform(10, 1000), 3)
+        o, h, l, c = (round(x, 2) for x in (o, h, l, c))
+        candles.append({"t": t, "o": o, "h": h, "l": l, "c": c, "v": v})
+        price = c
+    return candles
+
+
+def arg(c, a):
+    if a == "C":
+        return c["c"]
+    if a == "HLC":
+        return (c["h"] + c["l"] + c["c"]) / 3
+    raise ValueError(a)
+
+
+def window(xs, i, n):
+    return xs[max(0, i - n + 1): i + 1]
+
+
+def sma(xs, n):
+    return [sum(window(xs, i, n)) / len(window(xs, i, n)) for i in range(len(xs))]
+
+
+def ema(xs, n):
+    alpha = 2 / (n + 1)
+    res = []
+    for i, x in enumerate(xs):
+        res.append(x if i == 0 else x * alpha + res[-1] * (1 - alpha))
+    return res
+
+
+def wma(xs, n):
+    res = []
+    for i in range(len(xs)):
+        w = window(xs, i, n)
+        weights = range(1, len(w) + 1)
+        res.append(sum(x * k for x, k in zip(w, weights)) / sum(weights))
+    return res
+
+
+def hma(xs, n):
+    half = wma(xs, max(1, n // 2))
+    full = wma(xs, n)
+    return wma([2 * a - b for a, b in zip(half, full)], max(1, int(math.sqrt(n))))
+
+
+def kama(xs, n, fast, slow):
+    fast_sc, slow_sc = 2 / (fast + 1), 2 / (slow + 1)
+    res = []
+    for i, x in enumerate(xs):
+        if i < n:
+            res.append(x)
+            continue
+        change = abs(x - xs[i - n])
+        volatility = sum(abs(xs[j] - xs[j - 1]) for j in range(i - n + 1, i + 1))
+        er = change / volatility if volatility > 0 else 0
+        sc = (er * (fast_sc - slow_sc) + slow_sc) ** 2
+        res.append(res[-1] + sc * (x - res[-1]))
+    return res
+
+
+def dema(xs, n):
+    e1 = ema(xs, n)
+    e2 = ema(e1, n)
+    return [2 * a - b for a, b in zip(e1, e2)]
+
+
+def tema(xs, n):
+    e1 = ema(xs, n)
+    e2 = ema(e1, n)
+    e3 = ema(e2, n)
+    return [3 * a - 3 * b + c for a, b, c in zip(e1, e2, e3)]
+
+
+def true_range(candles):
+    res = []
+    for i, c in enumerate(candles):
+        if i == 0:
+            res.append(c["h"] - c["l"])
+        else:
+            pc = candles[i - 1]["c"]
+            res.append(max(c["h"] - c["l"], abs(c["h"] - pc), abs(c["l"] - pc)))
+    return res
+
+
+def atr(candles, n):
+    tr = true_range(candles)
+    res = []
+    for i in range(len(tr)):
+        if i < n:
+            res.append(sum(tr[: i + 1]) / (i + 1))
+        else:
+            res.append((res[-1] * (n - 1) + tr[i]) / n)
+    return res
+
+
+def highest(candles, i, n):
+    return max(c["h"] for c in window(candles, i, n))
+
+
+def lowest(candles, i, n):
+    return min(c["l"] for c in window(candles, i, n))
+
+
+def position(v, hi, lo):
+    return 0.5 if hi == lo else (v - lo) / (hi - lo)
+
+
+def stochastic(candles, n, k_smooth, d_period):
+    raw = [position(c["c"], highest(candles, i, n), lowest(candles, i, n)) for i, c in enumerate(candles)]
+    k = sma(raw, k_smooth)
+    return k, sma(k, d_period)
+
+
+def rsi(xs, period):
+    """RSI пакета ta (0..1) с его схемой разогрева."""
+    res = []
+    avg_gain = avg_loss = value = 0.0
+    for i, x in enumerate(xs):
+        if i == 0:
+            res.append(value)
+            continue
+        diff = x - xs[i - 1]
+        gain, loss = (diff, 0.0) if diff > 0 else (0.0, abs(diff))
+        if i == 1:
+            avg_gain += gain
+            avg_loss += loss
+        if i < period:
+            if diff > 0:
+                avg_gain += (gain - avg_gain) / (i + 1)
+            else:
+                avg_loss += (loss - avg_loss) / (i + 1)
+        else:
+            avg_gain = (avg_gain * (period - 1) + gain) / period
+            avg_loss = (avg_loss * (period - 1) + loss) / period
+        value = 1.0 if avg_loss == 0 else 1 - 1 / (1 + avg_gain / avg_loss)
+        res.append(value)
+    return res
+
+
+def stoch_rsi(xs, rsi_period, n, k_smooth, d_period):
+    r = rsi(xs, rsi_period)
+    raw = [position(v, max(window(r, i, n)), min(window(r, i, n))) for i, v in enumerate(r)]
+    k = sma(raw, k_smooth)
+    return k, sma(k, d_period)
+
+
+def cci(xs, n):
+    res = []
+    for i, x in enumerate(xs):
+        w = window(xs, i, n)
+        mean = sum(w) / len(w)
+        md = sum(abs(v - mean) for v in w) / len(w)
+        res.append(0.0 if md == 0 else (x - mean) / (0.015 * md))
+    return res
+
+
+def ichimoku(candles, tenkan, kijun, senkou_b):
+    mid = lambda i, n: (highest(candles, i, n) + lowest(candles, i, n)) / 2
+    t = [mid(i, tenkan) for i in range(len(candles))]
+    k = [mid(i, kijun) for i in range(len(candles))]
+    a = [(x + y) / 2 for x, y in zip(t, k)]
+    b = [mid(i, senkou_b) for i in range(len(candles))]
+    return t, k, a, b
+
+
+def parabolic_sar(candles, step, max_step):
+    sars, trends = [], []
+    up, sar, ep, af = True, candles[0]["l"], candles[0]["h"], step
+    sars.append(sar)
+    trends.append(1.0)
+    for i in range(1, len(candles)):
+        c = candles[i]
+        prev = candles[max(0, i - 2): i]
+        sar = sar + af * (ep - sar)
+        if up:
+            sar = min([sar] + [p["l"] for p in prev])
+            if c["l"] < sar:
+                up, sar, ep, af = False, ep, c["l"], step
+            elif c["h"] > ep:
+                ep, af = c["h"], min(af + step, max_step)
+        else:
+            sar = max([sar] + [p["h"] for p in prev])
+            if c["h"] > sar:
+                up, sar, ep, af = True, ep, c["h"], step
+            elif c["l"] < ep:
+                ep, af = c["l"], min(af + step, max_step)
+        sars.append(sar)
+        trends.append(1.0 if up else -1.0)
+    return sars, trends
+
+
+def obv(candles):
+    res = [0.0]
+    for i in range(1, len(candles)):
+        c, p = candles[i]["c"], candles[i - 1]["c"]
+        res.append(res[-1] + (candles[i]["v"] if c > p else -candles[i]["v"] if c < p else 0))
+    return res
+
+
+def vwap(candles, a, session_minutes):
+    session_ms = session_minutes * 60000
+    res = []
+    for i, c in enumerate(candles):
+        session = c["t"] // session_ms
+        members = [x for x in candles[: i + 1] if x["t"] // session_ms == session]
+        volume = sum(x["v"] for x in members)
+        if volume == 0:
+            res.append(arg(c, a))
+        else:
+            res.append(sum(arg(x, a) * x["v"] for x in members) / volume)
+    return res
+
+
+def main():
+    candles = make_candles()
+    close = [arg(c, "C") for c in candles]
+    typical = [arg(c, "HLC") for c in candles]
+    cases = []
+
+    def add(name, params, outputs):
+        cases.append({"name": name, "params": params, "outputs": outputs})
+
+    add("ATR", {"period": 14}, {"ATR": atr(candles, 14)})
+    mid = ema(close, 20)
+    a10 = atr(candles, 10)
+    add("Keltner", {"arg": "C", "period": 20, "atrPeriod": 10, "mult": 2}, {
+        "MiddleBand": mid,
+        "UpperBand": [m + 2 * x for m, x in zip(mid, a10)],
+        "LowerBand": [m - 2 * x for m, x in zip(mid, a10)],
+    })
+    up = [highest(candles, i, 20) for i in range(len(candles))]
+    lo = [lowest(candles, i, 20) for i in range(len(candles))]
+    add("Donchian", {"period": 20}, {
+        "UpperBand": up,
+        "MiddleBand": [(x + y) / 2 for x, y in zip(up, lo)],
+        "LowerBand": lo,
+    })
+    k, d = stochastic(candles, 14, 3, 3)
+    add("Stochastic", {"period": 14, "kSmooth": 3, "dPeriod": 3}, {"K": k, "D": d})
+    k, d = stoch_rsi(close, 14, 14, 3, 3)
+    add("StochRSI", {"arg": "C", "rsiPeriod": 14, "period": 14, "kSmooth": 3, "dPeriod": 3}, {"K": k, "D": d})
+    add("CCI", {"arg": "HLC", "period": 20}, {"CCI": cci(typical, 20)})
+    t, kj, sa, sb = ichimoku(candles, 9, 26, 52)
+    add("Ichimoku", {"tenkan": 9, "kijun": 26, "senkouB": 52}, {"Tenkan": t, "Kijun": kj, "SpanA": sa, "SpanB": sb})
+    sar, trend = parabolic_sar(candles, 0.02, 0.2)
+    add("ParabolicSAR", {"step": 0.02, "maxStep": 0.2}, {"SAR": sar, "Trend": trend})
+    add("OBV", {}, {"OBV": obv(candles)})
+    add("VWAP", {"arg": "HLC", "sessionMinutes": 1440}, {"VWAP": vwap(candles, "HLC", 1440)})
+    add("VWAP", {"arg": "C", "sessionMinutes": 240}, {"VWAP": vwap(candles, "C", 240)})
+    add("WMA", {"arg": "C", "period": 10}, {"MA": wma(close, 10)})
+    add("HMA", {"arg": "C", "period": 16}, {"MA": hma(close, 16)})
+    add("HMA", {"arg": "C", "period": 9}, {"MA": hma(close, 9)})
+    add("KAMA", {"arg": "C", "period": 10, "fast": 2, "slow": 30}, {"MA": kama(close, 10, 2, 30)})
+    add("DEMA", {"arg": "C", "period": 10}, {"MA": dema(close, 10)})
+    add("TEMA", {"arg": "C", "period": 10}, {"MA": tema(close, 10)})
+
+    json.dump({"candles": candles, "cases": cases}, sys.stdout, indent=1)
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,211 @@
+package ta
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+	"slices"
+)
+
+// Трендовые индикаторы: Ichimoku, Parabolic SAR
+
+func init() {
+	register("Ichimoku", func() ichimokuParams {
+		return ichimokuParams{Tenkan: 9, Kijun: 26, SenkouB: 52}
+	}, func(p ichimokuParams) (Indicator, error) {
+		if p.Tenkan <= 0 || p.Kijun <= 0 || p.SenkouB <= 0 {
+			return nil, fmt.Errorf("неверные периоды: %d %d %d", p.Tenkan, p.Kijun, p.SenkouB)
+		}
+		return NewIchimokuIndicator(p.Tenkan, p.Kijun, p.SenkouB), nil
+	})
+	register("ParabolicSAR", func() psarParams {
+		return psarParams{Step: 0.02, MaxStep: 0.2}
+	}, func(p psarParams) (Indicator, error) {
+		if p.Step <= 0 || p.MaxStep < p.Step {
+			return nil, fmt.Errorf("неверные параметры: step=%v maxStep=%v", p.Step, p.MaxStep)
+		}
+		return NewParabolicSARIndicator(p.Step, p.MaxStep), nil
+	})
+}
+
+type ichimokuParams struct {
+	Tenkan  int `json:"tenkan"`
+	Kijun   int `json:"kijun"`
+	SenkouB int `json:"senkouB"`
+}
+
+type psarParams struct {
+	Step    float64 `json:"step"`
+	MaxStep float64 `json:"maxStep"`
+}
+
+// Пакетные формы
+
+// NewIchimoku рассчитывает линии Ichimoku, выходы "Tenkan", "Kijun", "SpanA", "SpanB"
+func NewIchimoku(candles []cdl.Candle, tenkan, kijun, senkouB int) *Series {
+	return NewSeries(NewIchimokuIndicator(tenkan, kijun, senkouB), candles)
+}
+
+// NewParabolicSAR рассчитывает Parabolic SAR, выходы "SAR", "Trend"
+func NewParabolicSAR(candles []cdl.Candle, step, maxStep float64) *Series {
+	return NewSeries(NewParabolicSARIndicator(step, maxStep), candles)
+}
+
+// Ichimoku -----------------------------
+
+type ichimokuIndicator struct {
+	tenkanPeriod  int
+	kijunPeriod   int
+	senkouBPeriod int
+	tenkanWindow  highLowWindow
+	kijunWindow   highLowWindow
+	senkouWindow  highLowWindow
+	tenkan        float64
+	kijun         float64
+	spanB         float64
+}
+
+// NewIchimokuIndicator создает потоковый Ichimoku
+// SpanA и SpanB рассчитываются по текущей свече без смещения вперед на kijun свечей:
+// смещение выполняется при отображении. Chikou не рассчитывается, так как смотрит в будущее
+func NewIchimokuIndicator(tenkan, kijun, senkouB int) Indicator {
+	i := &ichimokuIndicator{tenkanPeriod: tenkan, kijunPeriod: kijun, senkouBPeriod: senkouB}
+	i.Reset()
+	return i
+}
+
+func (i *ichimokuIndicator) Name() string { return "Ichimoku" }
+func (i *ichimokuIndicator) Outputs() []string {
+	return []string{"Tenkan", "Kijun", "SpanA", "SpanB"}
+}
+func (i *ichimokuIndicator) WarmUp() int {
+	return max(i.tenkanPeriod, i.kijunPeriod, i.senkouBPeriod)
+}
+
+func (i *ichimokuIndicator) Update(candle cdl.Candle) {
+	h, l := i.tenkanWindow.push(candle.H, candle.L)
+	i.tenkan = (h + l) / 2
+	h, l = i.kijunWindow.push(candle.H, candle.L)
+	i.kijun = (h + l) / 2
+	h, l = i.senkouWindow.push(candle.H, candle.L)
+	i.spanB = (h + l) / 2
+}
+
+func (i *ichimokuIndicator) Value(output string) float64 {
+	switch output {
+	case "Tenkan":
+		return i.tenkan
+	case "Kijun":
+		return i.kijun
+	case "SpanA":
+		return (i.tenkan + i.kijun) / 2
+	case "SpanB":
+		return i.spanB
+	}
+	return 0
+}
+
+func (i *ichimokuIndicator) Reset() {
+	*i = ichimokuIndicator{
+		tenkanPeriod:  i.tenkanPeriod,
+		kijunPeriod:   i.kijunPeriod,
+		senkouBPeriod: i.senkouBPeriod,
+		tenkanWindow:  highLowWindow{size: i.tenkanPeriod},
+		kijunWindow:   highLowWindow{size: i.kijunPeriod},
+		senkouWindow:  highLowWindow{size: i.senkouBPeriod},
+	}
+}
+
+func (i *ichimokuIndicator) Clone() Indicator {
+	c := *i
+	c.tenkanWindow = i.tenkanWindow.clone()
+	c.kijunWindow = i.kijunWindow.clone()
+	c.senkouWindow = i.senkouWindow.clone()
+	return &c
+}
+
+// Parabolic SAR -----------------------------
+
+type psarIndicator struct {
+	step    float64
+	maxStep float64
+	count   int
+	up      bool
+	sar     float64
+	ep      float64
+	af      float64
+	highs   []float64 // максимумы двух предыдущих свечей
+	lows    []float64 // минимумы двух предыдущих свечей
+}
+
+// NewParabolicSARIndicator создает потоковый Parabolic SAR Уайлдера
+// Расчет начинается с восходящего тренда: SAR - минимум первой свечи, экстремум - ее максимум.
+// Выход "Trend" равен 1 для восходящего и -1 для нисходящего тренда
+func NewParabolicSARIndicator(step, maxStep float64) Indicator {
+	return &psarIndicator{step: step, maxStep: maxStep}
+}
+
+func (p *psarIndicator) Name() string      { return "ParabolicSAR" }
+func (p *psarIndicator) Outputs() []string { return []string{"SAR", "Trend"} }
+func (p *psarIndicator) WarmUp() int       { return 2 }
+
+func (p *psarIndicator) Update(candle cdl.Candle) {
+	defer func() {
+		p.highs, _, _ = pushWindow(p.highs, candle.H, 2)
+		p.lows, _, _ = pushWindow(p.lows, candle.L, 2)
+		p.count++
+	}()
+	if p.count == 0 {
+		p.up, p.sar, p.ep, p.af = true, candle.L, candle.H, p.step
+		return
+	}
+	sar := p.sar + p.af*(p.ep-p.sar)
+	if p.up {
+		for _, l := range p.lows {
+			sar = min(sar, l)
+		}
+		switch {
+		case candle.L < sar:
+			p.up, sar, p.ep, p.af = false, p.ep, candle.L, p.step
+		case candle.H > p.ep:
+			p.ep, p.af = candle.H, min(p.af+p.step, p.maxStep)
+		}
+	} else {
+		for _, h := range p.highs {
+			sar = max(sar, h)
+		}
+		switch {
+		case candle.H > sar:
+			p.up, sar, p.ep, p.af = true, p.ep, candle.H, p.step
+		case candle.L < p.ep:
+			p.ep, p.af = candle.L, min(p.af+p.step, p.maxStep)
+		}
+	}
+	p.sar = sar
+}
+
+func (p *psarIndicator) Value(output string) float64 {
+	switch output {
+	case "SAR":
+		return p.sar
+	case "Trend":
+		if p.count == 0 {
+			return 0
+		}
+		if p.up {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+func (p *psarIndicator) Reset() {
+	*p = psarIndicator{step: p.step, maxStep: p.maxStep}
+}
+
+func (p *psarIndicator) Clone() Indicator {
+	c := *p
+	c.highs = slices.Clone(p.highs)
+	c.lows = slices.Clone(p.lows)
+	return &c
+}
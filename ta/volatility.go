@@ -0,0 +1,204 @@
+package ta
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+)
+
+// Индикаторы волатильности и ценовые каналы: ATR, Keltner, Donchian
+
+func init() {
+	register("ATR", func() periodParams { return periodParams{Period: 14} }, func(p periodParams) (Indicator, error) {
+		if p.Period <= 0 {
+			return nil, fmt.Errorf("неверный период: %d", p.Period)
+		}
+		return NewATRIndicator(p.Period), nil
+	})
+	register("Keltner", func() keltnerParams {
+		return keltnerParams{Arg: cdl.Close, Period: 20, AtrPeriod: 10, Mult: 2}
+	}, func(p keltnerParams) (Indicator, error) {
+		if p.Period <= 0 || p.AtrPeriod <= 0 {
+			return nil, fmt.Errorf("неверные периоды: period=%d atrPeriod=%d", p.Period, p.AtrPeriod)
+		}
+		return NewKeltnerIndicator(p.Arg, p.Period, p.AtrPeriod, p.Mult), nil
+	})
+	register("Donchian", func() periodParams { return periodParams{Period: 20} }, func(p periodParams) (Indicator, error) {
+		if p.Period <= 0 {
+			return nil, fmt.Errorf("неверный период: %d", p.Period)
+		}
+		return NewDonchianIndicator(p.Period), nil
+	})
+}
+
+type periodParams struct {
+	Period int `json:"period"`
+}
+
+type keltnerParams struct {
+	Arg       cdl.CandleArg `json:"arg"`
+	Period    int           `json:"period"`
+	AtrPeriod int           `json:"atrPeriod"`
+	Mult      float64       `json:"mult"`
+}
+
+// Пакетные формы
+
+// NewATR рассчитывает средний истинный диапазон, выход "ATR"
+func NewATR(candles []cdl.Candle, period int) *Series {
+	return NewSeries(NewATRIndicator(period), candles)
+}
+
+// NewKeltner рассчитывает канал Кельтнера, выходы "MiddleBand", "UpperBand", "LowerBand"
+func NewKeltner(candles []cdl.Candle, arg cdl.CandleArg, period, atrPeriod int, mult float64) *Series {
+	return NewSeries(NewKeltnerIndicator(arg, period, atrPeriod, mult), candles)
+}
+
+// NewDonchian рассчитывает канал Дончиана, выходы "UpperBand", "MiddleBand", "LowerBand"
+func NewDonchian(candles []cdl.Candle, period int) *Series {
+	return NewSeries(NewDonchianIndicator(period), candles)
+}
+
+// ATR -----------------------------
+
+type atrIndicator struct {
+	period    int
+	atr       wilderState
+	prevClose float64
+	hasPrev   bool
+}
+
+// NewATRIndicator создает потоковый ATR со сглаживанием Уайлдера
+// Первые period значений - среднее истинных диапазонов доступных свечей
+func NewATRIndicator(period int) Indicator {
+	return &atrIndicator{period: period, atr: wilderState{period: period}}
+}
+
+func (a *atrIndicator) Name() string      { return "ATR" }
+func (a *atrIndicator) Outputs() []string { return []string{"ATR"} }
+func (a *atrIndicator) WarmUp() int       { return a.period }
+
+func (a *atrIndicator) Update(candle cdl.Candle) {
+	a.atr.push(trueRange(candle.H, candle.L, a.prevClose, a.hasPrev))
+	a.prevClose, a.hasPrev = candle.C, true
+}
+
+func (a *atrIndicator) Value(output string) float64 {
+	if output == "ATR" {
+		return a.atr.value
+	}
+	return 0
+}
+
+func (a *atrIndicator) Reset() {
+	*a = atrIndicator{period: a.period, atr: wilderState{period: a.period}}
+}
+
+func (a *atrIndicator) Clone() Indicator {
+	c := *a
+	return &c
+}
+
+// Keltner -----------------------------
+
+type keltnerIndicator struct {
+	arg       cdl.CandleArg
+	period    int
+	atrPeriod int
+	mult      float64
+	ema       emaState
+	atr       atrIndicator
+	middle    float64
+}
+
+// NewKeltnerIndicator создает потоковый канал Кельтнера:
+// средняя линия - EMA(period), границы - средняя ± mult*ATR(atrPeriod)
+func NewKeltnerIndicator(arg cdl.CandleArg, period, atrPeriod int, mult float64) Indicator {
+	k := &keltnerIndicator{arg: arg, period: period, atrPeriod: atrPeriod, mult: mult}
+	k.Reset()
+	return k
+}
+
+func (k *keltnerIndicator) Name() string { return "Keltner" }
+func (k *keltnerIndicator) Outputs() []string {
+	return []string{"MiddleBand", "UpperBand", "LowerBand"}
+}
+func (k *keltnerIndicator) WarmUp() int { return max(k.period, k.atrPeriod) }
+
+func (k *keltnerIndicator) Update(candle cdl.Candle) {
+	k.middle = k.ema.push(candle.Arg(k.arg))
+	k.atr.Update(candle)
+}
+
+func (k *keltnerIndicator) Value(output string) float64 {
+	switch output {
+	case "MiddleBand":
+		return k.middle
+	case "UpperBand":
+		return k.middle + k.mult*k.atr.atr.value
+	case "LowerBand":
+		return k.middle - k.mult*k.atr.atr.value
+	}
+	return 0
+}
+
+func (k *keltnerIndicator) Reset() {
+	*k = keltnerIndicator{
+		arg:       k.arg,
+		period:    k.period,
+		atrPeriod: k.atrPeriod,
+		mult:      k.mult,
+		ema:       newEMAState(k.period),
+		atr:       atrIndicator{period: k.atrPeriod, atr: wilderState{period: k.atrPeriod}},
+	}
+}
+
+func (k *keltnerIndicator) Clone() Indicator {
+	c := *k
+	return &c
+}
+
+// Donchian -----------------------------
+
+type donchianIndicator struct {
+	period int
+	window highLowWindow
+	upper  float64
+	lower  float64
+}
+
+// NewDonchianIndicator создает потоковый канал Дончиана: максимум и минимум за period свечей
+func NewDonchianIndicator(period int) Indicator {
+	return &donchianIndicator{period: period, window: highLowWindow{size: period}}
+}
+
+func (d *donchianIndicator) Name() string { return "Donchian" }
+func (d *donchianIndicator) Outputs() []string {
+	return []string{"UpperBand", "MiddleBand", "LowerBand"}
+}
+func (d *donchianIndicator) WarmUp() int { return d.period }
+
+func (d *donchianIndicator) Update(candle cdl.Candle) {
+	d.upper, d.lower = d.window.push(candle.H, candle.L)
+}
+
+func (d *donchianIndicator) Value(output string) float64 {
+	switch output {
+	case "UpperBand":
+		return d.upper
+	case "MiddleBand":
+		return (d.upper + d.lower) / 2
+	case "LowerBand":
+		return d.lower
+	}
+	return 0
+}
+
+func (d *donchianIndicator) Reset() {
+	*d = donchianIndicator{period: d.period, window: highLowWindow{size: d.period}}
+}
+
+func (d *donchianIndicator) Clone() Indicator {
+	c := *d
+	c.window = d.window.clone()
+	return &c
+}
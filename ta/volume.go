@@ -0,0 +1,146 @@
+package ta
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+)
+
+// Объемные индикаторы: OBV, VWAP
+
+func init() {
+	register("OBV", func() struct{} { return struct{}{} }, func(struct{}) (Indicator, error) {
+		return NewOBVIndicator(), nil
+	})
+	register("VWAP", func() vwapParams {
+		return vwapParams{Arg: cdl.HLC, SessionMinutes: 24 * 60}
+	}, func(p vwapParams) (Indicator, error) {
+		if p.SessionMinutes <= 0 {
+			return nil, fmt.Errorf("неверная длина сессии: %d", p.SessionMinutes)
+		}
+		return NewVWAPIndicator(p.Arg, p.SessionMinutes), nil
+	})
+}
+
+type vwapParams struct {
+	Arg            cdl.CandleArg `json:"arg"`
+	SessionMinutes int           `json:"sessionMinutes"`
+}
+
+// Пакетные формы
+
+// NewOBV рассчитывает балансовый объем, выход "OBV"
+func NewOBV(candles []cdl.Candle) *Series {
+	return NewSeries(NewOBVIndicator(), candles)
+}
+
+// NewVWAP рассчитывает VWAP со сбросом в начале каждой сессии, выход "VWAP"
+func NewVWAP(candles []cdl.Candle, arg cdl.CandleArg, sessionMinutes int) *Series {
+	return NewSeries(NewVWAPIndicator(arg, sessionMinutes), candles)
+}
+
+// OBV -----------------------------
+
+type obvIndicator struct {
+	count     int
+	prevClose float64
+	value     float64
+}
+
+// NewOBVIndicator создает потоковый OBV: объем прибавляется при росте закрытия и вычитается при падении
+// Значение на первой свече равно 0
+func NewOBVIndicator() Indicator {
+	return &obvIndicator{}
+}
+
+func (o *obvIndicator) Name() string      { return "OBV" }
+func (o *obvIndicator) Outputs() []string { return []string{"OBV"} }
+func (o *obvIndicator) WarmUp() int       { return 1 }
+
+func (o *obvIndicator) Update(candle cdl.Candle) {
+	if o.count > 0 {
+		switch {
+		case candle.C > o.prevClose:
+			o.value += candle.Volume
+		case candle.C < o.prevClose:
+			o.value -= candle.Volume
+		}
+	}
+	o.prevClose = candle.C
+	o.count++
+}
+
+func (o *obvIndicator) Value(output string) float64 {
+	if output == "OBV" {
+		return o.value
+	}
+	return 0
+}
+
+func (o *obvIndicator) Reset() {
+	*o = obvIndicator{}
+}
+
+func (o *obvIndicator) Clone() Indicator {
+	c := *o
+	return &c
+}
+
+// VWAP -----------------------------
+
+type vwapIndicator struct {
+	arg            cdl.CandleArg
+	sessionMinutes int
+	session        int64
+	started        bool
+	sumPriceVol    float64
+	sumVolume      float64
+	value          float64
+}
+
+// NewVWAPIndicator создает потоковый VWAP
+// Сессии длиной sessionMinutes отсчитываются от начала эпохи Unix (для 1440 - сутки UTC),
+// накопленные суммы сбрасываются на первой свече новой сессии.
+// Пока накопленный объем равен 0, значение равно цене свечи
+func NewVWAPIndicator(arg cdl.CandleArg, sessionMinutes int) Indicator {
+	return &vwapIndicator{arg: arg, sessionMinutes: sessionMinutes}
+}
+
+func (v *vwapIndicator) Name() string      { return "VWAP" }
+func (v *vwapIndicator) Outputs() []string { return []string{"VWAP"} }
+func (v *vwapIndicator) WarmUp() int       { return 1 }
+
+func (v *vwapIndicator) Update(candle cdl.Candle) {
+	sessionMs := int64(v.sessionMinutes) * 60_000
+	session := candle.Time / sessionMs
+	if candle.Time < 0 && candle.Time%sessionMs != 0 {
+		session--
+	}
+	if !v.started || session != v.session {
+		v.session, v.started = session, true
+		v.sumPriceVol, v.sumVolume = 0, 0
+	}
+	price := candle.Arg(v.arg)
+	v.sumPriceVol += price * candle.Volume
+	v.sumVolume += candle.Volume
+	if v.sumVolume == 0 {
+		v.value = price
+		return
+	}
+	v.value = v.sumPriceVol / v.sumVolume
+}
+
+func (v *vwapIndicator) Value(output string) float64 {
+	if output == "VWAP" {
+		return v.value
+	}
+	return 0
+}
+
+func (v *vwapIndicator) Reset() {
+	*v = vwapIndicator{arg: v.arg, sessionMinutes: v.sessionMinutes}
+}
+
+func (v *vwapIndicator) Clone() Indicator {
+	c := *v
+	return &c
+}
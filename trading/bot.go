@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"goTradingBot/trading/config"
 	orderdb "goTradingBot/trading/db"
+	"goTradingBot/trading/notify"
+	"goTradingBot/trading/portfolio"
+	"goTradingBot/trading/schedule"
 	"goTradingBot/trading/types"
 	"goTradingBot/utils/slogx"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"log/slog"
@@ -24,15 +29,33 @@ type TradingBot struct {
 	strategysCtx       context.Context
 	cancelStrategys    context.CancelFunc
 	placeOrderInterval time.Duration
+	startedAt          time.Time
+	mu                 sync.Mutex
+	strategies         []*strategyEntry
+	killed             atomic.Bool
+	positions          *positionBook
+	activeOrders       sync.Map // linkId -> *types.OrderRequest
+	orderOwners        sync.Map // linkId -> имя стратегии
+	notifier           *notify.Notifier
+	store              orderdb.OrderStore
+	storeRetries       int
+	storeRetryInterval time.Duration
+	portfolio          *portfolio.Portfolio
+	scheduler          *schedule.Scheduler
+	tradeStats         sync.Map // имя стратегии -> *tradeStats
+	marginMode         string
+	fundingFrom        sync.Map // символ -> время последнего учтенного финансирования (мс)
 }
 
 // NewTradingBot создает новый экземпляр TradingBot
+// store - хранилище ордеров и истории торговли, при nil история хранится только в памяти
 func NewTradingBot(
 	ctx context.Context,
 	tradingClient types.TradingClient,
 	dataProvider types.DataProvider,
 	logger *slog.Logger,
 	cfg *config.TradingBotConfig,
+	store orderdb.OrderStore,
 ) *TradingBot {
 
 	if logger == nil {
@@ -41,6 +64,9 @@ func NewTradingBot(
 	if cfg == nil {
 		cfg = config.DefaultTradingBotConfig()
 	}
+	if store == nil {
+		store = orderdb.NewMemoryStore()
+	}
 	strategysCtx, cancelStrategys := context.WithCancel(context.Background())
 	b := &TradingBot{
 		ctx:                ctx,
@@ -52,8 +78,15 @@ func NewTradingBot(
 		strategysCtx:       strategysCtx,
 		cancelStrategys:    cancelStrategys,
 		placeOrderInterval: 200 * time.Millisecond,
+		startedAt:          time.Now(),
+		positions:          newPositionBook(),
+		store:              store,
+		storeRetries:       max(1, cfg.StoreRetries),
+		storeRetryInterval: time.Duration(cfg.StoreRetryInterval) * time.Millisecond,
+		marginMode:         cfg.MarginMode,
 	}
 
+	b.setMarginMode()
 	go b.runPolling()
 
 	return b
@@ -67,6 +100,7 @@ func (b *TradingBot) runPolling() {
 		<-b.ctx.Done()
 		b.logger.Log(slog.LevelInfo, "trading bot stopped")
 		b.cancelStrategys()
+		b.stopStrategyRuns("shutdown")
 		b.subData.Clear()
 
 		time.Sleep(3 * time.Second)
@@ -104,26 +138,62 @@ func (b *TradingBot) handleOrder(req *types.OrderRequest) {
 	}
 	reqClone := req.Clone()
 	b.logger.Log(slog.LevelInfo, "new order request", "orderRequest", reqClone)
+	b.activeOrders.Store(req.LinkId, req)
+	defer b.activeOrders.Delete(req.LinkId)
+	defer b.orderOwners.Delete(req.LinkId)
 
 	isReg := req.Order.GetID() != ""
 	if !isReg {
 		isReg = b.placeOrderWithRetry(req)
 	}
-	orderdb.InsertOrderRequest(reqClone) // отложенное сохранение старой копии
+	b.persist("insert order", reqClone.LinkId, func() error { // отложенное сохранение старой копии
+		return b.store.InsertOrderRequest(reqClone)
+	})
 	if isReg {
 		b.replyOrder(req)
 		reqClone := req.Clone()
 		b.logger.Log(slog.LevelInfo, "order is registered", "orderRequest", reqClone)
-		orderdb.UpdateOrderID(reqClone)
+		b.persist("update order id", reqClone.LinkId, func() error {
+			return b.store.UpdateOrderID(reqClone)
+		})
 		if b.waitForOrderClosed(req) {
 			b.replyOrder(req)
 			reqClone := req.Clone()
 			b.logger.Log(slog.LevelInfo, "order is closed", "orderRequest", reqClone)
-			orderdb.UpdateOrder(reqClone)
+			b.persist("update order", reqClone.LinkId, func() error {
+				return b.store.UpdateOrder(reqClone)
+			})
+			b.recordFill(reqClone)
 			return
 		}
 		b.replyOrder(req)
 		b.cancelOrderWithRetry(req)
+		if b.checkOrderClosed(req) { // учитываем частичное исполнение
+			reqClone := req.Clone()
+			b.persist("update order", reqClone.LinkId, func() error {
+				return b.store.UpdateOrder(reqClone)
+			})
+			b.recordFill(reqClone)
+		}
+	}
+}
+
+// persist выполняет запись в хранилище с повторными попытками и растущим интервалом
+// Каждая неудача пишется в журнал, исчерпание попыток - с уровнем Error
+func (b *TradingBot) persist(op string, key any, write func() error) {
+	interval := b.storeRetryInterval
+	for attempt := 1; ; attempt++ {
+		err := write()
+		if err == nil {
+			return
+		}
+		if attempt >= b.storeRetries {
+			b.logger.Log(slog.LevelError, "store write failed", "op", op, "key", key, "attempts", attempt, "error", err)
+			return
+		}
+		b.logger.Log(slog.LevelWarn, "store write retry", "op", op, "key", key, "attempt", attempt, "error", err)
+		time.Sleep(interval)
+		interval *= 2
 	}
 }
 
@@ -233,12 +303,19 @@ func (b *TradingBot) cancelOrderWithRetry(req *types.OrderRequest) {
 	}
 }
 
-// AddStrategy добавляет новую стратегию к торговому боту
+// AddStrategy добавляет новую стратегию к торговому боту и запускает ее
+// Заявки стратегии проходят через отдельный канал, что позволяет приостанавливать ее.
+// Каждая стратегия получает свой контекст, управление - StopStrategy, StartStrategy и RemoveStrategy
 func (b *TradingBot) AddStrategys(strategys ...types.Strategy) {
 	for _, s := range strategys {
-		s.Init(b.strategysCtx, b.subData, b.ch)
-		if err := s.Go(); err != nil {
-			b.logger.Log(slog.LevelError, "launching strategy", "error", err)
+		entry := b.registerStrategy(s)
+		b.allocate(entry)
+		b.setLeverage(entry)
+		b.setInstrument(entry)
+		b.attachSchedule(entry)
+		go b.forwardOrders(entry)
+		if err := b.launchStrategy(entry); err != nil {
+			b.logger.Log(slog.LevelError, "launching strategy", "strategy", entry.name, "error", err)
 		}
 	}
 }
@@ -0,0 +1,42 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"goTradingBot/utils/slogx"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestPersist(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := &TradingBot{
+		logger:             slogx.NewAsyncSlog(ctx, slog.New(slog.NewTextHandler(io.Discard, nil))),
+		storeRetries:       3,
+		storeRetryInterval: time.Millisecond,
+	}
+
+	calls := 0
+	b.persist("insert", "a", func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("database is locked")
+		}
+		return nil
+	})
+	if calls != 2 {
+		t.Errorf("успех после повтора: %d попыток", calls)
+	}
+
+	calls = 0
+	b.persist("insert", "b", func() error {
+		calls++
+		return errors.New("disk I/O error")
+	})
+	if calls != 3 {
+		t.Errorf("исчерпание попыток: %d попыток", calls)
+	}
+}
@@ -1,5 +1,14 @@
 package config
 
+import (
+	"encoding/json"
+	"fmt"
+	"goTradingBot/ta/structure"
+	"goTradingBot/trading/regime"
+	"goTradingBot/trading/schedule"
+	"os"
+)
+
 type TradingBotConfig struct {
 	ChannelBufferSize  int `json:"channelBufferSize"`  // размер буфера канала для приёма ордеров
 	SubDataBufferSize  int `json:"subDataBufferSize"`  // размер буфера исторических данных
@@ -8,6 +17,16 @@ type TradingBotConfig struct {
 	CheckOrderInterval int `json:"checkOrderInterval"` // интервал проверки статуса (мс)
 	LongCheckInterval  int `json:"longCheckInterval"`  // увеличенный интервал проверки (мс)
 	OrderStatusTimeout int `json:"orderStatusTimeout"` // таймаут ожидания закрытия (мс)
+	StoreRetries       int `json:"storeRetries"`       // количество попыток записи в хранилище
+	StoreRetryInterval int `json:"storeRetryInterval"` // начальный интервал между попытками записи (мс), удваивается
+	// режим маржи бессрочных контрактов, устанавливаемый при запуске: isolated, cross или "" - не менять
+	MarginMode string `json:"marginMode"`
+	// окна торговли стратегий, закрытие позиций и периодические задачи
+	Schedule schedule.Config `json:"schedule"`
+	// режимы рынка, в которых стратегии открывают позиции: trend_up, trend_down, range, high_vol; пусто - все
+	Regimes []regime.Regime `json:"regimes"`
+	// параметры рыночной структуры, подтверждающей сигналы стратегий трендом; nil - без подтверждения
+	Structure *structure.Params `json:"structure"`
 }
 
 // DefaultConfig возвращает конфигурацию по умолчанию
@@ -20,7 +39,33 @@ func DefaultTradingBotConfig() *TradingBotConfig {
 		CheckOrderInterval: 500,
 		LongCheckInterval:  5000,
 		OrderStatusTimeout: 3600000,
+		StoreRetries:       5,
+		StoreRetryInterval: 200,
+	}
+}
+
+// Load читает конфигурацию из JSON-файла path
+// Пропущенные в файле поля получают значения по умолчанию
+func Load(path string) (*TradingBotConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Load: %w", err)
+	}
+	cfg := DefaultTradingBotConfig()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("Load: %s: %w", path, err)
+	}
+	for _, r := range cfg.Regimes {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("Load: %s: %w", path, err)
+		}
+	}
+	if cfg.Structure != nil {
+		if err := cfg.Structure.Validate(); err != nil {
+			return nil, fmt.Errorf("Load: %s: %w", path, err)
+		}
 	}
+	return cfg, nil
 }
 
 type Strategy struct {
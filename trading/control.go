@@ -0,0 +1,440 @@
+package trading
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"goTradingBot/cdl"
+	orderdb "goTradingBot/trading/db"
+	"goTradingBot/trading/notify"
+	"goTradingBot/trading/types"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// strategyEntry стратегия, зарегистрированная в боте
+type strategyEntry struct {
+	name     string
+	strategy types.Strategy
+	paused   atomic.Bool
+	ch       chan *types.OrderRequest
+	runID    atomic.Int64       // запись о запуске в хранилище, 0 - нет открытого запуска
+	cancel   context.CancelFunc // отменяет контекст текущего запуска, изменяется под TradingBot.mu
+	stopped  atomic.Bool
+	flatten  atomic.Bool // передавать закрывающие заявки стратегии после остановки
+	removed  chan struct{}
+}
+
+// StrategyStatus состояние стратегии
+type StrategyStatus struct {
+	Name   string         `json:"name"`
+	State  StrategyState  `json:"state"`
+	Paused bool           `json:"paused"`
+	Budget *float64       `json:"budget,omitempty"` // бюджет из портфеля, nil без портфеля
+	Params map[string]any `json:"params,omitempty"`
+}
+
+// Status общее состояние торгового бота
+type Status struct {
+	StartedAt    time.Time        `json:"startedAt"`
+	Killed       bool             `json:"killed"`
+	Strategies   []StrategyStatus `json:"strategies"`
+	ActiveOrders int              `json:"activeOrders"`
+	Positions    int              `json:"positions"` // количество открытых позиций
+}
+
+// PnL результат торговли по инструменту
+type PnL struct {
+	Symbol     string  `json:"symbol"`
+	Realized   float64 `json:"realized"`
+	Unrealized float64 `json:"unrealized"` // по последней цене, 0 если цена недоступна
+	Fees       float64 `json:"fees"`
+	Funding    float64 `json:"funding"` // полученное финансирование, <0 - уплаченное
+	Net        float64 `json:"net"`     // Realized + Unrealized - Fees + Funding
+}
+
+// registerStrategy присваивает стратегии уникальное имя и отдельный канал заявок
+func (b *TradingBot) registerStrategy(s types.Strategy) *strategyEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	name := fmt.Sprintf("strategy-%d", len(b.strategies)+1)
+	if named, ok := s.(types.NamedStrategy); ok && named.Name() != "" {
+		name = named.Name()
+	}
+	base := name
+	for i := 2; b.findStrategy(name) != nil; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	entry := &strategyEntry{name: name, strategy: s, ch: make(chan *types.OrderRequest), removed: make(chan struct{})}
+	b.strategies = append(b.strategies, entry)
+	return entry
+}
+
+func (b *TradingBot) findStrategy(name string) *strategyEntry {
+	for _, entry := range b.strategies {
+		if strings.EqualFold(entry.name, name) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// forwardOrders передает заявки стратегии в общий канал бота
+// Заявки приостановленной стратегии отбрасываются, пока стратегия работает.
+// Закрывающие заявки, отправленные стратегией при остановке, передаются при остановке бота
+// и при остановке стратегии с закрытием позиции
+func (b *TradingBot) forwardOrders(entry *strategyEntry) {
+	removed := entry.removed
+	var expired <-chan time.Time
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-removed:
+			// закрывающая заявка удаленной стратегии может прийти после удаления
+			removed, expired = nil, time.After(removeGrace)
+		case <-expired:
+			return
+		case req := <-entry.ch:
+			if reason := b.dropReason(entry, req); reason != "" {
+				b.logger.Log(
+					slog.LevelWarn,
+					"order request of "+reason+" strategy dropped",
+					"strategy", entry.name,
+					"orderRequest", req.Clone(),
+				)
+				continue
+			}
+			b.orderOwners.Store(req.LinkId, entry.name)
+			b.sendOrder(req)
+		}
+	}
+}
+
+// sendOrder передает заявку в цикл обработки ордеров
+func (b *TradingBot) sendOrder(req *types.OrderRequest) bool {
+	if b.ctx.Err() != nil {
+		return false
+	}
+	select {
+	case <-b.ctx.Done():
+		return false
+	case b.ch <- req:
+		return true
+	}
+}
+
+// SetNotifier подключает уведомления об исполнении ордеров и позициях
+// Вызывается до добавления стратегий
+func (b *TradingBot) SetNotifier(n *notify.Notifier) {
+	b.notifier = n
+}
+
+// recordFill учитывает исполнение закрытого ордера в позициях и отправляет уведомления
+func (b *TradingBot) recordFill(req *types.OrderRequest) {
+	order := req.Order
+	if order.ExecQty == 0 {
+		return
+	}
+	var strategy string
+	if owner, ok := b.orderOwners.Load(req.LinkId); ok {
+		strategy = owner.(string)
+	}
+	change := b.positions.apply(order)
+	fill := &orderdb.Fill{
+		LinkId:   req.LinkId,
+		OrderID:  order.ID,
+		Symbol:   order.Symbol,
+		Strategy: strategy,
+		Qty:      math.Copysign(math.Abs(order.ExecQty), order.Qty),
+		Price:    order.AvgPrice,
+		Fee:      order.Fee,
+		Time:     order.UpdatedAt,
+	}
+	b.persist("insert fill", req.LinkId, func() error { return b.store.InsertFill(fill) })
+	b.notifier.Notify(&notify.Event{
+		Kind:     notify.OrderFilled,
+		Symbol:   order.Symbol,
+		Strategy: strategy,
+		Qty:      order.ExecQty,
+		Price:    order.AvgPrice,
+		Fee:      order.Fee,
+	})
+	if p := change.closed; p != nil {
+		if strategy != "" {
+			b.recordTrade(strategy, p.Realized-p.Fees+p.Funding)
+		}
+		b.notifier.Notify(&notify.Event{
+			Kind:     notify.PositionClosed,
+			Symbol:   p.Symbol,
+			Strategy: strategy,
+			Qty:      p.Qty,
+			Price:    p.AvgPrice,
+			PnL:      p.Realized,
+			Fee:      p.Fees,
+		})
+	}
+	if p := change.opened; p != nil {
+		b.notifier.Notify(&notify.Event{
+			Kind:     notify.PositionOpened,
+			Symbol:   p.Symbol,
+			Strategy: strategy,
+			Qty:      p.Qty,
+			Price:    p.AvgPrice,
+		})
+	}
+}
+
+// Exposure возвращает открытые позиции со стоимостью по последней цене
+func (b *TradingBot) Exposure() []notify.Exposure {
+	positions := b.Positions()
+	res := make([]notify.Exposure, len(positions))
+	for i, p := range positions {
+		price := p.AvgPrice
+		if last, err := b.lastPrice(p.Symbol); err == nil {
+			price = last
+		}
+		res[i] = notify.Exposure{Symbol: p.Symbol, Qty: p.Qty, Notional: p.notional(price)}
+	}
+	return res
+}
+
+// SubData возвращает общие для стратегий подписки на данные
+func (b *TradingBot) SubData() *types.SubData {
+	return b.subData
+}
+
+// Status возвращает состояние бота
+func (b *TradingBot) Status() Status {
+	b.mu.Lock()
+	strategies := make([]StrategyStatus, len(b.strategies))
+	for i, entry := range b.strategies {
+		strategies[i] = b.strategyStatus(entry)
+	}
+	b.mu.Unlock()
+
+	var positions int
+	for _, p := range b.positions.snapshot() {
+		if p.Qty != 0 {
+			positions++
+		}
+	}
+	return Status{
+		StartedAt:    b.startedAt,
+		Killed:       b.killed.Load(),
+		Strategies:   strategies,
+		ActiveOrders: len(b.ActiveOrders()),
+		Positions:    positions,
+	}
+}
+
+// Positions возвращает позиции, открытые ботом с момента запуска
+func (b *TradingBot) Positions() []Position {
+	return slices.DeleteFunc(b.positions.snapshot(), func(p Position) bool {
+		return p.Qty == 0
+	})
+}
+
+// PnL возвращает результат торговли по всем инструментам с момента запуска
+// Нереализованный результат считается по цене закрытия последней минутной свечи
+func (b *TradingBot) PnL() []PnL {
+	positions := b.positions.snapshot()
+	res := make([]PnL, len(positions))
+	for i, p := range positions {
+		res[i] = PnL{Symbol: p.Symbol, Realized: p.Realized, Fees: p.Fees, Funding: p.Funding}
+		if p.Qty != 0 {
+			if price, err := b.lastPrice(p.Symbol); err == nil {
+				res[i].Unrealized = p.unrealized(price)
+			} else {
+				b.logger.Log(slog.LevelError, "getting last price", "symbol", p.Symbol, "error", err)
+			}
+		}
+		res[i].Net = res[i].Realized + res[i].Unrealized - res[i].Fees + res[i].Funding
+	}
+	return res
+}
+
+func (b *TradingBot) lastPrice(symbol string) (float64, error) {
+	candles, err := b.dataProvider.GetCandles(symbol, cdl.M1, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(candles) == 0 {
+		return 0, fmt.Errorf("TradingBot: нет свечей %s", symbol)
+	}
+	return candles[len(candles)-1].C, nil
+}
+
+// ActiveOrders возвращает копии заявок, которые обрабатываются в данный момент
+func (b *TradingBot) ActiveOrders() []*types.OrderRequest {
+	var res []*types.OrderRequest
+	b.activeOrders.Range(func(_, value any) bool {
+		res = append(res, value.(*types.OrderRequest).Clone())
+		return true
+	})
+	slices.SortFunc(res, func(a, b *types.OrderRequest) int {
+		return cmp.Compare(a.Order.CreatedAt, b.Order.CreatedAt)
+	})
+	return res
+}
+
+// PauseStrategy приостанавливает отправку заявок стратегией name
+// Стратегия продолжает получать данные, ее заявки отбрасываются
+func (b *TradingBot) PauseStrategy(name string) error {
+	return b.setPaused(name, true)
+}
+
+// ResumeStrategy возобновляет отправку заявок стратегией name
+// Пустое имя возобновляет все стратегии
+func (b *TradingBot) ResumeStrategy(name string) error {
+	return b.setPaused(name, false)
+}
+
+func (b *TradingBot) setPaused(name string, paused bool) error {
+	if !paused && b.killed.Load() {
+		return fmt.Errorf("TradingBot: бот остановлен командой kill")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if name == "" {
+		for _, entry := range b.strategies {
+			entry.paused.Store(paused)
+		}
+		return nil
+	}
+	entry := b.findStrategy(name)
+	if entry == nil {
+		return fmt.Errorf("TradingBot: стратегия %q не найдена", name)
+	}
+	entry.paused.Store(paused)
+	b.logger.Log(slog.LevelWarn, "strategy state changed", "strategy", entry.name, "paused", paused)
+	return nil
+}
+
+// Flatten закрывает позицию бота по инструменту
+// Запущенные стратегии инструмента закрывают свою часть позиции сами, чтобы их учет позиции
+// не расходился с биржей. Остаток закрывается рыночным ордером бота.
+// Возвращает LinkId ордера бота или пустую строку, если всю позицию закрывают стратегии
+func (b *TradingBot) Flatten(symbol string) (string, error) {
+	symbol = strings.ToUpper(symbol)
+	p := b.positions.get(symbol)
+	owners := b.positionStrategies(symbol)
+	if math.Abs(p.Qty) < 1e-12 && len(owners) == 0 {
+		return "", fmt.Errorf("TradingBot: нет открытой позиции %s", symbol)
+	}
+	qty := p.Qty
+	for _, entry := range owners {
+		s := entry.strategy.(types.PositionStrategy)
+		qty -= s.Position()
+		entry.strategy.(types.ScheduledStrategy).Flatten()
+		b.logger.Log(slog.LevelWarn, "flatten strategy position", "strategy", entry.name, "symbol", symbol)
+	}
+	// остаток другого знака означает расхождение учета стратегий с ботом, ордер бота не отправляется
+	if qty*p.Qty <= 0 || math.Abs(qty) < 1e-12 {
+		return "", nil
+	}
+	req := &types.OrderRequest{
+		LinkId:       uuid.NewString(),
+		Tag:          "flatten",
+		Order:        types.NewOrder(symbol, -qty, nil),
+		CloseTimeout: time.Minute,
+	}
+	if !b.sendOrder(req) {
+		return "", fmt.Errorf("TradingBot: бот остановлен")
+	}
+	b.logger.Log(slog.LevelWarn, "flatten position", "symbol", symbol, "qty", -qty)
+	return req.LinkId, nil
+}
+
+// Trades сообщает, торгует ли инструментом symbol хотя бы одна запущенная стратегия
+func (b *TradingBot) Trades(symbol string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, entry := range b.strategies {
+		if s, ok := entry.strategy.(types.MarketStrategy); ok && !entry.stopped.Load() && s.Symbol() == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// positionStrategies возвращает запущенные стратегии инструмента symbol, которые ведут учет позиции
+// и умеют закрывать ее сами
+func (b *TradingBot) positionStrategies(symbol string) []*strategyEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var res []*strategyEntry
+	for _, entry := range b.strategies {
+		if entry.stopped.Load() {
+			continue
+		}
+		ps, ok := entry.strategy.(types.PositionStrategy)
+		if _, scheduled := entry.strategy.(types.ScheduledStrategy); !ok || !scheduled || ps.Symbol() != symbol {
+			continue
+		}
+		res = append(res, entry)
+	}
+	return res
+}
+
+// Kill останавливает все стратегии. Стратегии закрывают свои позиции при остановке,
+// обработка ордеров продолжается. Повторный запуск стратегий невозможен
+func (b *TradingBot) Kill() {
+	if b.killed.Swap(true) {
+		return
+	}
+	b.logger.Log(slog.LevelWarn, "trading bot killed")
+	b.mu.Lock()
+	for _, entry := range b.strategies {
+		entry.stopped.Store(true)
+	}
+	b.mu.Unlock()
+	b.cancelStrategys()
+	b.stopStrategyRuns("kill")
+}
+
+// startStrategyRun сохраняет запуск стратегии с текущими параметрами
+func (b *TradingBot) startStrategyRun(entry *strategyEntry) {
+	params := []byte("{}")
+	if ps, ok := entry.strategy.(types.ParameterizedStrategy); ok {
+		if data, err := json.Marshal(ps.Params()); err == nil {
+			params = data
+		}
+	}
+	b.persist("start strategy run", entry.name, func() error {
+		id, err := b.store.StartStrategyRun(entry.name, string(params), time.Now().UnixMilli())
+		if err == nil {
+			entry.runID.Store(id)
+		}
+		return err
+	})
+}
+
+// stopStrategyRuns отмечает остановку всех запущенных стратегий
+func (b *TradingBot) stopStrategyRuns(reason string) {
+	b.mu.Lock()
+	entries := slices.Clone(b.strategies)
+	b.mu.Unlock()
+	for _, entry := range entries {
+		b.stopStrategyRun(entry, reason)
+	}
+}
+
+// stopStrategyRun отмечает остановку запуска стратегии
+func (b *TradingBot) stopStrategyRun(entry *strategyEntry, reason string) {
+	if id := entry.runID.Swap(0); id != 0 {
+		now := time.Now().UnixMilli()
+		b.persist("stop strategy run", entry.name, func() error {
+			return b.store.StopStrategyRun(id, now, reason)
+		})
+	}
+}
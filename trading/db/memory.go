@@ -0,0 +1,250 @@
+package db
+
+import (
+	"fmt"
+	"goTradingBot/trading/types"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранилище в памяти для тестов и бэктестов
+type MemoryStore struct {
+	mu        sync.Mutex
+	orders    map[string]*types.OrderRequest
+	fills     []*Fill
+	positions []*PositionSnapshot
+	runs      []*StrategyRun
+	equity    map[int64]*EquitySnapshot
+	risk      []*RiskEvent
+}
+
+// NewMemoryStore создает пустое хранилище в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		orders: make(map[string]*types.OrderRequest),
+		equity: make(map[int64]*EquitySnapshot),
+	}
+}
+
+func (s *MemoryStore) InsertOrderRequest(r *types.OrderRequest) error {
+	if r.Order == nil {
+		return fmt.Errorf("отсутствует данные ордера")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[r.LinkId] = &types.OrderRequest{LinkId: r.LinkId, Tag: r.Tag, Order: r.Order.Clone()}
+	return nil
+}
+
+func (s *MemoryStore) UpdateOrderID(r *types.OrderRequest) error {
+	id := r.Order.GetID()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored, ok := s.orders[r.LinkId]; ok {
+		stored.Order.ID = id
+		stored.Order.UpdatedAt = time.Now().UnixMilli()
+	}
+	return nil
+}
+
+func (s *MemoryStore) UpdateOrder(r *types.OrderRequest) error {
+	if r.Order == nil {
+		return fmt.Errorf("отсутствуют данные ордера")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.orders[r.LinkId]; ok {
+		s.orders[r.LinkId] = &types.OrderRequest{LinkId: r.LinkId, Tag: r.Tag, Order: r.Order.Clone()}
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetFilledOrders(fromMs int64) ([]*types.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var orders []*types.Order
+	for _, r := range s.orders {
+		if r.Order.IsClosed && r.Order.ExecQty != 0 && r.Order.UpdatedAt >= fromMs {
+			orders = append(orders, r.Order.Clone())
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].UpdatedAt < orders[j].UpdatedAt })
+	return orders, nil
+}
+
+// sorted возвращает копии ордеров по фильтру в порядке createdAt, linkId
+func (s *MemoryStore) sorted(f *OrderFilter) []*types.OrderRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var orders []*types.OrderRequest
+	for _, r := range s.orders {
+		if f.match(r) {
+			orders = append(orders, &types.OrderRequest{LinkId: r.LinkId, Tag: r.Tag, Order: r.Order.Clone()})
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		a, b := orders[i], orders[j]
+		if a.Order.CreatedAt != b.Order.CreatedAt {
+			return a.Order.CreatedAt < b.Order.CreatedAt
+		}
+		return a.LinkId < b.LinkId
+	})
+	return orders
+}
+
+func (s *MemoryStore) QueryOrders(q *OrderQuery) (*OrderPage, error) {
+	if err := q.Validate(); err != nil {
+		return nil, fmt.Errorf("QueryOrders: %w", err)
+	}
+	orders := s.sorted(&q.OrderFilter)
+	slices.Reverse(orders)
+	if q.Cursor != "" {
+		createdAt, linkId, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("QueryOrders: %w", err)
+		}
+		i := 0
+		for i < len(orders) && (orders[i].Order.CreatedAt > createdAt ||
+			(orders[i].Order.CreatedAt == createdAt && orders[i].LinkId >= linkId)) {
+			i++
+		}
+		orders = orders[i:]
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	limit = min(limit, MaxQueryLimit)
+	page := &OrderPage{Orders: orders}
+	if page.Orders == nil {
+		page.Orders = []*types.OrderRequest{}
+	}
+	if len(page.Orders) > limit {
+		page.Orders = page.Orders[:limit]
+		page.NextCursor = encodeCursor(page.Orders[limit-1])
+	}
+	return page, nil
+}
+
+func (s *MemoryStore) EachOrder(f *OrderFilter, fn func(r *types.OrderRequest) error) error {
+	if err := f.Validate(); err != nil {
+		return fmt.Errorf("EachOrder: %w", err)
+	}
+	for _, r := range s.sorted(f) {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) InsertFill(f *Fill) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fill := *f
+	s.fills = append(s.fills, &fill)
+	return nil
+}
+
+func (s *MemoryStore) GetFills(fromMs int64) ([]*Fill, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var fills []*Fill
+	for _, f := range s.fills {
+		if f.Time >= fromMs {
+			fill := *f
+			fills = append(fills, &fill)
+		}
+	}
+	sort.SliceStable(fills, func(i, j int) bool { return fills[i].Time < fills[j].Time })
+	return fills, nil
+}
+
+func (s *MemoryStore) InsertPositionSnapshots(snapshots []*PositionSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range snapshots {
+		snapshot := *p
+		s.positions = append(s.positions, &snapshot)
+	}
+	return nil
+}
+
+func (s *MemoryStore) StartStrategyRun(strategy, params string, startedAt int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := int64(len(s.runs) + 1)
+	s.runs = append(s.runs, &StrategyRun{ID: id, Strategy: strategy, Params: params, StartedAt: startedAt})
+	return id, nil
+}
+
+func (s *MemoryStore) StopStrategyRun(id int64, stoppedAt int64, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id < 1 || id > int64(len(s.runs)) {
+		return fmt.Errorf("StopStrategyRun: запуск %d не найден", id)
+	}
+	s.runs[id-1].StoppedAt = stoppedAt
+	s.runs[id-1].Reason = reason
+	return nil
+}
+
+func (s *MemoryStore) GetStrategyRuns(strategy string, limit int) ([]*StrategyRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var runs []*StrategyRun
+	for i := len(s.runs) - 1; i >= 0 && len(runs) < limit; i-- {
+		if strategy == "" || s.runs[i].Strategy == strategy {
+			run := *s.runs[i]
+			runs = append(runs, &run)
+		}
+	}
+	return runs, nil
+}
+
+func (s *MemoryStore) InsertEquitySnapshot(e *EquitySnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := *e
+	s.equity[e.Time] = &snapshot
+	return nil
+}
+
+func (s *MemoryStore) GetEquitySnapshots(fromMs int64) ([]*EquitySnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var snapshots []*EquitySnapshot
+	for t, e := range s.equity {
+		if t >= fromMs {
+			snapshot := *e
+			snapshots = append(snapshots, &snapshot)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Time < snapshots[j].Time })
+	return snapshots, nil
+}
+
+func (s *MemoryStore) InsertRiskEvent(e *RiskEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event := *e
+	s.risk = append(s.risk, &event)
+	return nil
+}
+
+// RiskEvents возвращает сохраненные события риска
+func (s *MemoryStore) RiskEvents() []RiskEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := make([]RiskEvent, len(s.risk))
+	for i, e := range s.risk {
+		events[i] = *e
+	}
+	return events
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -11,43 +11,48 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// DefaultPath путь к базе ордеров по умолчанию
+const DefaultPath = "orders.db"
+
 var (
-	dbConn *sql.DB
-	once   sync.Once
+	defaultStore *SQLiteStore
+	defaultErr   error
+	once         sync.Once
 )
 
-const dbPath = "orders.db"
+// migrate применяет миграции схемы schema.go
+var migrate = db.Migrations(migrations...)
 
-// migrate выполняет необходимые миграции базы данных
-func migrate(db *sql.DB) error {
-	query := `
-CREATE TABLE IF NOT EXISTS orders (
-    linkId TEXT PRIMARY KEY,
-    tag TEXT NOT NULL,
-    id TEXT NOT NULL,
-    symbol TEXT NOT NULL,
-    qty REAL NOT NULL,
-    price REAL,
-    avgPrice REAL NOT NULL,
-    execQty REAL NOT NULL,
-    execValue REAL NOT NULL,
-    fee REAL NOT NULL,
-    isClosed INTEGER NOT NULL CHECK (isClosed IN (0, 1)),
-    createdAt INTEGER NOT NULL,
-    updatedAt INTEGER NOT NULL
-);
-`
-	if _, err := db.Exec(query); err != nil {
-		return fmt.Errorf("ошибка выполнения миграции: %w", err)
-	}
-	return nil
+// SQLiteStore хранилище ордеров и торговой истории в SQLite
+type SQLiteStore struct {
+	db *sql.DB
 }
 
-func InsertOrderRequest(r *types.OrderRequest) error {
-	once.Do(func() { dbConn, _ = db.InitDB(dbPath, migrate) })
-	if dbConn == nil {
-		return fmt.Errorf("база данных не инициализирована")
+// OpenSQLite открывает базу ордеров по пути path и применяет миграции
+func OpenSQLite(path string) (*SQLiteStore, error) {
+	conn, err := db.InitDB(path, migrate)
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, fmt.Errorf("OpenSQLite: %w", err)
 	}
+	return &SQLiteStore{db: conn}, nil
+}
+
+// Default возвращает общее хранилище по пути DefaultPath, открытое при первом вызове
+func Default() (*SQLiteStore, error) {
+	once.Do(func() { defaultStore, defaultErr = OpenSQLite(DefaultPath) })
+	return defaultStore, defaultErr
+}
+
+// Close закрывает базу данных
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// InsertOrderRequest сохраняет заявку, существующая заявка с тем же linkId заменяется
+func (s *SQLiteStore) InsertOrderRequest(r *types.OrderRequest) error {
 	if r.Order == nil {
 		return fmt.Errorf("отсутствует данные ордера")
 	}
@@ -61,7 +66,7 @@ func InsertOrderRequest(r *types.OrderRequest) error {
 	if r.Order.IsClosed {
 		isClosed = 1
 	}
-	_, err := dbConn.Exec(query,
+	_, err := s.db.Exec(query,
 		r.LinkId,
 		r.Tag,
 		r.Order.ID,
@@ -83,18 +88,14 @@ func InsertOrderRequest(r *types.OrderRequest) error {
 }
 
 // UpdateOrderID обновляет только поле ID ордера в базе данных
-func UpdateOrderID(r *types.OrderRequest) error {
-	once.Do(func() { dbConn, _ = db.InitDB(dbPath, migrate) })
-	if dbConn == nil {
-		return fmt.Errorf("база данных не инициализирована")
-	}
+func (s *SQLiteStore) UpdateOrderID(r *types.OrderRequest) error {
 	query := `
     UPDATE orders
     SET id = ?,
 	updatedAt = ?
     WHERE linkId = ?
     `
-	_, err := dbConn.Exec(query,
+	_, err := s.db.Exec(query,
 		r.Order.ID,
 		time.Now().UnixMilli(),
 		r.LinkId,
@@ -107,12 +108,9 @@ func UpdateOrderID(r *types.OrderRequest) error {
 
 // GetOrderRequestsByPeriod возвращает список OrderRequest за указанный период времени (в секундах)
 // periodSec определяет период времени от текущего момента (например, 24*60*60 для суток)
-func GetOrderRequestsByPeriod(periodSec int64) ([]*types.OrderRequest, error) {
-	once.Do(func() { dbConn, _ = db.InitDB(dbPath, migrate) })
-	if dbConn == nil {
-		return nil, fmt.Errorf("база данных не инициализирована")
-	}
-	timeBoundary := time.Now().UnixMilli()/1000 - periodSec
+func (s *SQLiteStore) GetOrderRequestsByPeriod(periodSec int64) ([]*types.OrderRequest, error) {
+	// updatedAt хранится в миллисекундах
+	timeBoundary := time.Now().UnixMilli() - periodSec*1000
 	query := `
 	SELECT
 		linkId, tag, id, symbol, qty, price, avgPrice, execQty, execValue,
@@ -121,7 +119,7 @@ func GetOrderRequestsByPeriod(periodSec int64) ([]*types.OrderRequest, error) {
 	WHERE updatedAt >= ?
 	ORDER BY updatedAt DESC
 	`
-	rows, err := dbConn.Query(query, timeBoundary)
+	rows, err := s.db.Query(query, timeBoundary)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
 	}
@@ -158,11 +156,7 @@ func GetOrderRequestsByPeriod(periodSec int64) ([]*types.OrderRequest, error) {
 }
 
 // UpdateOrder обновляет поля tag, id, symbol, qty, price, avgPrice, execQty, execValue, fee, isClosed, createdAt, updatedAt в таблице orders
-func UpdateOrder(r *types.OrderRequest) error {
-	once.Do(func() { dbConn, _ = db.InitDB(dbPath, migrate) })
-	if dbConn == nil {
-		return fmt.Errorf("база данных не инициализирована")
-	}
+func (s *SQLiteStore) UpdateOrder(r *types.OrderRequest) error {
 	if r.Order == nil {
 		return fmt.Errorf("отсутствуют данные ордера")
 	}
@@ -187,7 +181,7 @@ func UpdateOrder(r *types.OrderRequest) error {
 	if r.Order.IsClosed {
 		isClosed = 1
 	}
-	_, err := dbConn.Exec(query,
+	_, err := s.db.Exec(query,
 		r.Tag,
 		r.Order.ID,
 		r.Order.Symbol,
@@ -207,3 +201,36 @@ func UpdateOrder(r *types.OrderRequest) error {
 	}
 	return nil
 }
+
+// GetFilledOrders возвращает закрытые ордера с ненулевым исполнением, обновленные не раньше fromMs (мс),
+// в порядке возрастания времени обновления
+func (s *SQLiteStore) GetFilledOrders(fromMs int64) ([]*types.Order, error) {
+	query := `
+	SELECT
+		id, symbol, qty, price, avgPrice, execQty, execValue,
+		fee, createdAt, updatedAt
+	FROM orders
+	WHERE isClosed = 1 AND execQty != 0 AND updatedAt >= ?
+	ORDER BY updatedAt ASC
+	`
+	rows, err := s.db.Query(query, fromMs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+	var orders []*types.Order
+	for rows.Next() {
+		order := &types.Order{IsClosed: true}
+		if err := rows.Scan(
+			&order.ID, &order.Symbol, &order.Qty, &order.Price, &order.AvgPrice,
+			&order.ExecQty, &order.ExecValue, &order.Fee, &order.CreatedAt, &order.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+	return orders, nil
+}
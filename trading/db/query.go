@@ -0,0 +1,422 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"goTradingBot/trading/types"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Статус ордера для фильтра
+const (
+	StatusOpen     = "open"     // ордер размещен и не завершен
+	StatusClosed   = "closed"   // ордер завершен
+	StatusRejected = "rejected" // ордер не принят биржей (пустой ID)
+)
+
+// Направление ордера для фильтра
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+const (
+	DefaultQueryLimit = 100
+	MaxQueryLimit     = 1000
+)
+
+// OrderFilter фильтр ордеров, пустые поля не ограничивают выборку
+type OrderFilter struct {
+	Symbols []string // торговые пары
+	Tags    []string // теги ордеров
+	Status  string   // StatusOpen, StatusClosed или StatusRejected
+	Side    string   // SideBuy или SideSell
+	From    int64    // время создания не раньше From (мс)
+	To      int64    // время создания раньше To (мс)
+}
+
+// OrderQuery запрос страницы ордеров, упорядоченных от новых к старым
+type OrderQuery struct {
+	OrderFilter
+	Cursor string // курсор из OrderPage.NextCursor для следующей страницы
+	Limit  int    // размер страницы, по умолчанию DefaultQueryLimit
+}
+
+// OrderPage страница ордеров
+type OrderPage struct {
+	Orders     []*types.OrderRequest `json:"orders"`
+	NextCursor string                `json:"nextCursor,omitempty"` // пусто на последней странице
+}
+
+// SymbolStats сводка ордеров по торговой паре
+type SymbolStats struct {
+	Symbol      string  `json:"symbol"`
+	Orders      int     `json:"orders"`      // количество ордеров
+	Filled      int     `json:"filled"`      // количество ордеров с исполнением
+	BuyVolume   float64 `json:"buyVolume"`   // стоимость исполненных покупок
+	SellVolume  float64 `json:"sellVolume"`  // стоимость исполненных продаж
+	Fees        float64 `json:"fees"`        // комиссии
+	RealizedPnL float64 `json:"realizedPnL"` // реализованный результат без комиссий по средней цене входа
+}
+
+// Validate проверяет значения статуса и направления
+func (f *OrderFilter) Validate() error {
+	_, _, err := f.where()
+	return err
+}
+
+// where строит условие WHERE для фильтра
+func (f *OrderFilter) where() (string, []any, error) {
+	var conds []string
+	var args []any
+	in := func(column string, values []string) {
+		if len(values) == 0 {
+			return
+		}
+		conds = append(conds, column+" IN (?"+strings.Repeat(", ?", len(values)-1)+")")
+		for _, v := range values {
+			args = append(args, v)
+		}
+	}
+	in("symbol", f.Symbols)
+	in("tag", f.Tags)
+	switch f.Status {
+	case "":
+	case StatusOpen:
+		conds = append(conds, "isClosed = 0 AND id != ''")
+	case StatusClosed:
+		conds = append(conds, "isClosed = 1 AND id != ''")
+	case StatusRejected:
+		conds = append(conds, "id = ''")
+	default:
+		return "", nil, fmt.Errorf("неизвестный статус ордера: %q", f.Status)
+	}
+	switch f.Side {
+	case "":
+	case SideBuy:
+		conds = append(conds, "qty > 0")
+	case SideSell:
+		conds = append(conds, "qty < 0")
+	default:
+		return "", nil, fmt.Errorf("неизвестное направление ордера: %q", f.Side)
+	}
+	if f.From > 0 {
+		conds = append(conds, "createdAt >= ?")
+		args = append(args, f.From)
+	}
+	if f.To > 0 {
+		conds = append(conds, "createdAt < ?")
+		args = append(args, f.To)
+	}
+	if len(conds) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+// match проверяет ордер фильтром, аналог where для хранилищ без SQL
+func (f *OrderFilter) match(r *types.OrderRequest) bool {
+	o := r.Order
+	if len(f.Symbols) > 0 && !slices.Contains(f.Symbols, o.Symbol) {
+		return false
+	}
+	if len(f.Tags) > 0 && !slices.Contains(f.Tags, r.Tag) {
+		return false
+	}
+	switch f.Status {
+	case StatusOpen:
+		if o.IsClosed || o.ID == "" {
+			return false
+		}
+	case StatusClosed:
+		if !o.IsClosed || o.ID == "" {
+			return false
+		}
+	case StatusRejected:
+		if o.ID != "" {
+			return false
+		}
+	}
+	if (f.Side == SideBuy && o.Qty <= 0) || (f.Side == SideSell && o.Qty >= 0) {
+		return false
+	}
+	if (f.From > 0 && o.CreatedAt < f.From) || (f.To > 0 && o.CreatedAt >= f.To) {
+		return false
+	}
+	return true
+}
+
+const orderColumns = `
+		linkId, tag, id, symbol, qty, price, avgPrice, execQty, execValue,
+		fee, isClosed, createdAt, updatedAt`
+
+func scanOrderRequest(rows *sql.Rows) (*types.OrderRequest, error) {
+	var (
+		r      types.OrderRequest
+		order  types.Order
+		closed int
+	)
+	if err := rows.Scan(
+		&r.LinkId, &r.Tag, &order.ID, &order.Symbol, &order.Qty, &order.Price, &order.AvgPrice,
+		&order.ExecQty, &order.ExecValue, &order.Fee, &closed, &order.CreatedAt, &order.UpdatedAt,
+	); err != nil {
+		return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+	}
+	order.IsClosed = closed == 1
+	r.Order = &order
+	return &r, nil
+}
+
+// encodeCursor курсор - позиция последнего ордера страницы: время создания и linkId
+func encodeCursor(r *types.OrderRequest) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d:%s", r.Order.CreatedAt, r.LinkId))
+}
+
+func decodeCursor(cursor string) (int64, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", fmt.Errorf("неверный курсор")
+	}
+	ts, linkId, ok := strings.Cut(string(raw), ":")
+	createdAt, err := strconv.ParseInt(ts, 10, 64)
+	if !ok || err != nil {
+		return 0, "", fmt.Errorf("неверный курсор")
+	}
+	return createdAt, linkId, nil
+}
+
+// QueryOrders возвращает страницу ордеров по фильтру от новых к старым
+// Порядок по времени создания не меняется при обновлении ордеров, поэтому курсор стабилен
+func (s *SQLiteStore) QueryOrders(q *OrderQuery) (*OrderPage, error) {
+	where, args, err := q.where()
+	if err != nil {
+		return nil, fmt.Errorf("QueryOrders: %w", err)
+	}
+	if q.Cursor != "" {
+		createdAt, linkId, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("QueryOrders: %w", err)
+		}
+		cond := "(createdAt < ? OR (createdAt = ? AND linkId < ?))"
+		if where == "" {
+			where = " WHERE " + cond
+		} else {
+			where += " AND " + cond
+		}
+		args = append(args, createdAt, createdAt, linkId)
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	limit = min(limit, MaxQueryLimit)
+	// запрашиваем на один ордер больше, чтобы узнать о наличии следующей страницы
+	orders, err := s.queryOrderRequests(
+		"SELECT"+orderColumns+" FROM orders"+where+" ORDER BY createdAt DESC, linkId DESC LIMIT ?",
+		append(args, limit+1)...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("QueryOrders: %w", err)
+	}
+	page := &OrderPage{Orders: orders}
+	if page.Orders == nil {
+		page.Orders = []*types.OrderRequest{}
+	}
+	if len(page.Orders) > limit {
+		page.Orders = page.Orders[:limit]
+		page.NextCursor = encodeCursor(page.Orders[limit-1])
+	}
+	return page, nil
+}
+
+// EachOrder вызывает fn для каждого ордера по фильтру от старых к новым, не загружая выборку в память
+// Ордера читаются пачками: между пачками соединение с базой свободно для записи ордеров ботом
+// Ошибка fn прерывает обход и возвращается
+func (s *SQLiteStore) EachOrder(f *OrderFilter, fn func(r *types.OrderRequest) error) error {
+	const batchSize = 500
+	where, args, err := f.where()
+	if err != nil {
+		return fmt.Errorf("EachOrder: %w", err)
+	}
+	var last *types.OrderRequest
+	for {
+		batchWhere, batchArgs := where, slices.Clip(args)
+		if last != nil {
+			cond := "(createdAt > ? OR (createdAt = ? AND linkId > ?))"
+			if batchWhere == "" {
+				batchWhere = " WHERE " + cond
+			} else {
+				batchWhere += " AND " + cond
+			}
+			batchArgs = append(batchArgs, last.Order.CreatedAt, last.Order.CreatedAt, last.LinkId)
+		}
+		batch, err := s.queryOrderRequests(
+			"SELECT"+orderColumns+" FROM orders"+batchWhere+" ORDER BY createdAt ASC, linkId ASC LIMIT ?",
+			append(batchArgs, batchSize)...,
+		)
+		if err != nil {
+			return fmt.Errorf("EachOrder: %w", err)
+		}
+		for _, r := range batch {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		last = batch[len(batch)-1]
+	}
+}
+
+// queryOrderRequests выполняет запрос, выбирающий orderColumns
+func (s *SQLiteStore) queryOrderRequests(query string, args ...any) ([]*types.OrderRequest, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+	var orders []*types.OrderRequest
+	for rows.Next() {
+		r, err := scanOrderRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+	return orders, nil
+}
+
+// AggregateOrders возвращает сводку по торговым парам, отсортированную по символу
+// Реализованный результат считается по средней цене входа в пределах выборки:
+// позиция, открытая до начала периода, не учитывается
+func AggregateOrders(store OrderStore, f *OrderFilter) ([]SymbolStats, error) {
+	type position struct {
+		qty, avgPrice float64
+	}
+	stats := make(map[string]*SymbolStats)
+	positions := make(map[string]*position)
+	err := store.EachOrder(f, func(r *types.OrderRequest) error {
+		o := r.Order
+		s, ok := stats[o.Symbol]
+		if !ok {
+			s = &SymbolStats{Symbol: o.Symbol}
+			stats[o.Symbol] = s
+			positions[o.Symbol] = &position{}
+		}
+		s.Orders++
+		s.Fees += o.Fee
+		if o.ExecQty == 0 {
+			return nil
+		}
+		s.Filled++
+		value := math.Abs(o.ExecValue)
+		qty := math.Copysign(math.Abs(o.ExecQty), o.Qty)
+		if qty > 0 {
+			s.BuyVolume += value
+		} else {
+			s.SellVolume += value
+		}
+		price := o.AvgPrice
+		if price == 0 {
+			price = value / math.Abs(qty)
+		}
+		p := positions[o.Symbol]
+		if p.qty != 0 && (p.qty > 0) != (qty > 0) {
+			// сокращение или разворот позиции
+			closed := math.Min(math.Abs(qty), math.Abs(p.qty))
+			s.RealizedPnL += math.Copysign(closed, p.qty) * (price - p.avgPrice)
+			p.qty += math.Copysign(closed, qty)
+			qty -= math.Copysign(closed, qty)
+			if p.qty == 0 {
+				p.avgPrice = 0
+			}
+		}
+		if qty != 0 {
+			p.avgPrice = (p.avgPrice*math.Abs(p.qty) + price*math.Abs(qty)) / (math.Abs(p.qty) + math.Abs(qty))
+			p.qty += qty
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("AggregateOrders: %w", err)
+	}
+	result := make([]SymbolStats, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Symbol < result[j].Symbol })
+	return result, nil
+}
+
+// Форматы выгрузки ордеров
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var csvHeader = []string{
+	"linkId", "tag", "id", "symbol", "qty", "price", "avgPrice", "execQty", "execValue",
+	"fee", "isClosed", "createdAt", "updatedAt",
+}
+
+// ExportOrders построчно записывает ордера по фильтру в w в формате FormatCSV или FormatJSON (массив)
+func ExportOrders(w io.Writer, store OrderStore, format string, f *OrderFilter) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return fmt.Errorf("ExportOrders: %w", err)
+		}
+		formatFloat := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+		err := store.EachOrder(f, func(r *types.OrderRequest) error {
+			o := r.Order
+			price := ""
+			if o.Price != nil {
+				price = formatFloat(*o.Price)
+			}
+			return cw.Write([]string{
+				r.LinkId, r.Tag, o.ID, o.Symbol, formatFloat(o.Qty), price, formatFloat(o.AvgPrice),
+				formatFloat(o.ExecQty), formatFloat(o.ExecValue), formatFloat(o.Fee),
+				strconv.FormatBool(o.IsClosed), strconv.FormatInt(o.CreatedAt, 10), strconv.FormatInt(o.UpdatedAt, 10),
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("ExportOrders: %w", err)
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return fmt.Errorf("ExportOrders: %w", err)
+		}
+		enc := json.NewEncoder(w)
+		first := true
+		err := store.EachOrder(f, func(r *types.OrderRequest) error {
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			return enc.Encode(r)
+		})
+		if err != nil {
+			return fmt.Errorf("ExportOrders: %w", err)
+		}
+		_, err = io.WriteString(w, "]\n")
+		return err
+	}
+	return fmt.Errorf("ExportOrders: неизвестный формат: %q", format)
+}
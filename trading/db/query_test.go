@@ -0,0 +1,134 @@
+package db
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"goTradingBot/trading/types"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stores возвращает пустые хранилища всех реализаций
+func stores(t *testing.T) map[string]OrderStore {
+	sqlite, err := OpenSQLite(filepath.Join(t.TempDir(), "orders.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlite.Close() })
+	return map[string]OrderStore{"sqlite": sqlite, "memory": NewMemoryStore()}
+}
+
+func insertOrder(t *testing.T, store OrderStore, linkId, symbol string, qty, price float64, createdAt int64) {
+	t.Helper()
+	r := &types.OrderRequest{
+		LinkId: linkId,
+		Tag:    "test",
+		Order: &types.Order{
+			ID: "id-" + linkId, Symbol: symbol, Qty: qty, AvgPrice: price,
+			ExecQty: qty, ExecValue: math.Abs(qty) * price, Fee: 0.1,
+			CreatedAt: createdAt, UpdatedAt: createdAt, IsClosed: true,
+		},
+	}
+	if err := store.InsertOrderRequest(r); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueryOrders(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) { testQueryOrders(t, store) })
+	}
+}
+
+func testQueryOrders(t *testing.T, store OrderStore) {
+	base := time.Now().Add(-time.Hour).UnixMilli()
+	// BTC: покупка 2 по 100, продажа 1 по 110, продажа 1 по 90 => (10 - 10) = 0
+	// ETH: продажа 1 по 50, покупка 1 по 40 => 10
+	insertOrder(t, store, "a", "BTCUSDT", 2, 100, base)
+	insertOrder(t, store, "b", "BTCUSDT", -1, 110, base+1)
+	insertOrder(t, store, "c", "ETHUSDT", -1, 50, base+1)
+	insertOrder(t, store, "d", "BTCUSDT", -1, 90, base+2)
+	insertOrder(t, store, "e", "ETHUSDT", 1, 40, base+3)
+
+	var seen []string
+	q := &OrderQuery{Limit: 2}
+	for {
+		page, err := store.QueryOrders(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range page.Orders {
+			seen = append(seen, r.LinkId)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	if fmt.Sprint(seen) != "[e d c b a]" {
+		t.Errorf("страницы: %v", seen)
+	}
+
+	page, err := store.QueryOrders(&OrderQuery{OrderFilter: OrderFilter{Symbols: []string{"BTCUSDT"}, Side: SideSell}})
+	if err != nil || len(page.Orders) != 2 {
+		t.Fatalf("фильтр: %v %v", page, err)
+	}
+
+	stats, err := AggregateOrders(store, &OrderFilter{})
+	if err != nil || len(stats) != 2 {
+		t.Fatalf("сводка: %v %v", stats, err)
+	}
+	btc, eth := stats[0], stats[1]
+	if btc.Orders != 3 || btc.BuyVolume != 200 || btc.SellVolume != 200 || math.Abs(btc.RealizedPnL) > 1e-9 {
+		t.Errorf("BTCUSDT: %+v", btc)
+	}
+	if eth.RealizedPnL != 10 || math.Abs(eth.Fees-0.2) > 1e-9 {
+		t.Errorf("ETHUSDT: %+v", eth)
+	}
+
+	orders, err := store.GetFilledOrders(base)
+	if err != nil || len(orders) != 5 {
+		t.Errorf("GetFilledOrders: %d %v", len(orders), err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportOrders(&buf, store, FormatCSV, &OrderFilter{Symbols: []string{"ETHUSDT"}}); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(records) != 3 || records[1][0] != "c" {
+		t.Errorf("CSV: %v %v", records, err)
+	}
+}
+
+func TestRecords(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) { testRecords(t, store) })
+	}
+}
+
+func testRecords(t *testing.T, store OrderStore) {
+	now := time.Now().UnixMilli()
+	if err := store.InsertFill(&Fill{LinkId: "f", Symbol: "BTCUSDT", Strategy: "BTCUSDT-M5", Qty: 1, Price: 100, Time: now}); err != nil {
+		t.Fatal(err)
+	}
+	fills, err := store.GetFills(now)
+	if err != nil || len(fills) != 1 || fills[0].Strategy != "BTCUSDT-M5" {
+		t.Errorf("GetFills: %v %v", fills, err)
+	}
+
+	id, err := store.StartStrategyRun("BTCUSDT-M5", `{"limit":15}`, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.StopStrategyRun(id, now+1, "kill"); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := store.GetStrategyRuns("", 10)
+	if err != nil || len(runs) != 1 || runs[0].StoppedAt != now+1 || runs[0].Reason != "kill" {
+		t.Errorf("GetStrategyRuns: %+v %v", runs, err)
+	}
+}
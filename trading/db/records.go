@@ -0,0 +1,214 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Fill исполнение ордера: прирост исполненного количества между обновлениями ордера
+type Fill struct {
+	LinkId   string  `json:"linkId"`
+	OrderID  string  `json:"orderId"`
+	Symbol   string  `json:"symbol"`
+	Strategy string  `json:"strategy"`
+	Qty      float64 `json:"qty"`   // <0 для продажи
+	Price    float64 `json:"price"` // средняя цена исполненной части
+	Fee      float64 `json:"fee"`
+	Time     int64   `json:"time"` // мс
+}
+
+// PositionSnapshot состояние позиции на момент времени
+type PositionSnapshot struct {
+	Symbol     string  `json:"symbol"`
+	Qty        float64 `json:"qty"`
+	AvgPrice   float64 `json:"avgPrice"`
+	MarkPrice  float64 `json:"markPrice"`
+	Realized   float64 `json:"realized"`
+	Unrealized float64 `json:"unrealized"`
+	Fees       float64 `json:"fees"`
+	Time       int64   `json:"time"` // мс
+}
+
+// StrategyRun период работы стратегии с параметрами запуска
+type StrategyRun struct {
+	ID        int64  `json:"id"`
+	Strategy  string `json:"strategy"`
+	Params    string `json:"params"` // параметры в JSON
+	StartedAt int64  `json:"startedAt"`
+	StoppedAt int64  `json:"stoppedAt,omitempty"` // 0 - стратегия работает
+	Reason    string `json:"reason,omitempty"`    // причина остановки
+}
+
+// EquitySnapshot состояние счета на момент времени
+type EquitySnapshot struct {
+	Time       int64   `json:"time"` // мс
+	Realized   float64 `json:"realized"`
+	Unrealized float64 `json:"unrealized"`
+	Fees       float64 `json:"fees"`
+	Equity     float64 `json:"equity"` // Realized + Unrealized - Fees
+}
+
+// RiskEvent срабатывание ограничения риска
+type RiskEvent struct {
+	Kind      string  `json:"kind"` // вид ограничения, например maxExposure
+	Symbol    string  `json:"symbol,omitempty"`
+	Strategy  string  `json:"strategy,omitempty"`
+	Value     float64 `json:"value"`     // значение, нарушившее ограничение
+	Threshold float64 `json:"threshold"` // порог ограничения
+	Message   string  `json:"message,omitempty"`
+	Time      int64   `json:"time"` // мс
+}
+
+// InsertFill сохраняет исполнение ордера
+func (s *SQLiteStore) InsertFill(f *Fill) error {
+	_, err := s.db.Exec(`
+	INSERT INTO fills (linkId, orderId, symbol, strategy, qty, price, fee, time)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		f.LinkId, f.OrderID, f.Symbol, f.Strategy, f.Qty, f.Price, f.Fee, f.Time,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения исполнения: %w", err)
+	}
+	return nil
+}
+
+// GetFills возвращает исполнения не раньше fromMs (мс) по возрастанию времени
+func (s *SQLiteStore) GetFills(fromMs int64) ([]*Fill, error) {
+	rows, err := s.db.Query(`
+	SELECT linkId, orderId, symbol, strategy, qty, price, fee, time
+	FROM fills WHERE time >= ? ORDER BY time ASC, id ASC`, fromMs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+	var fills []*Fill
+	for rows.Next() {
+		f := new(Fill)
+		if err := rows.Scan(&f.LinkId, &f.OrderID, &f.Symbol, &f.Strategy, &f.Qty, &f.Price, &f.Fee, &f.Time); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		fills = append(fills, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+	return fills, nil
+}
+
+// InsertPositionSnapshots сохраняет состояние позиций одной транзакцией
+func (s *SQLiteStore) InsertPositionSnapshots(snapshots []*PositionSnapshot) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения позиций: %w", err)
+	}
+	defer tx.Rollback()
+	for _, p := range snapshots {
+		_, err := tx.Exec(`
+		INSERT INTO position_snapshots (symbol, qty, avgPrice, markPrice, realized, unrealized, fees, time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			p.Symbol, p.Qty, p.AvgPrice, p.MarkPrice, p.Realized, p.Unrealized, p.Fees, p.Time,
+		)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения позиций: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка сохранения позиций: %w", err)
+	}
+	return nil
+}
+
+// StartStrategyRun сохраняет запуск стратегии и возвращает его ID
+func (s *SQLiteStore) StartStrategyRun(strategy, params string, startedAt int64) (int64, error) {
+	res, err := s.db.Exec(`INSERT INTO strategy_runs (strategy, params, startedAt) VALUES (?, ?, ?)`,
+		strategy, params, startedAt)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка сохранения запуска стратегии: %w", err)
+	}
+	return res.LastInsertId()
+}
+
+// StopStrategyRun отмечает остановку стратегии
+func (s *SQLiteStore) StopStrategyRun(id int64, stoppedAt int64, reason string) error {
+	_, err := s.db.Exec(`UPDATE strategy_runs SET stoppedAt = ?, reason = ? WHERE id = ?`, stoppedAt, reason, id)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения остановки стратегии: %w", err)
+	}
+	return nil
+}
+
+// GetStrategyRuns возвращает запуски стратегии strategy, начиная с последнего
+// Пустое strategy возвращает запуски всех стратегий
+func (s *SQLiteStore) GetStrategyRuns(strategy string, limit int) ([]*StrategyRun, error) {
+	rows, err := s.db.Query(`
+	SELECT id, strategy, params, startedAt, stoppedAt, reason
+	FROM strategy_runs WHERE ? = '' OR strategy = ?
+	ORDER BY startedAt DESC, id DESC LIMIT ?`, strategy, strategy, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+	var runs []*StrategyRun
+	for rows.Next() {
+		r := new(StrategyRun)
+		var stoppedAt sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.Strategy, &r.Params, &r.StartedAt, &stoppedAt, &r.Reason); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		r.StoppedAt = stoppedAt.Int64
+		runs = append(runs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+	return runs, nil
+}
+
+// InsertEquitySnapshot сохраняет состояние счета, повторная запись за то же время заменяет прежнюю
+func (s *SQLiteStore) InsertEquitySnapshot(e *EquitySnapshot) error {
+	_, err := s.db.Exec(`
+	INSERT OR REPLACE INTO equity_snapshots (time, realized, unrealized, fees, equity)
+	VALUES (?, ?, ?, ?, ?)`,
+		e.Time, e.Realized, e.Unrealized, e.Fees, e.Equity,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения состояния счета: %w", err)
+	}
+	return nil
+}
+
+// GetEquitySnapshots возвращает состояния счета не раньше fromMs (мс) по возрастанию времени
+func (s *SQLiteStore) GetEquitySnapshots(fromMs int64) ([]*EquitySnapshot, error) {
+	rows, err := s.db.Query(`
+	SELECT time, realized, unrealized, fees, equity
+	FROM equity_snapshots WHERE time >= ? ORDER BY time ASC`, fromMs)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer rows.Close()
+	var snapshots []*EquitySnapshot
+	for rows.Next() {
+		e := new(EquitySnapshot)
+		if err := rows.Scan(&e.Time, &e.Realized, &e.Unrealized, &e.Fees, &e.Equity); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования строки: %w", err)
+		}
+		snapshots = append(snapshots, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по результатам: %w", err)
+	}
+	return snapshots, nil
+}
+
+// InsertRiskEvent сохраняет срабатывание ограничения риска
+func (s *SQLiteStore) InsertRiskEvent(e *RiskEvent) error {
+	_, err := s.db.Exec(`
+	INSERT INTO risk_events (kind, symbol, strategy, value, threshold, message, time)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		e.Kind, e.Symbol, e.Strategy, e.Value, e.Threshold, e.Message, e.Time,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения события риска: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package db
+
+import "goTradingBot/db"
+
+// migrations схема базы данных ордеров. Примененные миграции не меняются,
+// изменения добавляются в конец списка со следующей версией
+var migrations = []db.Migration{
+	{
+		Version: 1,
+		Name:    "orders",
+		// IF NOT EXISTS: таблица уже существует в базах, созданных до появления миграций
+		Up: `
+CREATE TABLE IF NOT EXISTS orders (
+    linkId TEXT PRIMARY KEY,
+    tag TEXT NOT NULL,
+    id TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    qty REAL NOT NULL,
+    price REAL,
+    avgPrice REAL NOT NULL,
+    execQty REAL NOT NULL,
+    execValue REAL NOT NULL,
+    fee REAL NOT NULL,
+    isClosed INTEGER NOT NULL CHECK (isClosed IN (0, 1)),
+    createdAt INTEGER NOT NULL,
+    updatedAt INTEGER NOT NULL
+);
+`,
+	},
+	{
+		Version: 2,
+		Name:    "orders indexes",
+		Up: `
+CREATE INDEX IF NOT EXISTS idx_orders_created ON orders(createdAt, linkId);
+CREATE INDEX IF NOT EXISTS idx_orders_symbol_created ON orders(symbol, createdAt);
+CREATE INDEX IF NOT EXISTS idx_orders_updated ON orders(updatedAt);
+`,
+	},
+	{
+		Version: 3,
+		Name:    "fills",
+		Up: `
+CREATE TABLE fills (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    linkId TEXT NOT NULL,
+    orderId TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    strategy TEXT NOT NULL,
+    qty REAL NOT NULL,
+    price REAL NOT NULL,
+    fee REAL NOT NULL,
+    time INTEGER NOT NULL
+);
+CREATE INDEX idx_fills_symbol_time ON fills(symbol, time);
+CREATE INDEX idx_fills_link ON fills(linkId);
+`,
+	},
+	{
+		Version: 4,
+		Name:    "position snapshots",
+		Up: `
+CREATE TABLE position_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol TEXT NOT NULL,
+    qty REAL NOT NULL,
+    avgPrice REAL NOT NULL,
+    markPrice REAL NOT NULL,
+    realized REAL NOT NULL,
+    unrealized REAL NOT NULL,
+    fees REAL NOT NULL,
+    time INTEGER NOT NULL
+);
+CREATE INDEX idx_position_snapshots_symbol_time ON position_snapshots(symbol, time);
+`,
+	},
+	{
+		Version: 5,
+		Name:    "strategy runs",
+		Up: `
+CREATE TABLE strategy_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    strategy TEXT NOT NULL,
+    params TEXT NOT NULL,
+    startedAt INTEGER NOT NULL,
+    stoppedAt INTEGER,
+    reason TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX idx_strategy_runs_strategy ON strategy_runs(strategy, startedAt);
+`,
+	},
+	{
+		Version: 6,
+		Name:    "equity snapshots",
+		Up: `
+CREATE TABLE equity_snapshots (
+    time INTEGER PRIMARY KEY,
+    realized REAL NOT NULL,
+    unrealized REAL NOT NULL,
+    fees REAL NOT NULL,
+    equity REAL NOT NULL
+);
+`,
+	},
+	{
+		Version: 7,
+		Name:    "risk events",
+		Up: `
+CREATE TABLE risk_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    strategy TEXT NOT NULL,
+    value REAL NOT NULL,
+    threshold REAL NOT NULL,
+    message TEXT NOT NULL,
+    time INTEGER NOT NULL
+);
+CREATE INDEX idx_risk_events_time ON risk_events(time);
+`,
+	},
+}
@@ -0,0 +1,37 @@
+package db
+
+import "goTradingBot/trading/types"
+
+// OrderStore хранилище ордеров и торговой истории бота
+// Реализации: SQLiteStore для работы и MemoryStore для тестов и бэктестов
+type OrderStore interface {
+	// InsertOrderRequest сохраняет заявку, существующая заявка с тем же linkId заменяется
+	InsertOrderRequest(r *types.OrderRequest) error
+	// UpdateOrderID обновляет ID ордера на бирже
+	UpdateOrderID(r *types.OrderRequest) error
+	// UpdateOrder обновляет все поля ордера
+	UpdateOrder(r *types.OrderRequest) error
+	// GetFilledOrders возвращает закрытые ордера с исполнением, обновленные не раньше fromMs
+	GetFilledOrders(fromMs int64) ([]*types.Order, error)
+	// QueryOrders возвращает страницу ордеров по фильтру от новых к старым
+	QueryOrders(q *OrderQuery) (*OrderPage, error)
+	// EachOrder обходит ордера по фильтру от старых к новым
+	EachOrder(f *OrderFilter, fn func(r *types.OrderRequest) error) error
+
+	InsertFill(f *Fill) error
+	GetFills(fromMs int64) ([]*Fill, error)
+	InsertPositionSnapshots(snapshots []*PositionSnapshot) error
+	StartStrategyRun(strategy, params string, startedAt int64) (int64, error)
+	StopStrategyRun(id int64, stoppedAt int64, reason string) error
+	GetStrategyRuns(strategy string, limit int) ([]*StrategyRun, error)
+	InsertEquitySnapshot(e *EquitySnapshot) error
+	GetEquitySnapshots(fromMs int64) ([]*EquitySnapshot, error)
+	InsertRiskEvent(e *RiskEvent) error
+
+	Close() error
+}
+
+var (
+	_ OrderStore = (*SQLiteStore)(nil)
+	_ OrderStore = (*MemoryStore)(nil)
+)
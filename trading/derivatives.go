@@ -0,0 +1,246 @@
+package trading
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	orderdb "goTradingBot/trading/db"
+	"goTradingBot/trading/notify"
+	"goTradingBot/trading/types"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Liquidation удаленность позиции на бирже от цены ликвидации
+type Liquidation struct {
+	Symbol    string  `json:"symbol"`
+	Qty       float64 `json:"qty"`
+	MarkPrice float64 `json:"markPrice"`
+	LiqPrice  float64 `json:"liqPrice"`
+	Leverage  float64 `json:"leverage"`
+	Distance  float64 `json:"distance"` // |MarkPrice - LiqPrice| / MarkPrice
+}
+
+// derivatives возвращает клиент бессрочных контрактов, если торговый клиент его поддерживает
+func (b *TradingBot) derivatives() (types.DerivativesClient, bool) {
+	dc, ok := b.tradingClient.(types.DerivativesClient)
+	return dc, ok
+}
+
+// setMarginMode устанавливает режим маржи из конфигурации
+func (b *TradingBot) setMarginMode() {
+	if b.marginMode == "" {
+		return
+	}
+	dc, ok := b.derivatives()
+	if !ok {
+		b.logger.Log(slog.LevelWarn, "margin mode is not supported by trading client", "marginMode", b.marginMode)
+		return
+	}
+	var err error
+	switch b.marginMode {
+	case "isolated", "cross":
+		err = dc.SetMarginMode(b.marginMode == "isolated")
+	default:
+		err = fmt.Errorf("неизвестный режим маржи %q", b.marginMode)
+	}
+	if err != nil {
+		b.logger.Log(slog.LevelError, "setting margin mode", "marginMode", b.marginMode, "error", err)
+		return
+	}
+	b.logger.Log(slog.LevelInfo, "margin mode is set", "marginMode", b.marginMode)
+}
+
+// setLeverage устанавливает кредитное плечо инструмента стратегии
+func (b *TradingBot) setLeverage(entry *strategyEntry) {
+	s, ok := entry.strategy.(types.LeveragedStrategy)
+	if !ok || s.Leverage() <= 0 {
+		return
+	}
+	dc, ok := b.derivatives()
+	if !ok {
+		b.logger.Log(slog.LevelWarn, "leverage is not supported by trading client", "strategy", entry.name)
+		return
+	}
+	if err := dc.SetLeverage(s.Symbol(), s.Leverage()); err != nil {
+		b.logger.Log(slog.LevelError, "setting leverage", "strategy", entry.name, "symbol", s.Symbol(), "error", err)
+		return
+	}
+	b.logger.Log(slog.LevelInfo, "leverage is set", "strategy", entry.name, "symbol", s.Symbol(), "leverage", s.Leverage())
+}
+
+// setInstrument учитывает категорию инструмента стратегии в позициях бота
+func (b *TradingBot) setInstrument(entry *strategyEntry) {
+	s, ok := entry.strategy.(types.MarketStrategy)
+	if !ok {
+		return
+	}
+	info, err := b.subData.GetInstrumentInfo(s.Symbol())
+	if err != nil {
+		b.logger.Log(slog.LevelError, "getting instrument info", "strategy", entry.name, "symbol", s.Symbol(), "error", err)
+		return
+	}
+	if info.IsInverse() {
+		b.positions.setInverse(s.Symbol())
+	}
+}
+
+// Liquidations возвращает удаленность открытых позиций на бирже от цены ликвидации
+// Позиции без цены ликвидации не включаются
+func (b *TradingBot) Liquidations() ([]Liquidation, error) {
+	dc, ok := b.derivatives()
+	if !ok {
+		return nil, fmt.Errorf("Liquidations: торговый клиент не поддерживает позиции по контрактам")
+	}
+	data, err := dc.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("Liquidations: %w", err)
+	}
+	var positions []types.ExchangePosition
+	if err := json.Unmarshal(data, &positions); err != nil {
+		return nil, fmt.Errorf("Liquidations: %w", err)
+	}
+	var res []Liquidation
+	for _, p := range positions {
+		if p.LiqPrice <= 0 || p.MarkPrice <= 0 {
+			continue
+		}
+		res = append(res, Liquidation{
+			Symbol:    p.Symbol,
+			Qty:       p.Qty,
+			MarkPrice: p.MarkPrice,
+			LiqPrice:  p.LiqPrice,
+			Leverage:  p.Leverage,
+			Distance:  math.Abs(p.MarkPrice-p.LiqPrice) / p.MarkPrice,
+		})
+	}
+	return res, nil
+}
+
+// WatchLiquidation с периодом interval проверяет удаленность позиций от ликвидации
+// и уведомляет, когда она становится меньше minDistance. Повторное уведомление по символу
+// отправляется после того, как удаленность восстановится
+func (b *TradingBot) WatchLiquidation(ctx context.Context, interval time.Duration, minDistance float64) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	alerted := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		liquidations, err := b.Liquidations()
+		if err != nil {
+			b.logger.Log(slog.LevelError, "checking liquidation distance", "error", err)
+			continue
+		}
+		near := make(map[string]bool)
+		for _, l := range liquidations {
+			if l.Distance >= minDistance {
+				continue
+			}
+			near[l.Symbol] = true
+			if alerted[l.Symbol] {
+				continue
+			}
+			b.reportLiquidationRisk(l, minDistance)
+		}
+		alerted = near
+	}
+}
+
+func (b *TradingBot) reportLiquidationRisk(l Liquidation, minDistance float64) {
+	message := fmt.Sprintf("до ликвидации %.2f%%: цена %g, ликвидация %g", l.Distance*100, l.MarkPrice, l.LiqPrice)
+	b.logger.Log(slog.LevelWarn, "liquidation is near", "liquidation", l)
+	event := &orderdb.RiskEvent{
+		Kind:      "liquidationDistance",
+		Symbol:    l.Symbol,
+		Value:     l.Distance,
+		Threshold: minDistance,
+		Message:   message,
+		Time:      time.Now().UnixMilli(),
+	}
+	b.persist("insert risk event", l.Symbol, func() error { return b.store.InsertRiskEvent(event) })
+	b.notifier.Notify(&notify.Event{
+		Kind:    notify.RiskLimit,
+		Symbol:  l.Symbol,
+		Qty:     l.Qty,
+		Price:   l.MarkPrice,
+		Message: message,
+	})
+}
+
+// WatchFunding с периодом interval учитывает в PnL выплаты финансирования
+// Выплата рассчитывается по размеру позиции в момент начисления и последней цене: long платит
+// Qty * Price * Rate при положительной ставке (Qty * Rate для обратного контракта). Учитываются начисления после запуска бота,
+// в том числе по позициям, закрытым после начисления
+func (b *TradingBot) WatchFunding(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := b.applyFunding(); err != nil {
+			b.logger.Log(slog.LevelError, "applying funding", "error", err)
+		}
+	}
+}
+
+// applyFunding учитывает новые начисления финансирования по позициям, открытым в момент начисления
+func (b *TradingBot) applyFunding() error {
+	dc, ok := b.derivatives()
+	if !ok {
+		return fmt.Errorf("applyFunding: торговый клиент не поддерживает финансирование")
+	}
+	for _, p := range b.positions.snapshot() {
+		from := b.startedAt.UnixMilli()
+		if v, ok := b.fundingFrom.Load(p.Symbol); ok {
+			from = v.(int64) + 1
+		}
+		if p.Qty == 0 && !b.positions.changedSince(p.Symbol, from) {
+			continue
+		}
+		data, err := dc.GetFundingRates(p.Symbol, from)
+		if err != nil {
+			return fmt.Errorf("applyFunding: %s: %w", p.Symbol, err)
+		}
+		var rates []types.FundingRate
+		if err := json.Unmarshal(data, &rates); err != nil {
+			return fmt.Errorf("applyFunding: %s: %w", p.Symbol, err)
+		}
+		if len(rates) == 0 {
+			continue
+		}
+		price, err := b.lastPrice(p.Symbol)
+		if err != nil {
+			price = p.AvgPrice
+		}
+		var total float64
+		var payments int
+		for _, r := range rates {
+			qty := b.positions.qtyAt(p.Symbol, r.Time)
+			if qty == 0 {
+				continue
+			}
+			held := Position{Qty: qty, inverse: p.inverse}
+			amount := -math.Copysign(held.notional(price), qty) * r.Rate
+			b.positions.addFunding(p.Symbol, amount, r.Time)
+			total += amount
+			payments++
+		}
+		last := rates[len(rates)-1].Time
+		b.fundingFrom.Store(p.Symbol, last)
+		b.positions.pruneHistory(p.Symbol, last)
+		if payments > 0 {
+			b.logger.Log(slog.LevelInfo, "funding applied", "symbol", p.Symbol, "amount", total, "payments", payments)
+		}
+	}
+	return nil
+}
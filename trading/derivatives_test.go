@@ -0,0 +1,175 @@
+package trading
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"goTradingBot/cdl"
+	orderdb "goTradingBot/trading/db"
+	"goTradingBot/trading/notify"
+	"goTradingBot/trading/types"
+	"goTradingBot/utils/slogx"
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+)
+
+// fakeExchange торговый клиент и поставщик данных бессрочных контрактов для тестов
+type fakeExchange struct {
+	price     float64
+	positions []types.ExchangePosition
+	rates     []types.FundingRate
+}
+
+func (f *fakeExchange) PlaceOrder(string, float64, *float64) (string, error) { return "", nil }
+func (f *fakeExchange) CancelOrder(string, string) (string, error)           { return "", nil }
+func (f *fakeExchange) GetOrder(string) ([]byte, error)                      { return nil, nil }
+func (f *fakeExchange) SetMarginMode(bool) error                             { return nil }
+func (f *fakeExchange) SetLeverage(string, float64) error                    { return nil }
+func (f *fakeExchange) GetPositions() ([]byte, error)                        { return json.Marshal(f.positions) }
+
+func (f *fakeExchange) GetFundingRates(symbol string, from int64) ([]byte, error) {
+	var res []types.FundingRate
+	for _, r := range f.rates {
+		if r.Symbol == symbol && r.Time >= from {
+			res = append(res, r)
+		}
+	}
+	return json.Marshal(res)
+}
+
+func (f *fakeExchange) GetCandles(string, cdl.Interval, int) ([]cdl.Candle, error) {
+	return []cdl.Candle{{C: f.price}}, nil
+}
+
+func (f *fakeExchange) CandleStream(context.Context, string, cdl.Interval) (<-chan *cdl.CandleStreamData, error) {
+	return nil, errors.New("нет потока")
+}
+
+func (f *fakeExchange) GetInstrumentInfo(string) ([]byte, error) { return nil, nil }
+
+func newTestBot(ex *fakeExchange) *TradingBot {
+	strategysCtx, cancelStrategys := context.WithCancel(context.Background())
+	return &TradingBot{
+		ctx:             context.Background(),
+		ch:              make(chan *types.OrderRequest, 8),
+		strategysCtx:    strategysCtx,
+		cancelStrategys: cancelStrategys,
+		tradingClient:   ex,
+		dataProvider:    ex,
+		subData:         types.NewSubData(context.Background(), ex, 10),
+		logger:          slogx.NewAsyncSlog(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil))),
+		positions:       newPositionBook(),
+		store:           orderdb.NewMemoryStore(),
+		storeRetries:    1,
+		startedAt:       time.UnixMilli(1000),
+	}
+}
+
+func TestApplyFunding(t *testing.T) {
+	ex := &fakeExchange{price: 100, rates: []types.FundingRate{
+		{Symbol: "BTCUSDT", Rate: 0.001, Time: 500}, // до запуска бота
+		{Symbol: "BTCUSDT", Rate: 0.001, Time: 2000},
+		{Symbol: "BTCUSDT", Rate: -0.0005, Time: 3000},
+	}}
+	b := newTestBot(ex)
+	b.positions.apply(&types.Order{Symbol: "BTCUSDT", ExecQty: 2, AvgPrice: 100})
+
+	if err := b.applyFunding(); err != nil {
+		t.Fatal(err)
+	}
+	// long 2 по 100: платит 0.2, получает 0.1
+	if f := b.PnL()[0].Funding; math.Abs(f+0.1) > 1e-9 {
+		t.Errorf("финансирование: %v", f)
+	}
+	// повторный запрос не учитывает начисления дважды
+	if err := b.applyFunding(); err != nil {
+		t.Fatal(err)
+	}
+	pnl := b.PnL()[0]
+	if math.Abs(pnl.Funding+0.1) > 1e-9 || math.Abs(pnl.Net-pnl.Funding) > 1e-9 {
+		t.Errorf("повторный учет: %+v", pnl)
+	}
+
+	c := b.positions.apply(&types.Order{Symbol: "BTCUSDT", ExecQty: -2, AvgPrice: 110})
+	if c.closed == nil || math.Abs(c.closed.Funding+0.1) > 1e-9 {
+		t.Errorf("финансирование сделки: %+v", c.closed)
+	}
+}
+
+func TestApplyFundingHistory(t *testing.T) {
+	ex := &fakeExchange{price: 100}
+	b := newTestBot(ex)
+	// accrue добавляет начисление в момент at
+	accrue := func(at int64) {
+		ex.rates = append(ex.rates, types.FundingRate{Symbol: "BTCUSDT", Rate: 0.001, Time: at})
+	}
+	fill := func(qty float64, at int64) positionChange {
+		return b.positions.apply(&types.Order{Symbol: "BTCUSDT", ExecQty: qty, AvgPrice: 100, UpdatedAt: at})
+	}
+	funding := func() float64 {
+		if err := b.applyFunding(); err != nil {
+			t.Fatal(err)
+		}
+		return b.PnL()[0].Funding
+	}
+
+	// открыта после начисления 2000, закрыта между проверками после начисления 3000: платит только 3000
+	accrue(2000)
+	fill(1, 2500)
+	accrue(3000)
+	fill(-1, 3500)
+	if f := funding(); math.Abs(f+0.1) > 1e-9 {
+		t.Errorf("открытие после начисления и закрытие до проверки: %v", f)
+	}
+
+	// повторное открытие после периода без позиции: начисление 4000 не учитывается, 5000 - по размеру 2
+	accrue(4000)
+	if f := funding(); math.Abs(f+0.1) > 1e-9 {
+		t.Errorf("период без позиции: %v", f)
+	}
+	fill(2, 4500)
+	accrue(5000)
+	if f := funding(); math.Abs(f+0.3) > 1e-9 {
+		t.Errorf("повторное открытие: %v", f)
+	}
+	if c := fill(-2, 6000); c.closed == nil || math.Abs(c.closed.Funding+0.2) > 1e-9 {
+		t.Errorf("финансирование повторной сделки: %+v", c.closed)
+	}
+	if f := funding(); math.Abs(f+0.3) > 1e-9 {
+		t.Errorf("повторный учет: %v", f)
+	}
+}
+
+func TestLiquidations(t *testing.T) {
+	ex := &fakeExchange{positions: []types.ExchangePosition{
+		{Symbol: "BTCUSDT", Qty: 1, MarkPrice: 100, LiqPrice: 95, Leverage: 10},
+		{Symbol: "ETHUSDT", Qty: -1, MarkPrice: 50, LiqPrice: 0},
+	}}
+	b := newTestBot(ex)
+	notified := make(chan *notify.Event, 1)
+	b.notifier = notify.NewNotifier(t.Context(), notify.WithSink(notify.SinkFunc(func(_ context.Context, e *notify.Event) error {
+		notified <- e
+		return nil
+	}), notify.RiskLimit))
+	res, err := b.Liquidations()
+	if err != nil || len(res) != 1 || math.Abs(res[0].Distance-0.05) > 1e-9 {
+		t.Fatalf("Liquidations: %+v %v", res, err)
+	}
+
+	b.reportLiquidationRisk(res[0], 0.1)
+	events := b.store.(*orderdb.MemoryStore).RiskEvents()
+	if len(events) != 1 || events[0].Kind != "liquidationDistance" || events[0].Symbol != "BTCUSDT" {
+		t.Errorf("событие риска: %+v", events)
+	}
+	select {
+	case e := <-notified:
+		if e.Symbol != "BTCUSDT" || e.Price != 100 {
+			t.Errorf("уведомление: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Error("нет уведомления об ограничении риска")
+	}
+}
@@ -0,0 +1,200 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"goTradingBot/trading/types"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// StrategyState состояние стратегии в боте
+type StrategyState string
+
+const (
+	StrategyRunning StrategyState = "running" // стратегия работает и отправляет заявки
+	StrategyPaused  StrategyState = "paused"  // стратегия работает, ее заявки отбрасываются
+	StrategyStopped StrategyState = "stopped" // контекст стратегии отменен, ее можно запустить снова
+)
+
+// removeGrace время, в течение которого передаются закрывающие заявки удаленной стратегии
+const removeGrace = 10 * time.Second
+
+// strategyStatus возвращает состояние стратегии, вызывается под b.mu
+func (b *TradingBot) strategyStatus(entry *strategyEntry) StrategyStatus {
+	status := StrategyStatus{Name: entry.name, State: StrategyRunning, Paused: entry.paused.Load()}
+	switch {
+	case entry.stopped.Load():
+		status.State = StrategyStopped
+	case status.Paused:
+		status.State = StrategyPaused
+	}
+	if _, ok := entry.strategy.(types.AllocatedStrategy); ok && b.portfolio != nil {
+		budget := b.portfolio.Budget(entry.name)
+		status.Budget = &budget
+	}
+	if ps, ok := entry.strategy.(types.ParameterizedStrategy); ok {
+		status.Params = ps.Params()
+	}
+	return status
+}
+
+// dropReason возвращает причину, по которой заявка стратегии не передается боту, или пустую строку
+func (b *TradingBot) dropReason(entry *strategyEntry, req *types.OrderRequest) string {
+	switch {
+	case b.strategysCtx.Err() != nil:
+		// бот остановлен: закрывающие заявки передаются всегда
+		return ""
+	case entry.stopped.Load():
+		if entry.flatten.Load() {
+			return ""
+		}
+		return "stopped"
+	case entry.paused.Load() && !req.Flatten:
+		// закрытие позиции по расписанию или смене режима выполняется и на паузе
+		return "paused"
+	}
+	return ""
+}
+
+// launchStrategy запускает стратегию с новым контекстом, производным от контекста стратегий бота
+func (b *TradingBot) launchStrategy(entry *strategyEntry) error {
+	ctx, cancel := context.WithCancel(b.strategysCtx)
+	b.mu.Lock()
+	entry.cancel = cancel
+	b.mu.Unlock()
+	entry.flatten.Store(false)
+	entry.stopped.Store(false)
+
+	b.startStrategyRun(entry)
+	entry.strategy.Init(ctx, b.subData, entry.ch)
+	if err := entry.strategy.Go(); err != nil {
+		entry.stopped.Store(true)
+		cancel()
+		b.stopStrategyRun(entry, "error")
+		return err
+	}
+	return nil
+}
+
+// Strategy возвращает состояние стратегии name
+func (b *TradingBot) Strategy(name string) (StrategyStatus, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry := b.findStrategy(name)
+	if entry == nil {
+		return StrategyStatus{}, fmt.Errorf("TradingBot: стратегия %q не найдена", name)
+	}
+	return b.strategyStatus(entry), nil
+}
+
+// StartStrategy повторно запускает остановленную стратегию name
+// Стратегия получает новый контекст через Init, ее позиция сохраняется
+func (b *TradingBot) StartStrategy(name string) error {
+	if b.killed.Load() {
+		return fmt.Errorf("TradingBot: бот остановлен командой kill")
+	}
+	entry, err := b.lookupStrategy(name)
+	if err != nil {
+		return err
+	}
+	if !entry.stopped.CompareAndSwap(true, false) {
+		return fmt.Errorf("TradingBot: стратегия %q уже запущена", entry.name)
+	}
+	entry.paused.Store(false)
+	b.setLeverage(entry)
+	if err := b.launchStrategy(entry); err != nil {
+		b.logger.Log(slog.LevelError, "launching strategy", "strategy", entry.name, "error", err)
+		return fmt.Errorf("TradingBot: %w", err)
+	}
+	b.logger.Log(slog.LevelWarn, "strategy started", "strategy", entry.name)
+	return nil
+}
+
+// StopStrategy останавливает стратегию name, отменяя ее контекст
+// flatten - передать боту заявку, которой стратегия закрывает позицию при остановке,
+// иначе позиция остается открытой и учитывается стратегией при следующем запуске
+func (b *TradingBot) StopStrategy(name string, flatten bool) error {
+	entry, err := b.lookupStrategy(name)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	if !entry.stopped.CompareAndSwap(false, true) {
+		b.mu.Unlock()
+		return fmt.Errorf("TradingBot: стратегия %q уже остановлена", entry.name)
+	}
+	// до отмены контекста: закрывающая заявка отправляется после нее
+	entry.flatten.Store(flatten)
+	entry.cancel()
+	b.mu.Unlock()
+
+	b.stopStrategyRun(entry, "stop")
+	b.logger.Log(slog.LevelWarn, "strategy stopped", "strategy", entry.name, "flatten", flatten)
+	return nil
+}
+
+// RemoveStrategy останавливает стратегию name, если она работает, и удаляет ее из бота
+// flatten имеет тот же смысл, что и в StopStrategy. Стратегия исключается из портфеля и расписания
+func (b *TradingBot) RemoveStrategy(name string, flatten bool) error {
+	entry, err := b.lookupStrategy(name)
+	if err != nil {
+		return err
+	}
+	if !entry.stopped.Load() {
+		if err := b.StopStrategy(entry.name, flatten); err != nil {
+			return err
+		}
+	}
+	b.mu.Lock()
+	b.strategies = slices.DeleteFunc(b.strategies, func(e *strategyEntry) bool { return e == entry })
+	b.mu.Unlock()
+	close(entry.removed)
+	if b.portfolio != nil {
+		b.portfolio.Unregister(entry.name)
+	}
+	if b.scheduler != nil {
+		b.scheduler.Detach(entry.name)
+	}
+	b.tradeStats.Delete(entry.name)
+	b.logger.Log(slog.LevelWarn, "strategy removed", "strategy", entry.name)
+	return nil
+}
+
+// ReloadStrategy заменяет параметры стратегии name без остановки
+// Стратегия должна реализовывать types.ReloadableStrategy. Позиция стратегии сохраняется,
+// в хранилище открывается новый запуск с новыми параметрами
+func (b *TradingBot) ReloadStrategy(name string, params map[string]any) error {
+	entry, err := b.lookupStrategy(name)
+	if err != nil {
+		return err
+	}
+	rs, ok := entry.strategy.(types.ReloadableStrategy)
+	if !ok {
+		return fmt.Errorf("TradingBot: стратегия %q не поддерживает изменение параметров", entry.name)
+	}
+	if err := rs.Reload(params); err != nil {
+		return fmt.Errorf("TradingBot: %w", err)
+	}
+	b.allocate(entry)
+	if !entry.stopped.Load() {
+		b.setLeverage(entry)
+		b.stopStrategyRun(entry, "reload")
+		b.startStrategyRun(entry)
+	}
+	b.logger.Log(slog.LevelWarn, "strategy reloaded", "strategy", entry.name, "params", params)
+	return nil
+}
+
+func (b *TradingBot) lookupStrategy(name string) (*strategyEntry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry := b.findStrategy(name)
+	if entry == nil {
+		return nil, fmt.Errorf("TradingBot: стратегия %q не найдена", name)
+	}
+	return entry, nil
+}
@@ -0,0 +1,207 @@
+package trading
+
+import (
+	"context"
+	"goTradingBot/cdl"
+	"goTradingBot/trading/types"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeStrategy стратегия, закрывающая позицию рыночной заявкой при остановке
+type fakeStrategy struct {
+	ctx    context.Context
+	req    chan<- *types.OrderRequest
+	starts atomic.Int32
+	ratio  atomic.Value
+}
+
+func (s *fakeStrategy) Init(ctx context.Context, _ *types.SubData, req chan<- *types.OrderRequest) {
+	s.ctx, s.req = ctx, req
+}
+
+func (s *fakeStrategy) Go() error {
+	s.starts.Add(1)
+	ctx, req := s.ctx, s.req
+	go func() {
+		<-ctx.Done()
+		req <- &types.OrderRequest{LinkId: "close", Order: types.NewOrder("BTCUSDT", -1, nil)}
+	}()
+	return nil
+}
+
+func (s *fakeStrategy) Name() string { return "fake" }
+
+func (s *fakeStrategy) SetEntryFilter(types.EntryFilter) {}
+
+// Flatten закрывает позицию так же, как Strategy при закрытии по расписанию
+func (s *fakeStrategy) Flatten() {
+	select {
+	case <-s.ctx.Done():
+	case s.req <- &types.OrderRequest{LinkId: "flatten", Order: types.NewOrder("BTCUSDT", -1, nil), Flatten: true}:
+	}
+}
+
+func (s *fakeStrategy) Params() map[string]any {
+	return map[string]any{"longRatio": s.ratio.Load()}
+}
+
+func (s *fakeStrategy) Reload(params map[string]any) error {
+	s.ratio.Store(params["longRatio"])
+	return nil
+}
+
+func TestStrategyLifecycle(t *testing.T) {
+	b := newTestBot(&fakeExchange{})
+	s := &fakeStrategy{}
+	b.AddStrategys(s)
+
+	state := func() StrategyState {
+		status, err := b.Strategy("fake")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status.State
+	}
+	received := func() bool {
+		select {
+		case <-b.ch:
+			return true
+		case <-time.After(100 * time.Millisecond):
+			return false
+		}
+	}
+
+	if st := state(); st != StrategyRunning {
+		t.Fatalf("после запуска: %s", st)
+	}
+	if err := b.StopStrategy("fake", false); err != nil || state() != StrategyStopped {
+		t.Fatalf("stop: %v %s", err, state())
+	}
+	if received() {
+		t.Error("закрывающая заявка передана без flatten")
+	}
+	if err := b.StopStrategy("fake", false); err == nil {
+		t.Error("повторная остановка")
+	}
+
+	if err := b.StartStrategy("fake"); err != nil || state() != StrategyRunning || s.starts.Load() != 2 {
+		t.Fatalf("start: %v %s %d", err, state(), s.starts.Load())
+	}
+	if err := b.ReloadStrategy("fake", map[string]any{"longRatio": 0.3}); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := b.Strategy("fake"); status.Params["longRatio"] != 0.3 {
+		t.Errorf("reload: %+v", status.Params)
+	}
+
+	if err := b.StopStrategy("fake", true); err != nil {
+		t.Fatal(err)
+	}
+	if !received() {
+		t.Error("закрывающая заявка не передана с flatten")
+	}
+	if err := b.RemoveStrategy("fake", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Strategy("fake"); err == nil {
+		t.Error("стратегия не удалена")
+	}
+}
+
+func TestPausedStrategyFlatten(t *testing.T) {
+	b := newTestBot(&fakeExchange{})
+	s := &fakeStrategy{}
+	b.AddStrategys(s)
+	defer b.RemoveStrategy("fake", false)
+	if err := b.PauseStrategy("fake"); err != nil {
+		t.Fatal(err)
+	}
+
+	// обычная заявка приостановленной стратегии отбрасывается
+	s.req <- &types.OrderRequest{LinkId: "open", Order: types.NewOrder("BTCUSDT", 1, nil)}
+	select {
+	case req := <-b.ch:
+		t.Fatalf("заявка на паузе передана: %s", req.LinkId)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// момент закрытия по расписанию: планировщик вызывает Flatten
+	s.Flatten()
+	select {
+	case req := <-b.ch:
+		if req.LinkId != "flatten" {
+			t.Errorf("передана заявка %s", req.LinkId)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("закрывающая заявка на паузе не передана")
+	}
+}
+
+// marketStrategy стратегия с учетом позиции, закрывающие заявки считаются исполненными при отправке
+type marketStrategy struct {
+	fakeStrategy
+	mu       sync.Mutex
+	position float64
+}
+
+func (s *marketStrategy) Go() error {
+	ctx := s.ctx
+	go func() {
+		<-ctx.Done()
+		s.close()
+	}()
+	return nil
+}
+
+func (s *marketStrategy) Name() string           { return "market" }
+func (s *marketStrategy) Symbol() string         { return "BTCUSDT" }
+func (s *marketStrategy) Interval() cdl.Interval { return cdl.M5 }
+func (s *marketStrategy) Flatten()               { s.close() }
+
+func (s *marketStrategy) Position() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.position
+}
+
+func (s *marketStrategy) close() {
+	s.mu.Lock()
+	qty := -s.position
+	s.position = 0
+	s.mu.Unlock()
+	if qty != 0 {
+		s.req <- &types.OrderRequest{LinkId: "close", Order: types.NewOrder("BTCUSDT", qty, nil), Flatten: true}
+	}
+}
+
+func TestFlattenKill(t *testing.T) {
+	b := newTestBot(&fakeExchange{})
+	// позиция 1.5: 1 открыта стратегией, 0.5 вручную
+	b.positions.apply(&types.Order{Symbol: "BTCUSDT", ExecQty: 1.5, AvgPrice: 100})
+	s := &marketStrategy{position: 1}
+	b.AddStrategys(s)
+
+	linkId, err := b.Flatten("btcusdt")
+	if err != nil || linkId == "" {
+		t.Fatalf("Flatten: %q %v", linkId, err)
+	}
+	b.Kill()
+
+	net := 1.5
+	for {
+		select {
+		case req := <-b.ch:
+			net += req.Order.Qty
+			continue
+		case <-time.After(200 * time.Millisecond):
+		}
+		break
+	}
+	if math.Abs(net) > 1e-9 {
+		t.Errorf("позиция после flatten и kill: %v", net)
+	}
+}
@@ -0,0 +1,94 @@
+// Package notify формирует структурированные уведомления о работе торгового бота
+// и отправляет их в подключаемые приемники: Telegram, webhook и локальный файл
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kind тип уведомления
+type Kind string
+
+const (
+	OrderFilled     Kind = "orderFilled"     // ордер исполнен полностью или частично
+	PositionOpened  Kind = "positionOpened"  // открыта позиция
+	PositionClosed  Kind = "positionClosed"  // позиция закрыта, PnL - результат сделки
+	RiskLimit       Kind = "riskLimit"       // сработало ограничение риска
+	PortalDown      Kind = "portalDown"      // portal недоступен
+	PortalUp        Kind = "portalUp"        // portal снова доступен
+	StreamReconnect Kind = "streamReconnect" // переподключение потока данных
+	ReportReady     Kind = "report"          // периодический отчет
+)
+
+// Event уведомление
+type Event struct {
+	Kind     Kind      `json:"kind"`
+	Time     time.Time `json:"time"`
+	Symbol   string    `json:"symbol,omitempty"`
+	Strategy string    `json:"strategy,omitempty"`
+	Qty      float64   `json:"qty,omitempty"`   // количество, <0 для продажи
+	Price    float64   `json:"price,omitempty"` // средняя цена исполнения или входа
+	PnL      float64   `json:"pnl,omitempty"`   // результат сделки без учета комиссий
+	Fee      float64   `json:"fee,omitempty"`   // комиссии ордера или сделки
+	Message  string    `json:"message,omitempty"`
+	Report   *Report   `json:"report,omitempty"`
+}
+
+// Text возвращает текст уведомления для чтения человеком
+func (e *Event) Text() string {
+	switch e.Kind {
+	case OrderFilled:
+		return fmt.Sprintf("✅ ордер исполнен %s: %g по %g, комиссия %g%s", e.Symbol, e.Qty, e.Price, e.Fee, e.strategySuffix())
+	case PositionOpened:
+		side := "long"
+		if e.Qty < 0 {
+			side = "short"
+		}
+		return fmt.Sprintf("📈 открыта позиция %s %s: %g по %g%s", side, e.Symbol, e.Qty, e.Price, e.strategySuffix())
+	case PositionClosed:
+		return fmt.Sprintf("📉 закрыта позиция %s: PnL %.4f, комиссии %.4f, итого %.4f%s",
+			e.Symbol, e.PnL, e.Fee, e.PnL-e.Fee, e.strategySuffix())
+	case RiskLimit:
+		return fmt.Sprintf("⛔️ ограничение риска %s: %s%s", e.Symbol, e.Message, e.strategySuffix())
+	case PortalDown:
+		return "🔴 portal недоступен: " + e.Message
+	case PortalUp:
+		return "🟢 portal снова доступен"
+	case StreamReconnect:
+		return "🔄 переподключение потока " + e.Message
+	case ReportReady:
+		if e.Report != nil {
+			return e.Report.Text()
+		}
+	}
+	return fmt.Sprintf("%s: %s", e.Kind, e.Message)
+}
+
+func (e *Event) strategySuffix() string {
+	if e.Strategy == "" {
+		return ""
+	}
+	return " [" + e.Strategy + "]"
+}
+
+// Text возвращает текст отчета
+func (r *Report) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 отчет %s %s - %s\n", r.Period,
+		r.From.UTC().Format(time.DateTime), r.To.UTC().Format(time.DateTime))
+	fmt.Fprintf(&b, "PnL %.4f, комиссии %.4f, итого %.4f\n", r.PnL, r.Fees, r.PnL-r.Fees)
+	fmt.Fprintf(&b, "сделок %d, прибыльных %d (%.1f%%)\n", r.Trades, r.Wins, r.WinRate*100)
+	for _, s := range r.Strategies {
+		fmt.Fprintf(&b, "%s: PnL %.4f, комиссии %.4f, сделок %d, win rate %.1f%%\n",
+			s.Strategy, s.PnL, s.Fees, s.Trades, s.WinRate*100)
+	}
+	if len(r.Exposure) > 0 {
+		b.WriteString("открытые позиции:\n")
+		for _, e := range r.Exposure {
+			fmt.Fprintf(&b, "%s: %g (%.2f)\n", e.Symbol, e.Qty, e.Notional)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// Sink приемник уведомлений
+type Sink interface {
+	Send(ctx context.Context, e *Event) error
+}
+
+// SinkFunc функция-приемник уведомлений
+type SinkFunc func(ctx context.Context, e *Event) error
+
+func (f SinkFunc) Send(ctx context.Context, e *Event) error {
+	return f(ctx, e)
+}
+
+type sinkEntry struct {
+	sink  Sink
+	kinds []Kind
+}
+
+// Notifier рассылает уведомления приемникам в фоне
+// Методы безопасны для nil, что позволяет не проверять наличие уведомлений в вызывающем коде
+type Notifier struct {
+	ctx     context.Context
+	sinks   []sinkEntry
+	ch      chan *Event
+	logger  *slog.Logger
+	timeout time.Duration
+}
+
+// Option определяет тип функции для настройки Notifier
+type Option func(*Notifier)
+
+// WithSink добавляет приемник. Если kinds не пуст, приемник получает только эти типы уведомлений
+func WithSink(sink Sink, kinds ...Kind) Option {
+	return func(n *Notifier) {
+		n.sinks = append(n.sinks, sinkEntry{sink: sink, kinds: kinds})
+	}
+}
+
+// WithLogger устанавливает логгер ошибок отправки
+func WithLogger(logger *slog.Logger) Option {
+	return func(n *Notifier) {
+		n.logger = logger
+	}
+}
+
+// WithBufferSize устанавливает размер очереди уведомлений
+func WithBufferSize(size int) Option {
+	return func(n *Notifier) {
+		n.ch = make(chan *Event, size)
+	}
+}
+
+// WithSendTimeout устанавливает таймаут отправки одного уведомления в приемник
+func WithSendTimeout(timeout time.Duration) Option {
+	return func(n *Notifier) {
+		n.timeout = timeout
+	}
+}
+
+// NewNotifier создает Notifier, работающий до отмены ctx
+func NewNotifier(ctx context.Context, opts ...Option) *Notifier {
+	n := &Notifier{
+		ctx:     ctx,
+		ch:      make(chan *Event, 256),
+		logger:  slog.Default(),
+		timeout: 10 * time.Second,
+	}
+	for _, option := range opts {
+		option(n)
+	}
+	go n.run()
+	return n
+}
+
+// Notify ставит уведомление в очередь. При переполнении очереди уведомление отбрасывается
+func (n *Notifier) Notify(e *Event) {
+	if n == nil || e == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	select {
+	case n.ch <- e:
+	default:
+		n.logger.Warn("notification queue is full", "kind", e.Kind)
+	}
+}
+
+func (n *Notifier) run() {
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case e := <-n.ch:
+			n.dispatch(e)
+		}
+	}
+}
+
+func (n *Notifier) dispatch(e *Event) {
+	for _, entry := range n.sinks {
+		if len(entry.kinds) > 0 && !slices.Contains(entry.kinds, e.Kind) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(n.ctx, n.timeout)
+		if err := entry.sink.Send(ctx, e); err != nil {
+			n.logger.Error("sending notification", "kind", e.Kind, "error", err)
+		}
+		cancel()
+	}
+}
+
+// WatchHealth периодически вызывает check и уведомляет о переходах между доступностью и недоступностью
+// Используется для контроля portal: WatchHealth(ctx, time.Minute, portal.Ping)
+func (n *Notifier) WatchHealth(ctx context.Context, interval time.Duration, check func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	down := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := check()
+			switch {
+			case err != nil && !down:
+				down = true
+				n.Notify(&Event{Kind: PortalDown, Message: err.Error()})
+			case err == nil && down:
+				down = false
+				n.Notify(&Event{Kind: PortalUp})
+			}
+		}
+	}
+}
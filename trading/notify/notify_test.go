@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPeriodNext(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*3600)
+	cases := []struct {
+		period Period
+		t      time.Time
+		want   time.Time
+	}{
+		{Daily, time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC), time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{Daily, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{Daily, time.Date(2026, 12, 31, 23, 59, 0, 0, time.UTC), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// 19.10.2026 - понедельник
+		{Weekly, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC)},
+		{Weekly, time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC), time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC)},
+		{Weekly, time.Date(2026, 10, 21, 12, 0, 0, 0, time.UTC), time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC)},
+		{Weekly, time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		// понедельник 01:00 MSK - еще воскресенье по UTC
+		{Weekly, time.Date(2026, 10, 19, 1, 0, 0, 0, msk), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{Weekly, time.Date(2026, 12, 30, 8, 0, 0, 0, time.UTC), time.Date(2027, 1, 4, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		if got := c.period.next(c.t); !got.Equal(c.want) {
+			t.Errorf("%s.next(%s) = %s, ожидается %s", c.period, c.t, got, c.want)
+		}
+	}
+}
+
+func TestReporter(t *testing.T) {
+	r := NewReporter(func() []Exposure {
+		return []Exposure{{Symbol: "ETHUSDT", Qty: -1}, {Symbol: "BTCUSDT", Qty: 2}}
+	}, Daily, Weekly)
+	ctx := context.Background()
+	for _, e := range []*Event{
+		{Kind: OrderFilled, Strategy: "A", Fee: 1},
+		{Kind: PositionClosed, Strategy: "A", PnL: 10, Fee: 2}, // прибыльная после комиссий
+		{Kind: PositionClosed, Strategy: "A", PnL: 1, Fee: 2},  // убыточная после комиссий
+		{Kind: OrderFilled, Fee: 0.5},
+		{Kind: PositionClosed, Strategy: "B", PnL: -3},
+		{Kind: PortalDown, Strategy: "A", PnL: 100},
+	} {
+		r.Send(ctx, e)
+	}
+
+	// Snapshot не начинает новый период
+	snapshot := r.Snapshot(Daily)
+	report := r.Take(Daily)
+	if !slices.Equal(snapshot.Strategies, report.Strategies) || !snapshot.From.Equal(report.From) {
+		t.Errorf("снимок: %+v", snapshot)
+	}
+	want := []StrategyStats{
+		{Strategy: "A", PnL: 11, Fees: 1, Trades: 2, Wins: 1, WinRate: 0.5},
+		{Strategy: "B", PnL: -3, Trades: 1},
+		{Strategy: "manual", Fees: 0.5},
+	}
+	if !slices.Equal(report.Strategies, want) {
+		t.Errorf("стратегии: %+v", report.Strategies)
+	}
+	// комиссии учитываются по исполненным ордерам, комиссии сделки влияют только на прибыльность
+	if report.PnL != 8 || report.Fees != 1.5 || report.Trades != 3 || report.Wins != 1 || report.WinRate != 1.0/3 {
+		t.Errorf("итоги: %+v", report)
+	}
+	if len(report.Exposure) != 2 || report.Exposure[0].Symbol != "BTCUSDT" {
+		t.Errorf("открытые позиции: %+v", report.Exposure)
+	}
+
+	// Take начинает новый период только для своего периода
+	if next := r.Take(Daily); len(next.Strategies) != 0 || next.WinRate != 0 || !next.From.Equal(report.To) {
+		t.Errorf("новый период: %+v", next)
+	}
+	if weekly := r.Take(Weekly); weekly.Trades != 3 || weekly.PnL != 8 {
+		t.Errorf("недельный отчет: %+v", weekly)
+	}
+	if r.Take("monthly") != nil {
+		t.Error("неизвестный период")
+	}
+}
+
+func TestNotifierDispatch(t *testing.T) {
+	received := make(map[string][]Kind)
+	sink := func(name string) Sink {
+		return SinkFunc(func(_ context.Context, e *Event) error {
+			received[name] = append(received[name], e.Kind)
+			return nil
+		})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n := NewNotifier(ctx,
+		WithSink(sink("all")),
+		WithSink(sink("orders"), OrderFilled),
+		WithSink(sink("portal"), PortalDown, PortalUp),
+	)
+	kinds := []Kind{OrderFilled, PortalDown, RiskLimit, PortalUp}
+	for _, kind := range kinds {
+		n.dispatch(&Event{Kind: kind})
+	}
+	want := map[string][]Kind{
+		"all":    kinds,
+		"orders": {OrderFilled},
+		"portal": {PortalDown, PortalUp},
+	}
+	for name, w := range want {
+		if !slices.Equal(received[name], w) {
+			t.Errorf("%s получил %v, ожидается %v", name, received[name], w)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package notify
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Period период отчета
+type Period string
+
+const (
+	Daily  Period = "daily"  // с 00:00 UTC
+	Weekly Period = "weekly" // с понедельника 00:00 UTC
+)
+
+// next возвращает ближайшую границу периода после t
+func (p Period) next(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if p == Weekly {
+		daysToMonday := (8 - int(day.Weekday())) % 7
+		if daysToMonday == 0 {
+			daysToMonday = 7
+		}
+		return day.AddDate(0, 0, daysToMonday)
+	}
+	return day.AddDate(0, 0, 1)
+}
+
+// StrategyStats результат стратегии за период
+type StrategyStats struct {
+	Strategy string  `json:"strategy"`
+	PnL      float64 `json:"pnl"`  // результат закрытых сделок без учета комиссий
+	Fees     float64 `json:"fees"` // комиссии исполненных ордеров
+	Trades   int     `json:"trades"`
+	Wins     int     `json:"wins"` // сделки с положительным результатом после комиссий
+	WinRate  float64 `json:"winRate"`
+}
+
+// Exposure открытая позиция на момент отчета
+type Exposure struct {
+	Symbol   string  `json:"symbol"`
+	Qty      float64 `json:"qty"`
+	Notional float64 `json:"notional"` // стоимость позиции по последней цене
+}
+
+// Report отчет о результатах торговли за период
+type Report struct {
+	Period     Period          `json:"period"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	PnL        float64         `json:"pnl"`
+	Fees       float64         `json:"fees"`
+	Trades     int             `json:"trades"`
+	Wins       int             `json:"wins"`
+	WinRate    float64         `json:"winRate"`
+	Strategies []StrategyStats `json:"strategies"`
+	Exposure   []Exposure      `json:"exposure"`
+}
+
+type periodStats struct {
+	from  time.Time
+	stats map[string]*StrategyStats
+}
+
+// Reporter собирает статистику из уведомлений и формирует периодические отчеты
+// Подключается к Notifier как приемник: WithSink(reporter, OrderFilled, PositionClosed)
+type Reporter struct {
+	mu       sync.Mutex
+	periods  map[Period]*periodStats
+	exposure func() []Exposure
+}
+
+// NewReporter создает Reporter для периодов periods
+// exposure возвращает открытые позиции на момент отчета, может быть nil
+func NewReporter(exposure func() []Exposure, periods ...Period) *Reporter {
+	r := &Reporter{periods: make(map[Period]*periodStats), exposure: exposure}
+	now := time.Now()
+	for _, p := range periods {
+		r.periods[p] = &periodStats{from: now, stats: make(map[string]*StrategyStats)}
+	}
+	return r
+}
+
+// Send учитывает уведомление в статистике всех периодов
+func (r *Reporter) Send(_ context.Context, e *Event) error {
+	if e.Kind != OrderFilled && e.Kind != PositionClosed {
+		return nil
+	}
+	strategy := e.Strategy
+	if strategy == "" {
+		strategy = "manual"
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, ps := range r.periods {
+		s, ok := ps.stats[strategy]
+		if !ok {
+			s = &StrategyStats{Strategy: strategy}
+			ps.stats[strategy] = s
+		}
+		switch e.Kind {
+		case OrderFilled:
+			s.Fees += e.Fee
+		case PositionClosed:
+			s.PnL += e.PnL
+			s.Trades++
+			if e.PnL-e.Fee > 0 {
+				s.Wins++
+			}
+		}
+	}
+	return nil
+}
+
+// Take формирует отчет за период и начинает новый период
+func (r *Reporter) Take(period Period) *Report {
+	return r.report(period, true)
+}
+
+// Snapshot формирует отчет с начала текущего периода, не начиная новый период
+func (r *Reporter) Snapshot(period Period) *Report {
+	return r.report(period, false)
+}
+
+func (r *Reporter) report(period Period, reset bool) *Report {
+	now := time.Now()
+	r.mu.Lock()
+	ps, ok := r.periods[period]
+	if !ok {
+		r.mu.Unlock()
+		return nil
+	}
+	report := &Report{Period: period, From: ps.from, To: now}
+	for _, name := range slices.Sorted(maps.Keys(ps.stats)) {
+		s := *ps.stats[name]
+		if s.Trades > 0 {
+			s.WinRate = float64(s.Wins) / float64(s.Trades)
+		}
+		report.PnL += s.PnL
+		report.Fees += s.Fees
+		report.Trades += s.Trades
+		report.Wins += s.Wins
+		report.Strategies = append(report.Strategies, s)
+	}
+	if reset {
+		r.periods[period] = &periodStats{from: now, stats: make(map[string]*StrategyStats)}
+	}
+	r.mu.Unlock()
+
+	if report.Trades > 0 {
+		report.WinRate = float64(report.Wins) / float64(report.Trades)
+	}
+	if r.exposure != nil {
+		report.Exposure = r.exposure()
+		slices.SortFunc(report.Exposure, func(a, b Exposure) int {
+			return cmp.Compare(a.Symbol, b.Symbol)
+		})
+	}
+	return report
+}
+
+// Run отправляет отчеты через n на границах периодов до отмены ctx
+func (r *Reporter) Run(ctx context.Context, n *Notifier) {
+	r.mu.Lock()
+	periods := slices.Collect(maps.Keys(r.periods))
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range periods {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				timer := time.NewTimer(time.Until(p.next(time.Now())))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+					n.Notify(&Event{Kind: ReportReady, Report: r.Take(p)})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goTradingBot/external/telebot"
+	"goTradingBot/httpx"
+	"os"
+	"sync"
+)
+
+// TelegramSink отправляет текст уведомлений в чаты writeChatIDs бота
+type TelegramSink struct {
+	bot *telebot.Bot
+}
+
+func NewTelegramSink(bot *telebot.Bot) *TelegramSink {
+	return &TelegramSink{bot: bot}
+}
+
+func (s *TelegramSink) Send(_ context.Context, e *Event) error {
+	for _, part := range telebot.SplitText(e.Text(), telebot.MaxMessageLength) {
+		if _, err := s.bot.Write([]byte(s.bot.EscapeText(part))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WebhookSink отправляет уведомления в формате JSON методом POST
+type WebhookSink struct {
+	url    string
+	header map[string]string
+}
+
+// NewWebhookSink создает приемник webhook. header - дополнительные заголовки, например авторизация
+func NewWebhookSink(url string, header map[string]string) *WebhookSink {
+	return &WebhookSink{url: url, header: header}
+}
+
+func (s *WebhookSink) Send(ctx context.Context, e *Event) error {
+	payload := struct {
+		*Event
+		Text string `json:"text"`
+	}{e, e.Text()}
+	req := httpx.Post(s.url).
+		WithContext(ctx).
+		WithJsonData(payload).
+		AddHeader("Content-Type", "application/json")
+	for k, v := range s.header {
+		req = req.SetHeaderValue(k, v)
+	}
+	res := req.Do()
+	defer res.Close()
+	if err := res.Error(); err != nil {
+		return fmt.Errorf("WebhookSink: не удалось выполнить запрос: %w", err)
+	}
+	if !res.IsSuccess() {
+		return fmt.Errorf("WebhookSink: статус код ответа: %d", res.StatusCode())
+	}
+	return nil
+}
+
+// FileSink дописывает уведомления в файл построчно в формате JSON
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Send(_ context.Context, e *Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("FileSink: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("FileSink: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("FileSink: %w", err)
+	}
+	return nil
+}
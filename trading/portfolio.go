@@ -0,0 +1,93 @@
+package trading
+
+import (
+	"fmt"
+	"goTradingBot/trading/portfolio"
+	"goTradingBot/trading/sizing"
+	"goTradingBot/trading/types"
+	"sync"
+)
+
+// volatilityCandles количество свечей для оценки волатильности стратегии
+const volatilityCandles = 100
+
+// tradeStats результаты закрытых сделок стратегии
+type tradeStats struct {
+	mu     sync.Mutex
+	wins   int
+	losses int
+	profit float64 // сумма прибыльных сделок
+	loss   float64 // сумма убыточных сделок по модулю
+}
+
+// SetPortfolio подключает распределение капитала между стратегиями
+// Стратегии types.AllocatedStrategy получают бюджет из портфеля вместо фиксированного
+// Вызывается до добавления стратегий
+func (b *TradingBot) SetPortfolio(p *portfolio.Portfolio) {
+	b.portfolio = p
+}
+
+// allocate регистрирует стратегию в портфеле
+func (b *TradingBot) allocate(entry *strategyEntry) {
+	s, ok := entry.strategy.(types.AllocatedStrategy)
+	if !ok || b.portfolio == nil {
+		return
+	}
+	p, name := b.portfolio, entry.name
+	p.Register(name, s.Balance())
+	s.SetBudget(func() float64 { return p.Budget(name) })
+}
+
+// recordTrade учитывает результат закрытой сделки стратегии с учетом комиссий
+func (b *TradingBot) recordTrade(strategy string, net float64) {
+	v, _ := b.tradeStats.LoadOrStore(strategy, &tradeStats{})
+	ts := v.(*tradeStats)
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if net > 0 {
+		ts.wins++
+		ts.profit += net
+	} else {
+		ts.losses++
+		ts.loss -= net
+	}
+}
+
+// StrategyStats возвращает показатели стратегии для портфеля: волатильность инструмента
+// по последним свечам и результаты закрытых с момента запуска сделок
+func (b *TradingBot) StrategyStats(name string) (portfolio.Stats, error) {
+	b.mu.Lock()
+	entry := b.findStrategy(name)
+	b.mu.Unlock()
+	if entry == nil {
+		return portfolio.Stats{}, fmt.Errorf("StrategyStats: стратегия %q не найдена", name)
+	}
+
+	var stats portfolio.Stats
+	if v, ok := b.tradeStats.Load(entry.name); ok {
+		ts := v.(*tradeStats)
+		ts.mu.Lock()
+		stats.Trades = ts.wins + ts.losses
+		if stats.Trades > 0 {
+			stats.WinRate = float64(ts.wins) / float64(stats.Trades)
+		}
+		if ts.wins > 0 && ts.loss > 0 {
+			stats.Payoff = (ts.profit / float64(ts.wins)) / (ts.loss / float64(ts.losses))
+		}
+		ts.mu.Unlock()
+	}
+
+	if ms, ok := entry.strategy.(types.MarketStrategy); ok {
+		candles, err := b.subData.GetCandles(ms.Symbol(), ms.Interval(), volatilityCandles)
+		if err != nil {
+			return stats, fmt.Errorf("StrategyStats: %w", err)
+		}
+		closes := make([]float64, len(candles))
+		for i, c := range candles {
+			closes[i] = c.C
+		}
+		stats.Volatility = sizing.Volatility(closes)
+	}
+	return stats, nil
+}
@@ -0,0 +1,164 @@
+package portfolio
+
+import (
+	"fmt"
+	"math"
+)
+
+// Member стратегия портфеля и ее показатели на момент распределения
+type Member struct {
+	Name  string
+	Fixed float64 // фиксированный бюджет стратегии (для метода Fixed)
+	Stats Stats
+}
+
+// Stats показатели стратегии для распределения капитала
+type Stats struct {
+	Volatility float64 `json:"volatility"` // стандартное отклонение доходности инструмента за свечу, 0 - неизвестно
+	Trades     int     `json:"trades"`     // количество закрытых сделок
+	WinRate    float64 `json:"winRate"`    // доля прибыльных сделок
+	Payoff     float64 `json:"payoff"`     // отношение средней прибыли к среднему убытку, 0 - неизвестно
+}
+
+// Method способ распределения капитала между стратегиями
+// Allocate возвращает бюджеты стратегий в порядке members, сумма не превышает capital
+type Method interface {
+	Name() string
+	Allocate(capital float64, members []Member) []float64
+}
+
+type method struct {
+	name     string
+	allocate func(capital float64, members []Member) []float64
+}
+
+func (m *method) Name() string { return m.name }
+
+func (m *method) Allocate(capital float64, members []Member) []float64 {
+	if len(members) == 0 || capital <= 0 {
+		return make([]float64, len(members))
+	}
+	return m.allocate(capital, members)
+}
+
+// Fixed выделяет каждой стратегии ее фиксированный бюджет
+// Если капитала не хватает, бюджеты уменьшаются пропорционально
+func Fixed() Method {
+	return &method{name: "fixed", allocate: func(capital float64, members []Member) []float64 {
+		budgets := make([]float64, len(members))
+		var sum float64
+		for i, m := range members {
+			budgets[i] = max(m.Fixed, 0)
+			sum += budgets[i]
+		}
+		if sum > capital {
+			return scale(budgets, capital)
+		}
+		return budgets
+	}}
+}
+
+// EqualWeight делит капитал поровну
+func EqualWeight() Method {
+	return &method{name: "equal", allocate: func(capital float64, members []Member) []float64 {
+		budgets := make([]float64, len(members))
+		for i := range budgets {
+			budgets[i] = capital / float64(len(members))
+		}
+		return budgets
+	}}
+}
+
+// VolatilityParity делит капитал обратно пропорционально волатильности, чтобы риск
+// стратегий был одинаковым. Стратегии с неизвестной волатильностью получают средний вес
+func VolatilityParity() Method {
+	return &method{name: "volatility", allocate: func(capital float64, members []Member) []float64 {
+		weights := make([]float64, len(members))
+		var sum float64
+		var known int
+		for i, m := range members {
+			if m.Stats.Volatility > 0 {
+				weights[i] = 1 / m.Stats.Volatility
+				sum += weights[i]
+				known++
+			}
+		}
+		avg := 1.0
+		if known > 0 {
+			avg = sum / float64(known)
+		}
+		for i := range weights {
+			if weights[i] == 0 {
+				weights[i] = avg
+			}
+		}
+		return scale(weights, capital)
+	}}
+}
+
+// KellyCapped выделяет стратегии долю капитала по критерию Келли, не больше maxFraction
+// Пока у стратегии меньше minTrades закрытых сделок, ей выделяется равная доля,
+// также ограниченная maxFraction. Если сумма долей больше 1, доли уменьшаются пропорционально
+func KellyCapped(maxFraction float64, minTrades int) Method {
+	return &method{name: "kelly", allocate: func(capital float64, members []Member) []float64 {
+		fractions := make([]float64, len(members))
+		for i, m := range members {
+			f := 1 / float64(len(members))
+			if m.Stats.Trades >= minTrades {
+				f = Kelly(m.Stats.WinRate, m.Stats.Payoff)
+			}
+			fractions[i] = min(f, maxFraction)
+		}
+		var sum float64
+		for _, f := range fractions {
+			sum += f
+		}
+		for i := range fractions {
+			fractions[i] *= capital / max(sum, 1)
+		}
+		return fractions
+	}}
+}
+
+// ParseMethod возвращает способ распределения по имени: fixed, equal, volatility или kelly
+// Для kelly доля стратегии ограничена 0.25, критерий применяется после 20 сделок
+func ParseMethod(name string) (Method, error) {
+	switch name {
+	case "fixed":
+		return Fixed(), nil
+	case "equal":
+		return EqualWeight(), nil
+	case "volatility":
+		return VolatilityParity(), nil
+	case "kelly":
+		return KellyCapped(0.25, 20), nil
+	}
+	return nil, fmt.Errorf("ParseMethod: неизвестный способ распределения %q", name)
+}
+
+// Kelly возвращает долю капитала по критерию Келли f = p - (1-p)/b
+// Для стратегий без положительного матожидания возвращает 0, без убыточных сделок - 1
+func Kelly(winRate, payoff float64) float64 {
+	if winRate >= 1 {
+		return 1
+	}
+	if payoff <= 0 || math.IsNaN(payoff) {
+		return 0
+	}
+	return max(winRate-(1-winRate)/payoff, 0)
+}
+
+// scale пропорционально изменяет веса так, чтобы их сумма была равна total
+func scale(weights []float64, total float64) []float64 {
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+	if sum == 0 {
+		return weights
+	}
+	for i := range weights {
+		weights[i] *= total / sum
+	}
+	return weights
+}
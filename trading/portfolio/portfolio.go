@@ -0,0 +1,216 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// EquityFunc возвращает капитал кошелька, например bybit.TradingClientImpl.GetEquity
+type EquityFunc func() (float64, error)
+
+// StatsFunc возвращает показатели стратегии по имени
+type StatsFunc func(name string) (Stats, error)
+
+// Allocation бюджет стратегии после распределения
+type Allocation struct {
+	Name   string  `json:"name"`
+	Fixed  float64 `json:"fixed"`
+	Budget float64 `json:"budget"`
+	Stats  Stats   `json:"stats"`
+}
+
+// Snapshot состояние портфеля после последнего распределения
+type Snapshot struct {
+	Method      string       `json:"method"`
+	Equity      float64      `json:"equity"`  // капитал кошелька
+	Capital     float64      `json:"capital"` // капитал для распределения без резерва
+	RebalanceAt time.Time    `json:"rebalanceAt"`
+	Allocations []Allocation `json:"allocations"`
+}
+
+// Portfolio распределяет капитал кошелька между стратегиями и периодически
+// пересчитывает их бюджеты. До первого распределения бюджет стратегий равен 0
+type Portfolio struct {
+	equity   EquityFunc
+	stats    StatsFunc
+	method   Method
+	reserve  float64
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	members  []Member
+	budgets  map[string]float64
+	snapshot Snapshot
+}
+
+// Option определяет тип функции для настройки Portfolio
+type Option func(*Portfolio)
+
+// WithMethod устанавливает способ распределения, по умолчанию EqualWeight
+func WithMethod(m Method) Option {
+	return func(p *Portfolio) {
+		p.method = m
+	}
+}
+
+// WithStats устанавливает источник показателей стратегий для VolatilityParity и KellyCapped
+func WithStats(stats StatsFunc) Option {
+	return func(p *Portfolio) {
+		p.stats = stats
+	}
+}
+
+// WithReserve оставляет долю капитала ratio нераспределенной, по умолчанию 0.05
+func WithReserve(ratio float64) Option {
+	return func(p *Portfolio) {
+		p.reserve = min(max(ratio, 0), 1)
+	}
+}
+
+// WithRebalanceInterval устанавливает период пересчета бюджетов, по умолчанию 1 час
+func WithRebalanceInterval(d time.Duration) Option {
+	return func(p *Portfolio) {
+		p.interval = d
+	}
+}
+
+// WithLogger устанавливает логгер ошибок пересчета
+func WithLogger(logger *slog.Logger) Option {
+	return func(p *Portfolio) {
+		p.logger = logger
+	}
+}
+
+// New создает портфель с капиталом из equity
+func New(equity EquityFunc, opts ...Option) *Portfolio {
+	p := &Portfolio{
+		equity:   equity,
+		method:   EqualWeight(),
+		reserve:  0.05,
+		interval: time.Hour,
+		logger:   slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+		budgets:  make(map[string]float64),
+	}
+	for _, option := range opts {
+		option(p)
+	}
+	return p
+}
+
+// Register добавляет стратегию с фиксированным бюджетом fixed
+// Бюджет стратегии будет выделен при следующем распределении
+func (p *Portfolio) Register(name string, fixed float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := range p.members {
+		if p.members[i].Name == name {
+			p.members[i].Fixed = fixed
+			return
+		}
+	}
+	p.members = append(p.members, Member{Name: name, Fixed: fixed})
+}
+
+// Unregister исключает стратегию из распределения
+func (p *Portfolio) Unregister(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.members = slices.DeleteFunc(p.members, func(m Member) bool { return m.Name == name })
+	delete(p.budgets, name)
+}
+
+// Budget возвращает текущий бюджет стратегии в валюте кошелька
+func (p *Portfolio) Budget(name string) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.budgets[name]
+}
+
+// Snapshot возвращает результат последнего распределения
+func (p *Portfolio) Snapshot() Snapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.snapshot
+	s.Allocations = slices.Clone(s.Allocations)
+	return s
+}
+
+// Rebalance запрашивает капитал кошелька и показатели стратегий и пересчитывает бюджеты
+// При ошибке запроса капитала бюджеты не меняются
+func (p *Portfolio) Rebalance() error {
+	equity, err := p.equity()
+	if err != nil {
+		return fmt.Errorf("Rebalance: ошибка получения капитала: %w", err)
+	}
+
+	p.mu.Lock()
+	members := slices.Clone(p.members)
+	p.mu.Unlock()
+
+	if p.stats != nil {
+		for i := range members {
+			stats, err := p.stats(members[i].Name)
+			if err != nil {
+				p.logger.Warn("portfolio stats", "strategy", members[i].Name, "error", err)
+				continue
+			}
+			members[i].Stats = stats
+		}
+	}
+
+	capital := max(equity*(1-p.reserve), 0)
+	budgets := p.method.Allocate(capital, members)
+	snapshot := Snapshot{
+		Method:      p.method.Name(),
+		Equity:      equity,
+		Capital:     capital,
+		RebalanceAt: time.Now(),
+		Allocations: make([]Allocation, len(members)),
+	}
+	for i, m := range members {
+		snapshot.Allocations[i] = Allocation{Name: m.Name, Fixed: m.Fixed, Budget: budgets[i], Stats: m.Stats}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.budgets = make(map[string]float64, len(members))
+	for _, a := range snapshot.Allocations {
+		// стратегия могла быть исключена во время запроса показателей
+		if slices.ContainsFunc(p.members, func(m Member) bool { return m.Name == a.Name }) {
+			p.budgets[a.Name] = a.Budget
+		}
+	}
+	p.snapshot = snapshot
+	return nil
+}
+
+// Run распределяет капитал сразу и затем с периодом WithRebalanceInterval до отмены ctx
+// Запускается после добавления стратегий в бота
+func (p *Portfolio) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Rebalance(); err != nil {
+			p.logger.Error("portfolio rebalance", "error", err)
+		} else {
+			s := p.Snapshot()
+			p.logger.Info("portfolio rebalanced", "method", s.Method, "equity", s.Equity, "allocations", s.Allocations)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
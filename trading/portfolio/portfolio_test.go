@@ -0,0 +1,74 @@
+package portfolio
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestMethods(t *testing.T) {
+	members := []Member{
+		{Name: "a", Fixed: 300, Stats: Stats{Volatility: 0.01, Trades: 30, WinRate: 0.6, Payoff: 1}},
+		{Name: "b", Fixed: 900, Stats: Stats{Volatility: 0.02, Trades: 30, WinRate: 0.4, Payoff: 1}},
+		{Name: "c", Fixed: 0, Stats: Stats{Trades: 5}},
+	}
+	tests := []struct {
+		method Method
+		want   []float64
+	}{
+		{Fixed(), []float64{250, 750, 0}},
+		{EqualWeight(), []float64{1000. / 3, 1000. / 3, 1000. / 3}},
+		// веса 100, 50 и средний 75
+		{VolatilityParity(), []float64{1000 * 100 / 225., 1000 * 50 / 225., 1000 * 75 / 225.}},
+		// Келли: 0.2, 0 и равная доля 1/3, ограниченная 0.25
+		{KellyCapped(0.25, 20), []float64{200, 0, 250}},
+	}
+	for _, tt := range tests {
+		got := tt.method.Allocate(1000, members)
+		for i := range got {
+			if !near(got[i], tt.want[i]) {
+				t.Errorf("%s: %v, ожидается %v", tt.method.Name(), got, tt.want)
+				break
+			}
+		}
+	}
+	if got := Fixed().Allocate(5000, members); got[0] != 300 || got[1] != 900 {
+		t.Errorf("fixed без ограничения капитала: %v", got)
+	}
+}
+
+func TestRebalance(t *testing.T) {
+	equity, equityErr := 1000.0, error(nil)
+	p := New(
+		func() (float64, error) { return equity, equityErr },
+		WithReserve(0.1),
+		WithStats(func(name string) (Stats, error) { return Stats{}, errors.New("нет данных") }),
+	)
+	p.Register("a", 0)
+	p.Register("b", 0)
+	if p.Budget("a") != 0 {
+		t.Fatal("бюджет до распределения")
+	}
+	if err := p.Rebalance(); err != nil {
+		t.Fatal(err)
+	}
+	if !near(p.Budget("a"), 450) || !near(p.Budget("b"), 450) {
+		t.Errorf("бюджеты: %v %v", p.Budget("a"), p.Budget("b"))
+	}
+
+	equityErr = errors.New("timeout")
+	if err := p.Rebalance(); err == nil || !near(p.Budget("a"), 450) {
+		t.Errorf("ошибка капитала должна сохранять бюджеты: %v", err)
+	}
+
+	equityErr = nil
+	p.Unregister("b")
+	if err := p.Rebalance(); err != nil || !near(p.Budget("a"), 900) || p.Budget("b") != 0 {
+		t.Errorf("после исключения: %v %v %v", p.Budget("a"), p.Budget("b"), err)
+	}
+	if s := p.Snapshot(); s.Method != "equal" || s.Equity != 1000 || len(s.Allocations) != 1 {
+		t.Errorf("снимок: %+v", s)
+	}
+}
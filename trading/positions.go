@@ -0,0 +1,262 @@
+package trading
+
+import (
+	"goTradingBot/trading/types"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+)
+
+// Position позиция по инструменту, собранная из исполненных ордеров бота
+type Position struct {
+	Symbol   string  `json:"symbol"`
+	Qty      float64 `json:"qty"`      // >0 long, <0 short
+	AvgPrice float64 `json:"avgPrice"` // средняя цена входа открытой части
+	Realized float64 `json:"realized"` // реализованный результат без учета комиссий
+	Fees     float64 `json:"fees"`     // уплаченные комиссии
+	Funding  float64 `json:"funding"`  // полученное финансирование бессрочного контракта, <0 - уплаченное
+
+	tradeRealized float64 // результат текущей сделки
+	tradeFees     float64 // комиссии текущей сделки
+	tradeFunding  float64 // финансирование текущей сделки
+	openedAt      int64   // время открытия текущей сделки (мс)
+	inverse       bool    // обратный контракт: Qty в USD, результат пересчитан в USD по цене закрытия
+}
+
+// unrealized возвращает нереализованный результат открытой части по цене price
+func (p *Position) unrealized(price float64) float64 {
+	if p.Qty == 0 || p.AvgPrice == 0 {
+		return 0
+	}
+	if p.inverse {
+		return p.Qty * (price/p.AvgPrice - 1)
+	}
+	return p.Qty * (price - p.AvgPrice)
+}
+
+// notional возвращает стоимость открытой части по цене price
+func (p *Position) notional(price float64) float64 {
+	if p.inverse {
+		return math.Abs(p.Qty)
+	}
+	return math.Abs(p.Qty) * price
+}
+
+// qtyPoint размер позиции после исполнения ордера
+type qtyPoint struct {
+	time int64 // время исполнения (мс)
+	qty  float64
+}
+
+// positionBook учитывает позиции по методу средней цены
+type positionBook struct {
+	mu        sync.Mutex
+	positions map[string]*Position
+	inverse   map[string]bool
+	history   map[string][]qtyPoint // размер позиции во времени для учета финансирования
+}
+
+func newPositionBook() *positionBook {
+	return &positionBook{
+		positions: make(map[string]*Position),
+		inverse:   make(map[string]bool),
+		history:   make(map[string][]qtyPoint),
+	}
+}
+
+// setInverse отмечает инструмент как обратный контракт
+func (pb *positionBook) setInverse(symbol string) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.inverse[symbol] = true
+	if p, ok := pb.positions[symbol]; ok {
+		p.inverse = true
+	}
+}
+
+// positionChange открытие и закрытие позиций в результате исполнения ордера
+type positionChange struct {
+	closed *Position // закрытая позиция: Realized и Fees - результат сделки от открытия до закрытия
+	opened *Position // новая позиция
+}
+
+// apply учитывает исполненную часть закрытого ордера
+func (pb *positionBook) apply(order *types.Order) positionChange {
+	var change positionChange
+	qty := order.ExecQty
+	if qty == 0 {
+		return change
+	}
+	price := order.AvgPrice
+	if price == 0 {
+		price = order.ExecValue / qty
+	}
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	p, ok := pb.positions[order.Symbol]
+	if !ok {
+		p = &Position{Symbol: order.Symbol, inverse: pb.inverse[order.Symbol]}
+		pb.positions[order.Symbol] = p
+	}
+	at := orderTime(order)
+	defer func() {
+		pb.history[p.Symbol] = append(pb.history[p.Symbol], qtyPoint{time: at, qty: p.Qty})
+	}()
+	p.Fees += order.Fee
+	p.tradeFees += order.Fee
+	switch {
+	case p.Qty == 0 || math.Signbit(p.Qty) == math.Signbit(qty):
+		if p.Qty == 0 {
+			change.opened = &Position{Symbol: p.Symbol, Qty: qty, AvgPrice: price, inverse: p.inverse}
+			p.openedAt = at
+		}
+		if p.inverse && p.Qty != 0 {
+			// для обратного контракта средняя цена - гармоническая
+			p.AvgPrice = (math.Abs(p.Qty) + math.Abs(qty)) / (math.Abs(p.Qty)/p.AvgPrice + math.Abs(qty)/price)
+		} else {
+			p.AvgPrice = (math.Abs(p.Qty)*p.AvgPrice + math.Abs(qty)*price) / (math.Abs(p.Qty) + math.Abs(qty))
+		}
+		p.Qty += qty
+	default:
+		closing := min(math.Abs(qty), math.Abs(p.Qty))
+		direction := 1.0
+		if p.Qty < 0 {
+			direction = -1
+		}
+		realized := closing * (price - p.AvgPrice) * direction
+		if p.inverse {
+			realized = closing * (price/p.AvgPrice - 1) * direction
+		}
+		p.Realized += realized
+		p.tradeRealized += realized
+		rest := p.Qty + qty
+		if math.Abs(qty) < math.Abs(p.Qty) && math.Abs(rest) >= 1e-12 {
+			p.Qty = rest
+			return change
+		}
+		change.closed = &Position{
+			Symbol:   p.Symbol,
+			Qty:      p.Qty,
+			AvgPrice: p.AvgPrice,
+			Realized: p.tradeRealized,
+			Fees:     p.tradeFees,
+			Funding:  p.tradeFunding,
+			inverse:  p.inverse,
+		}
+		p.tradeRealized, p.tradeFees, p.tradeFunding = 0, 0, 0
+		if math.Abs(qty) > math.Abs(p.Qty) {
+			// разворот позиции: остаток открыт по цене ордера
+			p.Qty, p.AvgPrice, p.openedAt = rest, price, at
+			change.opened = &Position{Symbol: p.Symbol, Qty: rest, AvgPrice: price, inverse: p.inverse}
+		} else {
+			p.Qty, p.AvgPrice = 0, 0
+		}
+	}
+	return change
+}
+
+// orderTime возвращает время исполнения ордера (мс), 0 - неизвестно
+func orderTime(order *types.Order) int64 {
+	if order.UpdatedAt > 0 {
+		return order.UpdatedAt
+	}
+	return order.CreatedAt
+}
+
+// addFunding учитывает выплату финансирования в момент at
+// Выплата относится к текущей сделке, только если сделка открыта не позже at
+func (pb *positionBook) addFunding(symbol string, amount float64, at int64) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	p, ok := pb.positions[symbol]
+	if !ok {
+		return
+	}
+	p.Funding += amount
+	if p.Qty != 0 && p.openedAt <= at {
+		p.tradeFunding += amount
+	}
+}
+
+// qtyAt возвращает размер позиции в момент t по истории исполнений
+func (pb *positionBook) qtyAt(symbol string, t int64) float64 {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	var qty float64
+	for _, pt := range pb.history[symbol] {
+		if pt.time > t {
+			break
+		}
+		qty = pt.qty
+	}
+	return qty
+}
+
+// changedSince сообщает, менялась ли позиция начиная с момента t
+func (pb *positionBook) changedSince(symbol string, t int64) bool {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	h := pb.history[symbol]
+	return len(h) > 0 && h[len(h)-1].time >= t
+}
+
+// pruneHistory удаляет историю размера позиции до момента t, сохраняя размер в момент t
+func (pb *positionBook) pruneHistory(symbol string, t int64) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	h := pb.history[symbol]
+	i := 0
+	for i+1 < len(h) && h[i+1].time <= t {
+		i++
+	}
+	pb.history[symbol] = h[i:]
+}
+
+// get возвращает копию позиции по инструменту
+func (pb *positionBook) get(symbol string) Position {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if p, ok := pb.positions[symbol]; ok {
+		return *p
+	}
+	return Position{Symbol: symbol}
+}
+
+// snapshot возвращает копии всех позиций, отсортированные по инструменту
+func (pb *positionBook) snapshot() []Position {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	res := make([]Position, 0, len(pb.positions))
+	for _, symbol := range slices.Sorted(maps.Keys(pb.positions)) {
+		res = append(res, *pb.positions[symbol])
+	}
+	return res
+}
+
+// EquityPoint точка кривой доходности
+type EquityPoint struct {
+	Time     int64   `json:"time"`     // время обновления ордера (мс)
+	Realized float64 `json:"realized"` // накопленный реализованный результат
+	Fees     float64 `json:"fees"`     // накопленные комиссии
+	Equity   float64 `json:"equity"`   // Realized - Fees
+}
+
+// EquityCurve строит кривую реализованной доходности по исполненным ордерам,
+// отсортированным по времени обновления
+func EquityCurve(orders []*types.Order) []EquityPoint {
+	pb := newPositionBook()
+	res := make([]EquityPoint, 0, len(orders))
+	var realized, fees float64
+	for _, o := range orders {
+		if o.ExecQty == 0 {
+			continue
+		}
+		before := pb.get(o.Symbol).Realized
+		pb.apply(o)
+		realized += pb.get(o.Symbol).Realized - before
+		fees += o.Fee
+		res = append(res, EquityPoint{Time: o.UpdatedAt, Realized: realized, Fees: fees, Equity: realized - fees})
+	}
+	return res
+}
@@ -0,0 +1,66 @@
+package trading
+
+import (
+	"goTradingBot/trading/types"
+	"math"
+	"testing"
+)
+
+func TestPositionBook(t *testing.T) {
+	pb := newPositionBook()
+	fill := func(qty, price, fee float64) positionChange {
+		return pb.apply(&types.Order{Symbol: "BTCUSDT", ExecQty: qty, AvgPrice: price, Fee: fee})
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+	if c := fill(1, 100, 0.1); c.opened == nil || c.closed != nil {
+		t.Fatalf("открытие: %+v", c)
+	}
+	fill(1, 110, 0.1)
+	if p := pb.get("BTCUSDT"); !near(p.Qty, 2) || !near(p.AvgPrice, 105) {
+		t.Fatalf("усреднение: %+v", p)
+	}
+	if c := fill(-0.5, 115, 0.05); c.opened != nil || c.closed != nil {
+		t.Fatalf("частичное закрытие: %+v", c)
+	}
+	// разворот: закрываем 1.5 по 95 и открываем short 1
+	c := fill(-2.5, 95, 0.2)
+	if c.closed == nil || !near(c.closed.Realized, 0.5*10-1.5*10) || !near(c.closed.Fees, 0.45) {
+		t.Fatalf("закрытие сделки: %+v", c.closed)
+	}
+	if c.opened == nil || !near(c.opened.Qty, -1) || !near(c.opened.AvgPrice, 95) {
+		t.Fatalf("разворот: %+v", c.opened)
+	}
+	c = fill(1, 90, 0)
+	if c.closed == nil || !near(c.closed.Realized, 5) || c.opened != nil {
+		t.Fatalf("закрытие short: %+v", c)
+	}
+	p := pb.get("BTCUSDT")
+	if p.Qty != 0 || !near(p.Realized, -5) || !near(p.Fees, 0.45) {
+		t.Fatalf("итог: %+v", p)
+	}
+}
+
+func TestPositionBookInverse(t *testing.T) {
+	pb := newPositionBook()
+	pb.setInverse("BTCUSD")
+	fill := func(qty, price float64) positionChange {
+		return pb.apply(&types.Order{Symbol: "BTCUSD", ExecQty: qty, AvgPrice: price})
+	}
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-6 }
+
+	fill(100, 50000)
+	fill(100, 40000)
+	// гармоническая средняя: 200 / (100/50000 + 100/40000)
+	p := pb.get("BTCUSD")
+	if !near(p.AvgPrice, 200/(0.002+0.0025)) {
+		t.Fatalf("усреднение: %+v", p)
+	}
+	if !near(p.unrealized(50000), 25) || !near(p.notional(50000), 200) {
+		t.Fatalf("нереализованный результат: %v, стоимость %v", p.unrealized(50000), p.notional(50000))
+	}
+	c := fill(-200, 50000)
+	if c.closed == nil || !near(c.closed.Realized, 25) {
+		t.Fatalf("закрытие: %+v", c.closed)
+	}
+}
@@ -0,0 +1,141 @@
+package regime
+
+import (
+	"goTradingBot/cdl"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// historyLimit количество хранимых смен режима
+const historyLimit = 256
+
+// Point режим рынка, установившийся на свече Time
+type Point struct {
+	Time   int64  `json:"time"` // время открытия свечи в миллисекундах
+	Regime Regime `json:"regime"`
+}
+
+// Change смена режима рынка инструмента
+type Change struct {
+	Symbol   string
+	Interval cdl.Interval
+	Time     int64 // время открытия свечи, на которой сменился режим
+	From     Regime
+	To       Regime
+}
+
+// Detector отслеживает режим рынка инструмента по закрытым свечам и оповещает подписчиков о его смене
+// Один детектор может обновляться несколькими стратегиями одного инструмента
+type Detector struct {
+	symbol     string
+	interval   cdl.Interval
+	classifier *Classifier
+	mu         sync.Mutex
+	last       int64
+	current    Regime
+	history    []Point
+	subs       map[int]func(Change)
+	nextSub    int
+}
+
+// NewDetector создает детектор режима рынка инструмента symbol на интервале interval
+func NewDetector(symbol string, interval cdl.Interval, classifier *Classifier) *Detector {
+	return &Detector{
+		symbol:     symbol,
+		interval:   interval,
+		classifier: classifier,
+		subs:       make(map[int]func(Change)),
+	}
+}
+
+// Symbol возвращает инструмент детектора
+func (d *Detector) Symbol() string {
+	return d.symbol
+}
+
+// Interval возвращает интервал свечей детектора
+func (d *Detector) Interval() cdl.Interval {
+	return d.interval
+}
+
+// Candles возвращает количество свечей, которое нужно передавать в Update
+func (d *Detector) Candles() int {
+	return d.classifier.WarmUp()
+}
+
+// Update определяет режим по закрытым свечам candles и возвращает текущий режим
+// Свечи, уже учтенные детектором, пропускаются. При смене режима подписчики вызываются синхронно
+// и не должны блокировать: детектор может обновлять горутина любой стратегии с этим инструментом
+func (d *Detector) Update(candles []cdl.Candle) Regime {
+	if len(candles) == 0 {
+		return d.Current()
+	}
+	d.mu.Lock()
+	last := candles[len(candles)-1].Time
+	if last <= d.last {
+		current := d.current
+		d.mu.Unlock()
+		return current
+	}
+	d.last = last
+	next := d.classifier.Last(candles)
+	if next == d.current {
+		d.mu.Unlock()
+		return next
+	}
+	change := Change{Symbol: d.symbol, Interval: d.interval, Time: last, From: d.current, To: next}
+	d.current = next
+	d.history = append(d.history, Point{Time: last, Regime: next})
+	if len(d.history) > historyLimit {
+		d.history = slices.Delete(d.history, 0, len(d.history)-historyLimit)
+	}
+	subs := slices.Collect(maps.Values(d.subs))
+	d.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(change)
+	}
+	return next
+}
+
+// Current возвращает текущий режим рынка
+func (d *Detector) Current() Regime {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.current
+}
+
+// History возвращает последние смены режима рынка
+func (d *Detector) History() []Point {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.history)
+}
+
+// Subscribe подписывает fn на смены режима рынка и возвращает функцию отмены подписки
+func (d *Detector) Subscribe(fn func(Change)) (unsubscribe func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	id := d.nextSub
+	d.nextSub++
+	d.subs[id] = fn
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.subs, id)
+	}
+}
+
+// History возвращает смены режима рынка по свечам candles для тестов на истории
+func History(classifier *Classifier, candles []cdl.Candle) []Point {
+	var points []Point
+	var current Regime
+	for i, r := range classifier.Classify(candles) {
+		if r != current {
+			points = append(points, Point{Time: candles[i].Time, Regime: r})
+			current = r
+		}
+	}
+	return points
+}
@@ -0,0 +1,224 @@
+package regime
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+	"goTradingBot/ta"
+	"math"
+	"strings"
+)
+
+// Regime режим рынка на свече
+type Regime string
+
+const (
+	Unknown   Regime = ""           // недостаточно свечей для классификации
+	TrendUp   Regime = "trend_up"   // восходящий тренд
+	TrendDown Regime = "trend_down" // нисходящий тренд
+	Range     Regime = "range"      // боковое движение
+	HighVol   Regime = "high_vol"   // высокая волатильность без устойчивого направления
+)
+
+// Regimes все режимы рынка, кроме Unknown
+var Regimes = []Regime{TrendUp, TrendDown, Range, HighVol}
+
+// Parse разбирает режимы рынка, перечисленные через запятую
+func Parse(value string) ([]Regime, error) {
+	var regimes []Regime
+	for _, name := range strings.Split(value, ",") {
+		r := Regime(strings.TrimSpace(name))
+		if r == Unknown {
+			continue
+		}
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("Parse: %w", err)
+		}
+		regimes = append(regimes, r)
+	}
+	return regimes, nil
+}
+
+// Validate проверяет, что режим известен
+func (r Regime) Validate() error {
+	switch r {
+	case TrendUp, TrendDown, Range, HighVol:
+		return nil
+	}
+	return fmt.Errorf("неизвестный режим рынка %q", string(r))
+}
+
+// Trend сообщает, является ли режим трендом
+func (r Regime) Trend() bool {
+	return r == TrendUp || r == TrendDown
+}
+
+// Classifier определяет режим рынка по ADX, процентилю ширины полос Боллинджера
+// и показателю Херста, оцененному через отношение дисперсий
+type Classifier struct {
+	adxPeriod     int
+	adxThreshold  float64
+	bbPeriod      int
+	volLookback   int
+	volPercentile float64
+	hurstWindow   int
+	hurstLag      int
+	hurstMin      float64
+}
+
+// ClassifierOption определяет тип функции для настройки Classifier
+type ClassifierOption func(*Classifier)
+
+// WithADX устанавливает период ADX и порог тренда. ADX пакета ta принимает значения 0-1
+func WithADX(period int, threshold float64) ClassifierOption {
+	return func(c *Classifier) {
+		c.adxPeriod = period
+		c.adxThreshold = threshold
+	}
+}
+
+// WithVolatility устанавливает период полос Боллинджера, глубину истории ширины полос
+// и процентиль ширины, начиная с которого рынок считается высоковолатильным
+func WithVolatility(period, lookback int, percentile float64) ClassifierOption {
+	return func(c *Classifier) {
+		c.bbPeriod = period
+		c.volLookback = lookback
+		c.volPercentile = percentile
+	}
+}
+
+// WithHurst устанавливает окно и лаг отношения дисперсий и минимальный показатель Херста тренда
+func WithHurst(window, lag int, min float64) ClassifierOption {
+	return func(c *Classifier) {
+		c.hurstWindow = window
+		c.hurstLag = lag
+		c.hurstMin = min
+	}
+}
+
+// NewClassifier создает классификатор режимов рынка
+func NewClassifier(opts ...ClassifierOption) *Classifier {
+	c := &Classifier{
+		adxPeriod:     14,
+		adxThreshold:  0.25,
+		bbPeriod:      20,
+		volLookback:   200,
+		volPercentile: 0.9,
+		hurstWindow:   100,
+		hurstLag:      4,
+		hurstMin:      0.5,
+	}
+	for _, option := range opts {
+		option(c)
+	}
+	return c
+}
+
+// WarmUp возвращает количество свечей, начиная с которого классификатор определяет режим
+// ADX пакета ta начинается с 50 и затухает за 10 периодов
+func (c *Classifier) WarmUp() int {
+	return max(10*c.adxPeriod, c.bbPeriod+c.volLookback, c.hurstWindow+1)
+}
+
+// Classify возвращает режим рынка на каждой свече candles, первые WarmUp-1 свечей - Unknown
+// Режим свечи зависит только от нее и предыдущих свечей, что позволяет использовать историю в тестах на истории
+func (c *Classifier) Classify(candles []cdl.Candle) []Regime {
+	regimes := make([]Regime, len(candles))
+	if len(candles) == 0 {
+		return regimes
+	}
+	adx := ta.Compute(ta.NewAdxDiIndicator(c.adxPeriod, 1), candles)
+	bb := ta.Compute(ta.NewBollingerBandsIndicator(cdl.Close, ta.S, c.bbPeriod, 2), candles)
+	closes := make([]float64, len(candles))
+	widths := make([]float64, len(candles))
+	for i, candle := range candles {
+		closes[i] = candle.C
+		if mid := bb["MiddleBand"][i]; mid != 0 {
+			widths[i] = (bb["UpperBand"][i] - bb["LowerBand"][i]) / mid
+		}
+	}
+
+	warmUp := c.WarmUp()
+	for i := warmUp - 1; i < len(candles); i++ {
+		if percentileRank(widths[i-c.volLookback+1:i+1], widths[i]) >= c.volPercentile {
+			regimes[i] = HighVol
+			continue
+		}
+		hurst := Hurst(closes[i-c.hurstWindow:i+1], c.hurstLag)
+		// NaN ADX на свечах без диапазона - не тренд
+		if !(adx["ADX"][i] >= c.adxThreshold) || hurst < c.hurstMin {
+			regimes[i] = Range
+			continue
+		}
+		if adx["DiPlus"][i] >= adx["DiMinus"][i] {
+			regimes[i] = TrendUp
+		} else {
+			regimes[i] = TrendDown
+		}
+	}
+	return regimes
+}
+
+// Last возвращает режим рынка на последней свече candles
+func (c *Classifier) Last(candles []cdl.Candle) Regime {
+	if len(candles) < c.WarmUp() {
+		return Unknown
+	}
+	regimes := c.Classify(candles)
+	return regimes[len(regimes)-1]
+}
+
+// VarianceRatio возвращает отношение дисперсии лог-доходностей за lag свечей к lag дисперсиям
+// доходности за одну свечу. Больше 1 - движения продолжаются, меньше 1 - возвращаются к среднему
+func VarianceRatio(closes []float64, lag int) float64 {
+	if lag < 2 || len(closes) <= lag+1 {
+		return math.NaN()
+	}
+	returns := make([]float64, len(closes)-1)
+	for i := 1; i < len(closes); i++ {
+		returns[i-1] = math.Log(closes[i] / closes[i-1])
+	}
+	lagged := make([]float64, len(closes)-lag)
+	for i := lag; i < len(closes); i++ {
+		lagged[i-lag] = math.Log(closes[i] / closes[i-lag])
+	}
+	v := variance(returns)
+	if v == 0 {
+		return math.NaN()
+	}
+	return variance(lagged) / (float64(lag) * v)
+}
+
+// Hurst оценивает показатель Херста через отношение дисперсий: 0.5 - случайное блуждание,
+// больше 0.5 - трендовое движение, меньше 0.5 - возврат к среднему
+func Hurst(closes []float64, lag int) float64 {
+	vr := VarianceRatio(closes, lag)
+	if math.IsNaN(vr) || vr <= 0 {
+		return 0.5
+	}
+	return 0.5 + math.Log(vr)/(2*math.Log(float64(lag)))
+}
+
+// variance возвращает дисперсию выборки
+func variance(values []float64) float64 {
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return sum / float64(len(values))
+}
+
+// percentileRank возвращает долю значений values, не превышающих value
+func percentileRank(values []float64, value float64) float64 {
+	var count int
+	for _, v := range values {
+		if v <= value {
+			count++
+		}
+	}
+	return float64(count) / float64(len(values))
+}
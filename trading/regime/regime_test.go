@@ -0,0 +1,119 @@
+package regime
+
+import (
+	"goTradingBot/cdl"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// series возвращает свечи с лог-доходностями AR(1): r = phi*r' + drift + шум
+func series(n int, phi, drift, noise float64, seed uint64) []cdl.Candle {
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	candles := make([]cdl.Candle, n)
+	price, r := 100.0, 0.0
+	for i := range candles {
+		r = phi*r + drift + rnd.NormFloat64()*noise
+		next := price * math.Exp(r)
+		candles[i] = cdl.Candle{
+			Time: int64(i) * 60000,
+			O:    price,
+			H:    max(price, next) * (1 + noise/2),
+			L:    min(price, next) * (1 - noise/2),
+			C:    next,
+		}
+		price = next
+	}
+	return candles
+}
+
+func closes(candles []cdl.Candle) []float64 {
+	res := make([]float64, len(candles))
+	for i, c := range candles {
+		res[i] = c.C
+	}
+	return res
+}
+
+func TestHurst(t *testing.T) {
+	if h := Hurst(closes(series(2000, 0.6, 0, 0.01, 1)), 4); h <= 0.55 {
+		t.Errorf("трендовый ряд: %v", h)
+	}
+	if h := Hurst(closes(series(2000, -0.5, 0, 0.01, 1)), 4); h >= 0.45 {
+		t.Errorf("возврат к среднему: %v", h)
+	}
+	if vr := VarianceRatio([]float64{1, 2}, 4); !math.IsNaN(vr) {
+		t.Errorf("короткий ряд: %v", vr)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	c := NewClassifier()
+	up := series(400, 0.5, 0.002, 0.002, 2)
+	regimes := c.Classify(up)
+	if regimes[c.WarmUp()-2] != Unknown || regimes[c.WarmUp()-1] == Unknown {
+		t.Fatalf("прогрев: %v %v", regimes[c.WarmUp()-2], regimes[c.WarmUp()-1])
+	}
+	if r := c.Last(up); r != TrendUp {
+		t.Errorf("восходящий тренд: %s", r)
+	}
+	if r := c.Last(series(400, 0.5, -0.002, 0.002, 3)); r != TrendDown {
+		t.Errorf("нисходящий тренд: %s", r)
+	}
+
+	flat := series(400, -0.5, 0, 0.002, 4)
+	if r := c.Last(flat); r != Range {
+		t.Errorf("боковое движение: %s", r)
+	}
+	// резкий рост волатильности в конце бокового движения
+	shock := series(10, 0, 0, 0.03, 5)
+	price := flat[len(flat)-1].C / shock[0].O
+	for _, candle := range shock {
+		candle.Time += flat[len(flat)-1].Time + 60000
+		candle.O, candle.H, candle.L, candle.C = candle.O*price, candle.H*price, candle.L*price, candle.C*price
+		flat = append(flat, candle)
+	}
+	if r := c.Last(flat); r != HighVol {
+		t.Errorf("высокая волатильность: %s", r)
+	}
+	if r := c.Last(flat[:10]); r != Unknown {
+		t.Errorf("мало свечей: %s", r)
+	}
+
+	history := History(c, up)
+	if len(history) == 0 || history[0].Time != up[c.WarmUp()-1].Time {
+		t.Errorf("история: %v", history)
+	}
+}
+
+func TestDetector(t *testing.T) {
+	d := NewDetector("BTCUSDT", cdl.M1, NewClassifier())
+	var changes []Change
+	unsubscribe := d.Subscribe(func(change Change) { changes = append(changes, change) })
+
+	up := series(400, 0.5, 0.002, 0.002, 2)
+	if r := d.Update(up[:300]); r != TrendUp {
+		t.Fatalf("Update: %s", r)
+	}
+	d.Update(up[:300])
+	d.Update(up[:301])
+	if len(changes) != 1 || changes[0].From != Unknown || changes[0].To != TrendUp || changes[0].Symbol != "BTCUSDT" {
+		t.Fatalf("смены режима: %+v", changes)
+	}
+
+	unsubscribe()
+	d.Update(series(400, 0.5, -0.002, 0.002, 3)[:302])
+	if len(changes) != 1 {
+		t.Error("оповещение после отмены подписки")
+	}
+	if d.Current() != TrendDown || len(d.History()) != 2 {
+		t.Errorf("текущий режим %s, история %v", d.Current(), d.History())
+	}
+
+	if _, err := Parse("trend_up, range"); err != nil {
+		t.Error(err)
+	}
+	if _, err := Parse("trend"); err == nil {
+		t.Error("неизвестный режим")
+	}
+}
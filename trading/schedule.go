@@ -0,0 +1,22 @@
+package trading
+
+import (
+	"goTradingBot/trading/schedule"
+	"goTradingBot/trading/types"
+)
+
+// SetScheduler подключает расписание торговли стратегий
+// Стратегии types.ScheduledStrategy открывают позиции только в разрешенное время
+// и закрывают их в моменты закрытия. Вызывается до добавления стратегий
+func (b *TradingBot) SetScheduler(s *schedule.Scheduler) {
+	b.scheduler = s
+}
+
+// attachSchedule подключает стратегию к расписанию
+func (b *TradingBot) attachSchedule(entry *strategyEntry) {
+	s, ok := entry.strategy.(types.ScheduledStrategy)
+	if !ok || b.scheduler == nil {
+		return
+	}
+	b.scheduler.Attach(entry.name, s)
+}
@@ -0,0 +1,198 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Duration длительность в JSON в формате time.ParseDuration, например "30m"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("Duration: %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("Duration: %w", err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Window окно открытия позиций по времени UTC
+// To раньше From - окно переходит через полночь и относится к дню начала
+type Window struct {
+	Days []string `json:"days,omitempty"` // дни недели: mon, tue, wed, thu, fri, sat, sun; пусто - все дни
+	From string   `json:"from"`           // начало в формате 15:04
+	To   string   `json:"to"`             // конец в формате 15:04, не включая
+}
+
+// Event событие, вокруг которого позиции не открываются
+type Event struct {
+	Name   string    `json:"name"`
+	Time   time.Time `json:"time"`   // время события в формате RFC3339
+	Before Duration  `json:"before"` // запрет до события
+	After  Duration  `json:"after"`  // запрет после события
+}
+
+// Cutoff ежедневное время, до которого позиции стратегии закрываются
+type Cutoff struct {
+	Days          []string `json:"days,omitempty"` // дни недели, пусто - все дни
+	At            string   `json:"at"`             // время UTC в формате 15:04
+	FlattenBefore Duration `json:"flattenBefore"`  // позиции закрываются за FlattenBefore до At, позиции не открываются до At
+}
+
+// Rules правила торговли стратегии по времени
+// Пустые правила не ограничивают торговлю
+type Rules struct {
+	Windows []Window `json:"windows,omitempty"`
+	Events  []Event  `json:"events,omitempty"`
+	Cutoffs []Cutoff `json:"cutoffs,omitempty"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Validate проверяет формат времени и дней недели
+func (r *Rules) Validate() error {
+	for _, w := range r.Windows {
+		if _, err := clock(w.From); err != nil {
+			return fmt.Errorf("Rules: окно: %w", err)
+		}
+		if _, err := clock(w.To); err != nil {
+			return fmt.Errorf("Rules: окно: %w", err)
+		}
+		if err := validateDays(w.Days); err != nil {
+			return fmt.Errorf("Rules: окно: %w", err)
+		}
+	}
+	for _, e := range r.Events {
+		if e.Time.IsZero() || e.Before < 0 || e.After < 0 {
+			return fmt.Errorf("Rules: неверное событие %q", e.Name)
+		}
+	}
+	for _, c := range r.Cutoffs {
+		if _, err := clock(c.At); err != nil {
+			return fmt.Errorf("Rules: закрытие: %w", err)
+		}
+		if c.FlattenBefore < 0 {
+			return fmt.Errorf("Rules: закрытие %s: отрицательное flattenBefore", c.At)
+		}
+		if err := validateDays(c.Days); err != nil {
+			return fmt.Errorf("Rules: закрытие: %w", err)
+		}
+	}
+	return nil
+}
+
+// CanEnter сообщает, можно ли открывать или увеличивать позицию в момент t
+func (r *Rules) CanEnter(t time.Time) bool {
+	t = t.UTC()
+	if len(r.Windows) > 0 && !slices.ContainsFunc(r.Windows, func(w Window) bool { return w.contains(t) }) {
+		return false
+	}
+	for _, e := range r.Events {
+		if !t.Before(e.Time.Add(-time.Duration(e.Before))) && !t.After(e.Time.Add(time.Duration(e.After))) {
+			return false
+		}
+	}
+	for _, c := range r.Cutoffs {
+		// закрытие в день t и в следующий день, если FlattenBefore переходит через полночь
+		for _, day := range []time.Time{t, t.AddDate(0, 0, 1)} {
+			at, ok := c.at(day)
+			if ok && !t.Before(at.Add(-time.Duration(c.FlattenBefore))) && t.Before(at) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NextFlatten возвращает ближайший после t момент закрытия позиций, нулевое время - закрытий нет
+func (r *Rules) NextFlatten(t time.Time) time.Time {
+	t = t.UTC()
+	var next time.Time
+	for _, c := range r.Cutoffs {
+		for i := range 8 {
+			at, ok := c.at(t.AddDate(0, 0, i))
+			if !ok {
+				continue
+			}
+			flatten := at.Add(-time.Duration(c.FlattenBefore))
+			if flatten.After(t) {
+				if next.IsZero() || flatten.Before(next) {
+					next = flatten
+				}
+				break
+			}
+		}
+	}
+	return next
+}
+
+// contains сообщает, попадает ли t в окно
+func (w *Window) contains(t time.Time) bool {
+	from, _ := clock(w.From)
+	to, _ := clock(w.To)
+	minute := t.Hour()*60 + t.Minute()
+	if from <= to {
+		return hasDay(w.Days, t.Weekday()) && minute >= from && minute < to
+	}
+	// окно через полночь: вечер дня начала или утро следующего дня
+	if minute >= from {
+		return hasDay(w.Days, t.Weekday())
+	}
+	return minute < to && hasDay(w.Days, t.AddDate(0, 0, -1).Weekday())
+}
+
+// at возвращает время закрытия в день day, false - в этот день закрытия нет
+func (c *Cutoff) at(day time.Time) (time.Time, bool) {
+	if !hasDay(c.Days, day.Weekday()) {
+		return time.Time{}, false
+	}
+	minute, err := clock(c.At)
+	if err != nil {
+		return time.Time{}, false
+	}
+	date := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	return date.Add(time.Duration(minute) * time.Minute), true
+}
+
+// clock возвращает количество минут от полуночи для времени в формате 15:04
+func clock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("неверное время %q", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func validateDays(days []string) error {
+	for _, day := range days {
+		if _, ok := weekdays[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("неверный день недели %q", day)
+		}
+	}
+	return nil
+}
+
+func hasDay(days []string, weekday time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(days, func(day string) bool {
+		d, ok := weekdays[strings.ToLower(day)]
+		return ok && d == weekday
+	})
+}
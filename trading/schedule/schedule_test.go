@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"goTradingBot/trading/types"
+	"testing"
+	"time"
+)
+
+func utc(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRulesCanEnter(t *testing.T) {
+	var config Config
+	err := json.Unmarshal([]byte(`{
+		"windows": [{"days": ["mon", "tue"], "from": "22:00", "to": "02:00"}],
+		"events": [{"name": "FOMC", "time": "2025-06-03T00:30:00Z", "before": "15m", "after": "30m"}],
+		"cutoffs": [{"at": "01:30", "flattenBefore": "10m"}]
+	}`), &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Rules.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		time string
+		want bool
+	}{
+		{"2025-06-02T23:00:00Z", true},  // понедельник вечером
+		{"2025-06-03T01:00:00Z", false}, // событие
+		{"2025-06-03T00:05:00Z", true},  // утро вторника, окно понедельника
+		{"2025-06-03T01:25:00Z", false}, // перед закрытием
+		{"2025-06-03T12:00:00Z", false}, // вне окна
+		{"2025-06-04T01:00:00Z", true},  // утро среды, окно вторника
+		{"2025-06-05T01:00:00Z", false}, // утро четверга, окна среды нет
+	}
+	for _, tt := range tests {
+		if got := config.CanEnter(utc(tt.time)); got != tt.want {
+			t.Errorf("CanEnter(%s) = %v", tt.time, got)
+		}
+	}
+
+	if next := config.NextFlatten(utc("2025-06-03T01:25:00Z")); !next.Equal(utc("2025-06-04T01:20:00Z")) {
+		t.Errorf("NextFlatten: %v", next)
+	}
+	bad := Rules{Windows: []Window{{From: "25:00", To: "01:00"}}}
+	if bad.Validate() == nil {
+		t.Error("неверное время окна")
+	}
+}
+
+// fakeStrategy стратегия, считающая закрытия позиции
+type fakeStrategy struct {
+	types.Strategy
+	filter   types.EntryFilter
+	flattens int
+}
+
+func (s *fakeStrategy) SetEntryFilter(filter types.EntryFilter) { s.filter = filter }
+func (s *fakeStrategy) Flatten()                                { s.flattens++ }
+
+func TestScheduler(t *testing.T) {
+	config := Config{
+		Strategies: map[string]Rules{"night": {Cutoffs: []Cutoff{{At: "00:00", FlattenBefore: Duration(time.Minute)}}}},
+		Jobs:       []Job{{Name: "report", At: "00:05"}},
+	}
+	if _, err := New(config); err == nil {
+		t.Error("незарегистрированная задача")
+	}
+	s, err := New(config, WithJob("report", func(context.Context) error { return nil }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	night, day := &fakeStrategy{}, &fakeStrategy{}
+	s.Attach("night", night)
+	s.Attach("day", day)
+	if night.filter == nil || !day.filter(utc("2025-06-02T23:59:30Z")) || night.filter(utc("2025-06-02T23:59:30Z")) {
+		t.Fatal("фильтр открытия позиций")
+	}
+
+	s.flattenDue(utc("2025-06-02T23:58:50Z"), utc("2025-06-02T23:59:20Z"))
+	s.flattenDue(utc("2025-06-02T23:59:20Z"), utc("2025-06-02T23:59:50Z"))
+	if night.flattens != 1 || day.flattens != 0 {
+		t.Errorf("закрытие позиций: %d %d", night.flattens, day.flattens)
+	}
+
+	job := config.Jobs[0]
+	if next := job.next(utc("2025-06-02T00:05:00Z")); !next.Equal(utc("2025-06-03T00:05:00Z")) {
+		t.Errorf("следующий запуск задачи: %v", next)
+	}
+}
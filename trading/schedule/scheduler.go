@@ -0,0 +1,192 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"goTradingBot/trading/types"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+)
+
+// checkInterval период проверки моментов закрытия позиций
+const checkInterval = 30 * time.Second
+
+// Job периодическая задача, Every или At
+type Job struct {
+	Name  string   `json:"name"`            // имя задачи, зарегистрированной через WithJob
+	Every Duration `json:"every,omitempty"` // период запуска
+	At    string   `json:"at,omitempty"`    // ежедневное время запуска UTC в формате 15:04
+}
+
+// Config расписание бота из файла конфигурации
+type Config struct {
+	Rules                       // правила стратегий по умолчанию
+	Strategies map[string]Rules `json:"strategies,omitempty"` // правила по имени стратегии вместо правил по умолчанию
+	Jobs       []Job            `json:"jobs,omitempty"`
+}
+
+// JobFunc выполняет периодическую задачу
+type JobFunc func(ctx context.Context) error
+
+// Scheduler ограничивает торговлю стратегий по времени и запускает периодические задачи
+type Scheduler struct {
+	config     Config
+	jobs       map[string]JobFunc
+	logger     *slog.Logger
+	mu         sync.Mutex
+	strategies map[string]types.ScheduledStrategy
+}
+
+// Option определяет тип функции для настройки Scheduler
+type Option func(*Scheduler)
+
+// WithJob регистрирует задачу name, запускаемую по расписанию из Config.Jobs
+func WithJob(name string, job JobFunc) Option {
+	return func(s *Scheduler) {
+		s.jobs[name] = job
+	}
+}
+
+// WithLogger устанавливает логгер расписания
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Scheduler) {
+		s.logger = logger
+	}
+}
+
+// New создает расписание и проверяет конфигурацию
+// Каждая задача из config.Jobs должна быть зарегистрирована через WithJob
+func New(config Config, opts ...Option) (*Scheduler, error) {
+	s := &Scheduler{
+		config:     config,
+		jobs:       make(map[string]JobFunc),
+		logger:     slog.Default(),
+		strategies: make(map[string]types.ScheduledStrategy),
+	}
+	for _, option := range opts {
+		option(s)
+	}
+	if err := config.Rules.Validate(); err != nil {
+		return nil, fmt.Errorf("New: %w", err)
+	}
+	for name, rules := range config.Strategies {
+		if err := rules.Validate(); err != nil {
+			return nil, fmt.Errorf("New: %s: %w", name, err)
+		}
+	}
+	for _, job := range config.Jobs {
+		if _, ok := s.jobs[job.Name]; !ok {
+			return nil, fmt.Errorf("New: задача %q не зарегистрирована", job.Name)
+		}
+		if job.At != "" {
+			if _, err := clock(job.At); err != nil {
+				return nil, fmt.Errorf("New: задача %q: %w", job.Name, err)
+			}
+		} else if job.Every <= 0 {
+			return nil, fmt.Errorf("New: задача %q: не задан every или at", job.Name)
+		}
+	}
+	return s, nil
+}
+
+// Rules возвращает правила стратегии name
+func (s *Scheduler) Rules(name string) Rules {
+	if rules, ok := s.config.Strategies[name]; ok {
+		return rules
+	}
+	return s.config.Rules
+}
+
+// Attach подключает стратегию name к расписанию: открытие позиций ограничивается правилами,
+// позиция закрывается в моменты закрытия. Вызывается до запуска стратегии
+func (s *Scheduler) Attach(name string, strategy types.ScheduledStrategy) {
+	rules := s.Rules(name)
+	strategy.SetEntryFilter(rules.CanEnter)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.strategies[name] = strategy
+}
+
+// Detach отключает стратегию name от закрытия позиций по расписанию
+func (s *Scheduler) Detach(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.strategies, name)
+}
+
+// Run закрывает позиции стратегий по расписанию и запускает задачи до отмены ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.config.Jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runJob(ctx, job)
+		}()
+	}
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case now := <-ticker.C:
+			s.flattenDue(last, now)
+			last = now
+		}
+	}
+}
+
+// flattenDue закрывает позиции стратегий, момент закрытия которых попал в (from, to]
+func (s *Scheduler) flattenDue(from, to time.Time) {
+	s.mu.Lock()
+	strategies := maps.Clone(s.strategies)
+	s.mu.Unlock()
+	for name, strategy := range strategies {
+		rules := s.Rules(name)
+		next := rules.NextFlatten(from)
+		if next.IsZero() || next.After(to) {
+			continue
+		}
+		s.logger.Warn("flatten strategy by schedule", "strategy", name, "time", next)
+		strategy.Flatten()
+	}
+}
+
+// runJob запускает задачу по расписанию до отмены ctx, запуски одной задачи не пересекаются
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	for {
+		timer := time.NewTimer(time.Until(job.next(time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		start := time.Now()
+		if err := s.jobs[job.Name](ctx); err != nil {
+			s.logger.Error("scheduled job failed", "job", job.Name, "error", err)
+			continue
+		}
+		s.logger.Info("scheduled job done", "job", job.Name, "duration", time.Since(start))
+	}
+}
+
+// next возвращает время следующего запуска задачи после t
+func (j *Job) next(t time.Time) time.Time {
+	if j.At == "" {
+		return t.Add(time.Duration(j.Every))
+	}
+	minute, _ := clock(j.At)
+	t = t.UTC()
+	at := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Add(time.Duration(minute) * time.Minute)
+	if !at.After(t) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at
+}
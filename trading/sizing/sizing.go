@@ -0,0 +1,158 @@
+package sizing
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+	"goTradingBot/ta"
+	"goTradingBot/trading/types"
+	"goTradingBot/utils/numeric"
+	"math"
+)
+
+// secondsPerYear для приведения волатильности к годовой, криптовалюты торгуются круглосуточно
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// Input данные для расчета размера позиции
+type Input struct {
+	Signal     types.Signal          // направление позиции Buy или Sell
+	Price      float64               // текущая цена
+	Budget     float64               // капитал стратегии: бюджет портфеля или фиксированный баланс
+	Candles    []cdl.Candle          // последние закрытые свечи интервала стратегии
+	Interval   cdl.Interval          // интервал свечей
+	Confidence float64               // вероятность роста по модели от 0 до 1, NaN - неизвестна
+	Instrument *types.InstrumentInfo // правила инструмента, nil - количество в монете без округления
+}
+
+// Sizer рассчитывает количество позиции по сигналу
+// Size возвращает количество без знака, округленное по Quantize
+type Sizer interface {
+	Name() string
+	Size(in *Input) (float64, error)
+}
+
+// sizer рассчитывает стоимость позиции size в котируемой монете,
+// количество определяется по правилам инструмента
+type sizer struct {
+	name string
+	size func(in *Input) (float64, error)
+}
+
+func (s *sizer) Name() string { return s.name }
+
+func (s *sizer) Size(in *Input) (float64, error) {
+	if in.Price <= 0 {
+		return 0, fmt.Errorf("%s: неверная цена %v", s.name, in.Price)
+	}
+	notional, err := s.size(in)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", s.name, err)
+	}
+	if in.Instrument == nil {
+		return Quantize(notional/in.Price, in.Price, nil), nil
+	}
+	return Quantize(in.Instrument.QtyForNotional(notional, in.Price), in.Price, in.Instrument), nil
+}
+
+// Quantize округляет количество вниз до шага инструмента
+// Возвращает 0, если ордер не проходит ограничения инструмента. При info == nil возвращает qty
+func Quantize(qty, price float64, info *types.InstrumentInfo) float64 {
+	if info == nil {
+		return qty
+	}
+	qty = info.RoundQty(math.Max(qty, 0), false)
+	if !info.ValidOrder(qty, price) {
+		return 0
+	}
+	return qty
+}
+
+// FixedNotional позиция фиксированной стоимости notional
+func FixedNotional(notional float64) Sizer {
+	return &sizer{name: "fixedNotional", size: func(in *Input) (float64, error) {
+		return notional, nil
+	}}
+}
+
+// FixedFraction позиция стоимостью fraction от капитала стратегии
+// FixedFraction(1) соответствует размеру Budget / Price
+func FixedFraction(fraction float64) Sizer {
+	return &sizer{name: "fixedFraction", size: func(in *Input) (float64, error) {
+		return in.Budget * fraction, nil
+	}}
+}
+
+// ATRRisk позиция с фиксированным риском: при стопе на расстоянии stopMult * ATR(period)
+// убыток равен risk от капитала. Стоимость позиции не превышает капитала стратегии
+func ATRRisk(risk float64, period int, stopMult float64) Sizer {
+	return &sizer{name: "atrRisk", size: func(in *Input) (float64, error) {
+		if len(in.Candles) < period+1 {
+			return 0, fmt.Errorf("недостаточно свечей для ATR(%d): %d", period, len(in.Candles))
+		}
+		atr := ta.NewATR(in.Candles, period).Last("ATR")
+		if atr <= 0 {
+			return 0, fmt.Errorf("нулевой ATR")
+		}
+		notional := in.Budget * risk * in.Price / (atr * stopMult)
+		return math.Min(notional, in.Budget), nil
+	}}
+}
+
+// VolatilityTarget позиция, годовая волатильность которой равна target
+// Волатильность оценивается по доходности последних lookback свечей.
+// Стоимость позиции не превышает капитала стратегии
+func VolatilityTarget(target float64, lookback int) Sizer {
+	return &sizer{name: "volatilityTarget", size: func(in *Input) (float64, error) {
+		if len(in.Candles) < lookback+1 {
+			return 0, fmt.Errorf("недостаточно свечей для волатильности: %d", len(in.Candles))
+		}
+		closes := make([]float64, lookback+1)
+		for i, c := range in.Candles[len(in.Candles)-lookback-1:] {
+			closes[i] = c.C
+		}
+		annual := Volatility(closes) * math.Sqrt(secondsPerYear/float64(in.Interval.AsSeconds()))
+		if annual <= 0 {
+			return 0, fmt.Errorf("нулевая волатильность")
+		}
+		weight := math.Min(target/annual, 1)
+		return in.Budget * weight, nil
+	}}
+}
+
+// FractionalKelly позиция стоимостью fraction от доли Келли капитала
+// Вероятность успеха - уверенность модели в направлении сигнала, payoff - ожидаемое
+// отношение прибыли к убытку. Без уверенности модели позиция не открывается
+func FractionalKelly(fraction, payoff float64) Sizer {
+	return &sizer{name: "fractionalKelly", size: func(in *Input) (float64, error) {
+		if math.IsNaN(in.Confidence) {
+			return 0, fmt.Errorf("нет уверенности модели")
+		}
+		p := in.Confidence
+		if in.Signal == types.Sell {
+			p = 1 - p
+		}
+		if payoff <= 0 {
+			return 0, nil
+		}
+		kelly := math.Max(p-(1-p)/payoff, 0)
+		return in.Budget * math.Min(fraction*kelly, 1), nil
+	}}
+}
+
+// Volatility стандартное отклонение логарифмической доходности цен closes
+func Volatility(closes []float64) float64 {
+	var returns []float64
+	for i := 1; i < len(closes); i++ {
+		if closes[i-1] > 0 && closes[i] > 0 {
+			returns = append(returns, math.Log(closes[i]/closes[i-1]))
+		}
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+	mean := numeric.Avg(returns)
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns)-1))
+}
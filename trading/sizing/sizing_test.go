@@ -0,0 +1,71 @@
+package sizing
+
+import (
+	"goTradingBot/cdl"
+	"goTradingBot/trading/types"
+	"math"
+	"testing"
+)
+
+// candles возвращает свечи с ценой закрытия, чередующей рост и падение на step
+func candles(n int, price, step float64) []cdl.Candle {
+	res := make([]cdl.Candle, n)
+	for i := range res {
+		c := price
+		if i%2 == 1 {
+			c = price * (1 + step)
+		}
+		res[i] = cdl.Candle{Time: int64(i) * 60000, O: c, H: c * 1.01, L: c * 0.99, C: c}
+	}
+	return res
+}
+
+func TestSizers(t *testing.T) {
+	info := &types.InstrumentInfo{QtyPrecision: 2, MinOrderAmt: 5}
+	in := func() *Input {
+		return &Input{
+			Signal: types.Buy, Price: 100, Budget: 1000,
+			Candles: candles(50, 100, 0.01), Interval: cdl.M1,
+			Confidence: 0.75, Instrument: info,
+		}
+	}
+
+	tests := []struct {
+		sizer Sizer
+		want  float64
+	}{
+		{FixedNotional(250), 2.5},
+		{FixedFraction(0.333), 3.33},
+		// риск 1% = 10, стоп 2 * ATR(14) ~ 2 * 2.01
+		{ATRRisk(0.01, 14, 2), 2.48},
+		// дробный Келли: 0.5 * (0.75 - 0.25) = 0.25 капитала
+		{FractionalKelly(0.5, 1), 2.5},
+	}
+	for _, tt := range tests {
+		got, err := tt.sizer.Size(in())
+		if err != nil || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: %v %v, ожидается %v", tt.sizer.Name(), got, err, tt.want)
+		}
+	}
+
+	// высокая волатильность уменьшает позицию, ограничение - весь капитал
+	high, err := VolatilityTarget(0.5, 20).Size(in())
+	if err != nil || high <= 0 || high >= 10 {
+		t.Errorf("volatilityTarget: %v %v", high, err)
+	}
+	if full, _ := VolatilityTarget(1e6, 20).Size(in()); full != 10 {
+		t.Errorf("volatilityTarget без ограничения капитала: %v", full)
+	}
+
+	sell := in()
+	sell.Signal = types.Sell
+	if qty, _ := FractionalKelly(0.5, 1).Size(sell); qty != 0 {
+		t.Errorf("Келли против модели: %v", qty)
+	}
+	if qty, _ := FixedNotional(4).Size(in()); qty != 0 {
+		t.Errorf("меньше минимальной суммы ордера: %v", qty)
+	}
+	if _, err := ATRRisk(0.01, 100, 2).Size(in()); err == nil {
+		t.Error("ATRRisk без достаточного числа свечей")
+	}
+}
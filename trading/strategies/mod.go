@@ -1,13 +1,21 @@
 package strategies
 
 import (
+	"context"
+	"fmt"
 	"goTradingBot/cdl"
+	"goTradingBot/external/cryptos"
 	"goTradingBot/predict"
+	"goTradingBot/predict/features"
 	"goTradingBot/predict/portal"
+	"goTradingBot/trading/regime"
+	"goTradingBot/trading/sizing"
 	"goTradingBot/trading/types"
-	"goTradingBot/utils/numeric"
 	"goTradingBot/utils/seqs"
 	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -18,21 +26,74 @@ type Strategy struct {
 	StrategyABC
 	symbol            string
 	interval          cdl.Interval
-	model             string
-	balance           float64
-	longRatio         float64
+	config            atomic.Pointer[strategyConfig]
+	budget            types.BudgetFunc
 	orderLog          *seqs.OrderedMap[string, *types.Order]
-	qtyPrecision      int
-	minOrderAmt       float64
-	tickSize          float64
-	tickSizePrecision int
+	run               atomic.Pointer[strategyRun]
 	closeOrderTimeout time.Duration
-	confirmCandleChan chan *cdl.CandleStreamData
-	lastPriceChan     chan float64
 	lastPrice         atomic.Pointer[float64]
-	limitOrderOffset  float64
 	limitCeilPrice    atomic.Pointer[float64]
 	limitFloorPrice   atomic.Pointer[float64]
+	marketSource      features.MarketSource
+	sizer             sizing.Sizer
+	entryFilter       types.EntryFilter
+	detector          *regime.Detector
+	regimeFlatten     bool
+	rules             []Rule
+}
+
+// strategyRun состояние запуска стратегии от Go до отмены контекста
+// Передается горутинам запуска, поэтому Init следующего запуска не меняет данные работающих горутин
+type strategyRun struct {
+	ctx        context.Context
+	orders     chan<- *types.OrderRequest
+	subData    *types.SubData
+	instrument *types.InstrumentInfo
+	flatten    chan struct{} // запросы Flatten, выполняются горутиной confirmCandleHandler
+	done       chan struct{} // закрывается после отправки закрывающей заявки при остановке
+}
+
+// strategyConfig параметры стратегии, которые можно заменить без остановки через Reload
+type strategyConfig struct {
+	model            string
+	balance          float64
+	longRatio        float64
+	limitOrderOffset float64
+	leverage         float64
+	regimes          []regime.Regime
+}
+
+// sizingCandles количество свечей, передаваемых в sizing.Input
+const sizingCandles = 200
+
+// stopCloseTimeout время ожидания бота закрывающей заявкой при остановке стратегии
+const stopCloseTimeout = 10 * time.Second
+
+// StrategyOption определяет тип функции для настройки Strategy
+type StrategyOption func(*Strategy)
+
+// WithSizer устанавливает расчет размера позиции, по умолчанию sizing.FixedFraction(1)
+func WithSizer(sizer sizing.Sizer) StrategyOption {
+	return func(s *Strategy) {
+		s.sizer = sizer
+	}
+}
+
+// WithLeverage устанавливает кредитное плечо инструмента при запуске стратегии ботом
+func WithLeverage(leverage float64) StrategyOption {
+	return func(s *Strategy) {
+		s.config.Load().leverage = leverage
+	}
+}
+
+// WithRegimes ограничивает открытие позиций режимами рынка allowed по детектору инструмента стратегии
+// Пустой allowed - все режимы. flatten - закрывать позицию при переходе в запрещенный режим
+func WithRegimes(detector *regime.Detector, flatten bool, allowed ...regime.Regime) StrategyOption {
+	return func(s *Strategy) {
+		s.detector = detector
+		s.regimeFlatten = flatten
+		s.config.Load().regimes = allowed
+	}
 }
 
 func NewStrategy(
@@ -42,102 +103,337 @@ func NewStrategy(
 	balance float64,
 	longRatio float64,
 	limitOrderOffset float64,
+	opts ...StrategyOption,
 ) *Strategy {
 	closeOrderTimeout := time.Duration(interval.AsSeconds())*time.Second - 5
 
-	return &Strategy{
+	s := &Strategy{
 		symbol:            symbol,
 		interval:          interval,
-		model:             model,
-		balance:           balance,
-		longRatio:         longRatio,
 		orderLog:          seqs.NewOrderedMap[string, *types.Order](32),
 		closeOrderTimeout: closeOrderTimeout,
-		confirmCandleChan: make(chan *cdl.CandleStreamData),
-		lastPriceChan:     make(chan float64, 8),
-		limitOrderOffset:  limitOrderOffset,
+		marketSource:      cryptos.NewClient(),
+		sizer:             sizing.FixedFraction(1),
+	}
+	s.config.Store(&strategyConfig{
+		model:            model,
+		balance:          balance,
+		longRatio:        longRatio,
+		limitOrderOffset: limitOrderOffset,
+	})
+	for _, option := range opts {
+		option(s)
+	}
+	return s
+}
+
+// Name возвращает имя стратегии вида "HYPEUSDT-M5"
+func (s *Strategy) Name() string {
+	return s.symbol + "-" + s.interval.AsDisplayName()
+}
+
+// Leverage возвращает кредитное плечо стратегии, 0 - плечо биржи не меняется
+func (s *Strategy) Leverage() float64 {
+	return s.config.Load().leverage
+}
+
+// Symbol возвращает торговую пару стратегии
+func (s *Strategy) Symbol() string {
+	return s.symbol
+}
+
+// Interval возвращает интервал свечей стратегии
+func (s *Strategy) Interval() cdl.Interval {
+	return s.interval
+}
+
+// Position возвращает исполненную позицию стратегии
+func (s *Strategy) Position() float64 {
+	var info *types.InstrumentInfo
+	if r := s.run.Load(); r != nil {
+		info = r.instrument
+	}
+	return s.qtyPosition(info)
+}
+
+// Balance возвращает фиксированный бюджет стратегии
+func (s *Strategy) Balance() float64 {
+	return s.config.Load().balance
+}
+
+// SetBudget подключает бюджет портфеля вместо фиксированного, вызывается до Go
+func (s *Strategy) SetBudget(budget types.BudgetFunc) {
+	s.budget = budget
+}
+
+// SetEntryFilter ограничивает открытие и увеличение позиции по времени, вызывается до Go
+func (s *Strategy) SetEntryFilter(filter types.EntryFilter) {
+	s.entryFilter = filter
+}
+
+// Flatten закрывает позицию стратегии рыночной заявкой, стратегия продолжает работу
+// Заявка отправляется горутиной обработки свечей, поэтому не пересекается с заявками по сигналам
+func (s *Strategy) Flatten() {
+	r := s.run.Load()
+	if r == nil || r.ctx.Err() != nil {
+		return
+	}
+	r.requestFlatten()
+}
+
+// requestFlatten передает запрос закрытия позиции горутине confirmCandleHandler, не блокируя вызывающего
+func (r *strategyRun) requestFlatten() {
+	select {
+	case r.flatten <- struct{}{}:
+	default:
+		// предыдущий запрос еще не выполнен
+	}
+}
+
+// currentBudget возвращает бюджет портфеля или фиксированный бюджет
+func (s *Strategy) currentBudget() float64 {
+	if s.budget != nil {
+		return s.budget()
+	}
+	return s.config.Load().balance
+}
+
+// Params возвращает параметры стратегии
+func (s *Strategy) Params() map[string]any {
+	config := s.config.Load()
+	params := map[string]any{
+		"symbol":           s.symbol,
+		"interval":         s.interval.AsDisplayName(),
+		"model":            config.model,
+		"balance":          config.balance,
+		"longRatio":        config.longRatio,
+		"limitOrderOffset": config.limitOrderOffset,
+		"sizer":            s.sizer.Name(),
+		"leverage":         config.leverage,
+	}
+	if s.detector != nil {
+		regimes := make([]string, len(config.regimes))
+		for i, r := range config.regimes {
+			regimes[i] = string(r)
+		}
+		params["regimes"] = strings.Join(regimes, ",")
+	}
+	return params
+}
+
+// Reload заменяет параметры работающей стратегии, позиция и журнал ордеров сохраняются
+// Изменяемые параметры: model, balance, longRatio, limitOrderOffset, leverage и regimes.
+// symbol, interval и sizer изменить нельзя, их можно передать с текущим значением
+func (s *Strategy) Reload(params map[string]any) error {
+	config := *s.config.Load()
+	current := s.Params()
+	for key, value := range params {
+		var err error
+		switch key {
+		case "model":
+			model, ok := value.(string)
+			if !ok || model == "" {
+				err = fmt.Errorf("неверное значение %v", value)
+			}
+			config.model = model
+		case "balance":
+			config.balance, err = paramFloat(value, 0, math.Inf(1))
+		case "longRatio":
+			config.longRatio, err = paramFloat(value, 0, 1)
+		case "limitOrderOffset":
+			config.limitOrderOffset, err = paramFloat(value, 0, 1)
+		case "leverage":
+			config.leverage, err = paramFloat(value, 0, math.Inf(1))
+		case "regimes":
+			if s.detector == nil {
+				err = fmt.Errorf("детектор режима рынка не задан")
+				break
+			}
+			config.regimes, err = paramRegimes(value)
+		case "symbol", "interval", "sizer":
+			if fmt.Sprint(value) != fmt.Sprint(current[key]) {
+				err = fmt.Errorf("параметр не изменяется без пересоздания стратегии")
+			}
+		default:
+			err = fmt.Errorf("неизвестный параметр")
+		}
+		if err != nil {
+			return fmt.Errorf("Reload: %s: %w", key, err)
+		}
+	}
+	s.config.Store(&config)
+	return nil
+}
+
+// paramFloat приводит значение параметра к числу из диапазона [min, max]
+func paramFloat(value any, min, max float64) (float64, error) {
+	var v float64
+	switch value := value.(type) {
+	case float64:
+		v = value
+	case int:
+		v = float64(value)
+	case string:
+		var err error
+		if v, err = strconv.ParseFloat(value, 64); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("неверное значение %v", value)
+	}
+	if math.IsNaN(v) || v < min || v > max {
+		return 0, fmt.Errorf("значение %v вне диапазона [%v, %v]", v, min, max)
 	}
+	return v, nil
 }
 
+// paramRegimes приводит значение параметра к списку режимов рынка: строке через запятую или списку строк
+func paramRegimes(value any) ([]regime.Regime, error) {
+	switch v := value.(type) {
+	case string:
+		return regime.Parse(v)
+	case []any:
+		names := make([]string, len(v))
+		for i, name := range v {
+			names[i] = fmt.Sprint(name)
+		}
+		return regime.Parse(strings.Join(names, ","))
+	case []string:
+		return regime.Parse(strings.Join(v, ","))
+	}
+	return nil, fmt.Errorf("неверное значение %v", value)
+}
+
+// Go запускает стратегию с контекстом из Init
+// Остановленную стратегию можно запустить повторно после нового вызова Init.
+// Go дожидается завершения предыдущего запуска, чтобы его закрывающая заявка не пришла после нового запуска
 func (s *Strategy) Go() error {
+	if prev := s.run.Load(); prev != nil {
+		<-prev.done
+	}
 	info, err := s.subData.GetInstrumentInfo(s.symbol)
 	if err != nil {
 		return err
 	}
 
-	s.qtyPrecision = info.QtyPrecision
-	s.minOrderAmt = info.MinOrderAmt
-	s.tickSize = info.TickSize
-	s.tickSizePrecision = numeric.DecimalPlaces(s.tickSize)
+	if !info.Tradable() {
+		return fmt.Errorf("Go: инструмент %s недоступен для торговли: %s", s.symbol, info.Status)
+	}
+	if s.detector != nil && (s.detector.Symbol() != s.symbol || s.detector.Interval() != s.interval) {
+		return fmt.Errorf("Go: детектор режима рынка %s не соответствует стратегии %s", s.detector.Symbol(), s.Name())
+	}
 
-	go s.background()
-	go s.confirmCandleHandler()
-	go s.observeCandleStreamData()
+	r := &strategyRun{
+		ctx:        s.ctx,
+		orders:     s.orderRequest,
+		subData:    s.subData,
+		instrument: info,
+		flatten:    make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	s.run.Store(r)
+	unsubscribe := func() {}
+	if s.detector != nil {
+		unsubscribe = s.detector.Subscribe(func(change regime.Change) { s.onRegimeChange(r, change) })
+	}
+	confirmCandleChan := make(chan *cdl.CandleStreamData)
+	lastPriceChan := make(chan float64, 8)
+	go s.background(r, lastPriceChan)
+	go s.confirmCandleHandler(r, confirmCandleChan)
+	go s.observeCandleStreamData(r, lastPriceChan, confirmCandleChan)
 	go func() {
-		<-s.ctx.Done()
-		close(s.confirmCandleChan)
-		close(s.lastPriceChan)
-		s.close()
+		<-r.ctx.Done()
+		unsubscribe()
 	}()
 
 	return nil
 }
 
-func (s *Strategy) close() {
-	qty := -s.qtyPosition()
+// close отправляет рыночную заявку, закрывающую позицию, пока не отменен ctx
+func (s *Strategy) close(ctx context.Context, r *strategyRun) {
+	qty := r.instrument.RoundQty(-s.qtyPosition(r.instrument), true)
+	if qty == 0 {
+		return
+	}
 	order := types.NewOrder(s.symbol, qty, nil)
 	linkId := uuid.NewString()
-	s.orderRequest <- &types.OrderRequest{
+	// заявка учитывается в позиции, только если бот ее разместит
+	s.orderLog.Set(linkId, order)
+	select {
+	case <-ctx.Done():
+	case r.orders <- &types.OrderRequest{
 		LinkId:       linkId,
 		Tag:          "test",
 		Order:        order,
 		CloseTimeout: s.closeOrderTimeout,
 		Reply:        nil,
+		Flatten:      true,
+	}:
 	}
 }
 
-func (s *Strategy) background() {
+func (s *Strategy) background(r *strategyRun, lastPriceChan <-chan float64) {
 	ticker := time.NewTicker(8 * time.Second)
 	defer ticker.Stop()
 
-	lastPrice := <-s.lastPriceChan
+	var lastPrice float64
+	select {
+	case <-r.ctx.Done():
+		return
+	case lastPrice = <-lastPriceChan:
+	}
 	s.lastPrice.Store(&lastPrice)
 	s.limitCeilPrice.Store(&lastPrice)
-	s.limitCeilPrice.Store(&lastPrice)
-	for lastPrice := range s.lastPriceChan {
-		s.lastPrice.Store(&lastPrice)
+	s.limitFloorPrice.Store(&lastPrice)
+	for {
 		select {
-		case <-ticker.C:
-			limitCeilPrice := numeric.TruncateFloat(
-				lastPrice*(1+s.limitOrderOffset), s.tickSizePrecision,
-			)
-			s.limitCeilPrice.Store(&limitCeilPrice)
-			limitFloorPrice := numeric.TruncateFloat(
-				lastPrice*(1-s.limitOrderOffset), s.tickSizePrecision,
-			)
-			s.limitFloorPrice.Store(&limitFloorPrice)
-		default:
+		case <-r.ctx.Done():
+			return
+		case lastPrice := <-lastPriceChan:
+			s.lastPrice.Store(&lastPrice)
+			select {
+			case <-ticker.C:
+				offset := s.config.Load().limitOrderOffset
+				limitCeilPrice := r.instrument.RoundPrice(lastPrice * (1 + offset))
+				s.limitCeilPrice.Store(&limitCeilPrice)
+				limitFloorPrice := r.instrument.RoundPrice(lastPrice * (1 - offset))
+				s.limitFloorPrice.Store(&limitFloorPrice)
+			default:
+			}
 		}
 	}
 }
 
-func (s *Strategy) observeCandleStreamData() {
+func (s *Strategy) observeCandleStreamData(
+	r *strategyRun,
+	lastPriceChan chan<- float64,
+	confirmCandleChan chan<- *cdl.CandleStreamData,
+) {
 	for {
 		select {
-		case <-s.ctx.Done():
+		case <-r.ctx.Done():
 			return
 		default:
 			ch := make(chan *cdl.CandleStreamData)
-			done, err := s.subData.SubscribeChan(s.symbol, s.interval, ch)
+			done, err := r.subData.SubscribeChan(s.symbol, s.interval, ch)
 			if err != nil {
 				time.Sleep(time.Second)
 				continue
 			}
 
+		stream:
 			for data := range ch {
-				s.lastPriceChan <- data.Candle.C
+				select {
+				case <-r.ctx.Done():
+					break stream
+				case lastPriceChan <- data.Candle.C:
+				}
 				if data.Confirm {
-					s.confirmCandleChan <- data
+					select {
+					case <-r.ctx.Done():
+						break stream
+					case confirmCandleChan <- data:
+					}
 				}
 			}
 			close(done)
@@ -145,7 +441,10 @@ func (s *Strategy) observeCandleStreamData() {
 	}
 }
 
-func (s *Strategy) qtyPosition() float64 {
+// qtyPosition возвращает исполненную позицию стратегии по инструменту info
+// На споте комиссия покупки удерживается в базовой монете, в кошельке остается ExecQty - Fee
+func (s *Strategy) qtyPosition(info *types.InstrumentInfo) float64 {
+	spot := info != nil && info.Category == types.Spot
 	var qtyPosition float64
 	s.orderLog.Range(func(_ string, o *types.Order) bool {
 		o.Lock()
@@ -153,38 +452,53 @@ func (s *Strategy) qtyPosition() float64 {
 
 		if o.ID != "" {
 			qtyPosition += o.ExecQty
+			if spot && o.ExecQty > 0 {
+				qtyPosition -= o.Fee
+			}
 		}
 		return true
 	})
 	return qtyPosition
 }
 
-func (s *Strategy) confirmCandleHandler() {
-	for data := range s.confirmCandleChan {
-		signal, _ := s.getSignal(data)
+func (s *Strategy) confirmCandleHandler(r *strategyRun, confirmCandleChan <-chan *cdl.CandleStreamData) {
+	defer close(r.done)
+	for {
+		var data *cdl.CandleStreamData
+		select {
+		case <-r.ctx.Done():
+			// при остановке позиция закрывается, заявка ждет бота не дольше stopCloseTimeout
+			ctx, cancel := context.WithTimeout(context.Background(), stopCloseTimeout)
+			s.close(ctx, r)
+			cancel()
+			return
+		case <-r.flatten:
+			s.close(r.ctx, r)
+			continue
+		case data = <-confirmCandleChan:
+		}
+		s.updateRegime(r, data)
+		signal, confidence, _ := s.getSignal(r, data)
+		if signal != types.Hold {
+			signal = s.applyRules(r, data, signal)
+		}
 		if signal == types.Hold {
 			continue
 		}
 
-		calcQty := func() float64 {
-			qty := s.balance / *s.lastPrice.Load()
-			if signal == types.Buy {
-				return qty * s.longRatio
-			} else if signal == types.Sell {
-				return -qty * (1 - s.longRatio)
-			}
-			return 0
+		target, err := s.targetQty(r, signal, confidence)
+		if err != nil {
+			continue
 		}
 
-		var qty float64
-		if s.orderLog.Len() == 0 {
-			qty = calcQty()
-		} else {
-			qty = -s.qtyPosition() + calcQty()
+		position := s.qtyPosition(r.instrument)
+		if !s.canEnter(time.Now()) {
+			target = restrictEntry(position, target)
 		}
+		qty := target - position
 
-		qty = numeric.RoundFloat(qty, s.qtyPrecision)
-		if math.Abs(qty**s.lastPrice.Load()) < s.minOrderAmt {
+		qty = r.instrument.RoundQty(qty, false)
+		if !r.instrument.ValidOrder(qty, *s.lastPrice.Load()) {
 			continue
 		}
 
@@ -198,8 +512,8 @@ func (s *Strategy) confirmCandleHandler() {
 		linkId := uuid.NewString()
 		s.orderLog.Set(linkId, order)
 		select {
-		case <-s.ctx.Done():
-		case s.orderRequest <- &types.OrderRequest{
+		case <-r.ctx.Done():
+		case r.orders <- &types.OrderRequest{
 			LinkId:       linkId,
 			Tag:          "test",
 			Order:        order,
@@ -210,11 +524,104 @@ func (s *Strategy) confirmCandleHandler() {
 	}
 }
 
-func (s *Strategy) getSignal(data *cdl.CandleStreamData) (types.Signal, error) {
+// canEnter сообщает, можно ли открывать или увеличивать позицию по расписанию и режиму рынка
+func (s *Strategy) canEnter(t time.Time) bool {
+	if s.entryFilter != nil && !s.entryFilter(t) {
+		return false
+	}
+	return s.detector == nil || s.regimeAllowed(s.detector.Current())
+}
+
+// regimeAllowed сообщает, разрешено ли открытие позиций в режиме r
+// До определения режима открытие позиций не ограничивается
+func (s *Strategy) regimeAllowed(r regime.Regime) bool {
+	regimes := s.config.Load().regimes
+	return r == regime.Unknown || len(regimes) == 0 || slices.Contains(regimes, r)
+}
+
+// onRegimeChange закрывает позицию при переходе в запрещенный режим, если задан flatten
+// Вызывается горутиной, обновившей детектор, в том числе другой стратегии,
+// поэтому только передает закрытие горутине своего запуска
+func (s *Strategy) onRegimeChange(r *strategyRun, change regime.Change) {
+	if s.regimeFlatten && s.regimeAllowed(change.From) && !s.regimeAllowed(change.To) {
+		r.requestFlatten()
+	}
+}
+
+// updateRegime обновляет детектор режима рынка закрытой свечой data
+func (s *Strategy) updateRegime(r *strategyRun, data *cdl.CandleStreamData) {
+	if s.detector == nil {
+		return
+	}
+	candles, err := r.subData.GetCandles(s.symbol, s.interval, s.detector.Candles())
+	if err != nil || len(candles) == 0 {
+		return
+	}
+	if candles[len(candles)-1].Time < data.Candle.Time {
+		candles = append(candles, data.Candle)
+	}
+	s.detector.Update(candles)
+}
+
+// applyRules подтверждает сигнал правилами стратегии по закрытым свечам, включая свечу data
+func (s *Strategy) applyRules(r *strategyRun, data *cdl.CandleStreamData, signal types.Signal) types.Signal {
+	if len(s.rules) == 0 {
+		return signal
+	}
+	candles, err := r.subData.GetCandles(s.symbol, s.interval, ruleCandles)
+	if err != nil || len(candles) == 0 {
+		return types.Hold
+	}
+	if candles[len(candles)-1].Time < data.Candle.Time {
+		candles = append(candles, data.Candle)
+	}
+	return confirmSignal(s.rules, candles, signal)
+}
+
+// restrictEntry ограничивает целевую позицию уменьшением текущей position
+func restrictEntry(position, target float64) float64 {
+	if position == 0 || math.Signbit(position) != math.Signbit(target) {
+		return 0
+	}
+	return math.Copysign(min(math.Abs(target), math.Abs(position)), position)
+}
+
+// targetQty возвращает целевое количество позиции по сигналу: long со знаком плюс, short - минус
+// Количество задается в единицах инструмента: в монете для spot и linear, в контрактах для inverse
+func (s *Strategy) targetQty(r *strategyRun, signal types.Signal, confidence float64) (float64, error) {
+	candles, err := r.subData.GetCandles(s.symbol, s.interval, sizingCandles)
+	if err != nil {
+		return 0, err
+	}
+	qty, err := s.sizer.Size(&sizing.Input{
+		Signal:     signal,
+		Price:      *s.lastPrice.Load(),
+		Budget:     s.currentBudget(),
+		Candles:    candles,
+		Interval:   s.interval,
+		Confidence: confidence,
+		Instrument: r.instrument,
+	})
+	if err != nil {
+		return 0, err
+	}
+	longRatio := s.config.Load().longRatio
+	if signal == types.Buy {
+		return qty * longRatio, nil
+	}
+	// на споте продажа только закрывает позицию
+	if !r.instrument.CanShort() {
+		return 0, nil
+	}
+	return -qty * (1 - longRatio), nil
+}
+
+// getSignal возвращает сигнал и вероятность роста по последнему предсказанию модели
+func (s *Strategy) getSignal(r *strategyRun, data *cdl.CandleStreamData) (types.Signal, float64, error) {
 	limit := predict.GetModelWinSize(predict.A6N21P9) + predict.FeatureOffset
-	candles, err := s.subData.GetCandles(s.symbol, data.Interval, limit)
+	candles, err := r.subData.GetCandles(s.symbol, data.Interval, limit)
 	if err != nil {
-		return types.Hold, err
+		return types.Hold, math.NaN(), err
 	}
 
 	intervalDuration := time.Duration(data.Interval.AsSeconds())
@@ -223,26 +630,36 @@ func (s *Strategy) getSignal(data *cdl.CandleStreamData) (types.Signal, error) {
 		candles = append(candles, data.Candle)
 	}
 
-	features := predict.FeaturesGeneratorModel(predict.A6N21P9).
-		GenTranspose(candles, predict.FeatureOffset, -1)
+	fg := predict.FeaturesGeneratorModel(predict.A6N21P9)
+	aux, err := fg.LoadAux(r.subData, s.marketSource, data.Interval, len(candles))
+	if err != nil {
+		return types.Hold, math.NaN(), err
+	}
+	if aux != nil {
+		fg = fg.WithAux(aux)
+	}
+	features, err := fg.GenTranspose(candles, predict.FeatureOffset, -1)
+	if err != nil {
+		return types.Hold, math.NaN(), err
+	}
 	features = features[len(features)-2:]
 
 	prediction, err := portal.GetPrediction(
 		features,
-		s.model,
+		s.config.Load().model,
 	).UnwrapSinglePredict()
 
 	if err != nil {
-		return types.Hold, err
+		return types.Hold, math.NaN(), err
 	}
 
 	n := len(prediction)
 	if prediction[n-1] > 0.5 && prediction[n-2] < 0.5 {
-		return types.Buy, nil
+		return types.Buy, prediction[n-1], nil
 	}
 	if prediction[n-1] < 0.5 && prediction[n-2] > 0.5 {
-		return types.Sell, nil
+		return types.Sell, prediction[n-1], nil
 	}
 
-	return types.Hold, nil
+	return types.Hold, prediction[n-1], nil
 }
@@ -0,0 +1,146 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"goTradingBot/cdl"
+	"goTradingBot/trading/regime"
+	"goTradingBot/trading/types"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeProvider отдает параметры инструмента категории category, поток свечей недоступен
+type fakeProvider struct {
+	category string
+}
+
+func (fakeProvider) CandleStream(context.Context, string, cdl.Interval) (<-chan *cdl.CandleStreamData, error) {
+	return nil, errors.New("нет соединения")
+}
+
+func (fakeProvider) GetCandles(string, cdl.Interval, int) ([]cdl.Candle, error) {
+	return nil, errors.New("нет соединения")
+}
+
+func (p fakeProvider) GetInstrumentInfo(string) ([]byte, error) {
+	return []byte(`{"symbol":"BTCUSDT","category":"` + p.category + `","qtyStep":0.001}`), nil
+}
+
+// receiveOrder возвращает заявку стратегии или завершает тест по таймауту
+func receiveOrder(t *testing.T, ch <-chan *types.OrderRequest) *types.OrderRequest {
+	t.Helper()
+	select {
+	case req := <-ch:
+		return req
+	case <-time.After(time.Second):
+		t.Fatal("нет заявки")
+		return nil
+	}
+}
+
+func TestStrategyRestart(t *testing.T) {
+	s := NewStrategy("BTCUSDT", cdl.M5, "model", 100, 0.5, 0.001)
+	s.orderLog.Set("open", &types.Order{ID: "1", Symbol: "BTCUSDT", Qty: 1, ExecQty: 1})
+	subData := types.NewSubData(t.Context(), fakeProvider{}, 10)
+	ch := make(chan *types.OrderRequest, 8)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	s.Init(ctx, subData, ch)
+	if err := s.Go(); err != nil {
+		t.Fatal(err)
+	}
+	s.Flatten()
+	if req := receiveOrder(t, ch); req.Order.Qty != -1 || !req.Flatten {
+		t.Fatalf("Flatten: %v %v", req.Order.Qty, req.Flatten)
+	}
+
+	// остановка отправляет закрывающую заявку, повторный запуск идет параллельно с Flatten планировщика
+	cancel()
+	receiveOrder(t, ch)
+	ctx, cancel = context.WithCancel(t.Context())
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 4 {
+			s.Flatten()
+		}
+	}()
+	s.Init(ctx, subData, ch)
+	if err := s.Go(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	for len(ch) > 0 {
+		<-ch
+	}
+
+	s.Flatten()
+	if req := receiveOrder(t, ch); req.Order.Qty != -1 {
+		t.Fatalf("Flatten после перезапуска: %v", req.Order.Qty)
+	}
+}
+
+// trend возвращает n свечей с устойчивым ростом цены
+func trend(n int) []cdl.Candle {
+	rnd := rand.New(rand.NewPCG(2, 2))
+	candles := make([]cdl.Candle, n)
+	price, r := 100.0, 0.0
+	for i := range candles {
+		r = 0.5*r + 0.002 + rnd.NormFloat64()*0.002
+		next := price * math.Exp(r)
+		candles[i] = cdl.Candle{Time: int64(i) * 300000, O: price, H: max(price, next) * 1.001, L: min(price, next) * 0.999, C: next}
+		price = next
+	}
+	return candles
+}
+
+func TestRegimeChangeFlatten(t *testing.T) {
+	detector := regime.NewDetector("BTCUSDT", cdl.M5, regime.NewClassifier())
+	s := NewStrategy("BTCUSDT", cdl.M5, "model", 100, 0.5, 0.001, WithRegimes(detector, true, regime.Range))
+	s.orderLog.Set("open", &types.Order{ID: "1", Symbol: "BTCUSDT", Qty: 1, ExecQty: 1})
+	ch := make(chan *types.OrderRequest)
+	s.Init(t.Context(), types.NewSubData(t.Context(), fakeProvider{}, 10), ch)
+	if err := s.Go(); err != nil {
+		t.Fatal(err)
+	}
+
+	// детектор обновляет горутина другой стратегии, бот заявки не читает
+	updated := make(chan regime.Regime)
+	go func() { updated <- detector.Update(trend(300)) }()
+	select {
+	case r := <-updated:
+		if r != regime.TrendUp {
+			t.Fatalf("режим %s", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Update ждет отправки закрывающей заявки")
+	}
+	if req := receiveOrder(t, ch); req.Order.Qty != -1 || !req.Flatten {
+		t.Fatalf("закрытие при смене режима: %v %v", req.Order.Qty, req.Flatten)
+	}
+}
+
+func TestSpotFlatten(t *testing.T) {
+	// на споте комиссия покупки 0.0015 BTC удержана в базовой монете, продается остаток кошелька
+	s := NewStrategy("BTCUSDT", cdl.M5, "model", 100, 0.5, 0.001)
+	s.orderLog.Set("buy", &types.Order{ID: "1", Symbol: "BTCUSDT", Qty: 1, ExecQty: 1, Fee: 0.0015})
+	s.orderLog.Set("sell", &types.Order{ID: "2", Symbol: "BTCUSDT", Qty: -0.2, ExecQty: -0.2, Fee: 0.3})
+	ch := make(chan *types.OrderRequest, 1)
+	s.Init(t.Context(), types.NewSubData(t.Context(), fakeProvider{category: types.Spot}, 10), ch)
+	if err := s.Go(); err != nil {
+		t.Fatal(err)
+	}
+	if p := s.Position(); math.Abs(p-0.7985) > 1e-9 {
+		t.Errorf("позиция: %v", p)
+	}
+	s.Flatten()
+	if req := receiveOrder(t, ch); req.Order.Qty != -0.798 {
+		t.Errorf("закрытие спота: %v", req.Order.Qty)
+	}
+}
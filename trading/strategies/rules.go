@@ -0,0 +1,77 @@
+package strategies
+
+import (
+	"fmt"
+	"goTradingBot/cdl"
+	"goTradingBot/ta/structure"
+	"goTradingBot/trading/types"
+)
+
+// Rule формирует торговый сигнал по закрытым свечам, последняя свеча - текущая
+type Rule func(candles []cdl.Candle) types.Signal
+
+// ruleCandles количество свечей, передаваемых правилам
+const ruleCandles = 200
+
+// WithRules подтверждает сигналы модели правилами: сигнал исполняется,
+// только если все правила вернули тот же сигнал
+func WithRules(rules ...Rule) StrategyOption {
+	return func(s *Strategy) {
+		s.rules = append(s.rules, rules...)
+	}
+}
+
+// confirmSignal возвращает signal, если все правила rules вернули тот же сигнал, иначе Hold
+func confirmSignal(rules []Rule, candles []cdl.Candle, signal types.Signal) types.Signal {
+	for _, rule := range rules {
+		if rule(candles) != signal {
+			return types.Hold
+		}
+	}
+	return signal
+}
+
+// PatternRule возвращает правило по свечным паттернам на последней свече
+// Оценки направленных паттернов суммируются: Buy при сумме >= minScore, Sell при сумме <= -minScore.
+// Ненаправленные паттерны (доджи, внутренний бар) не учитываются. Без patterns используются все паттерны
+func PatternRule(minScore float64, patterns ...cdl.Pattern) Rule {
+	if len(patterns) == 0 {
+		patterns = cdl.Patterns()
+	}
+	return func(candles []cdl.Candle) types.Signal {
+		var score float64
+		for _, p := range patterns {
+			if p.Directional() {
+				score += p.Score(candles)
+			}
+		}
+		switch {
+		case score != 0 && score >= minScore:
+			return types.Buy
+		case score != 0 && score <= -minScore:
+			return types.Sell
+		}
+		return types.Hold
+	}
+}
+
+// StructureRule возвращает правило по состоянию тренда рыночной структуры на последней свече:
+// Buy при восходящем тренде, Sell при нисходящем, Hold в боковике
+func StructureRule(params structure.Params) (Rule, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("StructureRule: %w", err)
+	}
+	return func(candles []cdl.Candle) types.Signal {
+		a, _ := structure.NewAnalyzer(params)
+		for _, c := range candles {
+			a.Update(c)
+		}
+		switch a.Trend() {
+		case structure.UpTrend:
+			return types.Buy
+		case structure.DownTrend:
+			return types.Sell
+		}
+		return types.Hold
+	}, nil
+}
@@ -0,0 +1,92 @@
+package strategies
+
+import (
+	"goTradingBot/cdl"
+	"goTradingBot/ta/structure"
+	"goTradingBot/trading/types"
+	"testing"
+)
+
+func TestPatternRule(t *testing.T) {
+	// бычье поглощение: тело 1.6 перекрывает тело 1 предыдущей свечи, оценка 1 - 1/1.6 = 0.375
+	bullish := []cdl.Candle{
+		{O: 10, H: 10.2, L: 8.8, C: 9},
+		{O: 8.9, H: 10.6, L: 8.8, C: 10.5},
+	}
+	bearish := []cdl.Candle{
+		{O: 9, H: 10.2, L: 8.8, C: 10},
+		{O: 10.1, H: 10.2, L: 8.4, C: 8.5},
+	}
+	if s := PatternRule(0.3, cdl.Engulfing)(bullish); s != types.Buy {
+		t.Errorf("бычье поглощение: %v", s)
+	}
+	if s := PatternRule(0.3, cdl.Engulfing)(bearish); s != types.Sell {
+		t.Errorf("медвежье поглощение: %v", s)
+	}
+	if s := PatternRule(0.5, cdl.Engulfing)(bullish); s != types.Hold {
+		t.Errorf("оценка ниже порога: %v", s)
+	}
+	if s := PatternRule(0.3, cdl.Doji)(bullish); s != types.Hold {
+		t.Errorf("ненаправленный паттерн: %v", s)
+	}
+
+	// сигнал модели исполняется, только если его подтверждают все правила
+	buy := func([]cdl.Candle) types.Signal { return types.Buy }
+	rules := []Rule{PatternRule(0.3, cdl.Engulfing), buy}
+	cases := []struct {
+		rules   []Rule
+		candles []cdl.Candle
+		signal  types.Signal
+		want    types.Signal
+	}{
+		{nil, bearish, types.Sell, types.Sell},
+		{rules, bullish, types.Buy, types.Buy},
+		{rules, bullish, types.Sell, types.Hold},
+		{rules, bearish, types.Sell, types.Hold},
+		{rules[:1], bearish, types.Sell, types.Sell},
+	}
+	for i, c := range cases {
+		if got := confirmSignal(c.rules, c.candles, c.signal); got != c.want {
+			t.Errorf("случай %d: %v, ожидается %v", i, got, c.want)
+		}
+	}
+
+	s := NewStrategy("BTCUSDT", cdl.M5, "model", 100, 0.5, 0.001, WithRules(rules...), WithRules(buy))
+	if len(s.rules) != 3 {
+		t.Errorf("WithRules: %d правил", len(s.rules))
+	}
+}
+
+// zigzag возвращает свечи с циклами из 4 свечей роста и 2 свечей снижения на step
+func zigzag(n int, step float64) []cdl.Candle {
+	candles := make([]cdl.Candle, n)
+	price := 100.0
+	for i := range candles {
+		if i%6 < 4 {
+			price += step
+		} else {
+			price -= step
+		}
+		candles[i] = cdl.Candle{Time: int64(i), O: price, H: price + 0.5, L: price - 0.5, C: price}
+	}
+	return candles
+}
+
+func TestStructureRule(t *testing.T) {
+	rule, err := StructureRule(structure.DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := rule(zigzag(60, 1)); s != types.Buy {
+		t.Errorf("восходящая структура: %v", s)
+	}
+	if s := rule(zigzag(60, -1)); s != types.Sell {
+		t.Errorf("нисходящая структура: %v", s)
+	}
+	if s := rule(zigzag(5, 1)); s != types.Hold {
+		t.Errorf("мало свечей: %v", s)
+	}
+	if _, err := StructureRule(structure.Params{}); err == nil {
+		t.Error("неверные параметры")
+	}
+}
@@ -0,0 +1,164 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"goTradingBot/external/telebot"
+	"strings"
+	"time"
+)
+
+// TelegramRouter создает маршрутизатор команд Telegram для управления ботом
+// Команды принимаются только из чатов allowedChatIDs
+func (b *TradingBot) TelegramRouter(tg *telebot.Bot, allowedChatIDs []int64, opts ...telebot.RouterOption) *telebot.Router {
+	r := telebot.NewRouter(tg, allowedChatIDs, opts...)
+	r.Handle("status", "состояние бота", b.statusCommand)
+	r.Handle("positions", "открытые позиции", b.positionsCommand)
+	r.Handle("pnl", "результат торговли", b.pnlCommand)
+	r.Handle("orders", "ордера в обработке", b.ordersCommand)
+	r.Handle("pause", "<стратегия> приостановить стратегию", b.pauseCommand)
+	r.Handle("resume", "[стратегия] возобновить стратегию или все стратегии", b.resumeCommand)
+	r.Handle("stop", "<стратегия> [flatten] остановить стратегию, flatten - закрыть ее позицию", b.stopCommand)
+	r.Handle("run", "<стратегия> запустить остановленную стратегию", b.runCommand)
+	r.Handle("flatten", "<символ> закрыть позицию рыночным ордером", b.flattenCommand)
+	r.Handle("kill", "остановить все стратегии", b.killCommand)
+	return r
+}
+
+func (b *TradingBot) statusCommand(context.Context, *telebot.Command) (string, error) {
+	status := b.Status()
+	var sb strings.Builder
+	state := "работает"
+	if status.Killed {
+		state = "остановлен (kill)"
+	}
+	fmt.Fprintf(&sb, "бот %s, аптайм %s\n", state, time.Since(status.StartedAt).Truncate(time.Second))
+	fmt.Fprintf(&sb, "ордеров в обработке: %d, открытых позиций: %d\n", status.ActiveOrders, status.Positions)
+	for _, s := range status.Strategies {
+		strategyState := "активна"
+		switch s.State {
+		case StrategyPaused:
+			strategyState = "на паузе"
+		case StrategyStopped:
+			strategyState = "остановлена"
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", s.Name, strategyState)
+	}
+	return sb.String(), nil
+}
+
+func (b *TradingBot) positionsCommand(context.Context, *telebot.Command) (string, error) {
+	positions := b.Positions()
+	if len(positions) == 0 {
+		return "нет открытых позиций", nil
+	}
+	var sb strings.Builder
+	for _, p := range positions {
+		fmt.Fprintf(&sb, "%s: %g по %g\n", p.Symbol, p.Qty, p.AvgPrice)
+	}
+	return sb.String(), nil
+}
+
+func (b *TradingBot) pnlCommand(context.Context, *telebot.Command) (string, error) {
+	pnl := b.PnL()
+	if len(pnl) == 0 {
+		return "сделок нет", nil
+	}
+	var sb strings.Builder
+	var total float64
+	for _, p := range pnl {
+		total += p.Net
+		fmt.Fprintf(&sb, "%s: %.4f (реализ. %.4f, нереализ. %.4f, комиссии %.4f)\n",
+			p.Symbol, p.Net, p.Realized, p.Unrealized, p.Fees)
+	}
+	fmt.Fprintf(&sb, "итого: %.4f", total)
+	return sb.String(), nil
+}
+
+func (b *TradingBot) ordersCommand(context.Context, *telebot.Command) (string, error) {
+	orders := b.ActiveOrders()
+	if len(orders) == 0 {
+		return "нет ордеров в обработке", nil
+	}
+	var sb strings.Builder
+	for _, req := range orders {
+		price := "market"
+		if req.Order.Price != nil {
+			price = fmt.Sprint(*req.Order.Price)
+		}
+		fmt.Fprintf(&sb, "%s %s: %g по %s, исполнено %g\n",
+			req.Tag, req.Order.Symbol, req.Order.Qty, price, req.Order.ExecQty)
+	}
+	return sb.String(), nil
+}
+
+func (b *TradingBot) pauseCommand(_ context.Context, cmd *telebot.Command) (string, error) {
+	name := cmd.Arg(0)
+	if name == "" {
+		return "", fmt.Errorf("укажите стратегию: /pause <стратегия>")
+	}
+	if err := b.PauseStrategy(name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("стратегия %s приостановлена", name), nil
+}
+
+func (b *TradingBot) resumeCommand(_ context.Context, cmd *telebot.Command) (string, error) {
+	name := cmd.Arg(0)
+	if err := b.ResumeStrategy(name); err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "все стратегии возобновлены", nil
+	}
+	return fmt.Sprintf("стратегия %s возобновлена", name), nil
+}
+
+func (b *TradingBot) stopCommand(_ context.Context, cmd *telebot.Command) (string, error) {
+	name := cmd.Arg(0)
+	if name == "" {
+		return "", fmt.Errorf("укажите стратегию: /stop <стратегия> [flatten]")
+	}
+	flatten := cmd.Arg(1) == "flatten"
+	if err := b.StopStrategy(name, flatten); err != nil {
+		return "", err
+	}
+	if flatten {
+		return fmt.Sprintf("стратегия %s остановлена, ее позиция закрывается", name), nil
+	}
+	return fmt.Sprintf("стратегия %s остановлена", name), nil
+}
+
+func (b *TradingBot) runCommand(_ context.Context, cmd *telebot.Command) (string, error) {
+	name := cmd.Arg(0)
+	if name == "" {
+		return "", fmt.Errorf("укажите стратегию: /run <стратегия>")
+	}
+	if err := b.StartStrategy(name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("стратегия %s запущена", name), nil
+}
+
+func (b *TradingBot) flattenCommand(_ context.Context, cmd *telebot.Command) (string, error) {
+	symbol := cmd.Arg(0)
+	if symbol == "" {
+		return "", fmt.Errorf("укажите символ: /flatten <символ>")
+	}
+	linkId, err := b.Flatten(symbol)
+	if err != nil {
+		return "", err
+	}
+	if linkId == "" {
+		return fmt.Sprintf("позицию %s закрывают стратегии", strings.ToUpper(symbol)), nil
+	}
+	return fmt.Sprintf("заявка на закрытие %s отправлена: %s", strings.ToUpper(symbol), linkId), nil
+}
+
+func (b *TradingBot) killCommand(context.Context, *telebot.Command) (string, error) {
+	if b.killed.Load() {
+		return "бот уже остановлен", nil
+	}
+	b.Kill()
+	return "стратегии остановлены, открытые ими позиции закрываются", nil
+}
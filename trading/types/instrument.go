@@ -0,0 +1,109 @@
+package types
+
+import (
+	"goTradingBot/utils/numeric"
+	"math"
+)
+
+// Категории инструментов
+const (
+	Spot    = "spot"    // спот: количество в базовой монете, без коротких позиций
+	Linear  = "linear"  // линейные контракты: количество в базовой монете, расчеты в котируемой
+	Inverse = "inverse" // обратные контракты: количество в контрактах стоимостью 1 USD, расчеты в базовой монете
+)
+
+// InstrumentInfo правила торговли инструментом
+// Нулевые ограничения не проверяются
+type InstrumentInfo struct {
+	Symbol       string  `json:"symbol"`
+	Category     string  `json:"category"` // Spot, Linear или Inverse, пусто - Linear
+	Status       string  `json:"status"`   // статус торговли, Trading - доступен
+	BaseCoin     string  `json:"baseCoin"`
+	QuoteCoin    string  `json:"quoteCoin"`
+	SettleCoin   string  `json:"settleCoin"`   // монета расчетов
+	QtyPrecision int     `json:"qtyPrecision"` // количество знаков количества
+	QtyStep      float64 `json:"qtyStep"`      // шаг количества
+	MinOrderQty  float64 `json:"minOrderQty"`
+	MaxOrderQty  float64 `json:"maxOrderQty"`  // для лимитного ордера
+	MaxMarketQty float64 `json:"maxMarketQty"` // для рыночного ордера
+	MinOrderAmt  float64 `json:"minOrderAmt"`  // минимальная стоимость ордера в котируемой монете (USD для Inverse)
+	MaxOrderAmt  float64 `json:"maxOrderAmt"`
+	TickSize     float64 `json:"tickSize"` // шаг цены
+	MinPrice     float64 `json:"minPrice"`
+	MaxPrice     float64 `json:"maxPrice"`
+}
+
+// Tradable сообщает, доступен ли инструмент для торговли
+func (i *InstrumentInfo) Tradable() bool {
+	return i.Status == "" || i.Status == "Trading"
+}
+
+// IsInverse сообщает, является ли инструмент обратным контрактом
+func (i *InstrumentInfo) IsInverse() bool {
+	return i.Category == Inverse
+}
+
+// CanShort сообщает, можно ли открыть короткую позицию
+func (i *InstrumentInfo) CanShort() bool {
+	return i.Category != Spot
+}
+
+// Notional возвращает стоимость количества qty по цене price в котируемой монете
+func (i *InstrumentInfo) Notional(qty, price float64) float64 {
+	if i.IsInverse() {
+		return math.Abs(qty)
+	}
+	return math.Abs(qty) * price
+}
+
+// QtyForNotional возвращает количество стоимостью notional по цене price
+func (i *InstrumentInfo) QtyForNotional(notional, price float64) float64 {
+	if i.IsInverse() {
+		return notional
+	}
+	return notional / price
+}
+
+// RoundQty округляет количество вниз по модулю до шага и ограничивает максимальным количеством
+// market - ограничение рыночного ордера вместо лимитного
+func (i *InstrumentInfo) RoundQty(qty float64, market bool) float64 {
+	abs := math.Abs(qty)
+	if i.QtyStep > 0 {
+		// 1e-9 компенсирует погрешность деления, например 0.3 / 0.1
+		steps := math.Floor(abs/i.QtyStep + 1e-9)
+		abs = numeric.RoundFloat(steps*i.QtyStep, numeric.DecimalPlaces(i.QtyStep))
+	} else {
+		abs = numeric.FloorFloat(abs+1e-12, i.QtyPrecision)
+	}
+	maxQty := i.MaxOrderQty
+	if market && i.MaxMarketQty > 0 {
+		maxQty = i.MaxMarketQty
+	}
+	if maxQty > 0 {
+		abs = math.Min(abs, maxQty)
+	}
+	return math.Copysign(abs, qty)
+}
+
+// ValidOrder проверяет минимальное количество и ограничения стоимости ордера
+func (i *InstrumentInfo) ValidOrder(qty, price float64) bool {
+	if qty == 0 || math.Abs(qty) < i.MinOrderQty {
+		return false
+	}
+	notional := i.Notional(qty, price)
+	return notional >= i.MinOrderAmt && (i.MaxOrderAmt == 0 || notional <= i.MaxOrderAmt)
+}
+
+// RoundPrice округляет цену до шага и ограничивает допустимым диапазоном
+func (i *InstrumentInfo) RoundPrice(price float64) float64 {
+	if i.TickSize > 0 {
+		price = numeric.RoundFloat(math.Round(price/i.TickSize)*i.TickSize, numeric.DecimalPlaces(i.TickSize))
+	}
+	if i.MinPrice > 0 {
+		price = math.Max(price, i.MinPrice)
+	}
+	if i.MaxPrice > 0 {
+		price = math.Min(price, i.MaxPrice)
+	}
+	return price
+}
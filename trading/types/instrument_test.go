@@ -0,0 +1,38 @@
+package types
+
+import "testing"
+
+func TestInstrumentInfo(t *testing.T) {
+	linear := &InstrumentInfo{Category: Linear, QtyStep: 0.1, MinOrderQty: 0.1, MaxOrderQty: 100, MaxMarketQty: 10, MinOrderAmt: 5, TickSize: 0.5}
+	tests := []struct {
+		qty    float64
+		market bool
+		want   float64
+	}{
+		{0.3, false, 0.3},
+		{-1.27, false, -1.2},
+		{250, false, 100},
+		{-250, true, -10},
+	}
+	for _, tt := range tests {
+		if got := linear.RoundQty(tt.qty, tt.market); got != tt.want {
+			t.Errorf("RoundQty(%v, %v) = %v, ожидается %v", tt.qty, tt.market, got, tt.want)
+		}
+	}
+	if got := linear.RoundPrice(100.26); got != 100.5 {
+		t.Errorf("RoundPrice: %v", got)
+	}
+	if linear.ValidOrder(0.1, 40) || !linear.ValidOrder(0.2, 40) {
+		t.Error("ValidOrder: минимальная стоимость ордера")
+	}
+
+	inverse := &InstrumentInfo{Category: Inverse, QtyStep: 1, MinOrderAmt: 1}
+	if qty := inverse.QtyForNotional(250, 50000); qty != 250 || inverse.Notional(-250, 50000) != 250 {
+		t.Errorf("обратный контракт: количество %v", qty)
+	}
+
+	spot := &InstrumentInfo{Category: Spot, Status: "PreLaunch", QtyPrecision: 3}
+	if spot.CanShort() || spot.Tradable() || spot.RoundQty(0.12345, false) != 0.123 {
+		t.Errorf("спот: %+v", spot)
+	}
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"context"
 	"goTradingBot/cdl"
+	"time"
 )
 
 type Signal int
@@ -18,6 +19,61 @@ type Strategy interface {
 	Go() error
 }
 
+// ParameterizedStrategy стратегия, сообщающая свои параметры для отображения
+type ParameterizedStrategy interface {
+	Strategy
+	Params() map[string]any
+}
+
+// NamedStrategy стратегия с именем, по которому ею управляют команды бота
+type NamedStrategy interface {
+	Strategy
+	Name() string
+}
+
+// ReloadableStrategy стратегия, параметры которой можно заменить без остановки
+// Reload не должен сбрасывать позицию и журнал ордеров стратегии
+type ReloadableStrategy interface {
+	Strategy
+	Reload(params map[string]any) error
+}
+
+// EntryFilter сообщает, можно ли открывать или увеличивать позицию в момент t
+type EntryFilter func(t time.Time) bool
+
+// ScheduledStrategy стратегия, торговлю которой ограничивает расписание бота
+// SetEntryFilter вызывается до Go, Flatten закрывает позицию стратегии перед окончанием торговли
+type ScheduledStrategy interface {
+	Strategy
+	SetEntryFilter(filter EntryFilter)
+	Flatten()
+}
+
+// MarketStrategy стратегия, торгующая одним инструментом на одном интервале
+type MarketStrategy interface {
+	Strategy
+	Symbol() string
+	Interval() cdl.Interval
+}
+
+// PositionStrategy стратегия, ведущая учет своей позиции по инструменту
+// Position возвращает исполненное количество: > 0 - long, < 0 - short
+type PositionStrategy interface {
+	MarketStrategy
+	Position() float64
+}
+
+// BudgetFunc возвращает текущий бюджет стратегии в валюте кошелька
+type BudgetFunc func() float64
+
+// AllocatedStrategy стратегия, бюджет которой распределяет портфель бота
+// Balance - фиксированный бюджет стратегии, используемый без портфеля
+type AllocatedStrategy interface {
+	Strategy
+	Balance() float64
+	SetBudget(budget BudgetFunc)
+}
+
 type TradingClient interface {
 	PlaceOrder(symbol string, amount float64, price *float64) (string, error)
 	CancelOrder(symbol, orderId string) (string, error)
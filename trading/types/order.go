@@ -99,6 +99,7 @@ type OrderRequest struct {
 	Delay        time.Duration       `json:"-"`
 	CloseTimeout time.Duration       `json:"-"`
 	Reply        chan<- *OrderUpdate `json:"-"`
+	Flatten      bool                `json:"flatten"` // закрытие позиции, передается и у приостановленной стратегии
 }
 
 func (r *OrderRequest) Clone() *OrderRequest {
@@ -108,10 +109,11 @@ func (r *OrderRequest) Clone() *OrderRequest {
 	}
 
 	return &OrderRequest{
-		LinkId: r.LinkId,
-		Tag:    r.Tag,
-		Order:  clonedOrder,
-		Reply:  r.Reply,
+		LinkId:  r.LinkId,
+		Tag:     r.Tag,
+		Order:   clonedOrder,
+		Reply:   r.Reply,
+		Flatten: r.Flatten,
 	}
 }
 
@@ -0,0 +1,34 @@
+package types
+
+// ExchangePosition позиция по контракту на бирже
+type ExchangePosition struct {
+	Symbol    string  `json:"symbol"`
+	Qty       float64 `json:"qty"`       // >0 long, <0 short
+	AvgPrice  float64 `json:"avgPrice"`  // средняя цена входа
+	MarkPrice float64 `json:"markPrice"` // маркировочная цена
+	LiqPrice  float64 `json:"liqPrice"`  // цена ликвидации, 0 - ликвидация не ожидается
+	Leverage  float64 `json:"leverage"`  // кредитное плечо
+}
+
+// FundingRate ставка финансирования бессрочного контракта
+type FundingRate struct {
+	Symbol string  `json:"symbol"`
+	Rate   float64 `json:"rate"` // >0 long платит short
+	Time   int64   `json:"time"` // время начисления (мс)
+}
+
+// DerivativesClient торговый клиент бессрочных контрактов с маржей и финансированием
+// Позиции и ставки возвращаются в формате JSON массивов ExchangePosition и FundingRate
+type DerivativesClient interface {
+	SetMarginMode(isolated bool) error
+	SetLeverage(symbol string, leverage float64) error
+	GetPositions() ([]byte, error)
+	GetFundingRates(symbol string, from int64) ([]byte, error)
+}
+
+// LeveragedStrategy стратегия, для инструмента которой бот устанавливает кредитное плечо при запуске
+// Leverage <= 0 оставляет плечо, установленное на бирже
+type LeveragedStrategy interface {
+	MarketStrategy
+	Leverage() float64
+}
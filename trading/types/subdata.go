@@ -57,12 +57,6 @@ func (s *SubData) GetCandles(symbol string, interval cdl.Interval, limit int) ([
 	return candleSync.GetCandles(limit), nil
 }
 
-type InstrumentInfo struct {
-	QtyPrecision int     `json:"qtyPrecision"`
-	MinOrderAmt  float64 `json:"minOrderAmt"`
-	TickSize     float64 `json:"tickSize"`
-}
-
 func (s *SubData) GetInstrumentInfo(symbol string) (*InstrumentInfo, error) {
 	b, err := s.dataProvider.GetInstrumentInfo(symbol)
 	if err != nil {
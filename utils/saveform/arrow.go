@@ -0,0 +1,216 @@
+package saveform
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"slices"
+)
+
+// Константы формата Arrow IPC (Schema.fbs, Message.fbs)
+const (
+	arrowMagic           = "ARROW1"
+	arrowMetadataV5      = 4
+	arrowHeaderSchema    = 1
+	arrowHeaderRecBatch  = 3
+	arrowTypeInt         = 2
+	arrowTypeFloat       = 3
+	arrowTypeUtf8        = 5
+	arrowPrecisionDouble = 2
+	arrowAlignment       = 8
+	arrowContinuation    = 0xFFFFFFFF
+)
+
+// ArrowColumn столбец для сохранения в формате Arrow IPC
+type ArrowColumn struct {
+	name    string
+	typ     int
+	length  int
+	values  []byte
+	offsets []byte // только для строковых столбцов
+}
+
+// Float64Column создает столбец float64
+func Float64Column(name string, values []float64) ArrowColumn {
+	buf := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(buf[8*i:], math.Float64bits(v))
+	}
+	return ArrowColumn{name: name, typ: arrowTypeFloat, length: len(values), values: buf}
+}
+
+// Int64Column создает столбец int64
+func Int64Column(name string, values []int64) ArrowColumn {
+	buf := make([]byte, 8*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint64(buf[8*i:], uint64(v))
+	}
+	return ArrowColumn{name: name, typ: arrowTypeInt, length: len(values), values: buf}
+}
+
+// StringColumn создает строковый столбец
+func StringColumn(name string, values []string) ArrowColumn {
+	offsets := make([]byte, 4*(len(values)+1))
+	var data []byte
+	for i, v := range values {
+		data = append(data, v...)
+		binary.LittleEndian.PutUint32(offsets[4*(i+1):], uint32(len(data)))
+	}
+	return ArrowColumn{name: name, typ: arrowTypeUtf8, length: len(values), values: data, offsets: offsets}
+}
+
+// Len возвращает количество значений в столбце
+func (c ArrowColumn) Len() int {
+	return c.length
+}
+
+// ColumnsToArrow сохраняет столбцы в файл формата Arrow IPC (Feather v2) одним пакетом записей
+// metadata - пользовательские метаданные схемы
+// Все столбцы должны иметь одинаковую длину
+func ColumnsToArrow(path string, cols []ArrowColumn, metadata map[string]string) error {
+	if len(cols) == 0 {
+		return fmt.Errorf("ColumnsToArrow: отсутсвуют колонки для сохранения")
+	}
+	length := cols[0].length
+	for _, c := range cols {
+		if c.length != length {
+			return fmt.Errorf(
+				"ColumnsToArrow: длина столбца %s (%d) не совпадает с длиной первого столбца (%d)",
+				c.name, c.length, length,
+			)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := &arrowWriter{w: bufio.NewWriter(file)}
+
+	w.write([]byte(arrowMagic + "\x00\x00"))
+	w.writeMessage(arrowSchemaMessage(cols, metadata), nil)
+	body, nodes, buffers := arrowRecordBatchBody(cols)
+	batchOffset := w.pos
+	metaLen := w.writeMessage(arrowRecordBatchMessage(length, nodes, buffers, len(body)), body)
+	w.write(binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, arrowContinuation), 0))
+
+	block := make([]byte, 24)
+	binary.LittleEndian.PutUint64(block[0:], uint64(batchOffset))
+	binary.LittleEndian.PutUint32(block[8:], uint32(metaLen))
+	binary.LittleEndian.PutUint64(block[16:], uint64(len(body)))
+	footer := finishFlatBuffer(newFBTable().
+		addInt16(0, arrowMetadataV5).
+		addRef(1, arrowSchema(cols, metadata)).
+		addRef(2, fbStructs{}).
+		addRef(3, fbStructs{count: 1, data: block}))
+	w.write(footer)
+	w.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	w.write([]byte(arrowMagic))
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// arrowWriter отслеживает смещение в файле и первую ошибку записи
+type arrowWriter struct {
+	w   *bufio.Writer
+	pos int
+	err error
+}
+
+func (w *arrowWriter) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.pos += n
+	w.err = err
+}
+
+// writeMessage записывает инкапсулированное сообщение и возвращает длину его метаданных с префиксом
+func (w *arrowWriter) writeMessage(message []byte, body []byte) int {
+	prefix := binary.LittleEndian.AppendUint32(nil, arrowContinuation)
+	prefix = binary.LittleEndian.AppendUint32(prefix, uint32(len(message)))
+	w.write(prefix)
+	w.write(message)
+	w.write(body)
+	return len(prefix) + len(message)
+}
+
+func arrowSchema(cols []ArrowColumn, metadata map[string]string) *fbTable {
+	fields := make(fbTables, len(cols))
+	for i, c := range cols {
+		typ := newFBTable()
+		switch c.typ {
+		case arrowTypeFloat:
+			typ.addInt16(0, arrowPrecisionDouble)
+		case arrowTypeInt:
+			typ.addInt32(0, 64).addBool(1, true)
+		}
+		fields[i] = newFBTable().
+			addRef(0, fbString(c.name)).
+			addBool(1, false).
+			addInt8(2, uint8(c.typ)).
+			addRef(3, typ).
+			addRef(5, fbTables{})
+	}
+	schema := newFBTable().
+		addInt16(0, 0). // little endian
+		addRef(1, fields)
+	if len(metadata) > 0 {
+		keys := slices.Sorted(maps.Keys(metadata))
+		kv := make(fbTables, len(keys))
+		for i, k := range keys {
+			kv[i] = newFBTable().addRef(0, fbString(k)).addRef(1, fbString(metadata[k]))
+		}
+		schema.addRef(2, kv)
+	}
+	return schema
+}
+
+func arrowSchemaMessage(cols []ArrowColumn, metadata map[string]string) []byte {
+	return finishFlatBuffer(newFBTable().
+		addInt16(0, arrowMetadataV5).
+		addInt8(1, arrowHeaderSchema).
+		addRef(2, arrowSchema(cols, metadata)).
+		addInt64(3, 0))
+}
+
+func arrowRecordBatchMessage(length int, nodes, buffers []byte, bodyLen int) []byte {
+	batch := newFBTable().
+		addInt64(0, int64(length)).
+		addRef(1, fbStructs{count: len(nodes) / 16, data: nodes}).
+		addRef(2, fbStructs{count: len(buffers) / 16, data: buffers})
+	return finishFlatBuffer(newFBTable().
+		addInt16(0, arrowMetadataV5).
+		addInt8(1, arrowHeaderRecBatch).
+		addRef(2, batch).
+		addInt64(3, int64(bodyLen)))
+}
+
+// arrowRecordBatchBody формирует тело пакета записей, описания узлов (FieldNode) и буферов (Buffer)
+func arrowRecordBatchBody(cols []ArrowColumn) (body, nodes, buffers []byte) {
+	addBuffer := func(data []byte) {
+		offset := len(body)
+		body = append(body, data...)
+		for len(body)%arrowAlignment != 0 {
+			body = append(body, 0)
+		}
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(offset))
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(data)))
+	}
+	for _, c := range cols {
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(c.length))
+		nodes = binary.LittleEndian.AppendUint64(nodes, 0) // null_count
+		addBuffer(nil)                                     // validity bitmap отсутствует
+		if c.typ == arrowTypeUtf8 {
+			addBuffer(c.offsets)
+		}
+		addBuffer(c.values)
+	}
+	return body, nodes, buffers
+}
@@ -0,0 +1,270 @@
+package saveform
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Независимый от сериализатора разбор FlatBuffers по спецификации:
+// таблица начинается с soffset на vtable, vtable содержит смещения полей от начала таблицы
+
+type fbReader struct {
+	t   *testing.T
+	buf []byte
+}
+
+type fbRef struct {
+	r   *fbReader
+	pos int
+}
+
+func (r *fbReader) u16(pos int) int { return int(binary.LittleEndian.Uint16(r.buf[pos:])) }
+func (r *fbReader) u32(pos int) int { return int(binary.LittleEndian.Uint32(r.buf[pos:])) }
+
+// root возвращает корневую таблицу буфера
+func (r *fbReader) root() fbRef {
+	return fbRef{r, r.u32(0)}
+}
+
+// field возвращает абсолютное смещение поля id или -1, если поле отсутствует
+func (t fbRef) field(id int) int {
+	r := t.r
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(r.buf[t.pos:])))
+	if 4+2*id >= r.u16(vtable) {
+		return -1
+	}
+	offset := r.u16(vtable + 4 + 2*id)
+	if offset == 0 {
+		return -1
+	}
+	return t.pos + offset
+}
+
+func (t fbRef) scalar(id, size int) int64 {
+	pos := t.field(id)
+	if pos < 0 {
+		return 0
+	}
+	switch size {
+	case 1:
+		return int64(t.r.buf[pos])
+	case 2:
+		return int64(int16(t.r.u16(pos)))
+	case 4:
+		return int64(int32(t.r.u32(pos)))
+	}
+	if pos%8 != 0 {
+		t.r.t.Errorf("поле %d по смещению %d не выровнено по 8 байт", id, pos)
+	}
+	return int64(binary.LittleEndian.Uint64(t.r.buf[pos:]))
+}
+
+// deref возвращает объект, на который ссылается поле id
+func (t fbRef) deref(id int) fbRef {
+	pos := t.field(id)
+	if pos < 0 {
+		t.r.t.Fatalf("отсутствует поле %d", id)
+	}
+	return fbRef{t.r, pos + t.r.u32(pos)}
+}
+
+func (t fbRef) table(id int) fbRef { return t.deref(id) }
+
+func (t fbRef) str(id int) string {
+	s := t.deref(id)
+	n := t.r.u32(s.pos)
+	if t.r.buf[s.pos+4+n] != 0 {
+		t.r.t.Errorf("строка без завершающего нуля")
+	}
+	return string(t.r.buf[s.pos+4 : s.pos+4+n])
+}
+
+// tables возвращает вектор таблиц поля id
+func (t fbRef) tables(id int) []fbRef {
+	v := t.deref(id)
+	n := t.r.u32(v.pos)
+	res := make([]fbRef, n)
+	for i := range res {
+		pos := v.pos + 4 + 4*i
+		res[i] = fbRef{t.r, pos + t.r.u32(pos)}
+	}
+	return res
+}
+
+// structs возвращает данные вектора структур размера size поля id
+func (t fbRef) structs(id, size int) [][]byte {
+	v := t.deref(id)
+	n := t.r.u32(v.pos)
+	if n > 0 && (v.pos+4)%8 != 0 {
+		t.r.t.Errorf("вектор структур поля %d не выровнен по 8 байт", id)
+	}
+	res := make([][]byte, n)
+	for i := range res {
+		start := v.pos + 4 + size*i
+		res[i] = t.r.buf[start : start+size]
+	}
+	return res
+}
+
+func TestColumnsToArrow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.arrow")
+	floats := []float64{1.5, -2, math.Inf(1)}
+	ints := []int64{1, -1, 1 << 40}
+	strs := []string{"BTC", "", "ETHUSDT"}
+	err := ColumnsToArrow(path, []ArrowColumn{
+		Float64Column("x", floats),
+		Int64Column("time", ints),
+		StringColumn("symbol", strs),
+	}, map[string]string{"interval": "M5", "a": "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// файл: ARROW1 + 2 байта, поток сообщений, footer, длина footer, ARROW1
+	if !bytes.HasPrefix(data, []byte("ARROW1\x00\x00")) || !bytes.HasSuffix(data, []byte("ARROW1")) {
+		t.Fatal("нет сигнатуры ARROW1")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-10:]))
+	footerBuf := data[len(data)-10-footerLen : len(data)-10]
+	footer := (&fbReader{t, footerBuf}).root()
+	if v := footer.scalar(0, 2); v != 4 {
+		t.Errorf("версия метаданных footer: %d", v)
+	}
+	checkSchema(t, footer.table(1))
+
+	// сообщение схемы сразу после сигнатуры
+	schemaMsg, body := readMessage(t, data, 8)
+	if schemaMsg.scalar(1, 1) != 1 || len(body) != 0 {
+		t.Errorf("сообщение схемы: header %d", schemaMsg.scalar(1, 1))
+	}
+	checkSchema(t, schemaMsg.table(2))
+
+	blocks := footer.structs(3, 24)
+	if len(blocks) != 1 || len(footer.structs(2, 24)) != 0 {
+		t.Fatalf("блоки footer: %d", len(blocks))
+	}
+	offset := int(binary.LittleEndian.Uint64(blocks[0][0:]))
+	metaLen := int(binary.LittleEndian.Uint32(blocks[0][8:]))
+	bodyLen := int(binary.LittleEndian.Uint64(blocks[0][16:]))
+	if offset%8 != 0 || metaLen%8 != 0 {
+		t.Errorf("блок не выровнен: %d %d", offset, metaLen)
+	}
+	msg, body := readMessage(t, data, offset)
+	if msg.scalar(1, 1) != 3 || int(msg.scalar(3, 8)) != bodyLen || len(body) != bodyLen {
+		t.Fatalf("сообщение пакета записей: header %d, body %d", msg.scalar(1, 1), msg.scalar(3, 8))
+	}
+	// metaDataLength блока включает префикс сообщения, тело начинается сразу за метаданными
+	if !bytes.Equal(data[offset+metaLen:offset+metaLen+bodyLen], body) {
+		t.Errorf("длина метаданных блока %d", metaLen)
+	}
+
+	batch := msg.table(2)
+	if batch.scalar(0, 8) != 3 {
+		t.Errorf("длина пакета: %d", batch.scalar(0, 8))
+	}
+	nodes := batch.structs(1, 16)
+	buffers := batch.structs(2, 16)
+	if len(nodes) != 3 || len(buffers) != 7 {
+		t.Fatalf("узлов %d, буферов %d", len(nodes), len(buffers))
+	}
+	buffer := func(i int) []byte {
+		off := int(binary.LittleEndian.Uint64(buffers[i][0:]))
+		n := int(binary.LittleEndian.Uint64(buffers[i][8:]))
+		if off%8 != 0 || off+n > len(body) {
+			t.Fatalf("буфер %d: %d+%d", i, off, n)
+		}
+		return body[off : off+n]
+	}
+	for i, node := range nodes {
+		if binary.LittleEndian.Uint64(node[0:]) != 3 || binary.LittleEndian.Uint64(node[8:]) != 0 {
+			t.Errorf("узел %d: %v", i, node)
+		}
+	}
+	for i, v := range floats {
+		if got := math.Float64frombits(binary.LittleEndian.Uint64(buffer(1)[8*i:])); got != v {
+			t.Errorf("x[%d] = %v", i, got)
+		}
+	}
+	for i, v := range ints {
+		if got := int64(binary.LittleEndian.Uint64(buffer(3)[8*i:])); got != v {
+			t.Errorf("time[%d] = %v", i, got)
+		}
+	}
+	offsets, values := buffer(5), buffer(6)
+	for i, v := range strs {
+		start := binary.LittleEndian.Uint32(offsets[4*i:])
+		end := binary.LittleEndian.Uint32(offsets[4*(i+1):])
+		if got := string(values[start:end]); got != v {
+			t.Errorf("symbol[%d] = %q", i, got)
+		}
+	}
+
+	// конец потока: продолжение и нулевая длина перед footer
+	eos := data[offset+metaLen+bodyLen:]
+	if binary.LittleEndian.Uint32(eos) != arrowContinuation || binary.LittleEndian.Uint32(eos[4:]) != 0 {
+		t.Error("нет маркера конца потока")
+	}
+}
+
+// readMessage читает инкапсулированное сообщение по смещению offset и возвращает его метаданные и тело
+func readMessage(t *testing.T, data []byte, offset int) (fbRef, []byte) {
+	if binary.LittleEndian.Uint32(data[offset:]) != arrowContinuation {
+		t.Fatalf("нет маркера продолжения по смещению %d", offset)
+	}
+	n := int(binary.LittleEndian.Uint32(data[offset+4:]))
+	if n%8 != 0 {
+		t.Errorf("длина метаданных %d не кратна 8", n)
+	}
+	msg := (&fbReader{t, data[offset+8 : offset+8+n]}).root()
+	if v := msg.scalar(0, 2); v != 4 {
+		t.Errorf("версия метаданных сообщения: %d", v)
+	}
+	bodyStart := offset + 8 + n
+	return msg, data[bodyStart : bodyStart+int(msg.scalar(3, 8))]
+}
+
+func checkSchema(t *testing.T, schema fbRef) {
+	t.Helper()
+	if schema.scalar(0, 2) != 0 {
+		t.Error("порядок байт схемы")
+	}
+	fields := schema.tables(1)
+	want := []struct {
+		name string
+		typ  int64
+	}{{"x", arrowTypeFloat}, {"time", arrowTypeInt}, {"symbol", arrowTypeUtf8}}
+	if len(fields) != len(want) {
+		t.Fatalf("полей схемы: %d", len(fields))
+	}
+	for i, f := range fields {
+		if f.str(0) != want[i].name || f.scalar(2, 1) != want[i].typ || f.scalar(1, 1) != 0 {
+			t.Errorf("поле %d: %s %d", i, f.str(0), f.scalar(2, 1))
+		}
+		if len(f.tables(5)) != 0 {
+			t.Errorf("поле %s: дочерние поля", f.str(0))
+		}
+		typ := f.table(3)
+		switch want[i].typ {
+		case arrowTypeFloat:
+			if typ.scalar(0, 2) != arrowPrecisionDouble {
+				t.Errorf("точность %s", f.str(0))
+			}
+		case arrowTypeInt:
+			if typ.scalar(0, 4) != 64 || typ.scalar(1, 1) != 1 {
+				t.Errorf("целый тип %s", f.str(0))
+			}
+		}
+	}
+	metadata := schema.tables(2)
+	if len(metadata) != 2 || metadata[0].str(0) != "a" || metadata[1].str(0) != "interval" || metadata[1].str(1) != "M5" {
+		t.Errorf("метаданные схемы")
+	}
+}
@@ -0,0 +1,186 @@
+package saveform
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// Минимальный сериализатор FlatBuffers, достаточный для метаданных Arrow IPC.
+// Объекты записываются последовательно от корня к потомкам, поэтому все
+// ссылки (uoffset) указывают вперед, как того требует формат.
+
+// fbObject описывает объект, на который можно сослаться из таблицы или вектора
+type fbObject interface {
+	write(w *fbWriter) int
+}
+
+// fbField описывает поле таблицы: скаляр фиксированного размера или ссылку на объект
+type fbField struct {
+	id     int
+	size   int
+	scalar uint64
+	ref    fbObject
+}
+
+// fbTable таблица FlatBuffers
+type fbTable struct {
+	fields []fbField
+}
+
+// fbString строка FlatBuffers
+type fbString string
+
+// fbTables вектор ссылок на таблицы
+type fbTables []*fbTable
+
+// fbStructs вектор структур (или скаляров) с выравниванием 8 байт
+type fbStructs struct {
+	count int
+	data  []byte
+}
+
+func newFBTable() *fbTable {
+	return &fbTable{}
+}
+
+func (t *fbTable) addScalar(id, size int, v uint64) *fbTable {
+	t.fields = append(t.fields, fbField{id: id, size: size, scalar: v})
+	return t
+}
+
+func (t *fbTable) addBool(id int, v bool) *fbTable {
+	var u uint64
+	if v {
+		u = 1
+	}
+	return t.addScalar(id, 1, u)
+}
+
+func (t *fbTable) addInt8(id int, v uint8) *fbTable  { return t.addScalar(id, 1, uint64(v)) }
+func (t *fbTable) addInt16(id int, v int16) *fbTable { return t.addScalar(id, 2, uint64(uint16(v))) }
+func (t *fbTable) addInt32(id int, v int32) *fbTable { return t.addScalar(id, 4, uint64(uint32(v))) }
+func (t *fbTable) addInt64(id int, v int64) *fbTable { return t.addScalar(id, 8, uint64(v)) }
+func (t *fbTable) addRef(id int, v fbObject) *fbTable {
+	t.fields = append(t.fields, fbField{id: id, size: 4, ref: v})
+	return t
+}
+
+// fbWriter буфер, в который последовательно записываются объекты
+type fbWriter struct {
+	buf []byte
+}
+
+func (w *fbWriter) align(n int) {
+	for len(w.buf)%n != 0 {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *fbWriter) putUint32(pos int, v uint32) {
+	binary.LittleEndian.PutUint32(w.buf[pos:], v)
+}
+
+// patch записывает в pos ссылку на объект по адресу target
+func (w *fbWriter) patch(pos, target int) {
+	w.putUint32(pos, uint32(target-pos))
+}
+
+func (t *fbTable) write(w *fbWriter) int {
+	fields := make([]fbField, len(t.fields))
+	copy(fields, t.fields)
+	// крупные поля первыми, чтобы минимизировать выравнивание
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].size > fields[j].size })
+
+	numSlots := 0
+	for _, f := range fields {
+		numSlots = max(numSlots, f.id+1)
+	}
+	offsets := make([]int, len(fields))
+	inline := 4 // soffset на vtable
+	for i, f := range fields {
+		for inline%f.size != 0 {
+			inline++
+		}
+		offsets[i] = inline
+		inline += f.size
+	}
+	for inline%4 != 0 {
+		inline++
+	}
+
+	// vtable
+	w.align(2)
+	vtablePos := len(w.buf)
+	vtable := make([]byte, 4+2*numSlots)
+	binary.LittleEndian.PutUint16(vtable[0:], uint16(len(vtable)))
+	binary.LittleEndian.PutUint16(vtable[2:], uint16(inline))
+	for i, f := range fields {
+		binary.LittleEndian.PutUint16(vtable[4+2*f.id:], uint16(offsets[i]))
+	}
+	w.buf = append(w.buf, vtable...)
+
+	// таблица
+	w.align(8)
+	tablePos := len(w.buf)
+	w.buf = append(w.buf, make([]byte, inline)...)
+	w.putUint32(tablePos, uint32(int32(tablePos-vtablePos)))
+	for i, f := range fields {
+		pos := tablePos + offsets[i]
+		switch f.size {
+		case 1:
+			w.buf[pos] = byte(f.scalar)
+		case 2:
+			binary.LittleEndian.PutUint16(w.buf[pos:], uint16(f.scalar))
+		case 4:
+			w.putUint32(pos, uint32(f.scalar))
+		case 8:
+			binary.LittleEndian.PutUint64(w.buf[pos:], f.scalar)
+		}
+	}
+	for i, f := range fields {
+		if f.ref != nil {
+			w.patch(tablePos+offsets[i], f.ref.write(w))
+		}
+	}
+	return tablePos
+}
+
+func (s fbString) write(w *fbWriter) int {
+	w.align(4)
+	pos := len(w.buf)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(len(s)))
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, 0)
+	return pos
+}
+
+func (v fbTables) write(w *fbWriter) int {
+	w.align(4)
+	pos := len(w.buf)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(len(v)))
+	w.buf = append(w.buf, make([]byte, 4*len(v))...)
+	for i, t := range v {
+		w.patch(pos+4+4*i, t.write(w))
+	}
+	return pos
+}
+
+func (v fbStructs) write(w *fbWriter) int {
+	// данные вектора должны быть выровнены по 8 байт
+	w.align(4)
+	if (len(w.buf)+4)%8 != 0 {
+		w.buf = append(w.buf, 0, 0, 0, 0)
+	}
+	pos := len(w.buf)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(v.count))
+	w.buf = append(w.buf, v.data...)
+	return pos
+}
+
+// finishFlatBuffer сериализует корневую таблицу и возвращает буфер, дополненный до 8 байт
+func finishFlatBuffer(root *fbTable) []byte {
+	w := &fbWriter{buf: make([]byte, 4, 256)}
+	w.patch(0, root.write(w))
+	w.align(8)
+	return w.buf
+}
@@ -7,11 +7,14 @@ import (
 	cryptosdb "goTradingBot/external/cryptos/db"
 	"goTradingBot/predict"
 	"goTradingBot/predict/portal"
+	"goTradingBot/ta/structure"
 	orderdb "goTradingBot/trading/db"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type apiResponse struct {
@@ -279,7 +282,32 @@ func getTrendPredictHandler(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(res)
 		return
 	}
-	features := state.fgModels[predict.A6N21P9].GenTranspose(candles, predict.FeatureOffset, -1)
+	// интервал требуется только для загрузки вспомогательных инструментов за период свечей
+	interval, err := cdl.ParseInterval((candles[1].Time - candles[0].Time) / 60000)
+	if err != nil {
+		res.Error = fmt.Sprintf("неверный интервал свечей: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(res)
+		return
+	}
+	fg := state.fgModels[predict.A6N21P9]
+	aux, err := fg.LoadAuxRange(state.cdlProvider, state.cryptos, interval, candles[0].Time, candles[n-1].Time)
+	if err != nil {
+		res.Error = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(res)
+		return
+	}
+	if aux != nil {
+		fg = fg.WithAux(aux)
+	}
+	features, err := fg.GenTranspose(candles, predict.FeatureOffset, -1)
+	if err != nil {
+		res.Error = err.Error()
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(res)
+		return
+	}
 	query := r.URL.Query()
 	var markings []string
 	if query.Has("m") {
@@ -298,24 +326,169 @@ func getTrendPredictHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(res)
 }
 
-func getOrderLog(w http.ResponseWriter, r *http.Request) {
+// parseOrderFilter разбирает фильтр ордеров из параметров запроса:
+// s - монеты через запятую, tag - теги через запятую, status - open, closed или rejected,
+// side - buy или sell, from и to - время создания в мс, p - период в секундах от текущего момента
+func parseOrderFilter(query url.Values) (*orderdb.OrderFilter, error) {
+	f := &orderdb.OrderFilter{
+		Status: query.Get("status"),
+		Side:   query.Get("side"),
+	}
+	split := func(v string) []string {
+		if v == "" {
+			return nil
+		}
+		return strings.Split(v, ",")
+	}
+	f.Symbols = split(strings.ToUpper(query.Get("s")))
+	f.Tags = split(query.Get("tag"))
+	for key, dst := range map[string]*int64{"from": &f.From, "to": &f.To} {
+		if v := query.Get(key); v != "" {
+			ms, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("неверный параметр запроса: %s", key)
+			}
+			*dst = ms
+		}
+	}
+	if v := query.Get("p"); v != "" {
+		periodSec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || periodSec <= 0 {
+			return nil, fmt.Errorf("неверный параметр запроса: p")
+		}
+		f.From = time.Now().UnixMilli() - periodSec*1000
+	}
+	return f, f.Validate()
+}
+
+// orderLogHandler возвращает страницу журнала ордеров от новых к старым
+// Параметры: фильтр parseOrderFilter, l - размер страницы, cursor - курсор следующей страницы,
+// format - csv или json для выгрузки всех ордеров по фильтру файлом
+func (c *serverConfig) orderLogHandler(w http.ResponseWriter, r *http.Request) {
+	store, err := c.orderStore()
+	if err != nil {
+		writeResult(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+	query := r.URL.Query()
+	filter, err := parseOrderFilter(query)
+	if err != nil {
+		writeResult(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	if format := query.Get("format"); format != "" {
+		exportOrderLog(w, store, format, filter)
+		return
+	}
+	q := &orderdb.OrderQuery{OrderFilter: *filter, Cursor: query.Get("cursor")}
+	if v := query.Get("l"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil {
+			writeResult(w, http.StatusBadRequest, nil, "неверный параметр запроса: l")
+			return
+		}
+	}
+	page, err := store.QueryOrders(q)
+	if err != nil {
+		writeResult(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	writeResult(w, http.StatusOK, page, "")
+}
+
+func exportOrderLog(w http.ResponseWriter, store orderdb.OrderStore, format string, filter *orderdb.OrderFilter) {
+	contentType := map[string]string{
+		orderdb.FormatCSV:  "text/csv",
+		orderdb.FormatJSON: "application/json",
+	}[format]
+	if contentType == "" {
+		writeResult(w, http.StatusBadRequest, nil, "неверный параметр запроса: format")
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="orders.%s"`, format))
+	// заголовки уже отправлены, ошибку можно только оборвать выгрузкой
+	if err := orderdb.ExportOrders(w, store, format, filter); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// orderStatsHandler возвращает объем, комиссии и реализованный результат по монетам
+// Параметры: фильтр parseOrderFilter
+func (c *serverConfig) orderStatsHandler(w http.ResponseWriter, r *http.Request) {
+	store, err := c.orderStore()
+	if err != nil {
+		writeResult(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+	filter, err := parseOrderFilter(r.URL.Query())
+	if err != nil {
+		writeResult(w, http.StatusBadRequest, nil, err.Error())
+		return
+	}
+	stats, err := orderdb.AggregateOrders(store, filter)
+	if err != nil {
+		writeResult(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+	writeResult(w, http.StatusOK, stats, "")
+}
+
+// getStructureHandler возвращает рыночную структуру по закрытым свечам для отображения поверх графика
+// Параметры: s - монета, i - интервал, l - количество свечей, p - период фракталов
+func getStructureHandler(w http.ResponseWriter, r *http.Request) {
 	res := new(apiResponse)
 	w.Header().Set("Content-Type", "application/json")
 	query := r.URL.Query()
-	if !query.Has("p") {
+	if !query.Has("s") {
 		w.WriteHeader(http.StatusBadRequest)
-		res.Error = "пропущен обязательный параметр запроса: p"
+		res.Error = "пропущен обязательный параметр запроса: s"
 		json.NewEncoder(w).Encode(res)
 		return
 	}
-	periodSec, err := strconv.ParseInt(query.Get("p"), 10, 64)
+	if !query.Has("i") {
+		w.WriteHeader(http.StatusBadRequest)
+		res.Error = "пропущен обязательный параметр запроса: i"
+		json.NewEncoder(w).Encode(res)
+		return
+	}
+	coin := query.Get("s")
+	interval, err := cdl.ParseInterval(query.Get("i"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		res.Error = err.Error()
+		json.NewEncoder(w).Encode(res)
+		return
+	}
+	limit, err := strconv.ParseInt(query.Get("l"), 10, 64)
+	if err != nil || limit > 999 {
+		limit = 999
+	}
+	params := structure.DefaultParams()
+	if query.Has("p") {
+		period, err := strconv.Atoi(query.Get("p"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			res.Error = err.Error()
+			json.NewEncoder(w).Encode(res)
+			return
+		}
+		params.Period = period
+	}
+	symbol := coin + "USDT"
+	candles, err := state.cdlProvider.GetCandles(symbol, interval, int(limit)+1)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		res.Error = err.Error()
 		json.NewEncoder(w).Encode(res)
 		return
 	}
-	orders, err := orderdb.GetOrderRequestsByPeriod(periodSec)
+	if len(candles) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		res.Error = "пустой список свечей"
+		json.NewEncoder(w).Encode(res)
+		return
+	}
+	ms, err := structure.Analyze(candles[:len(candles)-1], params)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		res.Error = err.Error()
@@ -323,6 +496,6 @@ func getOrderLog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	w.WriteHeader(http.StatusOK)
-	res.Result = orders
+	res.Result = ms
 	json.NewEncoder(w).Encode(res)
 }
@@ -0,0 +1,263 @@
+package app
+
+import (
+	"encoding/json"
+	"goTradingBot/trading"
+	"goTradingBot/trading/types"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// BotController работающий торговый бот, которым управляет панель
+type BotController interface {
+	Status() trading.Status
+	Positions() []trading.Position
+	PnL() []trading.PnL
+	ActiveOrders() []*types.OrderRequest
+	Strategy(name string) (trading.StrategyStatus, error)
+	PauseStrategy(name string) error
+	ResumeStrategy(name string) error
+	StartStrategy(name string) error
+	StopStrategy(name string, flatten bool) error
+	RemoveStrategy(name string, flatten bool) error
+	ReloadStrategy(name string, params map[string]any) error
+	Flatten(symbol string) (string, error)
+}
+
+type dashboard struct {
+	bot    BotController
+	config *serverConfig
+}
+
+// NewDashboardHandler создает обработчик страницы и API панели управления ботом
+// Команды управления требуют уровень доступа Control: WithAuth или WithControlToken
+func NewDashboardHandler(bot BotController, opts ...ServerOption) http.Handler {
+	return newDashboardHandler(bot, newServerConfig(opts))
+}
+
+func newDashboardHandler(bot BotController, c *serverConfig) http.Handler {
+	d := &dashboard{bot: bot, config: c}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.rootHandler)
+	mux.HandleFunc("/dashboard", d.rootHandler)
+	mux.HandleFunc("/ping", pingHandler)
+	c.handleAuth(mux)
+	mux.Handle("GET /api/v1/bot/status", c.read(d.statusHandler))
+	mux.Handle("GET /api/v1/bot/positions", c.read(d.positionsHandler))
+	mux.Handle("GET /api/v1/bot/pnl", c.read(d.pnlHandler))
+	mux.Handle("GET /api/v1/bot/orders", c.read(d.ordersHandler))
+	mux.Handle("GET /api/v1/bot/equity", c.read(d.equityHandler))
+	mux.Handle("GET /api/v1/bot/strategy", c.read(d.strategyHandler))
+	mux.Handle("POST /api/v1/bot/pause", c.control(d.pauseHandler))
+	mux.Handle("POST /api/v1/bot/resume", c.control(d.resumeHandler))
+	mux.Handle("POST /api/v1/bot/start", c.control(d.startHandler))
+	mux.Handle("POST /api/v1/bot/stop", c.control(d.stopHandler))
+	mux.Handle("POST /api/v1/bot/remove", c.control(d.removeHandler))
+	mux.Handle("POST /api/v1/bot/reload", c.control(d.reloadHandler))
+	mux.Handle("POST /api/v1/bot/flatten", c.control(d.flattenHandler))
+	if c.stream != nil {
+		mux.Handle("/api/v1/stream", c.read(c.stream.ServeHTTP))
+	}
+
+	assets := http.FileServer(http.Dir("./web/assets/"))
+	mux.Handle("/assets/", http.StripPrefix("/assets", assets))
+	return mux
+}
+
+// RunDashboard запускает панель управления ботом в процессе бота
+func RunDashboard(addr string, bot BotController, opts ...ServerOption) error {
+	c := newServerConfig(opts)
+	return c.listenAndServe(addr, newDashboardHandler(bot, c))
+}
+
+func writeResult(w http.ResponseWriter, status int, result any, err string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&apiResponse{Result: result, Error: err})
+}
+
+func (d *dashboard) rootHandler(w http.ResponseWriter, r *http.Request) {
+	page, status := "web/pages/dashboard.html", http.StatusOK
+	if r.URL.Path != "/" && r.URL.Path != "/dashboard" {
+		page, status = "web/pages/404.html", http.StatusNotFound
+	}
+	content, err := os.ReadFile(page)
+	if err != nil {
+		http.Error(w, "Не удалось загрузить страницу", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	w.Write(content)
+}
+
+func (d *dashboard) statusHandler(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, http.StatusOK, d.bot.Status(), "")
+}
+
+func (d *dashboard) positionsHandler(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, http.StatusOK, d.bot.Positions(), "")
+}
+
+func (d *dashboard) pnlHandler(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, http.StatusOK, d.bot.PnL(), "")
+}
+
+func (d *dashboard) ordersHandler(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, http.StatusOK, d.bot.ActiveOrders(), "")
+}
+
+// equityHandler возвращает кривую реализованной доходности по ордерам из базы
+// Параметры: p - период в секундах от текущего момента (по умолчанию 30 суток)
+func (d *dashboard) equityHandler(w http.ResponseWriter, r *http.Request) {
+	period := int64(30 * 24 * 60 * 60)
+	if p := r.URL.Query().Get("p"); p != "" {
+		v, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || v <= 0 {
+			writeResult(w, http.StatusBadRequest, nil, "неверный параметр запроса: p")
+			return
+		}
+		period = v
+	}
+	store, err := d.config.orderStore()
+	if err != nil {
+		writeResult(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+	orders, err := store.GetFilledOrders(time.Now().Add(-time.Duration(period) * time.Second).UnixMilli())
+	if err != nil {
+		writeResult(w, http.StatusInternalServerError, nil, err.Error())
+		return
+	}
+	writeResult(w, http.StatusOK, trading.EquityCurve(orders), "")
+}
+
+type controlRequest struct {
+	Strategy string         `json:"strategy"`
+	Symbol   string         `json:"symbol"`
+	Flatten  bool           `json:"flatten"` // закрыть позицию при остановке стратегии
+	Params   map[string]any `json:"params"`  // новые параметры стратегии для reload
+}
+
+func decodeControlRequest(w http.ResponseWriter, r *http.Request) (*controlRequest, bool) {
+	var req controlRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&req); err != nil {
+		writeResult(w, http.StatusBadRequest, nil, "неверное тело запроса: "+err.Error())
+		return nil, false
+	}
+	return &req, true
+}
+
+func (d *dashboard) pauseHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeControlRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Strategy == "" {
+		writeResult(w, http.StatusBadRequest, nil, "пропущен обязательный параметр: strategy")
+		return
+	}
+	if err := d.bot.PauseStrategy(req.Strategy); err != nil {
+		writeResult(w, http.StatusNotFound, nil, err.Error())
+		return
+	}
+	writeResult(w, http.StatusOK, d.bot.Status(), "")
+}
+
+func (d *dashboard) resumeHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeControlRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := d.bot.ResumeStrategy(req.Strategy); err != nil {
+		writeResult(w, http.StatusConflict, nil, err.Error())
+		return
+	}
+	writeResult(w, http.StatusOK, d.bot.Status(), "")
+}
+
+// strategyHandler возвращает состояние стратегии
+// Параметры: name - имя стратегии
+func (d *dashboard) strategyHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeResult(w, http.StatusBadRequest, nil, "пропущен обязательный параметр: name")
+		return
+	}
+	status, err := d.bot.Strategy(name)
+	if err != nil {
+		writeResult(w, http.StatusNotFound, nil, err.Error())
+		return
+	}
+	writeResult(w, http.StatusOK, status, "")
+}
+
+// strategyControl выполняет команду управления стратегией из тела запроса
+func (d *dashboard) strategyControl(w http.ResponseWriter, r *http.Request, command func(req *controlRequest) error) {
+	req, ok := decodeControlRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Strategy == "" {
+		writeResult(w, http.StatusBadRequest, nil, "пропущен обязательный параметр: strategy")
+		return
+	}
+	if err := command(req); err != nil {
+		writeResult(w, http.StatusConflict, nil, err.Error())
+		return
+	}
+	writeResult(w, http.StatusOK, d.bot.Status(), "")
+}
+
+func (d *dashboard) startHandler(w http.ResponseWriter, r *http.Request) {
+	d.strategyControl(w, r, func(req *controlRequest) error {
+		return d.bot.StartStrategy(req.Strategy)
+	})
+}
+
+func (d *dashboard) stopHandler(w http.ResponseWriter, r *http.Request) {
+	d.strategyControl(w, r, func(req *controlRequest) error {
+		return d.bot.StopStrategy(req.Strategy, req.Flatten)
+	})
+}
+
+func (d *dashboard) removeHandler(w http.ResponseWriter, r *http.Request) {
+	d.strategyControl(w, r, func(req *controlRequest) error {
+		return d.bot.RemoveStrategy(req.Strategy, req.Flatten)
+	})
+}
+
+func (d *dashboard) reloadHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeControlRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Strategy == "" || len(req.Params) == 0 {
+		writeResult(w, http.StatusBadRequest, nil, "пропущен обязательный параметр: strategy или params")
+		return
+	}
+	if err := d.bot.ReloadStrategy(req.Strategy, req.Params); err != nil {
+		writeResult(w, http.StatusConflict, nil, err.Error())
+		return
+	}
+	writeResult(w, http.StatusOK, d.bot.Status(), "")
+}
+
+func (d *dashboard) flattenHandler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeControlRequest(w, r)
+	if !ok {
+		return
+	}
+	if req.Symbol == "" {
+		writeResult(w, http.StatusBadRequest, nil, "пропущен обязательный параметр: symbol")
+		return
+	}
+	linkId, err := d.bot.Flatten(req.Symbol)
+	if err != nil {
+		writeResult(w, http.StatusConflict, nil, err.Error())
+		return
+	}
+	writeResult(w, http.StatusOK, map[string]string{"linkId": linkId}, "")
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"goTradingBot/trading"
+	"goTradingBot/trading/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeBot struct {
+	paused   []string
+	stopped  map[string]bool // стратегия -> flatten
+	reloaded map[string]any
+}
+
+func (b *fakeBot) Status() trading.Status {
+	return trading.Status{Strategies: []trading.StrategyStatus{{Name: "HYPEUSDT-M5"}}}
+}
+func (b *fakeBot) Positions() []trading.Position         { return nil }
+func (b *fakeBot) PnL() []trading.PnL                    { return nil }
+func (b *fakeBot) ActiveOrders() []*types.OrderRequest   { return nil }
+func (b *fakeBot) ResumeStrategy(string) error           { return nil }
+func (b *fakeBot) Flatten(symbol string) (string, error) { return "link-" + symbol, nil }
+func (b *fakeBot) StartStrategy(string) error            { return nil }
+func (b *fakeBot) RemoveStrategy(string, bool) error     { return nil }
+func (b *fakeBot) Strategy(name string) (trading.StrategyStatus, error) {
+	if name != "HYPEUSDT-M5" {
+		return trading.StrategyStatus{}, fmt.Errorf("стратегия %q не найдена", name)
+	}
+	return trading.StrategyStatus{Name: name, State: trading.StrategyRunning}, nil
+}
+func (b *fakeBot) StopStrategy(name string, flatten bool) error {
+	b.stopped = map[string]bool{name: flatten}
+	return nil
+}
+func (b *fakeBot) ReloadStrategy(name string, params map[string]any) error {
+	b.reloaded = params
+	return nil
+}
+func (b *fakeBot) PauseStrategy(name string) error {
+	b.paused = append(b.paused, name)
+	return nil
+}
+
+func TestDashboardControlAuth(t *testing.T) {
+	bot := &fakeBot{}
+	srv := httptest.NewServer(NewDashboardHandler(bot, WithControlToken("secret")))
+	defer srv.Close()
+
+	// API закрыто без токена, в том числе для чтения
+	res, err := http.Get(srv.URL + "/api/v1/bot/status")
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status без токена: %v %v", res, err)
+	}
+	res.Body.Close()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/bot/status", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	res, err = http.DefaultClient.Do(req)
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("status: %v %v", res, err)
+	}
+	var status struct{ Result trading.Status }
+	json.NewDecoder(res.Body).Decode(&status)
+	res.Body.Close()
+	if len(status.Result.Strategies) != 1 {
+		t.Fatalf("status: %+v", status.Result)
+	}
+
+	pause := func(token string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/bot/pause", strings.NewReader(`{"strategy":"HYPEUSDT-M5"}`))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	if code := pause(""); code != http.StatusUnauthorized {
+		t.Errorf("без токена: %d", code)
+	}
+	if code := pause("wrong"); code != http.StatusUnauthorized {
+		t.Errorf("неверный токен: %d", code)
+	}
+	if code := pause("secret"); code != http.StatusOK {
+		t.Errorf("верный токен: %d", code)
+	}
+	if len(bot.paused) != 1 || bot.paused[0] != "HYPEUSDT-M5" {
+		t.Errorf("pause: %v", bot.paused)
+	}
+}
+
+func TestDashboardStrategyLifecycle(t *testing.T) {
+	bot := &fakeBot{}
+	srv := httptest.NewServer(NewDashboardHandler(bot, WithControlToken("secret")))
+	defer srv.Close()
+
+	post := func(action, body string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/api/v1/bot/"+action, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	if code := post("stop", `{"strategy":"HYPEUSDT-M5","flatten":true}`); code != http.StatusOK || !bot.stopped["HYPEUSDT-M5"] {
+		t.Errorf("stop: %d %v", code, bot.stopped)
+	}
+	if code := post("reload", `{"strategy":"HYPEUSDT-M5","params":{"longRatio":0.5}}`); code != http.StatusOK || bot.reloaded["longRatio"] != 0.5 {
+		t.Errorf("reload: %d %v", code, bot.reloaded)
+	}
+	if code := post("reload", `{"strategy":"HYPEUSDT-M5"}`); code != http.StatusBadRequest {
+		t.Errorf("reload без параметров: %d", code)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/v1/bot/strategy?name=unknown", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("неизвестная стратегия: %d", res.StatusCode)
+	}
+}
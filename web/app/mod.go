@@ -4,42 +4,51 @@ import (
 	"net/http"
 )
 
-func RunTerminal(addr string) error {
+// RunTerminal запускает веб-терминал. Настройки сервера - ServerOption
+func RunTerminal(addr string, opts ...ServerOption) error {
 	initAppState()
+	c := newServerConfig(opts)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", terminalRootHandler)
 	mux.HandleFunc("/terminal", terminalRootHandler)
 	mux.HandleFunc("/ping", pingHandler)
-	mux.HandleFunc("/api/v1/crypto", getCryptoHandler)
-	mux.HandleFunc("/api/v1/crypto/list", getCryptoList)
-	mux.HandleFunc("/api/v1/crypto/fearAndGreed", getCryptoFearAndGreed)
-	mux.HandleFunc("/api/v1/crypto/detail", getCryptoDetailHandler)
-	mux.HandleFunc("/api/v1/crypto/detail/lite", getCryptoLiteDetailHandler)
-	mux.HandleFunc("/api/v1/candles", getCandlesHandler)
-	mux.HandleFunc("/api/v1/candle", getCurrentCandleHandler)
-	mux.HandleFunc("/api/v1/predict/trend", getTrendPredictHandler)
-	mux.HandleFunc("/static/img/crypto/", getCryptoImgHandler)
+	c.handleAuth(mux)
+	mux.Handle("/api/v1/crypto", c.read(getCryptoHandler))
+	mux.Handle("/api/v1/crypto/list", c.read(getCryptoList))
+	mux.Handle("/api/v1/crypto/fearAndGreed", c.read(getCryptoFearAndGreed))
+	mux.Handle("/api/v1/crypto/detail", c.read(getCryptoDetailHandler))
+	mux.Handle("/api/v1/crypto/detail/lite", c.read(getCryptoLiteDetailHandler))
+	mux.Handle("/api/v1/candles", c.read(getCandlesHandler))
+	mux.Handle("/api/v1/candle", c.read(getCurrentCandleHandler))
+	mux.Handle("/api/v1/predict/trend", c.read(getTrendPredictHandler))
+	mux.Handle("/api/v1/structure", c.read(getStructureHandler))
+	mux.Handle("/api/v1/stream", c.read(state.stream.ServeHTTP))
+	mux.Handle("/static/img/crypto/", c.read(getCryptoImgHandler))
 
 	assets := http.FileServer(http.Dir("./web/assets/"))
 	mux.Handle("/assets/", http.StripPrefix("/assets", assets))
 
-	return http.ListenAndServe(addr, mux)
+	return c.listenAndServe(addr, mux)
 }
 
-func RunOrderLog(addr string) error {
+// RunOrderLog запускает журнал ордеров. Настройки сервера - ServerOption
+func RunOrderLog(addr string, opts ...ServerOption) error {
 	initAppState()
+	c := newServerConfig(opts)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", orderLogRootHandler)
 	mux.HandleFunc("/order-log", orderLogRootHandler)
 	mux.HandleFunc("/ping", pingHandler)
-	mux.HandleFunc("/api/v1/crypto", getCryptoHandler)
-	mux.HandleFunc("/static/img/crypto/", getCryptoImgHandler)
-	mux.HandleFunc("/api/v1/order-log", getOrderLog)
+	c.handleAuth(mux)
+	mux.Handle("/api/v1/crypto", c.read(getCryptoHandler))
+	mux.Handle("/static/img/crypto/", c.read(getCryptoImgHandler))
+	mux.Handle("/api/v1/order-log", c.read(c.orderLogHandler))
+	mux.Handle("/api/v1/order-log/stats", c.read(c.orderStatsHandler))
 
 	assets := http.FileServer(http.Dir("./web/assets/"))
 	mux.Handle("/assets/", http.StripPrefix("/assets", assets))
 
-	return http.ListenAndServe(addr, mux)
+	return c.listenAndServe(addr, mux)
 }
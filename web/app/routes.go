@@ -53,6 +53,17 @@ func orderLogRootHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(content)
 }
 
+func loginRootHandler(w http.ResponseWriter, r *http.Request) {
+	content, err := os.ReadFile("web/pages/login.html")
+	if err != nil {
+		http.Error(w, "Не удалось загрузить страницу", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
 func pingHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "pong")
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"fmt"
+	orderdb "goTradingBot/trading/db"
+	"goTradingBot/web/auth"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+type serverConfig struct {
+	auth              *auth.Auth
+	certFile, keyFile string
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	// параметры панели управления
+	controlToken string
+	stream       *StreamHub
+	orders       orderdb.OrderStore
+}
+
+// ServerOption определяет тип функции для настройки веб-сервера
+type ServerOption func(*serverConfig)
+
+// WithAuth включает авторизацию: страницы доступны всем, API требует уровень Read,
+// команды управления - Control. Без авторизации API закрыто
+func WithAuth(a *auth.Auth) ServerOption {
+	return func(c *serverConfig) {
+		c.auth = a
+	}
+}
+
+// WithTLS включает HTTPS с сертификатом certFile и ключом keyFile
+func WithTLS(certFile, keyFile string) ServerOption {
+	return func(c *serverConfig) {
+		c.certFile = certFile
+		c.keyFile = keyFile
+	}
+}
+
+// WithTimeouts устанавливает таймауты http.Server на чтение запроса, запись ответа
+// и простой keep-alive соединения. Нулевое значение оставляет таймаут по умолчанию
+func WithTimeouts(read, write, idle time.Duration) ServerOption {
+	return func(c *serverConfig) {
+		if read > 0 {
+			c.readTimeout = read
+		}
+		if write > 0 {
+			c.writeTimeout = write
+		}
+		if idle > 0 {
+			c.idleTimeout = idle
+		}
+	}
+}
+
+// WithControlToken добавляет статический токен с уровнем доступа Control
+// Если авторизация не задана, API доступно только с этим токеном
+func WithControlToken(token string) ServerOption {
+	return func(c *serverConfig) {
+		c.controlToken = token
+	}
+}
+
+// WithStreamHub подключает поток обновлений /api/v1/stream
+func WithStreamHub(hub *StreamHub) ServerOption {
+	return func(c *serverConfig) {
+		c.stream = hub
+	}
+}
+
+// WithOrderStore устанавливает хранилище ордеров для журнала и кривой доходности
+// По умолчанию используется orderdb.Default()
+func WithOrderStore(store orderdb.OrderStore) ServerOption {
+	return func(c *serverConfig) {
+		c.orders = store
+	}
+}
+
+// ServerOptionsFromEnv возвращает настройки сервера из переменных окружения:
+// авторизация auth.NewFromEnv и сертификаты WEB_TLS_CERT, WEB_TLS_KEY
+func ServerOptionsFromEnv() ([]ServerOption, error) {
+	var opts []ServerOption
+	cert, key := os.Getenv("WEB_TLS_CERT"), os.Getenv("WEB_TLS_KEY")
+	if (cert == "") != (key == "") {
+		return nil, fmt.Errorf("ServerOptionsFromEnv: WEB_TLS_CERT и WEB_TLS_KEY задаются вместе")
+	}
+	if cert != "" {
+		opts = append(opts, WithTLS(cert, key))
+	}
+	a, err := auth.NewFromEnv(auth.WithSecureCookie(cert != ""))
+	if err != nil {
+		return nil, fmt.Errorf("ServerOptionsFromEnv: %w", err)
+	}
+	if a != nil {
+		opts = append(opts, WithAuth(a))
+	}
+	return opts, nil
+}
+
+func newServerConfig(opts []ServerOption) *serverConfig {
+	c := &serverConfig{
+		readHeaderTimeout: 5 * time.Second,
+		readTimeout:       30 * time.Second,
+		writeTimeout:      60 * time.Second,
+		idleTimeout:       120 * time.Second,
+	}
+	for _, option := range opts {
+		option(c)
+	}
+	if c.controlToken != "" {
+		if c.auth == nil {
+			c.auth = auth.New()
+		}
+		c.auth.AddToken(c.controlToken, "control-token", auth.Control)
+	}
+	if c.auth == nil {
+		slog.Warn("web auth is not configured, API is closed: set WEB_AUTH_TOKENS, WEB_AUTH_USERS_DB or WEB_AUTH_ANONYMOUS=read")
+	}
+	return c
+}
+
+// orderStore возвращает хранилище ордеров из настроек или общее хранилище по умолчанию
+func (c *serverConfig) orderStore() (orderdb.OrderStore, error) {
+	if c.orders != nil {
+		return c.orders, nil
+	}
+	return orderdb.Default()
+}
+
+// read защищает маршрут уровнем доступа Read
+func (c *serverConfig) read(h http.HandlerFunc) http.Handler {
+	return c.auth.Require(auth.Read, h)
+}
+
+// control защищает маршрут уровнем доступа Control
+func (c *serverConfig) control(h http.HandlerFunc) http.Handler {
+	return c.auth.Require(auth.Control, h)
+}
+
+// handleAuth регистрирует маршруты входа, если авторизация включена
+func (c *serverConfig) handleAuth(mux *http.ServeMux) {
+	if c.auth == nil {
+		return
+	}
+	c.auth.Handle(mux)
+	mux.HandleFunc("/login", loginRootHandler)
+}
+
+// listenAndServe запускает http.Server с таймаутами и TLS из настроек
+// Потоки WebSocket не ограничены таймаутами: websocket.Upgrader сбрасывает их у соединения
+func (c *serverConfig) listenAndServe(addr string, handler http.Handler) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: c.readHeaderTimeout,
+		ReadTimeout:       c.readTimeout,
+		WriteTimeout:      c.writeTimeout,
+		IdleTimeout:       c.idleTimeout,
+	}
+	if c.certFile != "" {
+		return srv.ListenAndServeTLS(c.certFile, c.keyFile)
+	}
+	return srv.ListenAndServe()
+}
@@ -1,11 +1,13 @@
 package app
 
 import (
+	"context"
 	"goTradingBot/cdl"
 	"goTradingBot/external/bybit"
 	"goTradingBot/external/cryptos"
 	"goTradingBot/predict"
 	"goTradingBot/predict/features"
+	"goTradingBot/trading/types"
 	"sync"
 )
 
@@ -16,10 +18,13 @@ var (
 
 func initAppState() {
 	once.Do(func() {
+		client := bybit.NewClientFromEnv(bybit.WithCategory("linear"))
+		ctx := context.Background()
 		state = &appState{
 			cryptos:     cryptos.NewClient(),
-			cdlProvider: bybit.NewClientFromEnv(bybit.WithCategory("linear")),
+			cdlProvider: client,
 			fgModels:    predict.FeaturesGeneratorModels(),
+			stream:      NewStreamHub(ctx, types.NewSubData(ctx, client.DataProviderImpl(), 100)),
 		}
 	})
 }
@@ -28,5 +33,6 @@ type appState struct {
 	cryptos     *cryptos.Client
 	cdlProvider cdl.CandleProvider
 	fgModels    map[predict.Model]*features.Generator
+	stream      *StreamHub
 	// mu          sync.Mutex
 }
@@ -0,0 +1,460 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"goTradingBot/cdl"
+	"goTradingBot/trading/notify"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Темы потока /api/v1/stream
+const (
+	CandlesTopic = "candles" // свечи по символу и интервалу
+	EventsTopic  = "events"  // уведомления бота: исполнение ордеров, позиции, отчеты
+	LogsTopic    = "logs"    // записи журнала бота
+	errorTopic   = "error"
+)
+
+// CandleSubscriber источник потока свечей, например types.SubData
+type CandleSubscriber interface {
+	SubscribeChan(symbol string, interval cdl.Interval, ch chan<- *cdl.CandleStreamData) (chan<- struct{}, error)
+}
+
+// CandleSubscriberFunc функция-источник потока свечей
+type CandleSubscriberFunc func(symbol string, interval cdl.Interval, ch chan<- *cdl.CandleStreamData) (chan<- struct{}, error)
+
+func (f CandleSubscriberFunc) SubscribeChan(symbol string, interval cdl.Interval, ch chan<- *cdl.CandleStreamData) (chan<- struct{}, error) {
+	return f(symbol, interval, ch)
+}
+
+// StreamMessage сообщение клиенту
+type StreamMessage struct {
+	Topic    string `json:"topic"`
+	Symbol   string `json:"symbol,omitempty"`
+	Interval string `json:"interval,omitempty"`
+	Data     any    `json:"data"`
+}
+
+// streamCommand команда клиента
+// {"op": "subscribe", "topic": "candles", "symbol": "BTCUSDT", "interval": "5"}
+type streamCommand struct {
+	Op       string `json:"op"` // subscribe или unsubscribe
+	Topic    string `json:"topic"`
+	Symbol   string `json:"symbol"`
+	Interval string `json:"interval"`
+}
+
+// StreamHub рассылает свечи, уведомления и журнал подключенным по WebSocket клиентам
+// Каждый клиент подписывается на нужные темы. Один поток свечей по символу и интервалу
+// разделяется между всеми клиентами. Клиент, не успевающий читать сообщения, отключается
+type StreamHub struct {
+	ctx          context.Context
+	candles      CandleSubscriber
+	allowCandles func(symbol string, interval cdl.Interval) bool
+	maxFeeds     int
+	upgrader     websocket.Upgrader
+	clientBuffer int
+	logLevel     slog.Level
+
+	mu      sync.Mutex
+	clients map[*streamClient]struct{}
+	feeds   map[string]*candleFeed
+}
+
+// StreamOption определяет тип функции для настройки StreamHub
+type StreamOption func(*StreamHub)
+
+// WithClientBuffer устанавливает размер очереди сообщений клиента
+func WithClientBuffer(size int) StreamOption {
+	return func(h *StreamHub) {
+		h.clientBuffer = size
+	}
+}
+
+// WithCandleFilter ограничивает темы candles символами и интервалами, для которых allow возвращает true
+// Каждая новая пара может запускать поток свечей у источника, поэтому доступ к ним стоит ограничить
+func WithCandleFilter(allow func(symbol string, interval cdl.Interval) bool) StreamOption {
+	return func(h *StreamHub) {
+		h.allowCandles = allow
+	}
+}
+
+// WithMaxFeeds устанавливает максимальное количество потоков свечей одного клиента
+func WithMaxFeeds(n int) StreamOption {
+	return func(h *StreamHub) {
+		h.maxFeeds = n
+	}
+}
+
+// WithCheckOrigin устанавливает проверку заголовка Origin при подключении
+func WithCheckOrigin(check func(r *http.Request) bool) StreamOption {
+	return func(h *StreamHub) {
+		h.upgrader.CheckOrigin = check
+	}
+}
+
+// WithLogLevel устанавливает минимальный уровень записей журнала для темы logs
+func WithLogLevel(level slog.Level) StreamOption {
+	return func(h *StreamHub) {
+		h.logLevel = level
+	}
+}
+
+// NewStreamHub создает StreamHub, работающий до отмены ctx
+// candles может быть nil, тогда тема candles недоступна
+func NewStreamHub(ctx context.Context, candles CandleSubscriber, opts ...StreamOption) *StreamHub {
+	h := &StreamHub{
+		ctx:          ctx,
+		candles:      candles,
+		maxFeeds:     8,
+		clientBuffer: 256,
+		logLevel:     slog.LevelInfo,
+		clients:      make(map[*streamClient]struct{}),
+		feeds:        make(map[string]*candleFeed),
+	}
+	for _, option := range opts {
+		option(h)
+	}
+	return h
+}
+
+type streamClient struct {
+	conn   *websocket.Conn
+	send   chan []byte
+	topics map[string]bool // под StreamHub.mu
+	once   sync.Once
+	done   chan struct{}
+}
+
+func (c *streamClient) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+type candleFeed struct {
+	clients map[*streamClient]struct{}
+	done    chan<- struct{}
+}
+
+// ServeHTTP принимает WebSocket подключение
+func (h *StreamHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &streamClient{
+		conn:   conn,
+		send:   make(chan []byte, h.clientBuffer),
+		topics: make(map[string]bool),
+		done:   make(chan struct{}),
+	}
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+
+	go h.writePump(c)
+	h.readPump(c)
+	h.removeClient(c)
+}
+
+func (h *StreamHub) readPump(c *streamClient) {
+	defer c.close()
+
+	c.conn.SetReadLimit(4 << 10)
+	for {
+		var cmd streamCommand
+		if err := c.conn.ReadJSON(&cmd); err != nil {
+			return
+		}
+		if err := h.handleCommand(c, &cmd); err != nil {
+			h.sendTo(c, &StreamMessage{Topic: errorTopic, Data: err.Error()})
+		}
+	}
+}
+
+func (h *StreamHub) writePump(c *streamClient) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	defer c.close()
+
+	for {
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-c.done:
+			return
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *StreamHub) handleCommand(c *streamClient, cmd *streamCommand) error {
+	if cmd.Op != "subscribe" && cmd.Op != "unsubscribe" {
+		return fmt.Errorf("неизвестная операция: %q", cmd.Op)
+	}
+	subscribe := cmd.Op == "subscribe"
+	switch cmd.Topic {
+	case EventsTopic, LogsTopic:
+		h.mu.Lock()
+		c.topics[cmd.Topic] = subscribe
+		h.mu.Unlock()
+		return nil
+	case CandlesTopic:
+		interval, err := cdl.ParseInterval(cmd.Interval)
+		if err != nil {
+			return err
+		}
+		symbol := strings.ToUpper(cmd.Symbol)
+		if symbol == "" {
+			return fmt.Errorf("пропущен обязательный параметр: symbol")
+		}
+		if subscribe {
+			return h.subscribeCandles(c, symbol, interval)
+		}
+		h.mu.Lock()
+		h.leaveFeed(c, candleKey(symbol, interval))
+		h.mu.Unlock()
+		return nil
+	}
+	return fmt.Errorf("неизвестная тема: %q", cmd.Topic)
+}
+
+func candleKey(symbol string, interval cdl.Interval) string {
+	return CandlesTopic + "." + interval.AsString() + "." + symbol
+}
+
+func (h *StreamHub) subscribeCandles(c *streamClient, symbol string, interval cdl.Interval) error {
+	if h.candles == nil {
+		return fmt.Errorf("поток свечей недоступен")
+	}
+	if h.allowCandles != nil && !h.allowCandles(symbol, interval) {
+		return fmt.Errorf("поток свечей %s %s недоступен", symbol, interval.AsString())
+	}
+	key := candleKey(symbol, interval)
+	h.mu.Lock()
+	feed, ok := h.feeds[key]
+	if ok {
+		if _, joined := feed.clients[c]; joined {
+			h.mu.Unlock()
+			return nil
+		}
+	}
+	if h.clientFeeds(c) >= h.maxFeeds {
+		h.mu.Unlock()
+		return fmt.Errorf("превышено количество потоков свечей: %d", h.maxFeeds)
+	}
+	if ok {
+		feed.clients[c] = struct{}{}
+		h.mu.Unlock()
+		return nil
+	}
+	h.mu.Unlock()
+
+	ch := make(chan *cdl.CandleStreamData, 64)
+	done, err := h.candles.SubscribeChan(symbol, interval, ch)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		// клиент отключился во время подписки
+		close(done)
+		return nil
+	}
+	if feed, ok := h.feeds[key]; ok {
+		// поток уже создан параллельным запросом
+		close(done)
+		feed.clients[c] = struct{}{}
+		return nil
+	}
+	feed = &candleFeed{clients: map[*streamClient]struct{}{c: {}}, done: done}
+	h.feeds[key] = feed
+	go h.runFeed(feed, ch, symbol, interval)
+	return nil
+}
+
+// clientFeeds возвращает количество потоков свечей клиента. Вызывается под h.mu
+func (h *StreamHub) clientFeeds(c *streamClient) int {
+	var n int
+	for _, feed := range h.feeds {
+		if _, ok := feed.clients[c]; ok {
+			n++
+		}
+	}
+	return n
+}
+
+// leaveFeed отписывает клиента от потока свечей и закрывает поток без клиентов. Вызывается под h.mu
+func (h *StreamHub) leaveFeed(c *streamClient, key string) {
+	feed, ok := h.feeds[key]
+	if !ok {
+		return
+	}
+	delete(feed.clients, c)
+	if len(feed.clients) == 0 {
+		close(feed.done)
+		delete(h.feeds, key)
+	}
+}
+
+func (h *StreamHub) runFeed(feed *candleFeed, ch <-chan *cdl.CandleStreamData, symbol string, interval cdl.Interval) {
+	for data := range ch {
+		msg, err := json.Marshal(&StreamMessage{
+			Topic:    CandlesTopic,
+			Symbol:   symbol,
+			Interval: interval.AsString(),
+			Data:     data,
+		})
+		if err != nil {
+			continue
+		}
+		h.mu.Lock()
+		for c := range feed.clients {
+			h.trySend(c, msg)
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (h *StreamHub) removeClient(c *streamClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.clients, c)
+	for key := range h.feeds {
+		h.leaveFeed(c, key)
+	}
+}
+
+// trySend ставит сообщение в очередь клиента, переполнение очереди отключает клиента
+func (h *StreamHub) trySend(c *streamClient, msg []byte) {
+	select {
+	case c.send <- msg:
+	default:
+		c.close()
+	}
+}
+
+func (h *StreamHub) sendTo(c *streamClient, m *StreamMessage) {
+	msg, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	h.trySend(c, msg)
+}
+
+// Publish рассылает данные клиентам, подписанным на тему topic
+func (h *StreamHub) Publish(topic string, data any) {
+	msg, err := json.Marshal(&StreamMessage{Topic: topic, Data: data})
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		if c.topics[topic] {
+			h.trySend(c, msg)
+		}
+	}
+}
+
+// Send реализует notify.Sink: уведомления рассылаются в теме events
+func (h *StreamHub) Send(_ context.Context, e *notify.Event) error {
+	h.Publish(EventsTopic, e)
+	return nil
+}
+
+// LogHandler возвращает обработчик журнала, рассылающий записи в теме logs
+func (h *StreamHub) LogHandler() slog.Handler {
+	return &streamLogHandler{hub: h}
+}
+
+type streamLogHandler struct {
+	hub   *StreamHub
+	attrs []slog.Attr
+	group string
+}
+
+func (lh *streamLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= lh.hub.logLevel
+}
+
+func (lh *streamLogHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make(map[string]any, r.NumAttrs()+len(lh.attrs))
+	for _, a := range lh.attrs {
+		attrs[a.Key] = logValue(a.Value)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		key := a.Key
+		if lh.group != "" {
+			key = lh.group + "." + key
+		}
+		attrs[key] = logValue(a.Value)
+		return true
+	})
+	lh.hub.Publish(LogsTopic, map[string]any{
+		"time":    r.Time,
+		"level":   r.Level.String(),
+		"message": r.Message,
+		"attrs":   attrs,
+	})
+	return nil
+}
+
+// logValue приводит значение атрибута к виду, пригодному для JSON
+func logValue(v slog.Value) any {
+	v = v.Resolve()
+	if err, ok := v.Any().(error); ok {
+		return err.Error()
+	}
+	if v.Kind() == slog.KindGroup {
+		group := make(map[string]any)
+		for _, a := range v.Group() {
+			group[a.Key] = logValue(a.Value)
+		}
+		return group
+	}
+	return v.Any()
+}
+
+func (lh *streamLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nh := &streamLogHandler{hub: lh.hub, group: lh.group, attrs: append([]slog.Attr(nil), lh.attrs...)}
+	for _, a := range attrs {
+		if lh.group != "" {
+			a.Key = lh.group + "." + a.Key
+		}
+		nh.attrs = append(nh.attrs, a)
+	}
+	return nh
+}
+
+func (lh *streamLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return lh
+	}
+	group := name
+	if lh.group != "" {
+		group = lh.group + "." + name
+	}
+	return &streamLogHandler{hub: lh.hub, group: group, attrs: lh.attrs}
+}
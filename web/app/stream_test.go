@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"goTradingBot/cdl"
+	"goTradingBot/trading/notify"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestStreamHub(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	feeds := make(chan chan<- *cdl.CandleStreamData, 1)
+	hub := NewStreamHub(ctx, CandleSubscriberFunc(
+		func(symbol string, interval cdl.Interval, ch chan<- *cdl.CandleStreamData) (chan<- struct{}, error) {
+			feeds <- ch
+			return make(chan struct{}), nil
+		},
+	), WithCandleFilter(func(symbol string, _ cdl.Interval) bool { return symbol == "BTCUSDT" }), WithMaxFeeds(2))
+	srv := httptest.NewServer(hub)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	read := func() StreamMessage {
+		var msg StreamMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	conn.WriteJSON(&streamCommand{Op: "subscribe", Topic: CandlesTopic, Symbol: "btcusdt", Interval: "5"})
+	var ch chan<- *cdl.CandleStreamData
+	select {
+	case ch = <-feeds:
+	case <-time.After(5 * time.Second):
+		t.Fatal("нет подписки на свечи")
+	}
+	ch <- &cdl.CandleStreamData{}
+	if msg := read(); msg.Topic != CandlesTopic || msg.Symbol != "BTCUSDT" || msg.Interval != "5" {
+		t.Fatalf("свеча: %+v", msg)
+	}
+
+	// символ без запущенной стратегии и потоки сверх ограничения клиента недоступны
+	conn.WriteJSON(&streamCommand{Op: "subscribe", Topic: CandlesTopic, Symbol: "ETHUSDT", Interval: "5"})
+	if msg := read(); msg.Topic != errorTopic {
+		t.Fatalf("недоступный символ: %+v", msg)
+	}
+	conn.WriteJSON(&streamCommand{Op: "subscribe", Topic: CandlesTopic, Symbol: "BTCUSDT", Interval: "5"})
+	conn.WriteJSON(&streamCommand{Op: "subscribe", Topic: CandlesTopic, Symbol: "BTCUSDT", Interval: "15"})
+	<-feeds
+	conn.WriteJSON(&streamCommand{Op: "subscribe", Topic: CandlesTopic, Symbol: "BTCUSDT", Interval: "60"})
+	if msg := read(); msg.Topic != errorTopic || !strings.Contains(msg.Data.(string), "2") {
+		t.Fatalf("ограничение потоков: %+v", msg)
+	}
+
+	conn.WriteJSON(&streamCommand{Op: "subscribe", Topic: EventsTopic})
+	conn.WriteJSON(&streamCommand{Op: "subscribe", Topic: "unknown"})
+	if msg := read(); msg.Topic != errorTopic {
+		t.Fatalf("ошибка: %+v", msg)
+	}
+	hub.Send(ctx, &notify.Event{Kind: notify.OrderFilled, Symbol: "BTCUSDT"})
+	msg := read()
+	data, _ := json.Marshal(msg.Data)
+	if msg.Topic != EventsTopic || !strings.Contains(string(data), `"orderFilled"`) {
+		t.Fatalf("уведомление: %+v", msg)
+	}
+}
@@ -4,7 +4,7 @@ class GoTradingClient {
 		this.baseUrl = baseUrl || window.location.origin;
 	}
 
-	async #makeRequest(endpoint, params = {}, method = 'GET', body = null) {
+	async #makeRequest(endpoint, params = {}, method = 'GET', body = null, headers = {}) {
 		const url = new URL(`${this.baseUrl}${endpoint}`);
 		Object.entries(params).forEach(([key, value]) => {
 			if (value !== undefined) {
@@ -16,6 +16,7 @@ class GoTradingClient {
 			method,
 			headers: {
 				'Content-Type': 'application/json',
+				...headers,
 			},
 		};
 
@@ -24,6 +25,11 @@ class GoTradingClient {
 		}
 
 		const response = await fetch(url, options);
+		if (response.status === 401 && !headers.Authorization && !endpoint.startsWith('/api/v1/auth/')) {
+			// сессия отсутствует или истекла: переходим на страницу входа
+			const next = encodeURIComponent(window.location.pathname + window.location.search);
+			window.location.assign(`/login?next=${next}`);
+		}
 		// if (!response.ok) {
 		// 	const errorData = await response.json().catch(() => ({}));
 		// 	throw new Error(errorData.error || `Request failed with status ${response.status}`);
@@ -37,6 +43,22 @@ class GoTradingClient {
 		return this.#makeRequest('/ping');
 	}
 
+	// Auth endpoints
+	login(username, password) {
+		if (!username || !password) {
+			return Promise.reject(new Error('Username and password are required'));
+		}
+		return this.#makeRequest('/api/v1/auth/login', {}, 'POST', { username, password });
+	}
+
+	logout() {
+		return this.#makeRequest('/api/v1/auth/logout', {}, 'POST');
+	}
+
+	getIdentity() {
+		return this.#makeRequest('/api/v1/auth/me');
+	}
+
 	// Crypto endpoints
 	searchCrypto(query) {
 		if (!query) {
@@ -89,11 +111,97 @@ class GoTradingClient {
 		return this.#makeRequest('/api/v1/candle', { s: symbol, i: interval });
 	}
 
-	getOrderLog(periodSec) {
-		if (!periodSec) {
-			return Promise.reject(new Error('periodSec is required'));
+	// Рыночная структура по закрытым свечам: точки разворота, уровни и линии тренда
+	getStructure(symbol, interval, limit = 999, period) {
+		if (!symbol || !interval) {
+			return Promise.reject(new Error('Symbol and interval are required'));
+		}
+		const params = { s: symbol, i: interval, l: limit };
+		if (period) params.p = period;
+		return this.#makeRequest('/api/v1/structure', params);
+	}
+
+	// filter: s, tag, status, side, from, to, p; page: l, cursor
+	getOrderLog(filter = {}, page = {}) {
+		return this.#makeRequest('/api/v1/order-log', { ...filter, ...page });
+	}
+
+	getOrderStats(filter = {}) {
+		return this.#makeRequest('/api/v1/order-log/stats', filter);
+	}
+
+	getOrderLogExportUrl(filter = {}, format = 'csv') {
+		const url = new URL(`${this.baseUrl}/api/v1/order-log`);
+		Object.entries({ ...filter, format }).forEach(([key, value]) => {
+			if (value !== undefined && value !== '') {
+				url.searchParams.append(key, value);
+			}
+		});
+		return url.toString();
+	}
+
+	// Bot dashboard endpoints
+	getBotStatus() {
+		return this.#makeRequest('/api/v1/bot/status');
+	}
+
+	getBotPositions() {
+		return this.#makeRequest('/api/v1/bot/positions');
+	}
+
+	getBotPnl() {
+		return this.#makeRequest('/api/v1/bot/pnl');
+	}
+
+	getBotOrders() {
+		return this.#makeRequest('/api/v1/bot/orders');
+	}
+
+	getBotEquity(periodSec) {
+		return this.#makeRequest('/api/v1/bot/equity', { p: periodSec });
+	}
+
+	#botControl(action, body, token) {
+		// без токена команда выполняется от имени сессии пользователя
+		const headers = token ? { Authorization: `Bearer ${token}` } : {};
+		return this.#makeRequest(`/api/v1/bot/${action}`, {}, 'POST', body, headers);
+	}
+
+	pauseStrategy(strategy, token) {
+		if (!strategy) {
+			return Promise.reject(new Error('Strategy is required'));
 		}
-		return this.#makeRequest('/api/v1/order-log', { p: periodSec });
+		return this.#botControl('pause', { strategy }, token);
+	}
+
+	resumeStrategy(strategy, token) {
+		return this.#botControl('resume', { strategy }, token);
+	}
+
+	startStrategy(strategy, token) {
+		return this.#botControl('start', { strategy }, token);
+	}
+
+	stopStrategy(strategy, flatten, token) {
+		return this.#botControl('stop', { strategy, flatten }, token);
+	}
+
+	removeStrategy(strategy, flatten, token) {
+		return this.#botControl('remove', { strategy, flatten }, token);
+	}
+
+	reloadStrategy(strategy, params, token) {
+		if (!params || Object.keys(params).length === 0) {
+			return Promise.reject(new Error('Params are required'));
+		}
+		return this.#botControl('reload', { strategy, params }, token);
+	}
+
+		flattenPosition(symbol, token) {
+		if (!symbol) {
+			return Promise.reject(new Error('Symbol is required'));
+		}
+		return this.#botControl('flatten', { symbol }, token);
 	}
 
 	// Prediction endpoint
@@ -110,4 +218,61 @@ class GoTradingClient {
 	}
 }
 
+// Поток обновлений /api/v1/stream с автоматическим переподключением
+class GoTradingStream {
+	constructor(baseUrl = '', reconnectDelay = 3000) {
+		const url = new URL('/api/v1/stream', baseUrl || window.location.origin);
+		url.protocol = url.protocol === 'https:' ? 'wss:' : 'ws:';
+		this.url = url.toString();
+		this.reconnectDelay = reconnectDelay;
+		this.handlers = {};
+		this.subscriptions = new Map();
+		this.socket = null;
+		this.#connect();
+	}
+
+	#connect() {
+		this.socket = new WebSocket(this.url);
+		this.socket.onopen = () => {
+			this.subscriptions.forEach(cmd => this.socket.send(JSON.stringify(cmd)));
+		};
+		this.socket.onmessage = event => {
+			const msg = JSON.parse(event.data);
+			(this.handlers[msg.topic] || []).forEach(handler => handler(msg.data, msg));
+		};
+		this.socket.onclose = () => {
+			setTimeout(() => this.#connect(), this.reconnectDelay);
+		};
+	}
+
+	#command(op, topic, symbol, interval) {
+		const cmd = { op, topic, symbol, interval: interval && String(interval) };
+		const key = `${topic}.${interval || ''}.${symbol || ''}`;
+		if (op === 'subscribe') {
+			this.subscriptions.set(key, cmd);
+		} else {
+			this.subscriptions.delete(key);
+		}
+		if (this.socket.readyState === WebSocket.OPEN) {
+			this.socket.send(JSON.stringify(cmd));
+		}
+	}
+
+	on(topic, handler) {
+		(this.handlers[topic] ||= []).push(handler);
+		return this;
+	}
+
+	subscribe(topic, symbol, interval) {
+		this.#command('subscribe', topic, symbol, interval);
+		return this;
+	}
+
+	unsubscribe(topic, symbol, interval) {
+		this.#command('unsubscribe', topic, symbol, interval);
+		return this;
+	}
+}
+
 window.GoTradingClient = GoTradingClient;
+window.GoTradingStream = GoTradingStream;
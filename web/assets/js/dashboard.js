@@ -0,0 +1,245 @@
+class DashboardManager {
+	constructor() {
+		this.client = new GoTradingClient();
+		this.tokenInput = document.getElementById('control-token');
+		this.message = document.getElementById('message');
+		this.strategiesBody = document.querySelector('#strategies-tb tbody');
+		this.positionsBody = document.querySelector('#positions-tb tbody');
+		this.ordersBody = document.querySelector('#orders-tb tbody');
+		this.logsList = document.getElementById('logs');
+		this.maxLogs = 200;
+		this.equityChart = null;
+		this.refreshInterval = 5000;
+
+		this.init();
+	}
+
+	init() {
+		this.tokenInput.value = localStorage.getItem('controlToken') || '';
+		this.bindEvents();
+		this.initEquityChart();
+		this.refresh();
+		this.loadEquity();
+		this.initStream();
+		setInterval(() => this.refresh(), this.refreshInterval);
+		setInterval(() => this.loadEquity(), this.refreshInterval * 12);
+	}
+
+	bindEvents() {
+		document.getElementById('save-token').addEventListener('click', () => {
+			localStorage.setItem('controlToken', this.tokenInput.value);
+			this.showMessage('token saved');
+		});
+		document.addEventListener('click', event => {
+			const button = event.target.closest('button[data-action]');
+			if (button) {
+				this.handleControl(button.dataset.action, button.dataset.target);
+			}
+		});
+	}
+
+	initStream() {
+		this.stream = new GoTradingStream()
+			.on('events', event => {
+				this.showMessage(`${event.kind}: ${event.message || event.symbol || ''}`);
+				this.refresh();
+			})
+			.on('logs', record => this.appendLog(record))
+			.on('error', error => this.showMessage(`stream: ${error}`, true))
+			.subscribe('events')
+			.subscribe('logs');
+	}
+
+	appendLog(record) {
+		const item = document.createElement('li');
+		item.className = `log-${String(record.level).toLowerCase()}`;
+		item.textContent = `${this.formatTimestamp(record.time)} ${record.level} ${record.message} ${JSON.stringify(record.attrs || {})}`;
+		this.logsList.prepend(item);
+		while (this.logsList.children.length > this.maxLogs) {
+			this.logsList.lastChild.remove();
+		}
+	}
+
+	showMessage(text, isError = false) {
+		this.message.textContent = text;
+		this.message.classList.toggle('error', isError);
+	}
+
+	escape(value) {
+		const div = document.createElement('div');
+		div.textContent = String(value ?? '');
+		return div.innerHTML;
+	}
+
+	formatNumber(value, digits = 4) {
+		return Number(value || 0).toFixed(digits);
+	}
+
+	formatTimestamp(timestamp) {
+		if (!timestamp) return '-';
+		return new Date(timestamp).toLocaleString();
+	}
+
+	async refresh() {
+		try {
+			const [status, positions, pnl, orders] = await Promise.all([
+				this.client.getBotStatus(),
+				this.client.getBotPositions(),
+				this.client.getBotPnl(),
+				this.client.getBotOrders(),
+			]);
+			this.renderStatus(status.result);
+			this.renderPositions(positions.result || [], pnl.result || []);
+			this.renderOrders(orders.result || []);
+		} catch (error) {
+			this.showMessage(`Error loading data: ${error.message}`, true);
+		}
+	}
+
+	renderStatus(status) {
+		const state = status.killed ? 'killed' : 'running';
+		document.getElementById('bot-state').textContent =
+			`${state} since ${this.formatTimestamp(status.startedAt)}`;
+
+		this.strategiesBody.innerHTML = (status.strategies || []).map(s => {
+			const entries = Object.entries(s.params || {});
+			if (s.budget !== undefined) {
+				entries.push(['budget', s.budget.toFixed(2)]);
+			}
+			const params = entries
+				.map(([k, v]) => `${this.escape(k)}=${this.escape(v)}`)
+				.join(' ');
+			const state = s.state || (s.paused ? 'paused' : 'running');
+			const actions = state === 'stopped'
+				? ['start', 'remove']
+				: [s.paused ? 'resume' : 'pause', 'reload', 'stop'];
+			const buttons = actions
+				.map(a => `<button data-action="${a}" data-target="${this.escape(s.name)}">${a}</button>`)
+				.join(' ');
+			return `
+        <tr>
+          <td>${this.escape(s.name)}</td>
+          <td class="${state === 'running' ? 'active' : 'paused'}">${state}</td>
+          <td class="params">${params}</td>
+          <td>${buttons}</td>
+        </tr>
+      `;
+		}).join('');
+	}
+
+	renderPositions(positions, pnl) {
+		const pnlBySymbol = Object.fromEntries(pnl.map(p => [p.symbol, p]));
+		if (positions.length === 0) {
+			this.positionsBody.innerHTML = '<tr><td colspan="9">no open positions</td></tr>';
+			return;
+		}
+		this.positionsBody.innerHTML = positions.map(p => {
+			const result = pnlBySymbol[p.symbol] || {};
+			const net = result.net || 0;
+			return `
+        <tr>
+          <td>${this.escape(p.symbol)}</td>
+          <td class="${p.qty > 0 ? 'long' : 'short'}">${p.qty}</td>
+          <td>${p.avgPrice}</td>
+          <td>${this.formatNumber(result.unrealized)}</td>
+          <td>${this.formatNumber(result.realized)}</td>
+          <td>${this.formatNumber(result.fees)}</td>
+          <td>${this.formatNumber(result.funding)}</td>
+          <td class="${net >= 0 ? 'long' : 'short'}">${this.formatNumber(net)}</td>
+          <td><button data-action="flatten" data-target="${this.escape(p.symbol)}">flatten</button></td>
+        </tr>
+      `;
+		}).join('');
+	}
+
+	renderOrders(orders) {
+		if (orders.length === 0) {
+			this.ordersBody.innerHTML = '<tr><td colspan="6">no open orders</td></tr>';
+			return;
+		}
+		this.ordersBody.innerHTML = orders.map(req => `
+        <tr>
+          <td>${this.escape(req.order.symbol)}</td>
+          <td>${this.escape(req.tag)}</td>
+          <td class="${req.order.qty > 0 ? 'long' : 'short'}">${req.order.qty}</td>
+          <td>${req.order.price ?? 'market'}</td>
+          <td>${req.order.execQty}</td>
+          <td>${this.formatTimestamp(req.order.createdAt)}</td>
+        </tr>
+      `).join('');
+	}
+
+	async handleControl(action, target) {
+		const token = this.tokenInput.value;
+		if (action === 'flatten' && !confirm(`Flatten ${target}?`)) {
+			return;
+		}
+		let flatten = false;
+		if (action === 'stop' || action === 'remove') {
+			flatten = confirm(`Close the position of ${target} on ${action}?`);
+		}
+		let params;
+		if (action === 'reload') {
+			const input = prompt(`New params of ${target} as JSON, e.g. {"longRatio": 0.5}`);
+			if (!input) {
+				return;
+			}
+			try {
+				params = JSON.parse(input);
+			} catch (error) {
+				this.showMessage(`reload ${target}: ${error.message}`, true);
+				return;
+			}
+		}
+		const calls = {
+			pause: () => this.client.pauseStrategy(target, token),
+			resume: () => this.client.resumeStrategy(target, token),
+			start: () => this.client.startStrategy(target, token),
+			stop: () => this.client.stopStrategy(target, flatten, token),
+			remove: () => this.client.removeStrategy(target, flatten, token),
+			reload: () => this.client.reloadStrategy(target, params, token),
+			flatten: () => this.client.flattenPosition(target, token),
+		};
+		try {
+			const response = await calls[action]();
+			if (response.error) {
+				this.showMessage(`${action} ${target}: ${response.error}`, true);
+				return;
+			}
+			this.showMessage(`${action} ${target}: ok`);
+			this.refresh();
+		} catch (error) {
+			this.showMessage(`${action} ${target}: ${error.message}`, true);
+		}
+	}
+
+	initEquityChart() {
+		const canvas = document.getElementById('equity-chart');
+		this.equityChart = new Chart(canvas, {
+			type: 'line',
+			data: { labels: [], datasets: [{ label: 'equity', data: [], borderColor: '#73c936', pointRadius: 0 }] },
+			options: {
+				animation: false,
+				responsive: true,
+				maintainAspectRatio: false,
+				scales: {
+					y: { grid: { color: '#e0e0e020' } },
+					x: { grid: { display: false } },
+				},
+			},
+		});
+	}
+
+	async loadEquity() {
+		try {
+			const { result: points } = await this.client.getBotEquity();
+			this.equityChart.data.labels = (points || []).map(p => this.formatTimestamp(p.time));
+			this.equityChart.data.datasets[0].data = (points || []).map(p => p.equity);
+			this.equityChart.update();
+		} catch (error) {
+			this.showMessage(`Error loading equity: ${error.message}`, true);
+		}
+	}
+}
+
+document.addEventListener('DOMContentLoaded', () => new DashboardManager());
@@ -0,0 +1,23 @@
+document.addEventListener('DOMContentLoaded', () => {
+	const client = new GoTradingClient();
+	const form = document.getElementById('login-form');
+	const message = document.getElementById('message');
+
+	form.addEventListener('submit', async event => {
+		event.preventDefault();
+		const username = document.getElementById('username').value;
+		const password = document.getElementById('password').value;
+		try {
+			const response = await client.login(username, password);
+			if (response.error) {
+				message.textContent = response.error;
+				return;
+			}
+			// переходим только на страницы этого же сайта
+			const next = new URLSearchParams(window.location.search).get('next') || '/';
+			window.location.assign(next.startsWith('/') && !next.startsWith('//') ? next : '/');
+		} catch (error) {
+			message.textContent = error.message;
+		}
+	});
+});
@@ -2,9 +2,16 @@ class OrderLogManager {
 	constructor() {
 		this.client = new GoTradingClient();
 		this.tableBody = document.querySelector('#order-log-tb tbody');
+		this.statsBody = document.querySelector('#order-stats-tb tbody');
+		this.filterForm = document.getElementById('order-filter');
+		this.loadMoreButton = document.getElementById('load-more');
 		this.cryptoCache = {};
 		this.orderLogData = {};
 		this.currentSort = { key: null, asc: true };
+		this.filter = {};
+		this.cursor = '';
+		this.rowCount = 0;
+		this.pageSize = 100;
 
 		this.init();
 	}
@@ -18,18 +25,69 @@ class OrderLogManager {
 		document.querySelectorAll('th[data-sort-key]').forEach(th => {
 			th.addEventListener('click', () => this.handleSortClick(th));
 		});
+		this.filterForm.addEventListener('submit', event => {
+			event.preventDefault();
+			this.loadData();
+		});
+		this.loadMoreButton.addEventListener('click', () => this.loadPage());
+	}
+
+	readFilter() {
+		const data = new FormData(this.filterForm);
+		return Object.fromEntries([...data.entries()].filter(([, value]) => value !== ''));
 	}
 
 	async loadData() {
+		this.filter = this.readFilter();
+		this.cursor = '';
+		this.rowCount = 0;
+		this.tableBody.innerHTML = '';
+		this.orderLogData = {};
+		document.getElementById('export-csv').href = this.client.getOrderLogExportUrl(this.filter, 'csv');
+		document.getElementById('export-json').href = this.client.getOrderLogExportUrl(this.filter, 'json');
+		await Promise.all([this.loadPage(), this.loadStats()]);
+	}
+
+	async loadPage() {
 		try {
-			const { result: orders } = await this.client.getOrderLog(10000);
-			this.renderOrders(orders);
+			const page = { l: this.pageSize, cursor: this.cursor || undefined };
+			const { result, error } = await this.client.getOrderLog(this.filter, page);
+			if (error) {
+				this.showError(error);
+				return;
+			}
+			await this.renderOrders(result.orders);
+			this.cursor = result.nextCursor || '';
+			this.loadMoreButton.hidden = !this.cursor;
 		} catch (error) {
 			this.showError(error.message);
 			console.error('Error loading order data:', error);
 		}
 	}
 
+	async loadStats() {
+		try {
+			const { result: stats } = await this.client.getOrderStats(this.filter);
+			this.statsBody.innerHTML = '';
+			for (const s of stats || []) {
+				const row = document.createElement('tr');
+				const pnl = this.createCell(s.realizedPnL.toFixed(4), s.realizedPnL >= 0 ? 'pnl-positive' : 'pnl-negative');
+				row.append(
+					this.createCell(s.symbol),
+					this.createCell(s.orders),
+					this.createCell(s.filled),
+					this.createCell(s.buyVolume.toFixed(2)),
+					this.createCell(s.sellVolume.toFixed(2)),
+					this.createCell(s.fees.toFixed(4)),
+					pnl,
+				);
+				this.statsBody.appendChild(row);
+			}
+		} catch (error) {
+			console.error('Error loading order stats:', error);
+		}
+	}
+
 	showError(error) {
 		this.tableBody.innerHTML = `
       <tr>
@@ -96,14 +154,9 @@ class OrderLogManager {
 	}
 
 	async renderOrders(orders) {
-		this.tableBody.innerHTML = '';
-		this.orderLogData = {};
-
-		let rowNumber = orders.length;
-
 		for (const order of orders) {
-			rowNumber--;
-			const row = await this.createOrderRow(order, rowNumber); // Добавляем await
+			this.rowCount++;
+			const row = await this.createOrderRow(order, this.rowCount); // Добавляем await
 			this.tableBody.appendChild(row);
 		}
 	}
@@ -12,6 +12,10 @@ class ChartManager {
             '1h': { pt4: [], npt9: [] },
             '15m': { pt4: [], npt9: [] }
         };
+        this.allStructureData = {
+            '1h': null,
+            '15m': null
+        };
         this.lastSignals = {
             '1h': { pt4: null, npt9: null },
             '15m': { pt4: null, npt9: null }
@@ -215,6 +219,9 @@ class ChartManager {
             plugins: [{
                 id: 'candlestick',
                 beforeDraw: chart => this.drawCandlesticks(chart)
+            }, {
+                id: 'structure',
+                afterDraw: chart => this.drawStructure(chart)
             }]
         };
 
@@ -300,6 +307,69 @@ class ChartManager {
         ctx.restore();
     }
 
+    // Рисует поверх свечей уровни поддержки/сопротивления, линии тренда и метки точек разворота
+    drawStructure(chart) {
+        const structure = this.allStructureData[this.currentInterval];
+        if (!structure || !this.currentOhlcData?.length) return;
+
+        const { ctx, chartArea, scales: { x, y } } = chart;
+        const indexByTime = new Map(this.currentOhlcData.map((d, i) => [d?.t, i]));
+        const last = this.currentOhlcData.length - 1;
+
+        ctx.save();
+        ctx.beginPath();
+        ctx.rect(chartArea.left, chartArea.top, chartArea.width, chartArea.height);
+        ctx.clip();
+
+        ctx.lineWidth = 1;
+        ctx.setLineDash([4, 4]);
+        (structure.levels || []).forEach(level => {
+            const yPos = y.getPixelForValue(level.price);
+            ctx.strokeStyle = level.kind === 'support' ? '#73c93680' : '#f4384180';
+            ctx.beginPath();
+            ctx.moveTo(chartArea.left, yPos);
+            ctx.lineTo(chartArea.right, yPos);
+            ctx.stroke();
+        });
+
+        ctx.setLineDash([]);
+        (structure.trendlines || []).forEach(line => {
+            const from = indexByTime.get(line.from.time);
+            const to = indexByTime.get(line.to.time);
+            if (from === undefined || to === undefined) return;
+            ctx.strokeStyle = line.kind === 'support' ? '#73c936' : '#f43841';
+            ctx.beginPath();
+            ctx.moveTo(x.getPixelForValue(from), y.getPixelForValue(line.from.price));
+            ctx.lineTo(x.getPixelForValue(last), y.getPixelForValue(line.to.price + line.slope * (last - to)));
+            ctx.stroke();
+        });
+
+        ctx.font = '10px sans-serif';
+        ctx.textAlign = 'center';
+        ctx.fillStyle = '#e4e4ef';
+        (structure.swings || []).forEach(swing => {
+            const i = indexByTime.get(swing.time);
+            if (i === undefined || !swing.label) return;
+            const offset = swing.kind === 'high' ? -6 : 12;
+            ctx.fillText(swing.label, x.getPixelForValue(i), y.getPixelForValue(swing.price) + offset);
+        });
+
+        ctx.restore();
+    }
+
+    async updateStructureData(interval) {
+        try {
+            const structure = await this.client.getStructure(
+                this.currentSymbol,
+                interval === '1h' ? 60 : 15,
+                this.allOhlcData[interval].length
+            );
+            this.allStructureData[interval] = structure?.result || null;
+        } catch (error) {
+            console.error(`Error updating structure for ${interval}:`, error);
+        }
+    }
+
     async loadDataForAllIntervals(symbol) {
         try {
             if (!symbol) return false;
@@ -353,6 +423,7 @@ class ChartManager {
             await this.updateSignalsData(interval);
             this.allOhlcData[interval].push(currentData);
             this.allOhlcData[interval].shift();
+            await this.updateStructureData(interval);
         } else {
             this.allOhlcData[interval].splice(-1, 1, currentData);
         }
@@ -397,6 +468,11 @@ class ChartManager {
 
             this.currentSymbol = symbol;
 
+            await Promise.all([
+                this.updateStructureData('1h'),
+                this.updateStructureData('15m')
+            ]);
+
             const [predict1h, predict15m] = await Promise.all([
                 this.client.predictTrend(h1Candles.result, ['linear-', 'H1']),
                 this.client.predictTrend(m15Candles.result, ['linear-', 'M15'])
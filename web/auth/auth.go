@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Permission уровень доступа к маршрутам
+type Permission int
+
+const (
+	None    Permission = iota // доступ запрещен
+	Read                      // просмотр данных
+	Control                   // просмотр и управление ботом
+)
+
+// ParsePermission разбирает уровень доступа: none, read или control
+func ParsePermission(s string) (Permission, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "none":
+		return None, nil
+	case "read":
+		return Read, nil
+	case "control":
+		return Control, nil
+	}
+	return None, fmt.Errorf("ParsePermission: неизвестный уровень доступа: %q", s)
+}
+
+func (p Permission) String() string {
+	switch p {
+	case Read:
+		return "read"
+	case Control:
+		return "control"
+	}
+	return "none"
+}
+
+func (p Permission) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// Identity пользователь или токен, от имени которого выполняется запрос
+type Identity struct {
+	Name       string     `json:"name"`
+	Permission Permission `json:"permission"`
+}
+
+// ErrUnauthorized неверное имя пользователя или пароль
+var ErrUnauthorized = errors.New("неверное имя пользователя или пароль")
+
+const (
+	SessionCookie = "session"   // имя cookie сессии
+	Anonymous     = "anonymous" // имя Identity запроса без учетных данных
+)
+
+// Session сессия пользователя после входа по паролю
+type Session struct {
+	Token    string    `json:"-"`
+	Identity Identity  `json:"identity"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Auth проверяет статические токены "Authorization: Bearer <token>" и сессии
+// пользователей из UserStore. Запрос без учетных данных получает уровень anonymous
+type Auth struct {
+	users        *UserStore
+	anonymous    Permission
+	sessionTTL   time.Duration
+	secureCookie bool
+	limiter      *loginLimiter
+
+	mu       sync.Mutex
+	tokens   map[[sha256.Size]byte]Identity
+	sessions map[string]*Session
+}
+
+// Option определяет тип функции для настройки Auth
+type Option func(*Auth)
+
+// WithToken добавляет статический токен с уровнем доступа perm
+func WithToken(token, name string, perm Permission) Option {
+	return func(a *Auth) {
+		a.tokens[sha256.Sum256([]byte(token))] = Identity{Name: name, Permission: perm}
+	}
+}
+
+// WithUserStore включает вход по имени пользователя и паролю
+func WithUserStore(users *UserStore) Option {
+	return func(a *Auth) {
+		a.users = users
+	}
+}
+
+// WithAnonymous устанавливает уровень доступа запросов без учетных данных
+func WithAnonymous(perm Permission) Option {
+	return func(a *Auth) {
+		a.anonymous = perm
+	}
+}
+
+// WithSessionTTL устанавливает время жизни сессии
+func WithSessionTTL(ttl time.Duration) Option {
+	return func(a *Auth) {
+		a.sessionTTL = ttl
+	}
+}
+
+// WithSecureCookie отправляет cookie сессии только по HTTPS
+func WithSecureCookie(secure bool) Option {
+	return func(a *Auth) {
+		a.secureCookie = secure
+	}
+}
+
+// WithLoginLimit ограничивает попытки входа: attempts попыток с одного адреса
+// и attempts неудачных попыток для одного пользователя за window
+func WithLoginLimit(attempts int, window time.Duration) Option {
+	return func(a *Auth) {
+		a.limiter = newLoginLimiter(attempts, window)
+	}
+}
+
+// New создает Auth. По умолчанию запросы без учетных данных запрещены,
+// вход ограничен 10 попытками в минуту
+func New(opts ...Option) *Auth {
+	a := &Auth{
+		anonymous:  None,
+		sessionTTL: 12 * time.Hour,
+		limiter:    newLoginLimiter(10, time.Minute),
+		tokens:     make(map[[sha256.Size]byte]Identity),
+		sessions:   make(map[string]*Session),
+	}
+	for _, option := range opts {
+		option(a)
+	}
+	return a
+}
+
+// AddToken добавляет статический токен с уровнем доступа perm
+func (a *Auth) AddToken(token, name string, perm Permission) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tokens[sha256.Sum256([]byte(token))] = Identity{Name: name, Permission: perm}
+}
+
+// Authenticate определяет Identity запроса по токену или cookie сессии
+// Неверный токен возвращает ErrUnauthorized
+func (a *Auth) Authenticate(r *http.Request) (Identity, error) {
+	anonymous := Identity{Name: Anonymous, Permission: a.anonymous}
+	if header := r.Header.Get("Authorization"); header != "" {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return anonymous, ErrUnauthorized
+		}
+		a.mu.Lock()
+		id, ok := a.tokens[sha256.Sum256([]byte(token))]
+		a.mu.Unlock()
+		if !ok {
+			return anonymous, ErrUnauthorized
+		}
+		return id, nil
+	}
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return anonymous, nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.sessions[cookie.Value]
+	if !ok || time.Now().After(s.Expires) {
+		// истекшая сессия равносильна запросу без учетных данных
+		delete(a.sessions, cookie.Value)
+		return anonymous, nil
+	}
+	return s.Identity, nil
+}
+
+// Login проверяет пароль и создает сессию
+func (a *Auth) Login(username, password string) (*Session, error) {
+	if a.users == nil {
+		return nil, fmt.Errorf("Login: вход по паролю отключен")
+	}
+	id, err := a.users.Verify(username, password)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("Login: %w", err)
+	}
+	s := &Session{
+		Token:    hex.EncodeToString(buf),
+		Identity: id,
+		Expires:  time.Now().Add(a.sessionTTL),
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	for token, old := range a.sessions {
+		if now.After(old.Expires) {
+			delete(a.sessions, token)
+		}
+	}
+	a.sessions[s.Token] = s
+	return s, nil
+}
+
+// Logout завершает сессию
+func (a *Auth) Logout(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sessions, token)
+}
+
+type ctxKey struct{}
+
+// FromContext возвращает Identity запроса, прошедшего Require
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(ctxKey{}).(Identity)
+	return id, ok
+}
+
+// Require пропускает запросы с уровнем доступа не ниже perm
+// Для nil Auth доступ закрыт: авторизация не настроена
+func (a *Auth) Require(perm Permission, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a == nil {
+			writeError(w, http.StatusForbidden, "доступ закрыт: не настроена авторизация")
+			return
+		}
+		id, err := a.Authenticate(r)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if id.Permission < perm {
+			if id.Name == Anonymous {
+				writeError(w, http.StatusUnauthorized, "требуется авторизация")
+			} else {
+				writeError(w, http.StatusForbidden, "недостаточно прав: требуется "+perm.String())
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, id)))
+	})
+}
+
+func writeResult(w http.ResponseWriter, result any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"result": result, "error": ""})
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"result": nil, "error": msg})
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuth(t *testing.T) {
+	hashIterations = 1000
+	users, err := OpenUserStore(filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer users.Close()
+	if err := users.SetUser("viewer", "pass", Read); err != nil {
+		t.Fatal(err)
+	}
+
+	a := New(WithToken("ctl", "ops", Control), WithUserStore(users))
+	mux := http.NewServeMux()
+	a.Handle(mux)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	mux.Handle("/read", a.Require(Read, ok))
+	mux.Handle("/control", a.Require(Control, ok))
+
+	do := func(method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("GET", "/read", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("аноним: %d", w.Code)
+	}
+	if w := do("GET", "/control", "", map[string]string{"Authorization": "Bearer ctl"}); w.Code != http.StatusOK {
+		t.Errorf("токен control: %d", w.Code)
+	}
+	if w := do("GET", "/read", "", map[string]string{"Authorization": "Bearer bad"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("неверный токен: %d", w.Code)
+	}
+
+	if w := do("POST", "/api/v1/auth/login", `{"username":"viewer","password":"wrong"}`, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("неверный пароль: %d", w.Code)
+	}
+	w := do("POST", "/api/v1/auth/login", `{"username":"viewer","password":"pass"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("вход: %d %s", w.Code, w.Body)
+	}
+	cookie := w.Result().Cookies()[0]
+	session := map[string]string{"Cookie": cookie.Name + "=" + cookie.Value}
+	if w := do("GET", "/read", "", session); w.Code != http.StatusOK {
+		t.Errorf("сессия read: %d", w.Code)
+	}
+	if w := do("GET", "/control", "", session); w.Code != http.StatusForbidden {
+		t.Errorf("сессия control: %d", w.Code)
+	}
+	do("POST", "/api/v1/auth/logout", "", session)
+	if w := do("GET", "/read", "", session); w.Code != http.StatusUnauthorized {
+		t.Errorf("после выхода: %d", w.Code)
+	}
+
+	// без настроенной авторизации API закрыто
+	var none *Auth
+	w = httptest.NewRecorder()
+	none.Require(Read, ok).ServeHTTP(w, httptest.NewRequest("GET", "/read", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("без авторизации: %d", w.Code)
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	hashIterations = 1000
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !CheckPassword(hash, "secret") || CheckPassword(hash, "Secret") || CheckPassword("garbage", "secret") {
+		t.Errorf("CheckPassword: %s", hash)
+	}
+}
+
+func TestLoginLimit(t *testing.T) {
+	hashIterations = 1000
+	users, err := OpenUserStore(filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer users.Close()
+	if err := users.SetUser("viewer", "pass", Read); err != nil {
+		t.Fatal(err)
+	}
+	a := New(WithUserStore(users), WithLoginLimit(3, time.Minute))
+	login := func(addr, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(`{"username":"viewer","password":"`+password+`"}`))
+		req.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		a.LoginHandler(w, req)
+		return w
+	}
+
+	// подбор пароля с одного адреса
+	for i := range 3 {
+		if w := login("10.0.0.1:1000", "wrong"); w.Code != http.StatusUnauthorized {
+			t.Fatalf("попытка %d: %d", i, w.Code)
+		}
+	}
+	if w := login("10.0.0.1:1001", "pass"); w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("лимит адреса: %d", w.Code)
+	}
+	// подбор пароля пользователя с другого адреса
+	if w := login("10.0.0.2:1000", "pass"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("лимит пользователя: %d", w.Code)
+	}
+
+	// успешный вход сбрасывает неудачные попытки пользователя
+	a = New(WithUserStore(users), WithLoginLimit(2, time.Minute))
+	login("10.0.0.3:1000", "wrong")
+	if w := login("10.0.0.4:1000", "pass"); w.Code != http.StatusOK {
+		t.Fatalf("вход: %d", w.Code)
+	}
+	if w := login("10.0.0.5:1000", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("после входа: %d", w.Code)
+	}
+}
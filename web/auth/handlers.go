@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// LoginHandler принимает {"username", "password"} и устанавливает cookie сессии
+// Попытки сверх лимита адреса или пользователя отклоняются с кодом 429 без проверки пароля
+func (a *Auth) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var req loginRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "неверное тело запроса: "+err.Error())
+		return
+	}
+	now := time.Now()
+	ipKey, userKey := "ip:"+clientIP(r), "user:"+req.Username
+	if wait := a.limiter.wait(now, ipKey, userKey); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		writeError(w, http.StatusTooManyRequests, "слишком много попыток входа, повторите позже")
+		return
+	}
+	a.limiter.add(now, ipKey)
+	s, err := a.Login(req.Username, req.Password)
+	if err == ErrUnauthorized {
+		a.limiter.add(now, userKey)
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	a.limiter.clear(userKey)
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    s.Token,
+		Path:     "/",
+		Expires:  s.Expires,
+		HttpOnly: true,
+		Secure:   a.secureCookie,
+		SameSite: http.SameSiteStrictMode,
+	})
+	writeResult(w, s)
+}
+
+// LogoutHandler завершает сессию и удаляет cookie
+func (a *Auth) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		a.Logout(cookie.Value)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   a.secureCookie,
+		SameSite: http.SameSiteStrictMode,
+	})
+	writeResult(w, nil)
+}
+
+// MeHandler возвращает Identity текущего запроса
+func (a *Auth) MeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := a.Authenticate(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	writeResult(w, id)
+}
+
+// Handle регистрирует маршруты входа /api/v1/auth/{login,logout,me}
+func (a *Auth) Handle(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/v1/auth/login", a.LoginHandler)
+	mux.HandleFunc("POST /api/v1/auth/logout", a.LogoutHandler)
+	mux.HandleFunc("GET /api/v1/auth/me", a.MeHandler)
+}
+
+// NewFromEnv создает Auth из переменных окружения:
+//   - WEB_AUTH_TOKENS - статические токены "read:<token>,control:<token>"
+//   - WEB_AUTH_USERS_DB - путь к базе пользователей SQLite
+//   - WEB_AUTH_ANONYMOUS - уровень доступа без учетных данных (по умолчанию none),
+//     WEB_AUTH_ANONYMOUS=read явно открывает API для чтения
+//   - WEB_AUTH_SESSION_TTL - время жизни сессии, например 12h
+//
+// Если не задана ни одна из первых трех переменных, возвращает nil - авторизация
+// не настроена и API закрыто
+func NewFromEnv(opts ...Option) (*Auth, error) {
+	tokens := os.Getenv("WEB_AUTH_TOKENS")
+	usersDB := os.Getenv("WEB_AUTH_USERS_DB")
+	anonymous := os.Getenv("WEB_AUTH_ANONYMOUS")
+	if tokens == "" && usersDB == "" && anonymous == "" {
+		return nil, nil
+	}
+	var envOpts []Option
+	for i, item := range strings.Split(tokens, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		p, token, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok || token == "" {
+			return nil, fmt.Errorf("NewFromEnv: неверный формат WEB_AUTH_TOKENS, ожидается <уровень>:<токен>")
+		}
+		perm, err := ParsePermission(p)
+		if err != nil {
+			return nil, fmt.Errorf("NewFromEnv: %w", err)
+		}
+		envOpts = append(envOpts, WithToken(token, fmt.Sprintf("token-%d", i+1), perm))
+	}
+	if usersDB != "" {
+		users, err := OpenUserStore(usersDB)
+		if err != nil {
+			return nil, fmt.Errorf("NewFromEnv: %w", err)
+		}
+		envOpts = append(envOpts, WithUserStore(users))
+	}
+	if anonymous != "" {
+		perm, err := ParsePermission(anonymous)
+		if err != nil {
+			return nil, fmt.Errorf("NewFromEnv: %w", err)
+		}
+		envOpts = append(envOpts, WithAnonymous(perm))
+	}
+	if s := os.Getenv("WEB_AUTH_SESSION_TTL"); s != "" {
+		ttl, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("NewFromEnv: WEB_AUTH_SESSION_TTL: %w", err)
+		}
+		envOpts = append(envOpts, WithSessionTTL(ttl))
+	}
+	return New(append(envOpts, opts...)...), nil
+}
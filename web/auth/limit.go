@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// loginLimiter ограничивает количество попыток входа за окно времени по ключу:
+// адресу клиента или имени пользователя. Проверка пароля дорогая, поэтому
+// попытки сверх лимита отклоняются до проверки
+type loginLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu       sync.Mutex
+	attempts map[string]*loginAttempts
+}
+
+type loginAttempts struct {
+	count int
+	reset time.Time // начало следующего окна
+}
+
+func newLoginLimiter(limit int, window time.Duration) *loginLimiter {
+	return &loginLimiter{limit: limit, window: window, attempts: make(map[string]*loginAttempts)}
+}
+
+// wait возвращает время до следующей разрешенной попытки по ключам keys, 0 - попытка разрешена
+func (l *loginLimiter) wait(now time.Time, keys ...string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var wait time.Duration
+	for _, key := range keys {
+		if a, ok := l.attempts[key]; ok && now.Before(a.reset) && a.count >= l.limit {
+			wait = max(wait, a.reset.Sub(now))
+		}
+	}
+	return wait
+}
+
+// add учитывает попытку по ключу key
+func (l *loginLimiter) add(now time.Time, key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.attempts) > 1024 {
+		for k, a := range l.attempts {
+			if !now.Before(a.reset) {
+				delete(l.attempts, k)
+			}
+		}
+	}
+	a, ok := l.attempts[key]
+	if !ok || !now.Before(a.reset) {
+		a = &loginAttempts{reset: now.Add(l.window)}
+		l.attempts[key] = a
+	}
+	a.count++
+}
+
+// clear сбрасывает попытки по ключу key
+func (l *loginLimiter) clear(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.attempts, key)
+}
+
+// clientIP возвращает адрес клиента без порта
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
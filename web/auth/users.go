@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"goTradingBot/db"
+	"strconv"
+	"strings"
+)
+
+// hashIterations количество итераций PBKDF2-SHA256 для новых паролей
+var hashIterations = 600_000
+
+var migrate = db.Migrations(
+	db.Migration{
+		Version: 1,
+		Name:    "users",
+		Up: `
+CREATE TABLE IF NOT EXISTS users (
+    username TEXT PRIMARY KEY,
+    passwordHash TEXT NOT NULL,
+    permission INTEGER NOT NULL
+);
+`,
+	},
+)
+
+// UserStore пользователи веб-интерфейса в SQLite, пароли хранятся в виде хеша PBKDF2
+type UserStore struct {
+	db *sql.DB
+	// dummyHash проверяется для несуществующих пользователей, чтобы время ответа не выдавало их наличие
+	dummyHash string
+}
+
+// OpenUserStore открывает базу пользователей по пути path
+func OpenUserStore(path string) (*UserStore, error) {
+	conn, err := db.InitDB(path, migrate)
+	if err != nil {
+		return nil, fmt.Errorf("OpenUserStore: %w", err)
+	}
+	dummy, err := HashPassword("")
+	if err != nil {
+		return nil, fmt.Errorf("OpenUserStore: %w", err)
+	}
+	return &UserStore{db: conn, dummyHash: dummy}, nil
+}
+
+// Close закрывает базу пользователей
+func (s *UserStore) Close() error {
+	return s.db.Close()
+}
+
+// SetUser создает пользователя или меняет его пароль и уровень доступа
+func (s *UserStore) SetUser(username, password string, perm Permission) error {
+	if username == "" || password == "" {
+		return fmt.Errorf("SetUser: пустое имя пользователя или пароль")
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("SetUser: %w", err)
+	}
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO users (username, passwordHash, permission) VALUES (?, ?, ?)`,
+		username, hash, int(perm))
+	if err != nil {
+		return fmt.Errorf("SetUser: ошибка сохранения пользователя: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser удаляет пользователя
+func (s *UserStore) DeleteUser(username string) error {
+	if _, err := s.db.Exec(`DELETE FROM users WHERE username = ?`, username); err != nil {
+		return fmt.Errorf("DeleteUser: %w", err)
+	}
+	return nil
+}
+
+// Verify проверяет пароль пользователя, при ошибке возвращает ErrUnauthorized
+func (s *UserStore) Verify(username, password string) (Identity, error) {
+	var hash string
+	var perm int
+	err := s.db.QueryRow(`SELECT passwordHash, permission FROM users WHERE username = ?`, username).Scan(&hash, &perm)
+	if errors.Is(err, sql.ErrNoRows) {
+		CheckPassword(s.dummyHash, password)
+		return Identity{}, ErrUnauthorized
+	}
+	if err != nil {
+		return Identity{}, fmt.Errorf("Verify: %w", err)
+	}
+	if !CheckPassword(hash, password) {
+		return Identity{}, ErrUnauthorized
+	}
+	return Identity{Name: username, Permission: Permission(perm)}, nil
+}
+
+// HashPassword возвращает хеш пароля в формате "pbkdf2-sha256$<итерации>$<соль>$<ключ>"
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("HashPassword: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, hashIterations, 32)
+	if err != nil {
+		return "", fmt.Errorf("HashPassword: %w", err)
+	}
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", hashIterations, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// CheckPassword сравнивает пароль с хешем HashPassword
+func CheckPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter <= 0 {
+		return false
+	}
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := enc.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iter, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, want) == 1
+}